@@ -5058,6 +5058,12 @@ const docTemplate = `{
         "d7y_io_dragonfly_v2_manager_types.SchedulerClusterConfig": {
             "type": "object",
             "properties": {
+                "evaluator_weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
                 "filter_parent_limit": {
                     "type": "integer",
                     "maximum": 20,
@@ -66,12 +66,22 @@ const (
 	DefaultPieceChanSize      = 16
 	DefaultPieceQueueExponent = 4
 	DefaultObjectMaxReplicas  = 3
+
+	// DefaultObjectStorageS3Region is the default region of S3 compatible API.
+	DefaultObjectStorageS3Region = "us-east-1"
 )
 
 // Store strategy.
 const (
 	SimpleLocalTaskStoreStrategy  = StoreStrategy("io.d7y.storage.v2.simple")
 	AdvanceLocalTaskStoreStrategy = StoreStrategy("io.d7y.storage.v2.advance")
+	ContentLocalTaskStoreStrategy = StoreStrategy("io.d7y.storage.v2.content")
+)
+
+// Storage eviction policy.
+const (
+	LRUEvictionPolicy = EvictionPolicy("lru")
+	LFUEvictionPolicy = EvictionPolicy("lfu")
 )
 
 // Dfcache subcommand names.
@@ -84,11 +94,12 @@ const (
 
 // Service defalut port of listening.
 const (
-	DefaultEndPort                = 65535
-	DefaultPeerStartPort          = 65000
-	DefaultUploadStartPort        = 65002
-	DefaultObjectStorageStartPort = 65004
-	DefaultHealthyStartPort       = 40901
+	DefaultEndPort                  = 65535
+	DefaultPeerStartPort            = 65000
+	DefaultUploadStartPort          = 65002
+	DefaultObjectStorageStartPort   = 65004
+	DefaultObjectStorageS3StartPort = 65005
+	DefaultHealthyStartPort         = 40901
 )
 
 var (
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"net/url"
 	"os"
@@ -134,6 +135,14 @@ func (p *DaemonOption) Convert() error {
 		}
 	}
 
+	if p.ObjectStorage.S3.ListenOption.TCPListen != nil && p.ObjectStorage.S3.ListenOption.TCPListen.Listen == "" {
+		if p.Network.EnableIPv6 {
+			p.ObjectStorage.S3.ListenOption.TCPListen.Listen = net.IPv6zero.String()
+		} else {
+			p.ObjectStorage.S3.ListenOption.TCPListen.Listen = net.IPv4zero.String()
+		}
+	}
+
 	if p.Proxy.ListenOption.TCPListen != nil && p.Proxy.ListenOption.TCPListen.Listen == "" {
 		if p.Network.EnableIPv6 {
 			p.Proxy.ListenOption.TCPListen.Listen = net.IPv6zero.String()
@@ -186,6 +195,38 @@ func (p *DaemonOption) Validate() error {
 		if p.ObjectStorage.MaxReplicas <= 0 {
 			return errors.New("max replicas must be greater than 0")
 		}
+
+		if p.ObjectStorage.S3.Enable {
+			if err := p.ObjectStorage.S3.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := p.Download.PieceSizePolicy.Validate(); err != nil {
+		return err
+	}
+
+	for application, policy := range p.Download.ApplicationPieceSizePolicies {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("piece size policy of application %q: %w", application, err)
+		}
+	}
+
+	switch p.Storage.EvictionPolicy {
+	case "", LRUEvictionPolicy, LFUEvictionPolicy:
+	default:
+		return fmt.Errorf("storage eviction policy %s is not supported", p.Storage.EvictionPolicy)
+	}
+
+	for _, quota := range p.Storage.Quotas {
+		if quota.Application == "" && quota.Tag == "" {
+			return errors.New("storage quota requires parameter application or tag")
+		}
+
+		if quota.Limit <= 0 {
+			return fmt.Errorf("storage quota limit of application %q tag %q must be greater than 0", quota.Application, quota.Tag)
+		}
 	}
 
 	if p.Reload.Interval.Duration > 0 && p.Reload.Interval.Duration < time.Second {
@@ -308,9 +349,16 @@ type DownloadOption struct {
 	Concurrent           *ConcurrentOption `mapstructure:"concurrent" yaml:"concurrent"`
 	SyncPieceViaHTTPS    bool              `mapstructure:"syncPieceViaHTTPS" yaml:"syncPieceViaHTTPS"`
 	SplitRunningTasks    bool              `mapstructure:"splitRunningTasks" yaml:"splitRunningTasks"`
+	PieceSelectorType    string            `mapstructure:"pieceSelectorType" yaml:"pieceSelectorType"`
+	PieceDigestAlgorithm string            `mapstructure:"pieceDigestAlgorithm" yaml:"pieceDigestAlgorithm"`
 
 	RecursiveConcurrent    RecursiveConcurrent `mapstructure:"recursiveConcurrent" yaml:"recursiveConcurrent"`
 	CacheRecursiveMetadata time.Duration       `mapstructure:"cacheRecursiveMetadata" yaml:"cacheRecursiveMetadata"`
+
+	// PieceSizePolicy indicates the policy to choose the piece size of tasks downloaded from source
+	PieceSizePolicy PieceSizePolicyOption `mapstructure:"pieceSizePolicy" yaml:"pieceSizePolicy"`
+	// ApplicationPieceSizePolicies overrides PieceSizePolicy for the tasks of the application
+	ApplicationPieceSizePolicies map[string]PieceSizePolicyOption `mapstructure:"applicationPieceSizePolicies" yaml:"applicationPieceSizePolicies"`
 }
 
 type TransportOption struct {
@@ -338,6 +386,38 @@ type ConcurrentOption struct {
 	MaxAttempts int `mapstructure:"maxAttempts" yaml:"maxAttempts"`
 }
 
+type PieceSizePolicyOption struct {
+	// Type indicates the piece size policy, default chooses the piece size by content length,
+	// adaptive chooses the piece size by content length, expected peers and observed throughput
+	Type string `mapstructure:"type" yaml:"type"`
+	// MinPieceSize indicates the min piece size of adaptive policy, default: 1MiB
+	MinPieceSize unit.Bytes `mapstructure:"minPieceSize" yaml:"minPieceSize"`
+	// MaxPieceSize indicates the max piece size of adaptive policy, default: 64MiB
+	MaxPieceSize unit.Bytes `mapstructure:"maxPieceSize" yaml:"maxPieceSize"`
+	// ExpectedPeers indicates the expected count of peers downloading the task,
+	// adaptive policy keeps at least one piece for every peer
+	ExpectedPeers int `mapstructure:"expectedPeers" yaml:"expectedPeers"`
+}
+
+func (p *PieceSizePolicyOption) Validate() error {
+	if p.MinPieceSize < 0 || p.MaxPieceSize < 0 {
+		return errors.New("piece size must not be less than 0")
+	}
+
+	if p.MinPieceSize > 0 && p.MaxPieceSize > 0 && p.MinPieceSize > p.MaxPieceSize {
+		return fmt.Errorf("min piece size %s must not be greater than max piece size %s", p.MinPieceSize, p.MaxPieceSize)
+	}
+
+	if p.MaxPieceSize > math.MaxUint32 {
+		return fmt.Errorf("max piece size %s is too large", p.MaxPieceSize)
+	}
+
+	if p.ExpectedPeers < 0 {
+		return errors.New("expected peers must not be less than 0")
+	}
+	return nil
+}
+
 type RecursiveConcurrent struct {
 	// GoroutineCount indicates the concurrent goroutine count for every recursive task
 	GoroutineCount int `mapstructure:"goroutineCount" yaml:"goroutineCount"`
@@ -471,6 +551,8 @@ func (p *ProxyOption) unmarshal(unmarshal func(in []byte, out any) (err error),
 type UploadOption struct {
 	ListenOption `yaml:",inline" mapstructure:",squash"`
 	RateLimit    util.RateLimit `mapstructure:"rateLimit" yaml:"rateLimit"`
+	// PeerRateLimit indicates the upload rate limit of every requesting peer, zero means no limit
+	PeerRateLimit util.RateLimit `mapstructure:"peerRateLimit" yaml:"peerRateLimit"`
 }
 
 type ObjectStorageOption struct {
@@ -482,10 +564,56 @@ type ObjectStorageOption struct {
 	Filter string `mapstructure:"filter" yaml:"filter"`
 	// MaxReplicas is the maximum number of replicas of an object cache in seed peers.
 	MaxReplicas int `mapstructure:"maxReplicas" yaml:"maxReplicas"`
+	// S3 is the S3 compatible API of object storage service.
+	S3 ObjectStorageS3Option `mapstructure:"s3" yaml:"s3"`
 	// ListenOption is object storage service listener.
 	ListenOption `yaml:",inline" mapstructure:",squash"`
 }
 
+type ObjectStorageS3Option struct {
+	// Enable S3 compatible API, it's served by a separate listener
+	// and only path style addressing is supported.
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// Region is the region in the credential scope of AWS Signature Version 4.
+	Region string `mapstructure:"region" yaml:"region"`
+	// Credentials is the access keys used to verify the signature of requests.
+	Credentials []ObjectStorageS3CredentialOption `mapstructure:"credentials" yaml:"credentials"`
+	// ListenOption is S3 compatible API listener.
+	ListenOption `yaml:",inline" mapstructure:",squash"`
+}
+
+// Validate validates the S3 compatible API options.
+func (o *ObjectStorageS3Option) Validate() error {
+	if o.Region == "" {
+		return errors.New("object storage s3 region is not specified")
+	}
+
+	if len(o.Credentials) == 0 {
+		return errors.New("object storage s3 credentials are not specified")
+	}
+
+	accessKeys := map[string]struct{}{}
+	for _, credential := range o.Credentials {
+		if credential.AccessKey == "" || credential.SecretKey == "" {
+			return errors.New("object storage s3 credential requires accessKey and secretKey")
+		}
+
+		if _, ok := accessKeys[credential.AccessKey]; ok {
+			return fmt.Errorf("object storage s3 credential %s is duplicated", credential.AccessKey)
+		}
+		accessKeys[credential.AccessKey] = struct{}{}
+	}
+
+	return nil
+}
+
+type ObjectStorageS3CredentialOption struct {
+	// AccessKey is access key id.
+	AccessKey string `mapstructure:"accessKey" yaml:"accessKey"`
+	// SecretKey is access key secret.
+	SecretKey string `mapstructure:"secretKey" yaml:"secretKey"`
+}
+
 type ListenOption struct {
 	Security   SecurityOption    `mapstructure:"security" yaml:"security"`
 	TCPListen  *TCPListenOption  `mapstructure:"tcpListen,omitempty" yaml:"tcpListen,omitempty"`
@@ -617,10 +745,27 @@ type StorageOption struct {
 	// Multiplex indicates reusing underlying storage for same task id
 	Multiplex     bool          `mapstructure:"multiplex" yaml:"multiplex"`
 	StoreStrategy StoreStrategy `mapstructure:"strategy" yaml:"strategy"`
+	// Quotas indicates the storage limits of tasks per application or tag,
+	// when a quota is exceeded, the tasks of the quota will be gc
+	Quotas []StorageQuota `mapstructure:"quotas" yaml:"quotas"`
+	// EvictionPolicy indicates the order to gc tasks when the threshold or quota is exceeded,
+	// lru gc the least recently used tasks first, lfu gc the least frequently used tasks first
+	EvictionPolicy EvictionPolicy `mapstructure:"evictionPolicy" yaml:"evictionPolicy"`
 }
 
 type StoreStrategy string
 
+type EvictionPolicy string
+
+type StorageQuota struct {
+	// Application indicates the application of tasks in the quota, empty matches any application
+	Application string `mapstructure:"application" yaml:"application"`
+	// Tag indicates the tag of tasks in the quota, empty matches any tag
+	Tag string `mapstructure:"tag" yaml:"tag"`
+	// Limit indicates the max bytes of tasks in the quota
+	Limit unit.Bytes `mapstructure:"limit" yaml:"limit"`
+}
+
 type HealthOption struct {
 	ListenOption `yaml:",inline" mapstructure:",squash"`
 	Path         string `mapstructure:"path" yaml:"path"`
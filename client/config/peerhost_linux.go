@@ -96,7 +96,12 @@ var peerHostConfig = func() *DaemonOption {
 					},
 				},
 			},
-			SplitRunningTasks: false,
+			SplitRunningTasks:    false,
+			PieceSelectorType:    "sequential",
+			PieceDigestAlgorithm: "md5",
+			PieceSizePolicy: PieceSizePolicyOption{
+				Type: "default",
+			},
 		},
 		Upload: UploadOption{
 			RateLimit: util.RateLimit{
@@ -119,6 +124,22 @@ var peerHostConfig = func() *DaemonOption {
 			Enable:      false,
 			Filter:      "Expires&Signature&ns",
 			MaxReplicas: DefaultObjectMaxReplicas,
+			S3: ObjectStorageS3Option{
+				Enable: false,
+				Region: DefaultObjectStorageS3Region,
+				ListenOption: ListenOption{
+					Security: SecurityOption{
+						Insecure:  true,
+						TLSVerify: true,
+					},
+					TCPListen: &TCPListenOption{
+						PortRange: TCPListenPortRange{
+							Start: DefaultObjectStorageS3StartPort,
+							End:   DefaultEndPort,
+						},
+					},
+				},
+			},
 			ListenOption: ListenOption{
 				Security: SecurityOption{
 					Insecure:  true,
@@ -150,6 +171,7 @@ var peerHostConfig = func() *DaemonOption {
 			StoreStrategy:          SimpleLocalTaskStoreStrategy,
 			Multiplex:              false,
 			DiskGCThresholdPercent: 95,
+			EvictionPolicy:         LRUEvictionPolicy,
 		},
 		Health: &HealthOption{
 			ListenOption: ListenOption{
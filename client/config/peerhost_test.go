@@ -353,11 +353,27 @@ func TestPeerHostOption_Load(t *testing.T) {
 				MaxBackoff:     1,
 				MaxAttempts:    1,
 			},
+			PieceSizePolicy: PieceSizePolicyOption{
+				Type:          "adaptive",
+				MinPieceSize:  unit.MB,
+				MaxPieceSize:  64 * unit.MB,
+				ExpectedPeers: 8,
+			},
+			ApplicationPieceSizePolicies: map[string]PieceSizePolicyOption{
+				"model": {
+					Type:         "adaptive",
+					MinPieceSize: 16 * unit.MB,
+					MaxPieceSize: 256 * unit.MB,
+				},
+			},
 		},
 		Upload: UploadOption{
 			RateLimit: util.RateLimit{
 				Limit: 1024 * 1024 * 1024,
 			},
+			PeerRateLimit: util.RateLimit{
+				Limit: 512 * 1024 * 1024,
+			},
 			ListenOption: ListenOption{
 				Security: SecurityOption{
 					Insecure:  true,
@@ -379,6 +395,22 @@ func TestPeerHostOption_Load(t *testing.T) {
 			Enable:      true,
 			Filter:      "Expires&Signature&ns",
 			MaxReplicas: 3,
+			S3: ObjectStorageS3Option{
+				Enable: true,
+				Region: "us-east-1",
+				Credentials: []ObjectStorageS3CredentialOption{
+					{AccessKey: "foo", SecretKey: "bar"},
+				},
+				ListenOption: ListenOption{
+					TCPListen: &TCPListenOption{
+						Listen: "0.0.0.0",
+						PortRange: TCPListenPortRange{
+							Start: 65005,
+							End:   0,
+						},
+					},
+				},
+			},
 			ListenOption: ListenOption{
 				Security: SecurityOption{
 					Insecure:  true,
@@ -405,6 +437,17 @@ func TestPeerHostOption_Load(t *testing.T) {
 			DiskGCThreshold:        60 * unit.MB,
 			DiskGCThresholdPercent: 0.6,
 			Multiplex:              true,
+			EvictionPolicy:         LFUEvictionPolicy,
+			Quotas: []StorageQuota{
+				{
+					Application: "foo",
+					Limit:       10 * unit.MB,
+				},
+				{
+					Tag:   "bar",
+					Limit: 20 * unit.MB,
+				},
+			},
 		},
 		Health: &HealthOption{
 			Path: "/health",
@@ -242,6 +242,8 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 	pmOpts := []peer.PieceManagerOption{
 		peer.WithLimiter(rate.NewLimiter(opt.Download.TotalRateLimit.Limit, int(opt.Download.TotalRateLimit.Limit))),
 		peer.WithCalculateDigest(opt.Download.CalculateDigest),
+		peer.WithPieceDigestAlgorithm(opt.Download.PieceDigestAlgorithm),
+		peer.WithPieceSizePolicy(opt.Download.PieceSizePolicy, opt.Download.ApplicationPieceSizePolicies),
 		peer.WithTransportOption(opt.Download.Transport),
 		peer.WithConcurrentOption(opt.Download.Concurrent),
 	}
@@ -257,14 +259,15 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 
 	peerTaskManagerOption := &peer.TaskManagerOption{
 		TaskOption: peer.TaskOption{
-			PeerHost:        host,
-			SchedulerOption: opt.Scheduler,
-			PieceManager:    pieceManager,
-			StorageManager:  storageManager,
-			WatchdogTimeout: opt.Download.WatchdogTimeout,
-			CalculateDigest: opt.Download.CalculateDigest,
-			GRPCCredentials: grpcCredentials,
-			GRPCDialTimeout: opt.Download.GRPCDialTimeout,
+			PeerHost:          host,
+			SchedulerOption:   opt.Scheduler,
+			PieceManager:      pieceManager,
+			StorageManager:    storageManager,
+			WatchdogTimeout:   opt.Download.WatchdogTimeout,
+			CalculateDigest:   opt.Download.CalculateDigest,
+			GRPCCredentials:   grpcCredentials,
+			GRPCDialTimeout:   opt.Download.GRPCDialTimeout,
+			PieceSelectorType: opt.Download.PieceSelectorType,
 		},
 		SchedulerClient:   schedulerClient,
 		PerPeerRateLimit:  opt.Download.PerPeerRateLimit.Limit,
@@ -311,6 +314,7 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 
 	uploadOpts := []upload.Option{
 		upload.WithLimiter(rate.NewLimiter(opt.Upload.RateLimit.Limit, int(opt.Upload.RateLimit.Limit))),
+		upload.WithPeerRateLimit(opt.Upload.PeerRateLimit.Limit),
 	}
 
 	if opt.Security.AutoIssueCert && opt.Scheduler.Manager.Enable {
@@ -324,7 +328,7 @@ func New(opt *config.DaemonOption, d dfpath.Dfpath) (Daemon, error) {
 
 	var objectStorage objectstorage.ObjectStorage
 	if opt.ObjectStorage.Enable {
-		objectStorage, err = objectstorage.New(opt, dynconfig, peerTaskManager, storageManager, d.LogDir())
+		objectStorage, err = objectstorage.New(opt, dynconfig, peerTaskManager, storageManager, d.LogDir(), d.CacheDir())
 		if err != nil {
 			return nil, err
 		}
@@ -562,6 +566,19 @@ func (cd *clientDaemon) Serve() error {
 		}
 	}
 
+	// prepare object storage s3 compatible api listen
+	var objectStorageS3Listener net.Listener
+	if cd.Option.ObjectStorage.Enable && cd.Option.ObjectStorage.S3.Enable {
+		if cd.Option.ObjectStorage.S3.TCPListen == nil {
+			return errors.New("object storage s3 tcp listen option is empty")
+		}
+		objectStorageS3Listener, _, err = cd.prepareTCPListener(cd.Option.ObjectStorage.S3.ListenOption, true)
+		if err != nil {
+			logger.Errorf("failed to listen for object storage s3 compatible api: %v", err)
+			return err
+		}
+	}
+
 	g := errgroup.Group{}
 	// serve download grpc service
 	g.Go(func() error {
@@ -661,6 +678,21 @@ func (cd *clientDaemon) Serve() error {
 		})
 	}
 
+	// serve object storage s3 compatible api
+	if cd.Option.ObjectStorage.Enable && cd.Option.ObjectStorage.S3.Enable {
+		g.Go(func() error {
+			defer objectStorageS3Listener.Close()
+			logger.Infof("serve object storage s3 compatible api at %s://%s", objectStorageS3Listener.Addr().Network(), objectStorageS3Listener.Addr().String())
+			if err := cd.ObjectStorage.ServeS3(objectStorageS3Listener); err != nil && err != http.ErrServerClosed {
+				logger.Errorf("failed to serve for object storage s3 compatible api: %v", err)
+				return err
+			} else if err == http.ErrServerClosed {
+				logger.Infof("object storage s3 compatible api closed")
+			}
+			return nil
+		})
+	}
+
 	// serve announcer
 	var announcerOptions []announcer.Option
 	if cd.managerClient != nil {
@@ -42,6 +42,12 @@ const (
 
 	// SeedPeerDownload type is back-to-source
 	SeedPeerDownloadTypeBackToSource = "back_to_source"
+
+	// TrafficShaper class of tasks started by requests
+	TrafficShaperClassForeground = "foreground"
+
+	// TrafficShaper class of tasks started by daemon, like prefetch and seed tasks
+	TrafficShaperClassBackground = "background"
 )
 
 // Variables declared for metrics.
@@ -165,6 +171,34 @@ var (
 		Help:      "Counter of the total prefetched tasks.",
 	})
 
+	UploadPeerRequestCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "upload_peer_request_total",
+		Help:      "Counter of the total upload requests of requesting peers.",
+	}, []string{"remote"})
+
+	UploadPeerTrafficCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "upload_peer_traffic",
+		Help:      "Counter of the total upload traffic of requesting peers.",
+	}, []string{"remote"})
+
+	TrafficShaperAllocatedBandwidth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "traffic_shaper_allocated_bandwidth",
+		Help:      "Gauge of the download bandwidth allocated by traffic shaper.",
+	}, []string{"class"})
+
+	TrafficShaperPreemptedTaskCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: types.MetricsNamespace,
+		Subsystem: types.DfdaemonMetricsName,
+		Name:      "traffic_shaper_preempted_task",
+		Help:      "Gauge of the number of background tasks preempted by traffic shaper.",
+	})
+
 	VersionGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: types.MetricsNamespace,
 		Subsystem: types.DfdaemonMetricsName,
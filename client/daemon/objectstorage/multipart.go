@@ -0,0 +1,316 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/cache"
+	"d7y.io/dragonfly/v2/pkg/digest"
+)
+
+const (
+	// MaxPartNumber is the max part number of multipart upload.
+	MaxPartNumber = 10000
+
+	// defaultMultipartUploadExpiration is the expiration of idle multipart upload,
+	// the parts of expired upload are removed.
+	defaultMultipartUploadExpiration = 24 * time.Hour
+
+	// multipartUploadDirName is the directory name of multipart uploads in data directory.
+	multipartUploadDirName = "object-storage-uploads"
+
+	// multipartObjectFileName is the file name of the object assembled by parts.
+	multipartObjectFileName = "object"
+)
+
+var (
+	// errMultipartUploadNotFound is returned when the upload doesn't exist or it's expired.
+	errMultipartUploadNotFound = errors.New("multipart upload not found")
+
+	// errInvalidPart is returned when the completed part doesn't match the uploaded part.
+	errInvalidPart = errors.New("invalid part")
+)
+
+// multipartUpload is the upload of object in parts, the parts are stored in the directory of upload
+// until the upload is completed or aborted.
+type multipartUpload struct {
+	id          string
+	bucketName  string
+	objectKey   string
+	mode        uint
+	filter      string
+	maxReplicas int
+	dir         string
+
+	mu    sync.Mutex
+	parts map[int]Part
+	// completing indicates the parts are being assembled, the parts can't be changed
+	completing bool
+}
+
+// multipartUploadManager manages the multipart uploads of object storage.
+type multipartUploadManager struct {
+	dir     string
+	uploads cache.Cache
+}
+
+// newMultipartUploadManager returns the manager storing parts under dir,
+// the uploads left by previous process are removed.
+func newMultipartUploadManager(dir string, expiration time.Duration) (*multipartUploadManager, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	uploads := cache.New(expiration, expiration/24)
+	uploads.OnEvicted(func(id string, v any) {
+		upload := v.(*multipartUpload)
+		logger.Infof("remove multipart upload %s of object %s", id, upload.objectKey)
+		upload.remove()
+	})
+
+	return &multipartUploadManager{
+		dir:     dir,
+		uploads: uploads,
+	}, nil
+}
+
+// Create creates a multipart upload of object.
+func (m *multipartUploadManager) Create(bucketName, objectKey string, mode uint, filter string, maxReplicas int) (*multipartUpload, error) {
+	id := uuid.New().String()
+	upload := &multipartUpload{
+		id:          id,
+		bucketName:  bucketName,
+		objectKey:   objectKey,
+		mode:        mode,
+		filter:      filter,
+		maxReplicas: maxReplicas,
+		dir:         filepath.Join(m.dir, id),
+		parts:       map[int]Part{},
+	}
+
+	if err := os.MkdirAll(upload.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	m.uploads.SetDefault(id, upload)
+	return upload, nil
+}
+
+// Get returns the multipart upload of the bucket, the expiration of upload is refreshed.
+func (m *multipartUploadManager) Get(bucketName, id string) (*multipartUpload, error) {
+	v, ok := m.uploads.Get(id)
+	if !ok {
+		return nil, errMultipartUploadNotFound
+	}
+
+	upload := v.(*multipartUpload)
+	if upload.bucketName != bucketName {
+		return nil, errMultipartUploadNotFound
+	}
+
+	m.uploads.SetDefault(id, upload)
+	return upload, nil
+}
+
+// Remove removes the multipart upload and its parts.
+func (m *multipartUploadManager) Remove(upload *multipartUpload) {
+	// the directory of upload is removed by evicted callback
+	m.uploads.Delete(upload.id)
+}
+
+// PutPart stores the part of upload, the part of same number is overwritten.
+func (u *multipartUpload) PutPart(partNumber int, reader io.Reader) (Part, error) {
+	if partNumber < 1 || partNumber > MaxPartNumber {
+		return Part{}, fmt.Errorf("part number must be between 1 and %d", MaxPartNumber)
+	}
+
+	// write to temporary file, so the uploading part doesn't overwrite the uploaded one
+	f, err := os.CreateTemp(u.dir, "uploading-*")
+	if err != nil {
+		return Part{}, err
+	}
+	defer os.Remove(f.Name())
+
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), reader)
+	if err != nil {
+		f.Close()
+		return Part{}, err
+	}
+
+	if err := f.Close(); err != nil {
+		return Part{}, err
+	}
+
+	part := Part{
+		PartNumber: partNumber,
+		ETag:       hex.EncodeToString(hash.Sum(nil)),
+		Size:       size,
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.completing {
+		return Part{}, errors.New("multipart upload is completing")
+	}
+
+	if err := os.Rename(f.Name(), u.partPath(partNumber)); err != nil {
+		return Part{}, err
+	}
+
+	u.parts[partNumber] = part
+	return part, nil
+}
+
+// ListParts returns the uploaded parts in the order of part number.
+func (u *multipartUpload) ListParts() []Part {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	parts := make([]Part, 0, len(u.parts))
+	for _, part := range u.parts {
+		parts = append(parts, part)
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	return parts
+}
+
+// Complete assembles the parts into the object, the parts must be in ascending order of part number
+// and match the uploaded parts. The parts are consumed by assembling, so the upload can't be completed
+// again and should be removed when assembling fails.
+func (u *multipartUpload) Complete(parts []Part) (*fileContent, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: no parts", errInvalidPart)
+	}
+
+	u.mu.Lock()
+	if u.completing {
+		u.mu.Unlock()
+		return nil, errors.New("multipart upload is completing")
+	}
+
+	uploaded := make([]Part, 0, len(parts))
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			u.mu.Unlock()
+			return nil, fmt.Errorf("%w: parts must be in ascending order", errInvalidPart)
+		}
+
+		p, ok := u.parts[part.PartNumber]
+		if !ok || p.ETag != part.ETag {
+			u.mu.Unlock()
+			return nil, fmt.Errorf("%w: part %d", errInvalidPart, part.PartNumber)
+		}
+		uploaded = append(uploaded, p)
+	}
+	u.completing = true
+	u.mu.Unlock()
+
+	return u.assemble(uploaded)
+}
+
+// assemble concatenates the parts into the object file and computes the digest of object.
+func (u *multipartUpload) assemble(parts []Part) (*fileContent, error) {
+	path := filepath.Join(u.dir, multipartObjectFileName)
+
+	// the single part is the object, so it's not copied
+	if len(parts) == 1 {
+		if err := os.Rename(u.partPath(parts[0].PartNumber), path); err != nil {
+			return nil, err
+		}
+
+		return &fileContent{
+			path:   path,
+			name:   filepath.Base(u.objectKey),
+			size:   parts[0].Size,
+			digest: digest.New(digest.AlgorithmMD5, parts[0].ETag),
+		}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	w := io.MultiWriter(f, hash)
+	var size int64
+	for _, part := range parts {
+		n, err := copyFile(w, u.partPath(part.PartNumber))
+		if err != nil {
+			return nil, err
+		}
+		size += n
+
+		// the part is useless after it's assembled
+		if err := os.Remove(u.partPath(part.PartNumber)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return &fileContent{
+		path:   path,
+		name:   filepath.Base(u.objectKey),
+		size:   size,
+		digest: digest.New(digest.AlgorithmMD5, hex.EncodeToString(hash.Sum(nil))),
+	}, nil
+}
+
+func (u *multipartUpload) partPath(partNumber int) string {
+	return filepath.Join(u.dir, fmt.Sprintf("part-%05d", partNumber))
+}
+
+func (u *multipartUpload) remove() {
+	if err := os.RemoveAll(u.dir); err != nil {
+		logger.Errorf("remove multipart upload %s failed: %s", u.id, err)
+	}
+}
+
+func copyFile(w io.Writer, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(w, f)
+}
@@ -0,0 +1,221 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestMultipartUpload_Complete(t *testing.T) {
+	tests := []struct {
+		name      string
+		parts     map[int]string
+		complete  func(uploaded map[int]Part) []Part
+		expect    string
+		expectErr error
+	}{
+		{
+			name:  "complete all parts",
+			parts: map[int]string{1: "foo", 2: "bar", 3: "baz"},
+			complete: func(uploaded map[int]Part) []Part {
+				return []Part{uploaded[1], uploaded[2], uploaded[3]}
+			},
+			expect: "foobarbaz",
+		},
+		{
+			name:  "complete part of parts",
+			parts: map[int]string{1: "foo", 2: "bar", 5: "baz"},
+			complete: func(uploaded map[int]Part) []Part {
+				return []Part{uploaded[1], uploaded[5]}
+			},
+			expect: "foobaz",
+		},
+		{
+			name:  "parts out of order",
+			parts: map[int]string{1: "foo", 2: "bar"},
+			complete: func(uploaded map[int]Part) []Part {
+				return []Part{uploaded[2], uploaded[1]}
+			},
+			expectErr: errInvalidPart,
+		},
+		{
+			name:  "part not uploaded",
+			parts: map[int]string{1: "foo"},
+			complete: func(uploaded map[int]Part) []Part {
+				return []Part{uploaded[1], {PartNumber: 2, ETag: uploaded[1].ETag}}
+			},
+			expectErr: errInvalidPart,
+		},
+		{
+			name:  "etag mismatch",
+			parts: map[int]string{1: "foo"},
+			complete: func(uploaded map[int]Part) []Part {
+				return []Part{{PartNumber: 1, ETag: "bar"}}
+			},
+			expectErr: errInvalidPart,
+		},
+		{
+			name:  "no parts",
+			parts: map[int]string{1: "foo"},
+			complete: func(uploaded map[int]Part) []Part {
+				return nil
+			},
+			expectErr: errInvalidPart,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			m, err := newMultipartUploadManager(t.TempDir(), time.Minute)
+			assert.Nil(err)
+
+			upload, err := m.Create("bucket", "dir/object", WriteBack, "", 0)
+			assert.Nil(err)
+
+			uploaded := map[int]Part{}
+			for number, data := range tc.parts {
+				part, err := upload.PutPart(number, strings.NewReader(data))
+				assert.Nil(err)
+				assert.Equal(number, part.PartNumber)
+				assert.Equal(int64(len(data)), part.Size)
+				assert.Equal(md5Hex(data), part.ETag)
+				uploaded[number] = part
+			}
+
+			content, err := upload.Complete(tc.complete(uploaded))
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+
+				// the upload can be completed again with valid parts
+				_, err = upload.Complete([]Part{uploaded[1]})
+				assert.Nil(err)
+				return
+			}
+
+			assert.Nil(err)
+			assert.Equal("object", content.Name())
+			assert.Equal(int64(len(tc.expect)), content.Size())
+			assert.Equal(md5Hex(tc.expect), content.digest.Encoded)
+
+			f, err := content.Open()
+			assert.Nil(err)
+			defer f.Close()
+
+			data, err := io.ReadAll(f)
+			assert.Nil(err)
+			assert.Equal(tc.expect, string(data))
+
+			// the upload can't be changed after it's completed
+			_, err = upload.PutPart(1, strings.NewReader("foo"))
+			assert.Error(err)
+			_, err = upload.Complete(tc.complete(uploaded))
+			assert.Error(err)
+		})
+	}
+}
+
+func TestMultipartUpload_PutPart(t *testing.T) {
+	assert := testifyassert.New(t)
+	m, err := newMultipartUploadManager(t.TempDir(), time.Minute)
+	assert.Nil(err)
+
+	upload, err := m.Create("bucket", "object", WriteBack, "", 0)
+	assert.Nil(err)
+
+	_, err = upload.PutPart(0, strings.NewReader("foo"))
+	assert.Error(err)
+	_, err = upload.PutPart(MaxPartNumber+1, strings.NewReader("foo"))
+	assert.Error(err)
+
+	_, err = upload.PutPart(2, strings.NewReader("bar"))
+	assert.Nil(err)
+	_, err = upload.PutPart(1, strings.NewReader("foo"))
+	assert.Nil(err)
+
+	// the part of same number is overwritten
+	_, err = upload.PutPart(1, strings.NewReader("baz"))
+	assert.Nil(err)
+
+	assert.Equal([]Part{
+		{PartNumber: 1, ETag: md5Hex("baz"), Size: 3},
+		{PartNumber: 2, ETag: md5Hex("bar"), Size: 3},
+	}, upload.ListParts())
+}
+
+func TestMultipartUploadManager(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir := t.TempDir()
+
+	// the uploads left by previous process are removed
+	leftover := filepath.Join(dir, "leftover")
+	assert.Nil(os.Mkdir(leftover, 0700))
+
+	m, err := newMultipartUploadManager(dir, time.Minute)
+	assert.Nil(err)
+	assert.NoDirExists(leftover)
+
+	upload, err := m.Create("bucket", "object", WriteBack, "", 0)
+	assert.Nil(err)
+	assert.DirExists(upload.dir)
+
+	got, err := m.Get("bucket", upload.id)
+	assert.Nil(err)
+	assert.Equal(upload, got)
+
+	_, err = m.Get("other", upload.id)
+	assert.ErrorIs(err, errMultipartUploadNotFound)
+
+	_, err = m.Get("bucket", "unknown")
+	assert.ErrorIs(err, errMultipartUploadNotFound)
+
+	m.Remove(upload)
+	assert.NoDirExists(upload.dir)
+	_, err = m.Get("bucket", upload.id)
+	assert.ErrorIs(err, errMultipartUploadNotFound)
+}
+
+func TestMultipartUploadManager_Expiration(t *testing.T) {
+	assert := testifyassert.New(t)
+	m, err := newMultipartUploadManager(t.TempDir(), 24*time.Millisecond)
+	assert.Nil(err)
+
+	upload, err := m.Create("bucket", "object", WriteBack, "", 0)
+	assert.Nil(err)
+
+	assert.Eventually(func() bool {
+		_, err := os.Stat(upload.dir)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = m.Get("bucket", upload.id)
+	assert.ErrorIs(err, errMultipartUploadNotFound)
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
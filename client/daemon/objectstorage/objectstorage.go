@@ -19,7 +19,6 @@
 package objectstorage
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -33,6 +32,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -87,6 +87,9 @@ type ObjectStorage interface {
 	// Started object storage server.
 	Serve(lis net.Listener) error
 
+	// Started S3 compatible API server.
+	ServeS3(lis net.Listener) error
+
 	// Stop object storage server.
 	Stop() error
 }
@@ -99,16 +102,28 @@ type objectStorage struct {
 	peerTaskManager peer.TaskManager
 	storageManager  storage.Manager
 	peerIDGenerator peer.IDGenerator
+	// multipartUploads stores the parts of multipart uploads.
+	multipartUploads *multipartUploadManager
+	// s3Server serves S3 compatible API, it's nil when S3 compatible API is disabled.
+	s3Server *http.Server
+	// s3Verifier verifies the signature of S3 requests.
+	s3Verifier *sigV4Verifier
 }
 
-// New returns a new ObjectStorage instence.
-func New(cfg *config.DaemonOption, dynconfig config.Dynconfig, peerTaskManager peer.TaskManager, storageManager storage.Manager, logDir string) (ObjectStorage, error) {
+// New returns a new ObjectStorage instence, the parts of multipart uploads are stored in cacheDir.
+func New(cfg *config.DaemonOption, dynconfig config.Dynconfig, peerTaskManager peer.TaskManager, storageManager storage.Manager, logDir, cacheDir string) (ObjectStorage, error) {
+	multipartUploads, err := newMultipartUploadManager(filepath.Join(cacheDir, multipartUploadDirName), defaultMultipartUploadExpiration)
+	if err != nil {
+		return nil, err
+	}
+
 	o := &objectStorage{
-		config:          cfg,
-		dynconfig:       dynconfig,
-		peerTaskManager: peerTaskManager,
-		storageManager:  storageManager,
-		peerIDGenerator: peer.NewPeerIDGenerator(cfg.Host.AdvertiseIP.String()),
+		config:           cfg,
+		dynconfig:        dynconfig,
+		peerTaskManager:  peerTaskManager,
+		storageManager:   storageManager,
+		peerIDGenerator:  peer.NewPeerIDGenerator(cfg.Host.AdvertiseIP.String()),
+		multipartUploads: multipartUploads,
 	}
 
 	router := o.initRouter(cfg, logDir)
@@ -116,6 +131,13 @@ func New(cfg *config.DaemonOption, dynconfig config.Dynconfig, peerTaskManager p
 		Handler: router,
 	}
 
+	if cfg.ObjectStorage.S3.Enable {
+		o.s3Verifier = newSigV4Verifier(cfg.ObjectStorage.S3.Region, cfg.ObjectStorage.S3.Credentials)
+		o.s3Server = &http.Server{
+			Handler: o.initS3Router(cfg),
+		}
+	}
+
 	return o, nil
 }
 
@@ -124,8 +146,23 @@ func (o *objectStorage) Serve(lis net.Listener) error {
 	return o.Server.Serve(lis)
 }
 
+// Started S3 compatible API server.
+func (o *objectStorage) ServeS3(lis net.Listener) error {
+	if o.s3Server == nil {
+		return errors.New("s3 compatible api is disabled")
+	}
+
+	return o.s3Server.Serve(lis)
+}
+
 // Stop object storage server.
 func (o *objectStorage) Stop() error {
+	if o.s3Server != nil {
+		if err := o.s3Server.Shutdown(context.Background()); err != nil {
+			return err
+		}
+	}
+
 	return o.Server.Shutdown(context.Background())
 }
 
@@ -177,6 +214,14 @@ func (o *objectStorage) initRouter(cfg *config.DaemonOption, logDir string) *gin
 	b.GET(":id/objects/*object_key", o.getObject)
 	b.DELETE(":id/objects/*object_key", o.destroyObject)
 	b.PUT(":id/objects/*object_key", o.putObject)
+	b.GET(":id/objects", o.listObjects)
+
+	// Multipart uploads
+	b.POST(":id/uploads", o.createMultipartUpload)
+	b.POST(":id/uploads/:upload_id", o.completeMultipartUpload)
+	b.DELETE(":id/uploads/:upload_id", o.abortMultipartUpload)
+	b.GET(":id/uploads/:upload_id/parts", o.listParts)
+	b.PUT(":id/uploads/:upload_id/parts/:part_number", o.uploadPart)
 
 	return r
 }
@@ -294,22 +339,7 @@ func (o *objectStorage) getObject(ctx *gin.Context) {
 		urlMeta.Digest = ""
 	}
 
-	signURL, err := client.GetSignURL(ctx, bucketName, objectKey, objectstorage.MethodGet, defaultSignExpireTime)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
-		return
-	}
-
-	taskID := idgen.TaskID(signURL, urlMeta)
-	log := logger.WithTaskID(taskID)
-	log.Infof("get object %s meta: %s %#v", objectKey, signURL, urlMeta)
-
-	reader, attr, err := o.peerTaskManager.StartStreamTask(ctx, &peer.StreamTaskRequest{
-		URL:     signURL,
-		URLMeta: urlMeta,
-		Range:   artifactRange,
-		PeerID:  o.peerIDGenerator.PeerID(),
-	})
+	reader, attr, err := o.streamObject(ctx, client, bucketName, objectKey, urlMeta, artifactRange)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
 		return
@@ -323,10 +353,30 @@ func (o *objectStorage) getObject(ctx *gin.Context) {
 		}
 	}
 
-	log.Infof("object content length is %d and content type is %s", contentLength, attr[headers.ContentType])
+	logger.Infof("object %s content length is %d and content type is %s", objectKey, contentLength, attr[headers.ContentType])
 	ctx.DataFromReader(http.StatusOK, contentLength, attr[headers.ContentType], reader, nil)
 }
 
+// streamObject downloads object by stream task of peer task manager, so the object is shared by peers.
+func (o *objectStorage) streamObject(ctx context.Context, client objectstorage.ObjectStorage, bucketName, objectKey string,
+	urlMeta *commonv1.UrlMeta, artifactRange *util.Range) (io.ReadCloser, map[string]string, error) {
+	signURL, err := client.GetSignURL(ctx, bucketName, objectKey, objectstorage.MethodGet, defaultSignExpireTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	taskID := idgen.TaskID(signURL, urlMeta)
+	log := logger.WithTaskID(taskID)
+	log.Infof("get object %s meta: %s %#v", objectKey, signURL, urlMeta)
+
+	return o.peerTaskManager.StartStreamTask(ctx, &peer.StreamTaskRequest{
+		URL:     signURL,
+		URLMeta: urlMeta,
+		Range:   artifactRange,
+		PeerID:  o.peerIDGenerator.PeerID(),
+	})
+}
+
 // destroyObject uses to delete object data.
 func (o *objectStorage) destroyObject(ctx *gin.Context) {
 	var params ObjectParams
@@ -371,29 +421,214 @@ func (o *objectStorage) putObject(ctx *gin.Context) {
 	}
 
 	var (
-		bucketName  = params.ID
-		objectKey   = strings.TrimPrefix(params.ObjectKey, string(os.PathSeparator))
-		mode        = form.Mode
-		filter      = form.Filter
-		maxReplicas = form.MaxReplicas
-		fileHeader  = form.File
+		bucketName = params.ID
+		objectKey  = strings.TrimPrefix(params.ObjectKey, string(os.PathSeparator))
+		content    = &fileHeaderContent{header: form.File}
 	)
 
+	dgst, err := o.md5FromContent(content)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
+		return
+	}
+
+	if err := o.importObject(ctx, bucketName, objectKey, form.Mode, form.Filter, form.MaxReplicas, dgst, content, nil); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+	return
+}
+
+// listObjects uses to list metadatas of objects in bucket.
+func (o *objectStorage) listObjects(ctx *gin.Context) {
+	var params BucketParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	var query ListObjectsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
 	client, err := o.client()
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
 		return
 	}
 
-	signURL, err := client.GetSignURL(ctx, bucketName, objectKey, objectstorage.MethodGet, defaultSignExpireTime)
+	metadatas, err := client.ListObjectMetadatas(ctx, params.ID, query.Prefix, query.Marker, query.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, metadatas)
+}
+
+// createMultipartUpload uses to create multipart upload of object.
+func (o *objectStorage) createMultipartUpload(ctx *gin.Context) {
+	var params BucketParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	var json CreateMultipartUploadRequest
+	if err := ctx.ShouldBindJSON(&json); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	objectKey := strings.TrimPrefix(json.ObjectKey, string(os.PathSeparator))
+	upload, err := o.multipartUploads.Create(params.ID, objectKey, json.Mode, json.Filter, json.MaxReplicas)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
 		return
 	}
 
+	logger.Infof("create multipart upload %s of object %s in bucket %s", upload.id, objectKey, params.ID)
+	ctx.JSON(http.StatusOK, CreateMultipartUploadResponse{UploadID: upload.id})
+}
+
+// uploadPart uses to upload part of multipart upload, the request body is the data of part.
+func (o *objectStorage) uploadPart(ctx *gin.Context) {
+	var params PartParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	upload, err := o.multipartUploads.Get(params.ID, params.UploadID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"errors": err.Error()})
+		return
+	}
+
+	part, err := upload.PutPart(params.PartNumber, ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
+		return
+	}
+
+	ctx.Header(headers.ETag, part.ETag)
+	ctx.JSON(http.StatusOK, part)
+}
+
+// listParts uses to list uploaded parts of multipart upload.
+func (o *objectStorage) listParts(ctx *gin.Context) {
+	var params MultipartUploadParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	upload, err := o.multipartUploads.Get(params.ID, params.UploadID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"errors": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, upload.ListParts())
+}
+
+// completeMultipartUpload uses to assemble the parts into object and upload the object.
+func (o *objectStorage) completeMultipartUpload(ctx *gin.Context) {
+	var params MultipartUploadParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	var json CompleteMultipartUploadRequest
+	if err := ctx.ShouldBindJSON(&json); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	upload, err := o.multipartUploads.Get(params.ID, params.UploadID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"errors": err.Error()})
+		return
+	}
+
+	content, err := upload.Complete(json.Parts)
+	if err != nil {
+		if errors.Is(err, errInvalidPart) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"errors": err.Error()})
+			return
+		}
+
+		o.multipartUploads.Remove(upload)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
+		return
+	}
+
+	// The upload is removed after the object is imported to backend and seed peers.
+	if err := o.importObject(ctx, upload.bucketName, upload.objectKey, upload.mode, upload.filter, upload.maxReplicas,
+		content.digest, content, func() { o.multipartUploads.Remove(upload) }); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, CompleteMultipartUploadResponse{
+		ObjectKey: upload.objectKey,
+		Digest:    content.digest.String(),
+	})
+}
+
+// abortMultipartUpload uses to abort multipart upload and remove the uploaded parts.
+func (o *objectStorage) abortMultipartUpload(ctx *gin.Context) {
+	var params MultipartUploadParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	upload, err := o.multipartUploads.Get(params.ID, params.UploadID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"errors": err.Error()})
+		return
+	}
+
+	logger.Infof("abort multipart upload %s of object %s in bucket %s", upload.id, upload.objectKey, upload.bucketName)
+	o.multipartUploads.Remove(upload)
+	ctx.Status(http.StatusOK)
+}
+
+// importObject imports object to local storage and announces it to scheduler, then imports object to
+// seed peers and backend according to the mode. The done is called when the object is not used anymore,
+// it may be called after importObject returns.
+func (o *objectStorage) importObject(ctx context.Context, bucketName, objectKey string, mode uint, filter string, maxReplicas int,
+	dgst *digest.Digest, content objectContent, done func()) error {
+	var wg sync.WaitGroup
+	defer func() {
+		if done == nil {
+			return
+		}
+
+		go func() {
+			wg.Wait()
+			done()
+		}()
+	}()
+
+	client, err := o.client()
+	if err != nil {
+		return err
+	}
+
+	signURL, err := client.GetSignURL(ctx, bucketName, objectKey, objectstorage.MethodGet, defaultSignExpireTime)
+	if err != nil {
+		return err
+	}
+
 	// Initialize url meta.
 	urlMeta := &commonv1.UrlMeta{Filter: o.config.ObjectStorage.Filter}
-	dgst := o.md5FromFileHeader(fileHeader)
 	urlMeta.Digest = dgst.String()
 	if filter != "" {
 		urlMeta.Filter = filter
@@ -413,10 +648,9 @@ func (o *objectStorage) putObject(ctx *gin.Context) {
 
 	// Import object to local storage.
 	log.Infof("import object %s to local storage", objectKey)
-	if err := o.importObjectToLocalStorage(ctx, taskID, peerID, fileHeader); err != nil {
+	if err := o.importObjectToLocalStorage(ctx, taskID, peerID, content); err != nil {
 		log.Error(err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
-		return
+		return err
 	}
 
 	// Announce peer information to scheduler.
@@ -426,72 +660,121 @@ func (o *objectStorage) putObject(ctx *gin.Context) {
 		PeerID: peerID,
 	}, signURL, commonv1.TaskType_DfStore, urlMeta); err != nil {
 		log.Error(err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
-		return
+		return err
 	}
 
 	// Handle task for backend.
 	switch mode {
 	case Ephemeral:
-		ctx.Status(http.StatusOK)
-		return
+		return nil
 	case WriteBack:
 		// Import object to seed peer.
+		wg.Add(1)
 		go func() {
-			if err := o.importObjectToSeedPeers(context.Background(), bucketName, objectKey, urlMeta.Filter, Ephemeral, fileHeader, maxReplicas, log); err != nil {
+			defer wg.Done()
+			if err := o.importObjectToSeedPeers(context.Background(), bucketName, objectKey, urlMeta.Filter, Ephemeral, content, maxReplicas, log); err != nil {
 				log.Errorf("import object %s to seed peers failed: %s", objectKey, err)
 			}
 		}()
 
 		// Import object to object storage.
 		log.Infof("import object %s to bucket %s", objectKey, bucketName)
-		if err := o.importObjectToBackend(ctx, bucketName, objectKey, dgst, fileHeader, client); err != nil {
+		if err := o.importObjectToBackend(ctx, bucketName, objectKey, dgst, content, client); err != nil {
 			log.Error(err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
-			return
+			return err
 		}
 
-		ctx.Status(http.StatusOK)
-		return
+		return nil
 	case AsyncWriteBack:
 		// Import object to seed peer.
+		wg.Add(1)
 		go func() {
-			if err := o.importObjectToSeedPeers(context.Background(), bucketName, objectKey, urlMeta.Filter, Ephemeral, fileHeader, maxReplicas, log); err != nil {
+			defer wg.Done()
+			if err := o.importObjectToSeedPeers(context.Background(), bucketName, objectKey, urlMeta.Filter, Ephemeral, content, maxReplicas, log); err != nil {
 				log.Errorf("import object %s to seed peers failed: %s", objectKey, err)
 			}
 		}()
 
 		// Import object to object storage.
+		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			log.Infof("import object %s to bucket %s", objectKey, bucketName)
-			if err := o.importObjectToBackend(context.Background(), bucketName, objectKey, dgst, fileHeader, client); err != nil {
+			if err := o.importObjectToBackend(context.Background(), bucketName, objectKey, dgst, content, client); err != nil {
 				log.Errorf("import object %s to bucket %s failed: %s", objectKey, bucketName, err.Error())
 				return
 			}
 		}()
 
-		ctx.Status(http.StatusOK)
-		return
+		return nil
 	}
 
-	ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": fmt.Sprintf("unknow mode %d", mode)})
-	return
+	return fmt.Errorf("unknow mode %d", mode)
 }
 
-// getAvailableSeedPeer uses to calculate md5 with file header.
-func (o *objectStorage) md5FromFileHeader(fileHeader *multipart.FileHeader) *digest.Digest {
-	f, err := fileHeader.Open()
+// objectContent is the content of object to be imported.
+type objectContent interface {
+	// Open opens the content for reading, it can be opened multiple times.
+	Open() (io.ReadCloser, error)
+
+	// Size returns the size of content.
+	Size() int64
+
+	// Name returns the file name of content.
+	Name() string
+}
+
+// fileHeaderContent is the content of file in multipart form.
+type fileHeaderContent struct {
+	header *multipart.FileHeader
+}
+
+func (c *fileHeaderContent) Open() (io.ReadCloser, error) {
+	return c.header.Open()
+}
+
+func (c *fileHeaderContent) Size() int64 {
+	return c.header.Size
+}
+
+func (c *fileHeaderContent) Name() string {
+	return c.header.Filename
+}
+
+// fileContent is the content of local file.
+type fileContent struct {
+	path   string
+	name   string
+	size   int64
+	digest *digest.Digest
+}
+
+func (c *fileContent) Open() (io.ReadCloser, error) {
+	return os.Open(c.path)
+}
+
+func (c *fileContent) Size() int64 {
+	return c.size
+}
+
+func (c *fileContent) Name() string {
+	return c.name
+}
+
+// md5FromContent uses to calculate md5 of content.
+func (o *objectStorage) md5FromContent(content objectContent) (*digest.Digest, error) {
+	f, err := content.Open()
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer f.Close()
 
-	return digest.New(digest.AlgorithmMD5, digest.MD5FromReader(f))
+	return digest.New(digest.AlgorithmMD5, digest.MD5FromReader(f)), nil
 }
 
 // importObjectToBackend uses to import object to backend.
-func (o *objectStorage) importObjectToBackend(ctx context.Context, bucketName, objectKey string, dgst *digest.Digest, fileHeader *multipart.FileHeader, client objectstorage.ObjectStorage) error {
-	f, err := fileHeader.Open()
+func (o *objectStorage) importObjectToBackend(ctx context.Context, bucketName, objectKey string, dgst *digest.Digest, content objectContent, client objectstorage.ObjectStorage) error {
+	f, err := content.Open()
 	if err != nil {
 		return err
 	}
@@ -504,10 +787,10 @@ func (o *objectStorage) importObjectToBackend(ctx context.Context, bucketName, o
 }
 
 // importObjectToSeedPeers uses to import object to local storage.
-func (o *objectStorage) importObjectToLocalStorage(ctx context.Context, taskID, peerID string, fileHeader *multipart.FileHeader) error {
-	f, err := fileHeader.Open()
+func (o *objectStorage) importObjectToLocalStorage(ctx context.Context, taskID, peerID string, content objectContent) error {
+	f, err := content.Open()
 	if err != nil {
-		return nil
+		return err
 	}
 	defer f.Close()
 
@@ -525,7 +808,7 @@ func (o *objectStorage) importObjectToLocalStorage(ctx context.Context, taskID,
 	}
 
 	// Import task data to dfdaemon.
-	if err := o.peerTaskManager.GetPieceManager().Import(ctx, meta, tsd, fileHeader.Size, f); err != nil {
+	if err := o.peerTaskManager.GetPieceManager().Import(ctx, meta, tsd, content.Size(), f); err != nil {
 		return err
 	}
 
@@ -533,7 +816,7 @@ func (o *objectStorage) importObjectToLocalStorage(ctx context.Context, taskID,
 }
 
 // importObjectToSeedPeers uses to import object to available seed peers.
-func (o *objectStorage) importObjectToSeedPeers(ctx context.Context, bucketName, objectKey, filter string, mode int, fileHeader *multipart.FileHeader, maxReplicas int, log *logger.SugaredLoggerOnWith) error {
+func (o *objectStorage) importObjectToSeedPeers(ctx context.Context, bucketName, objectKey, filter string, mode int, content objectContent, maxReplicas int, log *logger.SugaredLoggerOnWith) error {
 	schedulers, err := o.dynconfig.GetSchedulers()
 	if err != nil {
 		return err
@@ -552,7 +835,7 @@ func (o *objectStorage) importObjectToSeedPeers(ctx context.Context, bucketName,
 	var replicas int
 	for _, seedPeerHost := range seedPeerHosts {
 		log.Infof("import object %s to seed peer %s", objectKey, seedPeerHost)
-		if err := o.importObjectToSeedPeer(ctx, seedPeerHost, bucketName, objectKey, filter, mode, fileHeader); err != nil {
+		if err := o.importObjectToSeedPeer(ctx, seedPeerHost, bucketName, objectKey, filter, mode, content); err != nil {
 			log.Errorf("import object %s to seed peer %s failed: %s", objectKey, seedPeerHost, err)
 			continue
 		}
@@ -567,39 +850,22 @@ func (o *objectStorage) importObjectToSeedPeers(ctx context.Context, bucketName,
 	return nil
 }
 
-// importObjectToSeedPeer uses to import object to seed peer.
-func (o *objectStorage) importObjectToSeedPeer(ctx context.Context, seedPeerHost, bucketName, objectKey, filter string, mode int, fileHeader *multipart.FileHeader) error {
-	f, err := fileHeader.Open()
+// importObjectToSeedPeer uses to import object to seed peer,
+// the form is streamed to seed peer so the object is not buffered in memory.
+func (o *objectStorage) importObjectToSeedPeer(ctx context.Context, seedPeerHost, bucketName, objectKey, filter string, mode int, content objectContent) error {
+	f, err := content.Open()
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("mode", fmt.Sprint(mode)); err != nil {
-		return err
-	}
-
-	if filter != "" {
-		if err := writer.WriteField("filter", filter); err != nil {
-			return err
-		}
-	}
-
-	part, err := writer.CreateFormFile("file", fileHeader.Filename)
-	if err != nil {
-		return err
-	}
-
-	if _, err := io.Copy(part, f); err != nil {
-		return err
-	}
+	body, pw := io.Pipe()
+	defer body.Close()
 
-	if err := writer.Close(); err != nil {
-		return err
-	}
+	writer := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeObjectForm(writer, filter, mode, content.Name(), f))
+	}()
 
 	u := url.URL{
 		Scheme: "http",
@@ -626,6 +892,30 @@ func (o *objectStorage) importObjectToSeedPeer(ctx context.Context, seedPeerHost
 	return nil
 }
 
+// writeObjectForm writes the form of putting object.
+func writeObjectForm(writer *multipart.Writer, filter string, mode int, fileName string, reader io.Reader) error {
+	if err := writer.WriteField("mode", fmt.Sprint(mode)); err != nil {
+		return err
+	}
+
+	if filter != "" {
+		if err := writer.WriteField("filter", filter); err != nil {
+			return err
+		}
+	}
+
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(part, reader); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
 // client uses to generate client of object storage.
 func (o *objectStorage) client() (objectstorage.ObjectStorage, error) {
 	config, err := o.dynconfig.GetObjectStorage()
@@ -0,0 +1,851 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-http-utils/headers"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/util"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/objectstorage"
+)
+
+const (
+	S3OtelServiceName = "dragonfly-dfdaemon-object-storage-s3"
+)
+
+const (
+	// s3Namespace is the xml namespace of S3 responses.
+	s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+	// s3MaxKeys is the max keys of listing objects.
+	s3MaxKeys = 1000
+
+	// s3MaxCompleteBodySize is the max size of the body of completing multipart upload.
+	s3MaxCompleteBodySize = 4 << 20
+
+	// s3StorageClass is the storage class of objects.
+	s3StorageClass = "STANDARD"
+
+	// S3 request and response headers.
+	headerAmzRequestID  = "X-Amz-Request-Id"
+	headerAmzCopySource = "X-Amz-Copy-Source"
+	headerContentMD5    = "Content-Md5"
+)
+
+// s3ErrorCode is the error code of S3 compatible API.
+type s3ErrorCode struct {
+	code       string
+	statusCode int
+}
+
+var (
+	s3ErrAccessDenied                      = s3ErrorCode{"AccessDenied", http.StatusForbidden}
+	s3ErrAuthorizationHeaderMalformed      = s3ErrorCode{"AuthorizationHeaderMalformed", http.StatusBadRequest}
+	s3ErrAuthorizationQueryParametersError = s3ErrorCode{"AuthorizationQueryParametersError", http.StatusBadRequest}
+	s3ErrBadDigest                         = s3ErrorCode{"BadDigest", http.StatusBadRequest}
+	s3ErrIncompleteBody                    = s3ErrorCode{"IncompleteBody", http.StatusBadRequest}
+	s3ErrInternalError                     = s3ErrorCode{"InternalError", http.StatusInternalServerError}
+	s3ErrInvalidAccessKeyID                = s3ErrorCode{"InvalidAccessKeyId", http.StatusForbidden}
+	s3ErrInvalidArgument                   = s3ErrorCode{"InvalidArgument", http.StatusBadRequest}
+	s3ErrInvalidDigest                     = s3ErrorCode{"InvalidDigest", http.StatusBadRequest}
+	s3ErrInvalidPart                       = s3ErrorCode{"InvalidPart", http.StatusBadRequest}
+	s3ErrInvalidRange                      = s3ErrorCode{"InvalidRange", http.StatusRequestedRangeNotSatisfiable}
+	s3ErrInvalidRequest                    = s3ErrorCode{"InvalidRequest", http.StatusBadRequest}
+	s3ErrMalformedXML                      = s3ErrorCode{"MalformedXML", http.StatusBadRequest}
+	s3ErrNoSuchBucket                      = s3ErrorCode{"NoSuchBucket", http.StatusNotFound}
+	s3ErrNoSuchKey                         = s3ErrorCode{"NoSuchKey", http.StatusNotFound}
+	s3ErrNoSuchUpload                      = s3ErrorCode{"NoSuchUpload", http.StatusNotFound}
+	s3ErrNotImplemented                    = s3ErrorCode{"NotImplemented", http.StatusNotImplemented}
+	s3ErrRequestTimeTooSkewed              = s3ErrorCode{"RequestTimeTooSkewed", http.StatusForbidden}
+	s3ErrSignatureDoesNotMatch             = s3ErrorCode{"SignatureDoesNotMatch", http.StatusForbidden}
+	s3ErrXAmzContentSHA256Mismatch         = s3ErrorCode{"XAmzContentSHA256Mismatch", http.StatusBadRequest}
+)
+
+// s3Error is the error responded by S3 compatible API.
+type s3Error struct {
+	code    s3ErrorCode
+	message string
+}
+
+func newS3Error(code s3ErrorCode, message string) *s3Error {
+	return &s3Error{code: code, message: message}
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.code.code, e.message)
+}
+
+// s3ErrorResponse is the body of error response.
+type s3ErrorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource"`
+	RequestID string   `xml:"RequestId"`
+}
+
+// s3ListBucketResult is the body of ListObjectsV2 response.
+type s3ListBucketResult struct {
+	XMLName               xml.Name         `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string           `xml:"Name"`
+	Prefix                string           `xml:"Prefix"`
+	Delimiter             string           `xml:"Delimiter,omitempty"`
+	MaxKeys               int              `xml:"MaxKeys"`
+	KeyCount              int              `xml:"KeyCount"`
+	IsTruncated           bool             `xml:"IsTruncated"`
+	EncodingType          string           `xml:"EncodingType,omitempty"`
+	ContinuationToken     string           `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string           `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string           `xml:"StartAfter,omitempty"`
+	Contents              []s3Object       `xml:"Contents"`
+	CommonPrefixes        []s3CommonPrefix `xml:"CommonPrefixes"`
+}
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// s3InitiateMultipartUploadResult is the body of CreateMultipartUpload response.
+type s3InitiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+// s3CompleteMultipartUpload is the body of CompleteMultipartUpload request.
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []s3Part `xml:"Part"`
+}
+
+// s3CompleteMultipartUploadResult is the body of CompleteMultipartUpload response.
+type s3CompleteMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+// s3ListPartsResult is the body of ListParts response, all parts are listed in one page.
+type s3ListPartsResult struct {
+	XMLName     xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListPartsResult"`
+	Bucket      string   `xml:"Bucket"`
+	Key         string   `xml:"Key"`
+	UploadID    string   `xml:"UploadId"`
+	MaxParts    int      `xml:"MaxParts"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	Parts       []s3Part `xml:"Part"`
+}
+
+type s3Part struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+	Size       int64  `xml:"Size,omitempty"`
+}
+
+// s3ContinuationToken is the state of listing objects, it's encoded as the opaque continuation token.
+type s3ContinuationToken struct {
+	// Marker is the last key listed.
+	Marker string `json:"marker"`
+
+	// CommonPrefix is the last common prefix listed, the keys of the prefix are skipped.
+	CommonPrefix string `json:"commonPrefix,omitempty"`
+}
+
+// Initialize router of S3 compatible API, only path style addressing is supported.
+func (o *objectStorage) initS3Router(cfg *config.DaemonOption) *gin.Engine {
+	r := gin.New()
+
+	// The paths are bucket names and object keys, so they must not be redirected.
+	r.RedirectTrailingSlash = false
+	r.RedirectFixedPath = false
+
+	// Middleware
+	r.Use(gin.Logger())
+	r.Use(gin.Recovery())
+
+	// Opentelemetry
+	if cfg.Options.Telemetry.Jaeger != "" {
+		r.Use(otelgin.Middleware(S3OtelServiceName))
+	}
+
+	r.Use(o.s3Authenticate)
+	r.NoRoute(o.s3NotImplemented)
+
+	// Buckets
+	r.HEAD("/:bucket", o.s3HeadBucket)
+	r.GET("/:bucket", o.s3GetBucket)
+
+	// Objects
+	r.HEAD("/:bucket/*object_key", o.s3HeadObject)
+	r.GET("/:bucket/*object_key", o.s3GetObject)
+	r.PUT("/:bucket/*object_key", o.s3PutObject)
+	r.POST("/:bucket/*object_key", o.s3PostObject)
+	r.DELETE("/:bucket/*object_key", o.s3DeleteObject)
+
+	return r
+}
+
+// s3Authenticate verifies the signature of request.
+func (o *objectStorage) s3Authenticate(ctx *gin.Context) {
+	ctx.Header(headerAmzRequestID, uuid.New().String())
+	if err := o.s3Verifier.Verify(ctx.Request); err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	ctx.Next()
+}
+
+// s3NotImplemented responds the operations which are not supported.
+func (o *objectStorage) s3NotImplemented(ctx *gin.Context) {
+	o.s3AbortWithError(ctx, newS3Error(s3ErrNotImplemented, "A header or query you provided implies functionality that is not implemented"))
+}
+
+// s3HeadBucket uses to check whether the bucket exists.
+func (o *objectStorage) s3HeadBucket(ctx *gin.Context) {
+	client, err := o.client()
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	isExist, err := client.IsBucketExist(ctx, ctx.Param("bucket"))
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	if !isExist {
+		o.s3AbortWithError(ctx, newS3Error(s3ErrNoSuchBucket, "The specified bucket does not exist"))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// s3GetBucket uses to list objects of bucket, only ListObjectsV2 is supported.
+func (o *objectStorage) s3GetBucket(ctx *gin.Context) {
+	if ctx.Query("list-type") != "2" {
+		o.s3NotImplemented(ctx)
+		return
+	}
+
+	o.s3ListObjectsV2(ctx)
+}
+
+// s3ListObjectsV2 uses to list objects and common prefixes of bucket.
+func (o *objectStorage) s3ListObjectsV2(ctx *gin.Context) {
+	var (
+		bucketName   = ctx.Param("bucket")
+		prefix       = ctx.Query("prefix")
+		delimiter    = ctx.Query("delimiter")
+		startAfter   = ctx.Query("start-after")
+		encodingType = ctx.Query("encoding-type")
+		maxKeys      = s3MaxKeys
+		token        = s3ContinuationToken{Marker: startAfter}
+	)
+
+	if value := ctx.Query("max-keys"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			o.s3AbortWithError(ctx, newS3Error(s3ErrInvalidArgument, "Provided max-keys not an integer or within integer range"))
+			return
+		}
+
+		if n < maxKeys {
+			maxKeys = n
+		}
+	}
+
+	if encodingType != "" && encodingType != "url" {
+		o.s3AbortWithError(ctx, newS3Error(s3ErrInvalidArgument, "Invalid Encoding Method specified in Request"))
+		return
+	}
+
+	continuationToken := ctx.Query("continuation-token")
+	if continuationToken != "" {
+		data, err := base64.RawURLEncoding.DecodeString(continuationToken)
+		if err != nil || json.Unmarshal(data, &token) != nil {
+			o.s3AbortWithError(ctx, newS3Error(s3ErrInvalidArgument, "The continuation token provided is incorrect"))
+			return
+		}
+	}
+
+	client, err := o.client()
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	metadatas, commonPrefixes, next, err := listObjectsV2(func(marker string, limit int64) ([]*objectstorage.ObjectMetadata, error) {
+		return client.ListObjectMetadatas(ctx, bucketName, prefix, marker, limit)
+	}, prefix, delimiter, token, maxKeys)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	encode := func(s string) string {
+		if encodingType == "url" {
+			return awsURIEncode(s, false)
+		}
+		return s
+	}
+
+	result := s3ListBucketResult{
+		Name:              bucketName,
+		Prefix:            encode(prefix),
+		Delimiter:         encode(delimiter),
+		MaxKeys:           maxKeys,
+		KeyCount:          len(metadatas) + len(commonPrefixes),
+		EncodingType:      encodingType,
+		ContinuationToken: continuationToken,
+		StartAfter:        encode(startAfter),
+	}
+
+	if next != nil {
+		data, err := json.Marshal(next)
+		if err != nil {
+			o.s3AbortWithError(ctx, err)
+			return
+		}
+
+		result.IsTruncated = true
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	for _, metadata := range metadatas {
+		result.Contents = append(result.Contents, s3Object{
+			Key:          encode(metadata.Key),
+			ETag:         quoteETag(metadata.ETag),
+			Size:         metadata.ContentLength,
+			StorageClass: s3StorageClass,
+		})
+	}
+
+	for _, commonPrefix := range commonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, s3CommonPrefix{Prefix: encode(commonPrefix)})
+	}
+
+	o.s3XML(ctx, http.StatusOK, result)
+}
+
+// listObjectsV2 lists at most maxKeys objects and common prefixes after the token by the list function,
+// the keys containing delimiter after prefix are rolled up into common prefixes.
+// It returns the token of next page when the objects are truncated.
+func listObjectsV2(list func(marker string, limit int64) ([]*objectstorage.ObjectMetadata, error), prefix, delimiter string,
+	token s3ContinuationToken, maxKeys int) ([]*objectstorage.ObjectMetadata, []string, *s3ContinuationToken, error) {
+	var (
+		metadatas      []*objectstorage.ObjectMetadata
+		commonPrefixes []string
+		marker         = token.Marker
+		lastPrefix     = token.CommonPrefix
+	)
+
+	for {
+		page, err := list(marker, s3MaxKeys)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, metadata := range page {
+			var commonPrefix string
+			if delimiter != "" && strings.HasPrefix(metadata.Key, prefix) {
+				if i := strings.Index(metadata.Key[len(prefix):], delimiter); i >= 0 {
+					commonPrefix = metadata.Key[:len(prefix)+i+len(delimiter)]
+				}
+			}
+
+			// The keys of listed common prefix are skipped.
+			if commonPrefix != "" && commonPrefix == lastPrefix {
+				marker = metadata.Key
+				continue
+			}
+
+			if len(metadatas)+len(commonPrefixes) >= maxKeys {
+				return metadatas, commonPrefixes, &s3ContinuationToken{Marker: marker, CommonPrefix: lastPrefix}, nil
+			}
+
+			if commonPrefix != "" {
+				commonPrefixes = append(commonPrefixes, commonPrefix)
+				lastPrefix = commonPrefix
+			} else {
+				metadatas = append(metadatas, metadata)
+			}
+			marker = metadata.Key
+		}
+
+		if len(page) < s3MaxKeys {
+			return metadatas, commonPrefixes, nil, nil
+		}
+	}
+}
+
+// s3HeadObject uses to head object.
+func (o *objectStorage) s3HeadObject(ctx *gin.Context) {
+	bucketName, objectKey := s3BucketAndObjectKey(ctx)
+	if objectKey == "" {
+		o.s3HeadBucket(ctx)
+		return
+	}
+
+	client, err := o.client()
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	meta, err := o.s3ObjectMetadata(ctx, client, bucketName, objectKey)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	setS3ObjectHeaders(ctx, meta)
+	ctx.Header(headers.ContentLength, fmt.Sprint(meta.ContentLength))
+	ctx.Status(http.StatusOK)
+}
+
+// s3GetObject uses to download object through peer task manager, or list the parts of multipart upload.
+func (o *objectStorage) s3GetObject(ctx *gin.Context) {
+	bucketName, objectKey := s3BucketAndObjectKey(ctx)
+	if objectKey == "" {
+		o.s3GetBucket(ctx)
+		return
+	}
+
+	if _, ok := ctx.GetQuery("uploadId"); ok {
+		o.s3ListParts(ctx)
+		return
+	}
+
+	client, err := o.client()
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	meta, err := o.s3ObjectMetadata(ctx, client, bucketName, objectKey)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	var (
+		urlMeta       = &commonv1.UrlMeta{Filter: o.config.ObjectStorage.Filter, Digest: meta.Digest}
+		artifactRange *util.Range
+		statusCode    = http.StatusOK
+		contentLength = meta.ContentLength
+	)
+
+	if rangeHeader := ctx.GetHeader(headers.Range); rangeHeader != "" {
+		r, err := util.ParseOneRange(rangeHeader, meta.ContentLength)
+		if err != nil {
+			o.s3AbortWithError(ctx, newS3Error(s3ErrInvalidRange, "The requested range is not satisfiable"))
+			return
+		}
+		artifactRange = &r
+
+		// Range in dragonfly is without "bytes=" and the digest is not calculated for range.
+		urlMeta.Range = fmt.Sprintf("%d-%d", r.Start, r.Start+r.Length-1)
+		urlMeta.Digest = ""
+
+		statusCode = http.StatusPartialContent
+		contentLength = r.Length
+		ctx.Header(headers.ContentRange, util.GetContentRange(r.Start, r.Start+r.Length-1, meta.ContentLength))
+	}
+
+	reader, _, err := o.streamObject(ctx, client, bucketName, objectKey, urlMeta, artifactRange)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+	defer reader.Close()
+
+	setS3ObjectHeaders(ctx, meta)
+	ctx.DataFromReader(statusCode, contentLength, meta.ContentType, reader, nil)
+}
+
+// s3PutObject uses to upload object, or upload part of multipart upload.
+func (o *objectStorage) s3PutObject(ctx *gin.Context) {
+	bucketName, objectKey := s3BucketAndObjectKey(ctx)
+	if objectKey == "" || ctx.GetHeader(headerAmzCopySource) != "" {
+		o.s3NotImplemented(ctx)
+		return
+	}
+
+	if _, ok := ctx.GetQuery("uploadId"); ok {
+		o.s3UploadPart(ctx)
+		return
+	}
+
+	// The object is stored as a multipart upload of single part, so the content can be read
+	// multiple times when it's imported to local storage, seed peers and backend.
+	upload, err := o.multipartUploads.Create(bucketName, objectKey, WriteBack, "", 0)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	part, err := o.s3PutPart(ctx, upload, 1)
+	if err != nil {
+		o.multipartUploads.Remove(upload)
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	content, err := upload.Complete([]Part{part})
+	if err != nil {
+		o.multipartUploads.Remove(upload)
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	if err := o.importObject(ctx, bucketName, objectKey, WriteBack, "", 0, content.digest, content,
+		func() { o.multipartUploads.Remove(upload) }); err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	ctx.Header(headers.ETag, quoteETag(part.ETag))
+	ctx.Status(http.StatusOK)
+}
+
+// s3PostObject uses to create or complete multipart upload.
+func (o *objectStorage) s3PostObject(ctx *gin.Context) {
+	if _, ok := ctx.GetQuery("uploads"); ok {
+		o.s3CreateMultipartUpload(ctx)
+		return
+	}
+
+	if _, ok := ctx.GetQuery("uploadId"); ok {
+		o.s3CompleteMultipartUpload(ctx)
+		return
+	}
+
+	o.s3NotImplemented(ctx)
+}
+
+// s3DeleteObject uses to delete object, or abort multipart upload.
+func (o *objectStorage) s3DeleteObject(ctx *gin.Context) {
+	bucketName, objectKey := s3BucketAndObjectKey(ctx)
+	if objectKey == "" {
+		o.s3NotImplemented(ctx)
+		return
+	}
+
+	if _, ok := ctx.GetQuery("uploadId"); ok {
+		o.s3AbortMultipartUpload(ctx)
+		return
+	}
+
+	client, err := o.client()
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	logger.Infof("destroy object %s in bucket %s", objectKey, bucketName)
+	if err := client.DeleteObject(ctx, bucketName, objectKey); err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// s3CreateMultipartUpload uses to create multipart upload of object.
+func (o *objectStorage) s3CreateMultipartUpload(ctx *gin.Context) {
+	bucketName, objectKey := s3BucketAndObjectKey(ctx)
+	if objectKey == "" {
+		o.s3NotImplemented(ctx)
+		return
+	}
+
+	upload, err := o.multipartUploads.Create(bucketName, objectKey, WriteBack, "", 0)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	logger.Infof("create multipart upload %s of object %s in bucket %s", upload.id, objectKey, bucketName)
+	o.s3XML(ctx, http.StatusOK, s3InitiateMultipartUploadResult{
+		Bucket:   bucketName,
+		Key:      objectKey,
+		UploadID: upload.id,
+	})
+}
+
+// s3UploadPart uses to upload part of multipart upload.
+func (o *objectStorage) s3UploadPart(ctx *gin.Context) {
+	partNumber, err := strconv.Atoi(ctx.Query("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > MaxPartNumber {
+		o.s3AbortWithError(ctx, newS3Error(s3ErrInvalidArgument, fmt.Sprintf("Part number must be an integer between 1 and %d, inclusive", MaxPartNumber)))
+		return
+	}
+
+	upload, err := o.s3MultipartUpload(ctx)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	part, err := o.s3PutPart(ctx, upload, partNumber)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	ctx.Header(headers.ETag, quoteETag(part.ETag))
+	ctx.Status(http.StatusOK)
+}
+
+// s3ListParts uses to list the uploaded parts of multipart upload.
+func (o *objectStorage) s3ListParts(ctx *gin.Context) {
+	upload, err := o.s3MultipartUpload(ctx)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	result := s3ListPartsResult{
+		Bucket:   upload.bucketName,
+		Key:      upload.objectKey,
+		UploadID: upload.id,
+		MaxParts: MaxPartNumber,
+	}
+
+	for _, part := range upload.ListParts() {
+		result.Parts = append(result.Parts, s3Part{
+			PartNumber: part.PartNumber,
+			ETag:       quoteETag(part.ETag),
+			Size:       part.Size,
+		})
+	}
+
+	o.s3XML(ctx, http.StatusOK, result)
+}
+
+// s3CompleteMultipartUpload uses to assemble the parts into object and upload the object.
+func (o *objectStorage) s3CompleteMultipartUpload(ctx *gin.Context) {
+	upload, err := o.s3MultipartUpload(ctx)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	var body s3CompleteMultipartUpload
+	if err := xml.NewDecoder(io.LimitReader(ctx.Request.Body, s3MaxCompleteBodySize)).Decode(&body); err != nil {
+		var s3Err *s3Error
+		if errors.As(err, &s3Err) {
+			o.s3AbortWithError(ctx, err)
+			return
+		}
+
+		o.s3AbortWithError(ctx, newS3Error(s3ErrMalformedXML, "The XML you provided was not well-formed or did not validate against our published schema"))
+		return
+	}
+
+	parts := make([]Part, 0, len(body.Parts))
+	for _, part := range body.Parts {
+		parts = append(parts, Part{PartNumber: part.PartNumber, ETag: strings.Trim(part.ETag, `"`)})
+	}
+
+	content, err := upload.Complete(parts)
+	if err != nil {
+		if errors.Is(err, errInvalidPart) {
+			o.s3AbortWithError(ctx, newS3Error(s3ErrInvalidPart, err.Error()))
+			return
+		}
+
+		o.multipartUploads.Remove(upload)
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	// The upload is removed after the object is imported to backend and seed peers.
+	if err := o.importObject(ctx, upload.bucketName, upload.objectKey, upload.mode, upload.filter, upload.maxReplicas,
+		content.digest, content, func() { o.multipartUploads.Remove(upload) }); err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	location := url.URL{
+		Scheme: "http",
+		Host:   ctx.Request.Host,
+		Path:   "/" + upload.bucketName + "/" + upload.objectKey,
+	}
+	if ctx.Request.TLS != nil {
+		location.Scheme = "https"
+	}
+
+	o.s3XML(ctx, http.StatusOK, s3CompleteMultipartUploadResult{
+		Location: location.String(),
+		Bucket:   upload.bucketName,
+		Key:      upload.objectKey,
+		ETag:     quoteETag(content.digest.Encoded),
+	})
+}
+
+// s3AbortMultipartUpload uses to abort multipart upload and remove the uploaded parts.
+func (o *objectStorage) s3AbortMultipartUpload(ctx *gin.Context) {
+	upload, err := o.s3MultipartUpload(ctx)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	logger.Infof("abort multipart upload %s of object %s in bucket %s", upload.id, upload.objectKey, upload.bucketName)
+	o.multipartUploads.Remove(upload)
+	ctx.Status(http.StatusNoContent)
+}
+
+// s3MultipartUpload returns the multipart upload of request.
+func (o *objectStorage) s3MultipartUpload(ctx *gin.Context) (*multipartUpload, error) {
+	bucketName, objectKey := s3BucketAndObjectKey(ctx)
+	upload, err := o.multipartUploads.Get(bucketName, ctx.Query("uploadId"))
+	if err != nil || upload.objectKey != objectKey {
+		return nil, newS3Error(s3ErrNoSuchUpload, "The specified upload does not exist")
+	}
+
+	return upload, nil
+}
+
+// s3PutPart stores the body of request as the part of upload, the part is verified by Content-MD5 header.
+func (o *objectStorage) s3PutPart(ctx *gin.Context, upload *multipartUpload, partNumber int) (Part, error) {
+	var expectMD5 string
+	if value := ctx.GetHeader(headerContentMD5); value != "" {
+		sum, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return Part{}, newS3Error(s3ErrInvalidDigest, "The Content-MD5 you specified was invalid")
+		}
+		expectMD5 = hex.EncodeToString(sum)
+	}
+
+	part, err := upload.PutPart(partNumber, ctx.Request.Body)
+	if err != nil {
+		return Part{}, err
+	}
+
+	if expectMD5 != "" && expectMD5 != part.ETag {
+		return Part{}, newS3Error(s3ErrBadDigest, "The Content-MD5 you specified did not match what we received")
+	}
+
+	return part, nil
+}
+
+// s3ObjectMetadata returns the metadata of object, it returns NoSuchKey error when the object doesn't exist.
+func (o *objectStorage) s3ObjectMetadata(ctx *gin.Context, client objectstorage.ObjectStorage, bucketName, objectKey string) (*objectstorage.ObjectMetadata, error) {
+	meta, isExist, err := client.GetObjectMetadata(ctx, bucketName, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isExist {
+		return nil, newS3Error(s3ErrNoSuchKey, "The specified key does not exist")
+	}
+
+	return meta, nil
+}
+
+// s3XML responds the xml body.
+func (o *objectStorage) s3XML(ctx *gin.Context, statusCode int, v any) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		o.s3AbortWithError(ctx, err)
+		return
+	}
+
+	ctx.Data(statusCode, "application/xml", append([]byte(xml.Header), body...))
+}
+
+// s3AbortWithError responds the error, the error which is not s3Error is responded as InternalError.
+func (o *objectStorage) s3AbortWithError(ctx *gin.Context, err error) {
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) {
+		logger.Errorf("s3 request %s %s failed: %s", ctx.Request.Method, ctx.Request.URL.Path, err)
+		s3Err = newS3Error(s3ErrInternalError, err.Error())
+	}
+
+	ctx.Abort()
+	o.s3XML(ctx, s3Err.code.statusCode, s3ErrorResponse{
+		Code:      s3Err.code.code,
+		Message:   s3Err.message,
+		Resource:  ctx.Request.URL.Path,
+		RequestID: ctx.Writer.Header().Get(headerAmzRequestID),
+	})
+}
+
+// s3BucketAndObjectKey returns the bucket name and object key of path style request.
+func s3BucketAndObjectKey(ctx *gin.Context) (string, string) {
+	return ctx.Param("bucket"), strings.TrimPrefix(ctx.Param("object_key"), "/")
+}
+
+// setS3ObjectHeaders sets the headers of object metadata.
+func setS3ObjectHeaders(ctx *gin.Context, meta *objectstorage.ObjectMetadata) {
+	for key, value := range map[string]string{
+		headers.ContentDisposition: meta.ContentDisposition,
+		headers.ContentEncoding:    meta.ContentEncoding,
+		headers.ContentLanguage:    meta.ContentLanguage,
+		headers.ContentType:        meta.ContentType,
+		headers.ETag:               quoteETag(meta.ETag),
+	} {
+		if value != "" {
+			ctx.Header(key, value)
+		}
+	}
+
+	ctx.Header(headers.AcceptRanges, "bytes")
+	ctx.Header(config.HeaderDragonflyObjectMetaDigest, meta.Digest)
+}
+
+// quoteETag returns the quoted ETag as S3 responds.
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
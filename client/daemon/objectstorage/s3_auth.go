@@ -0,0 +1,606 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+
+	"d7y.io/dragonfly/v2/client/config"
+)
+
+const (
+	// sigV4Algorithm is the algorithm of AWS Signature Version 4.
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// sigV4ChunkAlgorithm is the algorithm of the chunk signature of streaming payload.
+	sigV4ChunkAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+
+	// sigV4DateFormat is the format of X-Amz-Date.
+	sigV4DateFormat = "20060102T150405Z"
+
+	// sigV4Service is the service name in credential scope.
+	sigV4Service = "s3"
+
+	// sigV4Terminator is the terminator of credential scope.
+	sigV4Terminator = "aws4_request"
+
+	// sigV4MaxSkew is the max time difference between request and server.
+	sigV4MaxSkew = 15 * time.Minute
+
+	// sigV4MaxExpires is the max expires of presigned url.
+	sigV4MaxExpires = 7 * 24 * time.Hour
+
+	// sigV4MaxChunkHeaderSize is the max size of chunk header line of streaming payload.
+	sigV4MaxChunkHeaderSize = 4096
+)
+
+const (
+	// Payload hashes of x-amz-content-sha256 header.
+	unsignedPayload               = "UNSIGNED-PAYLOAD"
+	streamingSignedPayload        = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingSignedPayloadTrailer = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedPayload      = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+)
+
+const (
+	// S3 request headers.
+	headerAmzDate                 = "X-Amz-Date"
+	headerAmzContentSHA256        = "X-Amz-Content-Sha256"
+	headerAmzDecodedContentLength = "X-Amz-Decoded-Content-Length"
+)
+
+// emptySHA256 is the sha256 of empty payload.
+var emptySHA256 = hex.EncodeToString(sha256.New().Sum(nil))
+
+// sigV4Verifier verifies the AWS Signature Version 4 of S3 requests signed by header or presigned url.
+type sigV4Verifier struct {
+	region  string
+	secrets map[string]string
+	now     func() time.Time
+}
+
+// newSigV4Verifier returns the verifier of the credentials in region.
+func newSigV4Verifier(region string, credentials []config.ObjectStorageS3CredentialOption) *sigV4Verifier {
+	secrets := make(map[string]string, len(credentials))
+	for _, credential := range credentials {
+		secrets[credential.AccessKey] = credential.SecretKey
+	}
+
+	return &sigV4Verifier{
+		region:  region,
+		secrets: secrets,
+		now:     time.Now,
+	}
+}
+
+// sigV4Credential is the credential of signature.
+type sigV4Credential struct {
+	accessKey string
+	date      string
+	region    string
+	service   string
+}
+
+// scope returns the credential scope.
+func (c *sigV4Credential) scope() string {
+	return strings.Join([]string{c.date, c.region, c.service, sigV4Terminator}, "/")
+}
+
+// Verify verifies the signature of request. The body of request is replaced by the reader
+// verifying the payload, so reading body returns error when the payload doesn't match the signature.
+func (v *sigV4Verifier) Verify(req *http.Request) error {
+	if req.Header.Get(headers.Authorization) != "" {
+		return v.verifyHeader(req)
+	}
+
+	if req.URL.Query().Has("X-Amz-Algorithm") {
+		return v.verifyPresigned(req)
+	}
+
+	return newS3Error(s3ErrAccessDenied, "Anonymous access is not allowed")
+}
+
+// verifyHeader verifies the request signed by Authorization header.
+func (v *sigV4Verifier) verifyHeader(req *http.Request) error {
+	authorization := req.Header.Get(headers.Authorization)
+	algorithm, fields, ok := strings.Cut(authorization, " ")
+	if !ok || algorithm != sigV4Algorithm {
+		return newS3Error(s3ErrAccessDenied, "Only AWS Signature Version 4 is supported")
+	}
+
+	var credentialField, signedHeadersField, signature string
+	for _, field := range strings.Split(fields, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch key {
+		case "Credential":
+			credentialField = value
+		case "SignedHeaders":
+			signedHeadersField = value
+		case "Signature":
+			signature = value
+		}
+	}
+
+	if credentialField == "" || signedHeadersField == "" || signature == "" {
+		return newS3Error(s3ErrAuthorizationHeaderMalformed, "The authorization header is malformed")
+	}
+
+	amzDate := req.Header.Get(headerAmzDate)
+	date, err := time.Parse(sigV4DateFormat, amzDate)
+	if err != nil {
+		return newS3Error(s3ErrAccessDenied, "AWS authentication requires a valid x-amz-date header")
+	}
+
+	if skew := v.now().Sub(date); skew > sigV4MaxSkew || skew < -sigV4MaxSkew {
+		return newS3Error(s3ErrRequestTimeTooSkewed, "The difference between the request time and the server's time is too large")
+	}
+
+	credential, secret, err := v.credential(credentialField, amzDate)
+	if err != nil {
+		return err
+	}
+
+	payloadHash := req.Header.Get(headerAmzContentSHA256)
+	if payloadHash == "" {
+		return newS3Error(s3ErrInvalidRequest, "Missing required header for this request: x-amz-content-sha256")
+	}
+
+	signedHeaders := strings.Split(signedHeadersField, ";")
+	signingKey := sigV4SigningKey(secret, credential)
+	expect := sigV4Signature(signingKey, amzDate, credential, canonicalRequest(req, signedHeaders, payloadHash))
+	if subtle.ConstantTimeCompare([]byte(expect), []byte(signature)) != 1 {
+		return newS3Error(s3ErrSignatureDoesNotMatch, "The request signature we calculated does not match the signature you provided")
+	}
+
+	switch payloadHash {
+	case unsignedPayload:
+	case streamingSignedPayload:
+		req.Body = newChunkedReader(req.Body, &chunkSigner{
+			signingKey: signingKey,
+			amzDate:    amzDate,
+			scope:      credential.scope(),
+			signature:  signature,
+		})
+		req.ContentLength = decodedContentLength(req)
+	case streamingUnsignedPayload:
+		req.Body = newChunkedReader(req.Body, nil)
+		req.ContentLength = decodedContentLength(req)
+	case streamingSignedPayloadTrailer:
+		return newS3Error(s3ErrNotImplemented, "The signed payload with trailer is not supported")
+	default:
+		if _, err := hex.DecodeString(payloadHash); err != nil || len(payloadHash) != sha256.Size*2 {
+			return newS3Error(s3ErrInvalidArgument, "x-amz-content-sha256 must be UNSIGNED-PAYLOAD, STREAMING-AWS4-HMAC-SHA256-PAYLOAD or a valid sha256 value")
+		}
+
+		req.Body = &sha256VerifyReader{reader: req.Body, hash: sha256.New(), expect: payloadHash}
+	}
+
+	return nil
+}
+
+// verifyPresigned verifies the request of presigned url, the payload of presigned url is not signed.
+func (v *sigV4Verifier) verifyPresigned(req *http.Request) error {
+	query := req.URL.Query()
+	if query.Get("X-Amz-Algorithm") != sigV4Algorithm {
+		return newS3Error(s3ErrAuthorizationQueryParametersError, "X-Amz-Algorithm only supports AWS4-HMAC-SHA256")
+	}
+
+	var (
+		amzDate       = query.Get(headerAmzDate)
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		signature     = query.Get("X-Amz-Signature")
+	)
+	if signedHeaders == "" || signature == "" {
+		return newS3Error(s3ErrAuthorizationQueryParametersError, "Query-string authentication requires X-Amz-SignedHeaders and X-Amz-Signature")
+	}
+
+	date, err := time.Parse(sigV4DateFormat, amzDate)
+	if err != nil {
+		return newS3Error(s3ErrAuthorizationQueryParametersError, "X-Amz-Date must be in the ISO8601 long format")
+	}
+
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires <= 0 || time.Duration(expires)*time.Second > sigV4MaxExpires {
+		return newS3Error(s3ErrAuthorizationQueryParametersError, "X-Amz-Expires must be between 1 and 604800 seconds")
+	}
+
+	now := v.now()
+	if now.Before(date.Add(-sigV4MaxSkew)) {
+		return newS3Error(s3ErrAccessDenied, "Request is not valid yet")
+	}
+
+	if now.After(date.Add(time.Duration(expires) * time.Second)) {
+		return newS3Error(s3ErrAccessDenied, "Request has expired")
+	}
+
+	credential, secret, err := v.credential(query.Get("X-Amz-Credential"), amzDate)
+	if err != nil {
+		return err
+	}
+
+	payloadHash := req.Header.Get(headerAmzContentSHA256)
+	if payloadHash == "" {
+		payloadHash = unsignedPayload
+	}
+
+	expect := sigV4Signature(sigV4SigningKey(secret, credential), amzDate, credential,
+		canonicalRequest(req, strings.Split(signedHeaders, ";"), payloadHash))
+	if subtle.ConstantTimeCompare([]byte(expect), []byte(signature)) != 1 {
+		return newS3Error(s3ErrSignatureDoesNotMatch, "The request signature we calculated does not match the signature you provided")
+	}
+
+	return nil
+}
+
+// credential parses the credential of signature and returns the secret of access key.
+func (v *sigV4Verifier) credential(s, amzDate string) (*sigV4Credential, string, error) {
+	fields := strings.Split(s, "/")
+	if len(fields) != 5 || fields[4] != sigV4Terminator {
+		return nil, "", newS3Error(s3ErrAuthorizationHeaderMalformed, fmt.Sprintf("The credential %q is malformed", s))
+	}
+
+	credential := &sigV4Credential{
+		accessKey: fields[0],
+		date:      fields[1],
+		region:    fields[2],
+		service:   fields[3],
+	}
+
+	if !strings.HasPrefix(amzDate, credential.date) {
+		return nil, "", newS3Error(s3ErrAuthorizationHeaderMalformed, "The date of credential doesn't match the date of request")
+	}
+
+	if credential.region != v.region {
+		return nil, "", newS3Error(s3ErrAuthorizationHeaderMalformed, fmt.Sprintf("The region %q is wrong, expecting %q", credential.region, v.region))
+	}
+
+	if credential.service != sigV4Service {
+		return nil, "", newS3Error(s3ErrAuthorizationHeaderMalformed, fmt.Sprintf("The service %q is wrong, expecting %q", credential.service, sigV4Service))
+	}
+
+	secret, ok := v.secrets[credential.accessKey]
+	if !ok {
+		return nil, "", newS3Error(s3ErrInvalidAccessKeyID, "The access key Id you provided does not exist in our records")
+	}
+
+	return credential, secret, nil
+}
+
+// canonicalRequest returns the canonical request of signature.
+func canonicalRequest(req *http.Request, signedHeaders []string, payloadHash string) string {
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteByte(':')
+		canonicalHeaders.WriteString(canonicalHeaderValue(req, name))
+		canonicalHeaders.WriteByte('\n')
+	}
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		awsURIEncode(path, false),
+		canonicalQuery(req.URL.RawQuery),
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// canonicalHeaderValue returns the trimmed value of header, the values of same header are joined by comma.
+func canonicalHeaderValue(req *http.Request, name string) string {
+	var values []string
+	switch name {
+	case "host":
+		values = []string{req.Host}
+	case "content-length":
+		values = req.Header.Values(name)
+		if len(values) == 0 && req.ContentLength >= 0 {
+			values = []string{strconv.FormatInt(req.ContentLength, 10)}
+		}
+	default:
+		values = req.Header.Values(name)
+	}
+
+	for i, value := range values {
+		values[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return strings.Join(values, ",")
+}
+
+// canonicalQuery returns the canonical query string, the signature of presigned url is excluded.
+// The raw query is parsed manually because '+' is not space in S3 query.
+func canonicalQuery(rawQuery string) string {
+	var params []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(param, "=")
+		if k, err := url.PathUnescape(key); err == nil {
+			key = k
+		}
+
+		if key == "X-Amz-Signature" {
+			continue
+		}
+
+		if v, err := url.PathUnescape(value); err == nil {
+			value = v
+		}
+
+		params = append(params, awsURIEncode(key, true)+"="+awsURIEncode(value, true))
+	}
+
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// awsURIEncode encodes the string by the rules of AWS, the unreserved characters are not encoded
+// and the slash is encoded when encodeSlash is true.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			buf.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&buf, "%%%02X", c)
+	}
+	return buf.String()
+}
+
+// sigV4SigningKey derives the signing key of credential.
+func sigV4SigningKey(secret string, credential *sigV4Credential) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), credential.date)
+	key = hmacSHA256(key, credential.region)
+	key = hmacSHA256(key, credential.service)
+	return hmacSHA256(key, sigV4Terminator)
+}
+
+// sigV4Signature returns the signature of canonical request.
+func sigV4Signature(signingKey []byte, amzDate string, credential *sigV4Credential, canonicalRequest string) string {
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		credential.scope(),
+		hex.EncodeToString(hashed[:]),
+	}, "\n")
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// decodedContentLength returns the content length of streaming payload, it returns -1 when it's unknown.
+func decodedContentLength(req *http.Request) int64 {
+	length, err := strconv.ParseInt(req.Header.Get(headerAmzDecodedContentLength), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return length
+}
+
+// sha256VerifyReader verifies the sha256 of payload when it reaches EOF.
+type sha256VerifyReader struct {
+	reader io.ReadCloser
+	hash   hash.Hash
+	expect string
+}
+
+func (r *sha256VerifyReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(r.hash.Sum(nil)) != r.expect {
+		return n, newS3Error(s3ErrXAmzContentSHA256Mismatch, "The provided 'x-amz-content-sha256' header does not match what was computed")
+	}
+	return n, err
+}
+
+func (r *sha256VerifyReader) Close() error {
+	return r.reader.Close()
+}
+
+// chunkSigner verifies the chunk signatures of streaming payload, the signature of each chunk
+// is chained with the signature of previous chunk and the seed is the signature of request.
+type chunkSigner struct {
+	signingKey []byte
+	amzDate    string
+	scope      string
+	signature  string
+}
+
+// verify verifies the signature of chunk and chains the signature.
+func (s *chunkSigner) verify(chunkHash []byte, signature string) error {
+	stringToSign := strings.Join([]string{
+		sigV4ChunkAlgorithm,
+		s.amzDate,
+		s.scope,
+		s.signature,
+		emptySHA256,
+		hex.EncodeToString(chunkHash),
+	}, "\n")
+
+	expect := hex.EncodeToString(hmacSHA256(s.signingKey, stringToSign))
+	if subtle.ConstantTimeCompare([]byte(expect), []byte(signature)) != 1 {
+		return newS3Error(s3ErrSignatureDoesNotMatch, "The chunk signature we calculated does not match the signature you provided")
+	}
+
+	s.signature = signature
+	return nil
+}
+
+// chunkedReader decodes the aws-chunked payload, the chunk signatures are verified when signer is not nil
+// and the trailers are ignored.
+type chunkedReader struct {
+	body      io.ReadCloser
+	reader    *bufio.Reader
+	signer    *chunkSigner
+	remaining int64
+	hash      hash.Hash
+	signature string
+	err       error
+}
+
+func newChunkedReader(body io.ReadCloser, signer *chunkSigner) *chunkedReader {
+	return &chunkedReader{
+		body:   body,
+		reader: bufio.NewReaderSize(body, sigV4MaxChunkHeaderSize),
+		signer: signer,
+		hash:   sha256.New(),
+	}
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	for r.err == nil {
+		if r.remaining == 0 {
+			r.err = r.nextChunk()
+			continue
+		}
+
+		if int64(len(p)) > r.remaining {
+			p = p[:r.remaining]
+		}
+
+		n, err := r.reader.Read(p)
+		r.hash.Write(p[:n])
+		r.remaining -= int64(n)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err == nil && r.remaining == 0 {
+			err = r.finishChunk()
+		}
+
+		r.err = err
+		return n, nil
+	}
+
+	return 0, r.err
+}
+
+// nextChunk reads the header of next chunk, it returns io.EOF after the last chunk and trailers are read.
+func (r *chunkedReader) nextChunk() error {
+	line, err := r.readLine()
+	if err != nil {
+		return err
+	}
+
+	size, extension, _ := strings.Cut(line, ";")
+	r.remaining, err = strconv.ParseInt(strings.TrimSpace(size), 16, 64)
+	if err != nil || r.remaining < 0 {
+		return newS3Error(s3ErrIncompleteBody, "The chunk size is invalid")
+	}
+
+	if r.signer != nil {
+		if !strings.HasPrefix(extension, "chunk-signature=") {
+			return newS3Error(s3ErrSignatureDoesNotMatch, "The chunk signature is missing")
+		}
+		r.signature = strings.TrimPrefix(extension, "chunk-signature=")
+	}
+
+	r.hash.Reset()
+	if r.remaining > 0 {
+		return nil
+	}
+
+	// The last chunk is verified and followed by the trailers ending with empty line.
+	if r.signer != nil {
+		if err := r.signer.verify(r.hash.Sum(nil), r.signature); err != nil {
+			return err
+		}
+	}
+
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return err
+		}
+
+		if line == "" {
+			return io.EOF
+		}
+	}
+}
+
+// finishChunk reads the end of chunk data and verifies the chunk signature.
+func (r *chunkedReader) finishChunk() error {
+	line, err := r.readLine()
+	if err != nil {
+		return err
+	}
+
+	if line != "" {
+		return newS3Error(s3ErrIncompleteBody, "The chunk data is longer than the chunk size")
+	}
+
+	if r.signer != nil {
+		return r.signer.verify(r.hash.Sum(nil), r.signature)
+	}
+	return nil
+}
+
+// readLine reads the line ending with CRLF.
+func (r *chunkedReader) readLine() (string, error) {
+	line, err := r.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return "", newS3Error(s3ErrIncompleteBody, "The chunk header is too long")
+	}
+
+	if err == io.EOF {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))), nil
+}
+
+func (r *chunkedReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,218 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/objectstorage"
+)
+
+func TestListObjectsV2(t *testing.T) {
+	keys := []string{"a", "b/1", "b/2", "b/3", "c", "d/1", "d/2/1", "e"}
+	for i := 0; i < 1200; i++ {
+		keys = append(keys, fmt.Sprintf("f/%04d", i))
+	}
+	keys = append(keys, "g")
+	sort.Strings(keys)
+
+	list := func(prefix string) func(marker string, limit int64) ([]*objectstorage.ObjectMetadata, error) {
+		return func(marker string, limit int64) ([]*objectstorage.ObjectMetadata, error) {
+			var metadatas []*objectstorage.ObjectMetadata
+			for _, key := range keys {
+				if key > marker && strings.HasPrefix(key, prefix) && int64(len(metadatas)) < limit {
+					metadatas = append(metadatas, &objectstorage.ObjectMetadata{Key: key})
+				}
+			}
+			return metadatas, nil
+		}
+	}
+
+	tests := []struct {
+		name                 string
+		prefix               string
+		delimiter            string
+		maxKeys              int
+		expectKeys           []string
+		expectCommonPrefixes []string
+	}{
+		{
+			name:                 "list with delimiter",
+			delimiter:            "/",
+			maxKeys:              2,
+			expectKeys:           []string{"a", "c", "e", "g"},
+			expectCommonPrefixes: []string{"b/", "d/", "f/"},
+		},
+		{
+			name:                 "list with prefix and delimiter",
+			prefix:               "d/",
+			delimiter:            "/",
+			maxKeys:              1,
+			expectKeys:           []string{"d/1"},
+			expectCommonPrefixes: []string{"d/2/"},
+		},
+		{
+			name:       "list without delimiter",
+			prefix:     "b/",
+			maxKeys:    2,
+			expectKeys: []string{"b/1", "b/2", "b/3"},
+		},
+		{
+			name:       "list pages of backend",
+			prefix:     "f/",
+			maxKeys:    1000,
+			expectKeys: keys[8 : 8+1200],
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+
+			var (
+				objectKeys     []string
+				commonPrefixes []string
+				token          = &s3ContinuationToken{}
+			)
+			for token != nil {
+				metadatas, prefixes, next, err := listObjectsV2(list(tc.prefix), tc.prefix, tc.delimiter, *token, tc.maxKeys)
+				assert.Nil(err)
+				assert.LessOrEqual(len(metadatas)+len(prefixes), tc.maxKeys)
+
+				for _, metadata := range metadatas {
+					objectKeys = append(objectKeys, metadata.Key)
+				}
+				commonPrefixes = append(commonPrefixes, prefixes...)
+				token = next
+			}
+
+			sort.Strings(objectKeys)
+			assert.Equal(tc.expectKeys, objectKeys)
+			assert.Equal(tc.expectCommonPrefixes, commonPrefixes)
+		})
+	}
+}
+
+func TestS3MultipartUpload(t *testing.T) {
+	assert := testifyassert.New(t)
+	multipartUploads, err := newMultipartUploadManager(t.TempDir(), time.Minute)
+	assert.Nil(err)
+
+	o := &objectStorage{
+		multipartUploads: multipartUploads,
+		s3Verifier: newSigV4Verifier("us-east-1", []config.ObjectStorageS3CredentialOption{
+			{AccessKey: testAccessKey, SecretKey: testSecretKey},
+		}),
+	}
+	router := o.initS3Router(&config.DaemonOption{})
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		signS3Request(req, time.Now())
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Anonymous request is denied.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bucket/dir/object?uploads", nil))
+	assert.Equal(http.StatusForbidden, w.Code)
+	assert.Contains(w.Body.String(), "<Code>AccessDenied</Code>")
+
+	w = serve(http.MethodPost, "/bucket/dir/object?uploads", "")
+	assert.Equal(http.StatusOK, w.Code)
+
+	var initiate s3InitiateMultipartUploadResult
+	assert.Nil(xml.Unmarshal(w.Body.Bytes(), &initiate))
+	assert.Equal("bucket", initiate.Bucket)
+	assert.Equal("dir/object", initiate.Key)
+	assert.NotEmpty(initiate.UploadID)
+
+	w = serve(http.MethodPut, "/bucket/dir/object?partNumber=1&uploadId="+initiate.UploadID, "foo")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(quoteETag(md5Hex("foo")), w.Header().Get("ETag"))
+
+	w = serve(http.MethodPut, "/bucket/dir/object?partNumber=2&uploadId="+initiate.UploadID, "bar")
+	assert.Equal(http.StatusOK, w.Code)
+
+	// The upload belongs to another object.
+	w = serve(http.MethodPut, "/bucket/other?partNumber=3&uploadId="+initiate.UploadID, "baz")
+	assert.Equal(http.StatusNotFound, w.Code)
+	assert.Contains(w.Body.String(), "<Code>NoSuchUpload</Code>")
+
+	w = serve(http.MethodPut, "/bucket/dir/object?partNumber=0&uploadId="+initiate.UploadID, "baz")
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Contains(w.Body.String(), "<Code>InvalidArgument</Code>")
+
+	w = serve(http.MethodGet, "/bucket/dir/object?uploadId="+initiate.UploadID, "")
+	assert.Equal(http.StatusOK, w.Code)
+
+	var listParts s3ListPartsResult
+	assert.Nil(xml.Unmarshal(w.Body.Bytes(), &listParts))
+	assert.Equal([]s3Part{
+		{PartNumber: 1, ETag: quoteETag(md5Hex("foo")), Size: 3},
+		{PartNumber: 2, ETag: quoteETag(md5Hex("bar")), Size: 3},
+	}, listParts.Parts)
+
+	w = serve(http.MethodPost, "/bucket/dir/object?uploadId="+initiate.UploadID,
+		"<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>\"bar\"</ETag></Part></CompleteMultipartUpload>")
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Contains(w.Body.String(), "<Code>InvalidPart</Code>")
+
+	w = serve(http.MethodPost, "/bucket/dir/object?uploadId="+initiate.UploadID, "<CompleteMultipartUpload>")
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Contains(w.Body.String(), "<Code>MalformedXML</Code>")
+
+	w = serve(http.MethodDelete, "/bucket/dir/object?uploadId="+initiate.UploadID, "")
+	assert.Equal(http.StatusNoContent, w.Code)
+
+	w = serve(http.MethodGet, "/bucket/dir/object?uploadId="+initiate.UploadID, "")
+	assert.Equal(http.StatusNotFound, w.Code)
+	assert.Contains(w.Body.String(), "<Code>NoSuchUpload</Code>")
+}
+
+// signS3Request signs the request by Authorization header with unsigned payload.
+func signS3Request(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format(sigV4DateFormat)
+	req.Header.Set(headerAmzDate, amzDate)
+	req.Header.Set(headerAmzContentSHA256, unsignedPayload)
+
+	credential := &sigV4Credential{
+		accessKey: testAccessKey,
+		date:      amzDate[:8],
+		region:    "us-east-1",
+		service:   sigV4Service,
+	}
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	signature := sigV4Signature(sigV4SigningKey(testSecretKey, credential), amzDate, credential,
+		canonicalRequest(req, signedHeaders, unsignedPayload))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		sigV4Algorithm, testAccessKey, credential.scope(), strings.Join(signedHeaders, ";"), signature))
+}
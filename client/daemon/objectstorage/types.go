@@ -33,3 +33,50 @@ type PutObjectRequset struct {
 type GetObjectQuery struct {
 	Filter string `form:"filter" binding:"omitempty"`
 }
+
+type BucketParams struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+type ListObjectsQuery struct {
+	Prefix string `form:"prefix" binding:"omitempty"`
+	Marker string `form:"marker" binding:"omitempty"`
+	Limit  int64  `form:"limit,default=1000" binding:"omitempty,gt=0,lte=1000"`
+}
+
+type MultipartUploadParams struct {
+	ID       string `uri:"id" binding:"required"`
+	UploadID string `uri:"upload_id" binding:"required"`
+}
+
+type PartParams struct {
+	ID         string `uri:"id" binding:"required"`
+	UploadID   string `uri:"upload_id" binding:"required"`
+	PartNumber int    `uri:"part_number" binding:"required,gte=1,lte=10000"`
+}
+
+type CreateMultipartUploadRequest struct {
+	ObjectKey   string `json:"objectKey" binding:"required"`
+	Mode        uint   `json:"mode" binding:"omitempty,gte=0,lte=2"`
+	Filter      string `json:"filter" binding:"omitempty"`
+	MaxReplicas int    `json:"maxReplicas" binding:"omitempty,gt=0,lte=100"`
+}
+
+type CreateMultipartUploadResponse struct {
+	UploadID string `json:"uploadID"`
+}
+
+type Part struct {
+	PartNumber int    `json:"partNumber" binding:"required,gte=1,lte=10000"`
+	ETag       string `json:"etag" binding:"required"`
+	Size       int64  `json:"size,omitempty"`
+}
+
+type CompleteMultipartUploadRequest struct {
+	Parts []Part `json:"parts" binding:"required,min=1,dive"`
+}
+
+type CompleteMultipartUploadResponse struct {
+	ObjectKey string `json:"objectKey"`
+	Digest    string `json:"digest"`
+}
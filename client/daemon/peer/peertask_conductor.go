@@ -147,8 +147,12 @@ type peerTaskConductor struct {
 	trafficShaper TrafficShaper
 	// limiter will be used when enable per peer task rate limit
 	limiter *rate.Limiter
+	// background indicates the task is started by daemon itself, like prefetch, it can be preempted by traffic shaper
+	background atomic.Bool
 
 	startTime time.Time
+	// deadline is the deadline of the request which starts the task, it's zero when there is no deadline
+	deadline time.Time
 
 	// subtask only
 	parent *peerTaskConductor
@@ -170,6 +174,8 @@ type TaskOption struct {
 	GRPCDialTimeout time.Duration
 	// WatchdogTimeout > 0 indicates to start watch dog for every single peer task
 	WatchdogTimeout time.Duration
+	// PieceSelectorType indicates the strategy to select the next piece to download
+	PieceSelectorType string
 }
 
 func (ptm *peerTaskManager) newPeerTaskConductor(
@@ -179,6 +185,7 @@ func (ptm *peerTaskManager) newPeerTaskConductor(
 	parent *peerTaskConductor,
 	rg *util.Range,
 	seed bool) *peerTaskConductor {
+	requestCtx := ctx
 	// use a new context with span info
 	ctx = trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
 	ctx, span := tracer.Start(ctx, config.SpanPeerTask, trace.WithSpanKind(trace.SpanKindClient))
@@ -242,6 +249,9 @@ func (ptm *peerTaskManager) newPeerTaskConductor(
 	}
 
 	ptc.pieceDownloadCtx, ptc.pieceDownloadCancel = context.WithCancel(ptc.ctx)
+	if deadline, ok := requestCtx.Deadline(); ok {
+		ptc.deadline = deadline
+	}
 
 	return ptc
 }
@@ -545,7 +555,7 @@ func (pt *peerTaskConductor) pullPieces() {
 func (pt *peerTaskConductor) pullPiecesWithP2P() {
 	var (
 		// keep same size with pt.failedPieceCh for avoiding deadlock
-		pieceRequestQueue = ring.NewRandom[DownloadPieceRequest](config.DefaultPieceQueueExponent)
+		pieceRequestQueue = newPieceSelectorQueue(NewPieceSelector(pt.PieceSelectorType), config.DefaultPieceQueueExponent)
 	)
 	ctx, cancel := context.WithCancel(pt.ctx)
 
@@ -868,13 +878,14 @@ func (pt *peerTaskConductor) pullSinglePiece() {
 	pt.SetPieceMd5Sign(digest.SHA256FromStrings(pt.singlePiece.PieceInfo.PieceMd5))
 
 	request := &DownloadPieceRequest{
-		storage: pt.GetStorage(),
-		piece:   pt.singlePiece.PieceInfo,
-		log:     pt.Log(),
-		TaskID:  pt.GetTaskID(),
-		PeerID:  pt.GetPeerID(),
-		DstPid:  pt.singlePiece.DstPid,
-		DstAddr: pt.singlePiece.DstAddr,
+		storage:  pt.GetStorage(),
+		piece:    pt.singlePiece.PieceInfo,
+		log:      pt.Log(),
+		TaskID:   pt.GetTaskID(),
+		PeerID:   pt.GetPeerID(),
+		DstPid:   pt.singlePiece.DstPid,
+		DstAddr:  pt.singlePiece.DstAddr,
+		Priority: pt.request.UrlMeta.GetPriority(),
 	}
 
 	if result, err := pt.PieceManager.DownloadPiece(ctx, request); err == nil {
@@ -897,6 +908,18 @@ func (pt *peerTaskConductor) pullSinglePiece() {
 	}
 }
 
+// isValidPieceDigest returns false when the task is signed by sha256 merkle root but the piece digest is not sha256,
+// so that the untrusted parents can not downgrade the piece digests.
+func (pt *peerTaskConductor) isValidPieceDigest(pieceDigest string) bool {
+	sign, err := digest.Parse(pt.GetPieceMd5Sign())
+	if err != nil || sign.Algorithm != digest.AlgorithmMerkleSHA256 {
+		return true
+	}
+
+	d, err := digest.Parse(pieceDigest)
+	return err == nil && d.Algorithm == digest.AlgorithmSHA256
+}
+
 func (pt *peerTaskConductor) updateMetadata(piecePacket *commonv1.PiecePacket) {
 	// update total piece
 	var metadataChanged bool
@@ -1252,6 +1275,9 @@ func (pt *peerTaskConductor) initStorage(desiredLocation string) (err error) {
 				ContentLength:   pt.GetContentLength(),
 				TotalPieces:     pt.GetTotalPieces(),
 				PieceMd5Sign:    pt.GetPieceMd5Sign(),
+				Digest:          pt.request.UrlMeta.GetDigest(),
+				Application:     pt.request.UrlMeta.GetApplication(),
+				Tag:             pt.request.UrlMeta.GetTag(),
 			})
 	} else {
 		pt.storage, err = pt.StorageManager.RegisterSubTask(pt.ctx,
@@ -1514,6 +1540,34 @@ func (pt *peerTaskConductor) Validate() error {
 	return err
 }
 
+// resume restores the metadata and ready pieces of the partial task reloaded from disk,
+// so only the missing pieces will be downloaded.
+func (pt *peerTaskConductor) resume(reuse *storage.ReusePeerTask) error {
+	pt.SetContentLength(reuse.ContentLength)
+	pt.SetTotalPieces(reuse.TotalPieces)
+	pt.SetPieceMd5Sign(reuse.PieceMd5Sign)
+
+	piecePacket, err := pt.storage.GetPieces(pt.ctx, &commonv1.PieceTaskRequest{
+		TaskId:   pt.taskID,
+		StartNum: 0,
+		Limit:    uint32(reuse.TotalPieces),
+	})
+	if err != nil {
+		return err
+	}
+
+	pt.readyPiecesLock.Lock()
+	for _, piece := range piecePacket.PieceInfos {
+		pt.readyPieces.Set(piece.PieceNum)
+		pt.completedLength.Add(int64(piece.RangeSize))
+	}
+	pt.readyPiecesLock.Unlock()
+
+	pt.Infof("resume %d/%d pieces, completed length: %d/%d",
+		len(piecePacket.PieceInfos), reuse.TotalPieces, pt.completedLength.Load(), reuse.ContentLength)
+	return nil
+}
+
 func (pt *peerTaskConductor) PublishPieceInfo(pieceNum int32, size uint32) {
 	// mark piece ready
 	pt.readyPiecesLock.Lock()
@@ -140,11 +140,19 @@ type TaskManagerOption struct {
 }
 
 func NewPeerTaskManager(opt *TaskManagerOption) (TaskManager, error) {
+	// the traffic shaper estimates the piece size by the default piece size policy of piece manager
+	computePieceSize := util.ComputePieceSize
+	if pm, ok := opt.PieceManager.(*pieceManager); ok {
+		computePieceSize = func(contentLength int64) uint32 {
+			return pm.pieceSize("", contentLength)
+		}
+	}
+
 	ptm := &peerTaskManager{
 		TaskManagerOption: *opt,
 		runningPeerTasks:  sync.Map{},
 		conductorLock:     &sync.Mutex{},
-		trafficShaper:     NewTrafficShaper(opt.TrafficShaperType, opt.TotalRateLimit, util.ComputePieceSize),
+		trafficShaper:     NewTrafficShaper(opt.TrafficShaperType, opt.TotalRateLimit, computePieceSize),
 	}
 	ptm.trafficShaper.Start()
 	return ptm, nil
@@ -207,6 +215,15 @@ func (ptm *peerTaskManager) getOrCreatePeerTaskConductor(
 		logger.Debugf("peer task found: %s/%s", ptc.taskID, ptc.peerID)
 		return ptc, false, nil
 	}
+
+	// resume the partial task reloaded from disk with the existing peer id
+	var resume *storage.ReusePeerTask
+	if parent == nil {
+		if resume = ptm.StorageManager.FindResumableTask(taskID); resume != nil {
+			logger.Infof("resume partial peer task %s/%s, new peer id %s is replaced", taskID, resume.PeerID, request.PeerId)
+			request.PeerId = resume.PeerID
+		}
+	}
 	ptc := ptm.newPeerTaskConductor(ctx, request, limit, parent, rg, seed)
 
 	ptm.conductorLock.Lock()
@@ -232,6 +249,14 @@ func (ptm *peerTaskManager) getOrCreatePeerTaskConductor(
 		ptc.cancelNotRegisterred(commonv1.Code_ClientError, err.Error())
 		return nil, false, err
 	}
+
+	if resume != nil {
+		if err = ptc.resume(resume); err != nil {
+			ptc.Errorf("resume partial peer task error: %s", err)
+			ptc.cancelNotRegisterred(commonv1.Code_ClientError, err.Error())
+			return nil, false, err
+		}
+	}
 	return ptc, true, nil
 }
 
@@ -300,6 +325,7 @@ func (ptm *peerTaskManager) prefetchParentTask(request *schedulerv1.PeerTaskRequ
 
 	if prefetch != nil && prefetch.peerID == req.PeerId {
 		metrics.PrefetchTaskCount.Add(1)
+		prefetch.background.Store(true)
 	}
 	return prefetch
 }
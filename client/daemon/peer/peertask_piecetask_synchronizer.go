@@ -357,6 +357,10 @@ func (s *pieceTaskSynchronizer) dispatchPieceRequest(piecePacket *commonv1.Piece
 	for _, piece := range piecePacket.PieceInfos {
 		s.Infof("got piece %d from %s/%s, digest: %s, start: %d, size: %d",
 			piece.PieceNum, piecePacket.DstAddr, piecePacket.DstPid, piece.PieceMd5, piece.RangeStart, piece.RangeSize)
+		if !s.peerTaskConductor.isValidPieceDigest(piece.PieceMd5) {
+			s.Warnf("piece %d digest %s is not sha256, skip it, dest peer: %s", piece.PieceNum, piece.PieceMd5, s.dstPeer.PeerId)
+			continue
+		}
 		// FIXME when set total piece but no total digest, fetch again
 		s.peerTaskConductor.requestedPiecesLock.Lock()
 		if !s.peerTaskConductor.requestedPieces.IsSet(piece.PieceNum) {
@@ -364,13 +368,14 @@ func (s *pieceTaskSynchronizer) dispatchPieceRequest(piecePacket *commonv1.Piece
 		}
 		s.peerTaskConductor.requestedPiecesLock.Unlock()
 		req := &DownloadPieceRequest{
-			storage: s.peerTaskConductor.GetStorage(),
-			piece:   piece,
-			log:     s.peerTaskConductor.Log(),
-			TaskID:  s.peerTaskConductor.GetTaskID(),
-			PeerID:  s.peerTaskConductor.GetPeerID(),
-			DstPid:  piecePacket.DstPid,
-			DstAddr: piecePacket.DstAddr,
+			storage:  s.peerTaskConductor.GetStorage(),
+			piece:    piece,
+			log:      s.peerTaskConductor.Log(),
+			TaskID:   s.peerTaskConductor.GetTaskID(),
+			PeerID:   s.peerTaskConductor.GetPeerID(),
+			DstPid:   piecePacket.DstPid,
+			DstAddr:  piecePacket.DstAddr,
+			Priority: s.peerTaskConductor.request.UrlMeta.GetPriority(),
 		}
 
 		s.pieceRequestQueue.Enqueue(req)
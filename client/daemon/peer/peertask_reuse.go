@@ -63,6 +63,11 @@ func (ptm *peerTaskManager) tryReuseFilePeerTask(ctx context.Context,
 		reuse = ptm.StorageManager.FindCompletedTask(taskID)
 	}
 
+	// for whole file request, check the task with the same content
+	if reuse == nil && request.Range == nil && request.UrlMeta != nil && request.UrlMeta.Digest != "" {
+		reuse = ptm.StorageManager.FindCompletedTaskByDigest(taskID, request.UrlMeta.Digest)
+	}
+
 	if reuse == nil {
 		if request.Range == nil {
 			return nil, false
@@ -241,6 +246,11 @@ func (ptm *peerTaskManager) tryReuseStreamPeerTask(ctx context.Context,
 		reuse = ptm.StorageManager.FindCompletedTask(taskID)
 	}
 
+	// for whole file request, check the task with the same content
+	if reuse == nil && request.Range == nil && request.URLMeta != nil && request.URLMeta.Digest != "" {
+		reuse = ptm.StorageManager.FindCompletedTaskByDigest(taskID, request.URLMeta.Digest)
+	}
+
 	if reuse == nil {
 		if request.Range == nil {
 			return nil, nil, false
@@ -27,6 +27,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -35,6 +36,7 @@ import (
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
 
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/digest"
@@ -50,6 +52,8 @@ type DownloadPieceRequest struct {
 	DstPid     string
 	DstAddr    string
 	CalcDigest bool
+	// Priority is the priority of task, the parent shares the upload bandwidth by priority
+	Priority commonv1.Priority
 }
 
 type DownloadPieceResult struct {
@@ -208,6 +212,8 @@ func (p *pieceDownloader) buildDownloadPieceHTTPRequest(ctx context.Context, d *
 	req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d",
 		d.piece.RangeStart, d.piece.RangeStart+uint64(d.piece.RangeSize)-1))
 
+	req.Header.Set(config.HeaderDragonflyPriority, strconv.Itoa(int(d.Priority)))
+
 	// inject trace id into request header
 	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	return req
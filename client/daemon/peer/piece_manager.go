@@ -62,20 +62,25 @@ type PieceManager interface {
 
 type pieceManager struct {
 	*rate.Limiter
-	pieceDownloader   PieceDownloader
-	computePieceSize  func(contentLength int64) uint32
-	calculateDigest   bool
-	concurrentOption  *config.ConcurrentOption
-	syncPieceViaHTTPS bool
-	certPool          *x509.CertPool
+	pieceDownloader      PieceDownloader
+	computePieceSize     func(contentLength int64) uint32
+	pieceSizeSelector    *pieceSizeSelector
+	throughput           *throughputEstimator
+	calculateDigest      bool
+	pieceDigestAlgorithm string
+	concurrentOption     *config.ConcurrentOption
+	syncPieceViaHTTPS    bool
+	certPool             *x509.CertPool
 }
 
 type PieceManagerOption func(*pieceManager)
 
 func NewPieceManager(pieceDownloadTimeout time.Duration, opts ...PieceManagerOption) (PieceManager, error) {
 	pm := &pieceManager{
-		computePieceSize: util.ComputePieceSize,
-		calculateDigest:  true,
+		computePieceSize:     util.ComputePieceSize,
+		throughput:           newThroughputEstimator(),
+		calculateDigest:      true,
+		pieceDigestAlgorithm: digest.AlgorithmMD5,
 	}
 
 	pm.pieceDownloader = NewPieceDownloader(pieceDownloadTimeout, pm.certPool)
@@ -93,6 +98,28 @@ func WithCalculateDigest(enable bool) func(*pieceManager) {
 	}
 }
 
+// WithPieceDigestAlgorithm sets the algorithm of piece digest generated from source,
+// the sha256 piece digests are signed by the root of sha256 merkle tree.
+func WithPieceDigestAlgorithm(algorithm string) func(*pieceManager) {
+	return func(pm *pieceManager) {
+		if algorithm == "" {
+			return
+		}
+
+		logger.Infof("set pieceDigestAlgorithm to %s for piece manager", algorithm)
+		pm.pieceDigestAlgorithm = algorithm
+	}
+}
+
+// WithPieceSizePolicy sets the piece size policy of tasks downloaded from source,
+// the policy of application overrides the default policy.
+func WithPieceSizePolicy(policy config.PieceSizePolicyOption, applicationPolicies map[string]config.PieceSizePolicyOption) func(*pieceManager) {
+	return func(pm *pieceManager) {
+		logger.Infof("set piece size policy %q for piece manager", policy.Type)
+		pm.pieceSizeSelector = newPieceSizeSelector(policy, applicationPolicies, pm.throughput)
+	}
+}
+
 // WithLimiter sets upload rate limiter, the burst size must be bigger than piece size
 func WithLimiter(limiter *rate.Limiter) func(*pieceManager) {
 	return func(manager *pieceManager) {
@@ -226,6 +253,7 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, request *DownloadPiec
 			request.piece.PieceNum, result.Size, err)
 		return result, err
 	}
+	pm.throughput.Observe(result.Size, time.Duration(result.FinishTime-result.BeginTime))
 	return result, nil
 }
 
@@ -252,7 +280,12 @@ func (pm *pieceManager) processPieceFromSource(pt Task,
 	}
 	if pm.calculateDigest {
 		pt.Log().Debugf("piece %d calculate digest", pieceNum)
-		reader, _ = digest.NewReader(reader, digest.WithLogger(pt.Log()))
+		reader, err = digest.NewReader(reader, digest.WithAlgorithm(pm.pieceDigestAlgorithm), digest.WithLogger(pt.Log()))
+		if err != nil {
+			result.FinishTime = time.Now().UnixNano()
+			pt.Log().Errorf("init digest reader error: %s", err)
+			return
+		}
 	}
 	var n int64
 	result.Size, err = pt.GetStorage().WritePiece(
@@ -285,8 +318,9 @@ func (pm *pieceManager) processPieceFromSource(pt Task,
 		pt.Log().Errorf("put piece to storage failed, piece num: %d, wrote: %d, error: %s", pieceNum, n, err)
 		return
 	}
+	pm.throughput.Observe(result.Size, time.Duration(result.FinishTime-result.BeginTime))
 	if pm.calculateDigest {
-		md5 = reader.(digest.Reader).Encoded()
+		md5 = reader.(digest.Reader).Digest()
 	}
 	return
 }
@@ -361,7 +395,8 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt Task, peerTaskReq
 					return err
 				}
 				// use concurrent piece download mode
-				return pm.concurrentDownloadSource(ctx, pt, peerTaskRequest, parsedRange, 0)
+				pieceSize := pm.pieceSize(peerTaskRequest.UrlMeta.Application, parsedRange.Length)
+				return pm.concurrentDownloadSource(ctx, pt, peerTaskRequest, parsedRange, pieceSize, 0)
 			}
 		}
 	}
@@ -406,7 +441,7 @@ singleDownload:
 	}
 	contentLength := response.ContentLength
 	// we must calculate piece size
-	pieceSize := pm.computePieceSize(contentLength)
+	pieceSize := pm.pieceSize(peerTaskRequest.UrlMeta.Application, contentLength)
 	if contentLength < 0 {
 		log.Warnf("can not get content length for %s", peerTaskRequest.Url)
 	} else {
@@ -503,7 +538,7 @@ func (pm *pieceManager) downloadKnownLengthSource(ctx context.Context, pt Task,
 			speed := float64(pieceSize) / float64((result.FinishTime-result.BeginTime)/1000000)
 			if speed < float64(pm.concurrentOption.ThresholdSpeed) {
 				response.Body.Close()
-				return pm.concurrentDownloadSource(ctx, pt, peerTaskRequest, parsedRange, pieceNum+1)
+				return pm.concurrentDownloadSource(ctx, pt, peerTaskRequest, parsedRange, pieceSize, pieceNum+1)
 			}
 		}
 	}
@@ -608,7 +643,11 @@ func (pm *pieceManager) processPieceFromFile(ctx context.Context, ptm storage.Pe
 
 	if pm.calculateDigest {
 		log.Debugf("calculate digest in processPieceFromFile")
-		reader, _ = digest.NewReader(r, digest.WithLogger(log))
+		var err error
+		if reader, err = digest.NewReader(r, digest.WithAlgorithm(pm.pieceDigestAlgorithm), digest.WithLogger(log)); err != nil {
+			log.Errorf("init digest reader error: %s", err)
+			return 0, err
+		}
 	}
 	n, err := tsd.WritePiece(ctx,
 		&storage.WritePieceRequest{
@@ -644,7 +683,7 @@ func (pm *pieceManager) ImportFile(ctx context.Context, ptm storage.PeerTaskMeta
 		return errors.New(msg)
 	}
 	contentLength := stat.Size()
-	pieceSize := pm.computePieceSize(contentLength)
+	pieceSize := pm.pieceSize(req.UrlMeta.GetApplication(), contentLength)
 	maxPieceNum := util.ComputePieceCount(contentLength, pieceSize)
 
 	file, err := os.Open(req.Path)
@@ -709,7 +748,7 @@ func (pm *pieceManager) ImportFile(ctx context.Context, ptm storage.PeerTaskMeta
 
 func (pm *pieceManager) Import(ctx context.Context, ptm storage.PeerTaskMetadata, tsd storage.TaskStorageDriver, contentLength int64, reader io.Reader) error {
 	log := logger.WithTaskAndPeerID(ptm.TaskID, ptm.PeerID)
-	pieceSize := pm.computePieceSize(contentLength)
+	pieceSize := pm.pieceSize("", contentLength)
 	maxPieceNum := util.ComputePieceCount(contentLength, pieceSize)
 
 	for pieceNum := int32(0); pieceNum < maxPieceNum; pieceNum++ {
@@ -764,9 +803,10 @@ func (pm *pieceManager) Import(ctx context.Context, ptm storage.PeerTaskMetadata
 	return nil
 }
 
-func (pm *pieceManager) concurrentDownloadSource(ctx context.Context, pt Task, peerTaskRequest *schedulerv1.PeerTaskRequest, parsedRange *clientutil.Range, startPieceNum int32) error {
+// concurrentDownloadSource downloads the pieces from startPieceNum concurrently, the pieceSize must be same
+// with the downloaded pieces, so the piece size of task is consistent
+func (pm *pieceManager) concurrentDownloadSource(ctx context.Context, pt Task, peerTaskRequest *schedulerv1.PeerTaskRequest, parsedRange *clientutil.Range, pieceSize uint32, startPieceNum int32) error {
 	// parsedRange is always exist
-	pieceSize := pm.computePieceSize(parsedRange.Length)
 	pieceCount := util.ComputePieceCount(parsedRange.Length, pieceSize)
 	var downloadError atomic.Value
 
@@ -927,3 +967,12 @@ func (pm *pieceManager) downloadPieceFromSource(ctx context.Context,
 	pt.PublishPieceInfo(num, uint32(result.Size))
 	return nil
 }
+
+// pieceSize returns the piece size of task downloaded from source or imported by the piece size policy of the application,
+// it's called only once for every task, so the piece size is consistent across the task
+func (pm *pieceManager) pieceSize(application string, contentLength int64) uint32 {
+	if pm.pieceSizeSelector == nil {
+		return pm.computePieceSize(contentLength)
+	}
+	return pm.pieceSizeSelector.PieceSize(application, contentLength)
+}
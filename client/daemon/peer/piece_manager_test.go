@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -87,6 +88,7 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 		recordDownloadTime bool
 		bandwidth          clientutil.Size
 		concurrentOption   *config.ConcurrentOption
+		digestAlgorithm    string
 	}{
 		{
 			name:              "multiple pieces with content length, check digest",
@@ -94,6 +96,13 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 			checkDigest:       true,
 			withContentLength: true,
 		},
+		{
+			name:              "multiple pieces with content length, sha256 piece digest",
+			pieceSize:         1024,
+			checkDigest:       true,
+			withContentLength: true,
+			digestAlgorithm:   "sha256",
+		},
 		{
 			name:               "multiple pieces with content length",
 			pieceSize:          1024,
@@ -438,7 +447,7 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 			}))
 			defer ts.Close()
 
-			pm, err := NewPieceManager(pieceDownloadTimeout, WithConcurrentOption(tc.concurrentOption))
+			pm, err := NewPieceManager(pieceDownloadTimeout, WithConcurrentOption(tc.concurrentOption), WithPieceDigestAlgorithm(tc.digestAlgorithm))
 			assert.Nil(err)
 			pm.(*pieceManager).computePieceSize = func(length int64) uint32 {
 				return tc.pieceSize
@@ -467,6 +476,18 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 				log.Infof("download took %s", elapsed)
 			}
 
+			if tc.digestAlgorithm == "sha256" {
+				piecePacket, err := taskStorage.GetPieces(context.Background(), &commonv1.PieceTaskRequest{
+					TaskId: taskID,
+					DstPid: peerID,
+					Limit:  1,
+				})
+				assert.Nil(err)
+				assert.True(strings.HasPrefix(piecePacket.PieceMd5Sign, "merkle-sha256:"))
+				assert.True(strings.HasPrefix(piecePacket.PieceInfos[0].PieceMd5, "sha256:"))
+				assert.Nil(taskStorage.ValidateDigest(&storage.PeerTaskMetadata{PeerID: peerID, TaskID: taskID}))
+			}
+
 			err = storageManager.Store(context.Background(),
 				&storage.StoreRequest{
 					CommonTaskRequest: storage.CommonTaskRequest{
@@ -0,0 +1,202 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"sync"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/container/ring"
+)
+
+const (
+	TypeSequentialPieceSelector  = "sequential"
+	TypeRarestFirstPieceSelector = "rarest-first"
+	TypeHybridPieceSelector      = "hybrid"
+)
+
+const (
+	// DefaultHybridSequentialPieces is the count of pieces at the head of file
+	// downloaded sequentially by the hybrid piece selector.
+	DefaultHybridSequentialPieces = 16
+)
+
+// PieceSelector selects the next piece to download from the pending piece requests
+type PieceSelector interface {
+	// Select returns the index of the request to download in the pending requests,
+	// availability returns the count of parents which have the piece
+	Select(requests []*DownloadPieceRequest, availability func(pieceNum int32) int) int
+}
+
+func NewPieceSelector(pieceSelectorType string) PieceSelector {
+	var ps PieceSelector
+	switch pieceSelectorType {
+	case TypeSequentialPieceSelector, "":
+		ps = NewSequentialPieceSelector()
+	case TypeRarestFirstPieceSelector:
+		ps = NewRarestFirstPieceSelector()
+	case TypeHybridPieceSelector:
+		ps = NewHybridPieceSelector(DefaultHybridSequentialPieces)
+	default:
+		logger.Warnf("type \"%s\" doesn't exist, use sequential piece selector instead", pieceSelectorType)
+		ps = NewSequentialPieceSelector()
+	}
+	return ps
+}
+
+// sequentialPieceSelector selects the piece with the lowest piece number
+type sequentialPieceSelector struct{}
+
+func NewSequentialPieceSelector() PieceSelector {
+	return &sequentialPieceSelector{}
+}
+
+func (s *sequentialPieceSelector) Select(requests []*DownloadPieceRequest, _ func(int32) int) int {
+	selected := 0
+	for i, request := range requests {
+		if request.piece.PieceNum < requests[selected].piece.PieceNum {
+			selected = i
+		}
+	}
+	return selected
+}
+
+// rarestFirstPieceSelector selects the piece owned by the fewest parents,
+// so the peers fetching the same task spread the requests over the parents
+type rarestFirstPieceSelector struct{}
+
+func NewRarestFirstPieceSelector() PieceSelector {
+	return &rarestFirstPieceSelector{}
+}
+
+func (s *rarestFirstPieceSelector) Select(requests []*DownloadPieceRequest, availability func(int32) int) int {
+	selected, selectedAvailability := 0, availability(requests[0].piece.PieceNum)
+	for i, request := range requests[1:] {
+		n := availability(request.piece.PieceNum)
+		if n < selectedAvailability ||
+			(n == selectedAvailability && request.piece.PieceNum < requests[selected].piece.PieceNum) {
+			selected, selectedAvailability = i+1, n
+		}
+	}
+	return selected
+}
+
+// hybridPieceSelector selects the pieces at the head of file sequentially for streaming readers,
+// and selects the rest pieces by rarest first
+type hybridPieceSelector struct {
+	sequentialPieces int32
+	sequential       PieceSelector
+	rarestFirst      PieceSelector
+}
+
+func NewHybridPieceSelector(sequentialPieces int32) PieceSelector {
+	return &hybridPieceSelector{
+		sequentialPieces: sequentialPieces,
+		sequential:       NewSequentialPieceSelector(),
+		rarestFirst:      NewRarestFirstPieceSelector(),
+	}
+}
+
+func (s *hybridPieceSelector) Select(requests []*DownloadPieceRequest, availability func(int32) int) int {
+	selected := s.sequential.Select(requests, availability)
+	if requests[selected].piece.PieceNum < s.sequentialPieces {
+		return selected
+	}
+	return s.rarestFirst.Select(requests, availability)
+}
+
+// pieceSelectorQueue is a bounded queue of piece requests, it dequeues the request selected by PieceSelector,
+// and counts the availability of pieces by the parents which send the piece requests
+type pieceSelectorQueue struct {
+	locker       sync.Mutex
+	enqCond      *sync.Cond
+	deqCond      *sync.Cond
+	size         int
+	closed       bool
+	selector     PieceSelector
+	requests     []*DownloadPieceRequest
+	availability map[int32]map[string]struct{}
+}
+
+var _ ring.Queue[DownloadPieceRequest] = (*pieceSelectorQueue)(nil)
+
+func newPieceSelectorQueue(selector PieceSelector, exponent int) *pieceSelectorQueue {
+	q := &pieceSelectorQueue{
+		size:         1 << exponent,
+		selector:     selector,
+		availability: map[int32]map[string]struct{}{},
+	}
+	q.enqCond = sync.NewCond(&q.locker)
+	q.deqCond = sync.NewCond(&q.locker)
+	return q
+}
+
+func (q *pieceSelectorQueue) Enqueue(request *DownloadPieceRequest) {
+	q.locker.Lock()
+	defer q.locker.Unlock()
+
+	// record the parent before waiting, parents which send the same piece are counted even the queue is full
+	parents, ok := q.availability[request.piece.PieceNum]
+	if !ok {
+		parents = map[string]struct{}{}
+		q.availability[request.piece.PieceNum] = parents
+	}
+	parents[request.DstPid] = struct{}{}
+
+	for !q.closed && len(q.requests) >= q.size {
+		q.enqCond.Wait()
+	}
+
+	if q.closed {
+		return
+	}
+
+	q.requests = append(q.requests, request)
+	q.deqCond.Signal()
+}
+
+func (q *pieceSelectorQueue) Dequeue() (*DownloadPieceRequest, bool) {
+	q.locker.Lock()
+	defer q.locker.Unlock()
+
+	for !q.closed && len(q.requests) == 0 {
+		q.deqCond.Wait()
+	}
+
+	if q.closed {
+		return nil, false
+	}
+
+	i := q.selector.Select(q.requests, q.getAvailability)
+	request := q.requests[i]
+	q.requests = append(q.requests[:i], q.requests[i+1:]...)
+	q.enqCond.Signal()
+	return request, true
+}
+
+func (q *pieceSelectorQueue) Close() {
+	q.locker.Lock()
+	q.closed = true
+	q.enqCond.Broadcast()
+	q.deqCond.Broadcast()
+	q.locker.Unlock()
+}
+
+// getAvailability returns the count of parents which have the piece, it must be called with locker held
+func (q *pieceSelectorQueue) getAvailability(pieceNum int32) int {
+	return len(q.availability[pieceNum])
+}
@@ -0,0 +1,136 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"sync"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+)
+
+func newTestPieceRequest(pieceNum int32, dstPid string) *DownloadPieceRequest {
+	return &DownloadPieceRequest{
+		piece:  &commonv1.PieceInfo{PieceNum: pieceNum},
+		DstPid: dstPid,
+	}
+}
+
+func TestPieceSelector_Select(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		selector     PieceSelector
+		pieceNums    []int32
+		availability map[int32]int
+		expect       int32
+	}{
+		{
+			name:      "sequential selects the lowest piece",
+			selector:  NewSequentialPieceSelector(),
+			pieceNums: []int32{5, 3, 7},
+			expect:    3,
+		},
+		{
+			name:         "rarest first selects the piece of fewest parents",
+			selector:     NewRarestFirstPieceSelector(),
+			pieceNums:    []int32{1, 2, 3},
+			availability: map[int32]int{1: 3, 2: 1, 3: 2},
+			expect:       2,
+		},
+		{
+			name:         "rarest first selects the lowest piece of same availability",
+			selector:     NewRarestFirstPieceSelector(),
+			pieceNums:    []int32{9, 4, 6},
+			availability: map[int32]int{9: 1, 4: 1, 6: 2},
+			expect:       4,
+		},
+		{
+			name:         "hybrid selects the head piece sequentially",
+			selector:     NewHybridPieceSelector(4),
+			pieceNums:    []int32{5, 3, 8},
+			availability: map[int32]int{5: 3, 3: 3, 8: 1},
+			expect:       3,
+		},
+		{
+			name:         "hybrid selects the rarest piece out of head",
+			selector:     NewHybridPieceSelector(4),
+			pieceNums:    []int32{5, 6, 8},
+			availability: map[int32]int{5: 3, 6: 2, 8: 1},
+			expect:       8,
+		},
+		{
+			name:      "new piece selector falls back to sequential",
+			selector:  NewPieceSelector("unknown"),
+			pieceNums: []int32{2, 1},
+			expect:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			var requests []*DownloadPieceRequest
+			for _, pieceNum := range tc.pieceNums {
+				requests = append(requests, newTestPieceRequest(pieceNum, "peer"))
+			}
+
+			i := tc.selector.Select(requests, func(pieceNum int32) int {
+				return tc.availability[pieceNum]
+			})
+			assert.Equal(tc.expect, requests[i].piece.PieceNum)
+		})
+	}
+}
+
+func TestPieceSelectorQueue(t *testing.T) {
+	assert := testifyassert.New(t)
+	q := newPieceSelectorQueue(NewRarestFirstPieceSelector(), 2)
+
+	q.Enqueue(newTestPieceRequest(1, "peer-1"))
+	q.Enqueue(newTestPieceRequest(1, "peer-2"))
+	q.Enqueue(newTestPieceRequest(2, "peer-1"))
+	q.Enqueue(newTestPieceRequest(1, "peer-1"))
+	assert.Equal(2, q.getAvailability(1))
+	assert.Equal(1, q.getAvailability(2))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// the queue is full, enqueue waits for dequeue
+		q.Enqueue(newTestPieceRequest(3, "peer-3"))
+	}()
+
+	request, ok := q.Dequeue()
+	assert.True(ok)
+	assert.Equal(int32(2), request.piece.PieceNum)
+	wg.Wait()
+
+	request, ok = q.Dequeue()
+	assert.True(ok)
+	assert.Equal(int32(3), request.piece.PieceNum)
+
+	request, ok = q.Dequeue()
+	assert.True(ok)
+	assert.Equal(int32(1), request.piece.PieceNum)
+
+	q.Close()
+	_, ok = q.Dequeue()
+	assert.False(ok)
+}
@@ -0,0 +1,223 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"sync"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/config"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/internal/util"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+const (
+	TypeDefaultPieceSizePolicy  = "default"
+	TypeAdaptivePieceSizePolicy = "adaptive"
+)
+
+const (
+	// DefaultMinPieceSize is the default min piece size of adaptive piece size policy.
+	DefaultMinPieceSize = 1 * unit.MB
+
+	// DefaultMaxPieceSize is the default max piece size of adaptive piece size policy.
+	DefaultMaxPieceSize = 64 * unit.MB
+
+	// DefaultMaxPieceCount is the max piece count of task, the piece size grows to keep the metadata small.
+	DefaultMaxPieceCount = 1024
+
+	// DefaultPieceDownloadDuration is the expected duration to download a piece at the observed throughput.
+	DefaultPieceDownloadDuration = 5 * time.Second
+
+	// pieceSizeAlignment is the alignment of adaptive piece size.
+	pieceSizeAlignment = 64 * unit.KB
+
+	// throughputDecay is the weight of history throughput in the moving average.
+	throughputDecay = 0.8
+)
+
+// PieceSizePolicy chooses the piece size of the task downloaded from source.
+// The piece size is chosen only once by the peer which downloads the task from source,
+// and the other peers download the pieces as the parents split, so it's consistent across the task.
+type PieceSizePolicy interface {
+	// PieceSize returns the piece size of the task with the content length, the content length is -1 when unknown.
+	PieceSize(contentLength int64) uint32
+}
+
+// NewPieceSizePolicy returns the piece size policy of the option,
+// the adaptive policy uses the throughput observed by the piece manager.
+func NewPieceSizePolicy(option config.PieceSizePolicyOption, throughput *throughputEstimator) PieceSizePolicy {
+	var policy PieceSizePolicy
+	switch option.Type {
+	case TypeDefaultPieceSizePolicy, "":
+		policy = NewDefaultPieceSizePolicy()
+	case TypeAdaptivePieceSizePolicy:
+		policy = NewAdaptivePieceSizePolicy(option, throughput)
+	default:
+		logger.Warnf("type \"%s\" doesn't exist, use default piece size policy instead", option.Type)
+		policy = NewDefaultPieceSizePolicy()
+	}
+	return policy
+}
+
+// defaultPieceSizePolicy chooses the piece size by the content length only
+type defaultPieceSizePolicy struct{}
+
+func NewDefaultPieceSizePolicy() PieceSizePolicy {
+	return &defaultPieceSizePolicy{}
+}
+
+func (p *defaultPieceSizePolicy) PieceSize(contentLength int64) uint32 {
+	return util.ComputePieceSize(contentLength)
+}
+
+// adaptivePieceSizePolicy chooses the piece size by the content length, the expected peers and the observed throughput:
+// the piece size grows to keep the piece count under DefaultMaxPieceCount for large files,
+// shrinks to keep at least one piece for every expected peer,
+// and shrinks to download a piece in DefaultPieceDownloadDuration on slow links.
+type adaptivePieceSizePolicy struct {
+	minPieceSize  int64
+	maxPieceSize  int64
+	expectedPeers int64
+	throughput    *throughputEstimator
+}
+
+func NewAdaptivePieceSizePolicy(option config.PieceSizePolicyOption, throughput *throughputEstimator) PieceSizePolicy {
+	p := &adaptivePieceSizePolicy{
+		minPieceSize:  int64(option.MinPieceSize),
+		maxPieceSize:  int64(option.MaxPieceSize),
+		expectedPeers: int64(option.ExpectedPeers),
+		throughput:    throughput,
+	}
+
+	if p.minPieceSize <= 0 {
+		p.minPieceSize = int64(DefaultMinPieceSize)
+	}
+
+	if p.maxPieceSize <= 0 {
+		p.maxPieceSize = int64(DefaultMaxPieceSize)
+	}
+
+	if p.maxPieceSize < p.minPieceSize {
+		p.maxPieceSize = p.minPieceSize
+	}
+	return p
+}
+
+func (p *adaptivePieceSizePolicy) PieceSize(contentLength int64) uint32 {
+	size := int64(util.ComputePieceSize(contentLength))
+	if contentLength > 0 {
+		// keep the metadata small for large files
+		if s := contentLength / DefaultMaxPieceCount; s > size {
+			size = s
+		}
+
+		// a piece is never larger than the content
+		if contentLength < size {
+			size = contentLength
+		}
+
+		// keep enough pieces for the peers to exchange
+		if p.expectedPeers > 0 {
+			if s := contentLength / p.expectedPeers; s < size {
+				size = s
+			}
+		}
+	}
+
+	// download a piece in the expected duration on slow links
+	if throughput := p.throughput.Throughput(); throughput > 0 {
+		if s := int64(throughput * DefaultPieceDownloadDuration.Seconds()); s < size {
+			size = s
+		}
+	}
+
+	// align the piece size
+	size = (size + int64(pieceSizeAlignment) - 1) / int64(pieceSizeAlignment) * int64(pieceSizeAlignment)
+	if size < p.minPieceSize {
+		size = p.minPieceSize
+	}
+
+	if size > p.maxPieceSize {
+		size = p.maxPieceSize
+	}
+	return uint32(size)
+}
+
+// pieceSizeSelector selects the piece size policy by the application of task
+type pieceSizeSelector struct {
+	policy              PieceSizePolicy
+	applicationPolicies map[string]PieceSizePolicy
+}
+
+func newPieceSizeSelector(policy config.PieceSizePolicyOption, applicationPolicies map[string]config.PieceSizePolicyOption,
+	throughput *throughputEstimator) *pieceSizeSelector {
+	s := &pieceSizeSelector{
+		policy:              NewPieceSizePolicy(policy, throughput),
+		applicationPolicies: map[string]PieceSizePolicy{},
+	}
+
+	for application, option := range applicationPolicies {
+		s.applicationPolicies[application] = NewPieceSizePolicy(option, throughput)
+	}
+	return s
+}
+
+func (s *pieceSizeSelector) PieceSize(application string, contentLength int64) uint32 {
+	if policy, ok := s.applicationPolicies[application]; ok {
+		return policy.PieceSize(contentLength)
+	}
+	return s.policy.PieceSize(contentLength)
+}
+
+// throughputEstimator estimates the throughput of piece downloading by exponential moving average.
+type throughputEstimator struct {
+	mu         sync.RWMutex
+	throughput float64
+}
+
+func newThroughputEstimator() *throughputEstimator {
+	return &throughputEstimator{}
+}
+
+// Observe records the n bytes downloaded in the cost, it's a no-op for nil estimator.
+func (e *throughputEstimator) Observe(n int64, cost time.Duration) {
+	if e == nil || n <= 0 || cost <= 0 {
+		return
+	}
+
+	throughput := float64(n) / cost.Seconds()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.throughput == 0 {
+		e.throughput = throughput
+		return
+	}
+	e.throughput = e.throughput*throughputDecay + throughput*(1-throughputDecay)
+}
+
+// Throughput returns the estimated bytes per second, it returns 0 when there is no observation.
+func (e *throughputEstimator) Throughput() float64 {
+	if e == nil {
+		return 0
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.throughput
+}
@@ -0,0 +1,136 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/internal/util"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+func TestPieceSizePolicy_PieceSize(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		option        config.PieceSizePolicyOption
+		throughput    int64
+		contentLength int64
+		expect        uint32
+	}{
+		{
+			name:          "default policy",
+			option:        config.PieceSizePolicyOption{Type: TypeDefaultPieceSizePolicy},
+			contentLength: int64(unit.GB),
+			expect:        util.ComputePieceSize(int64(unit.GB)),
+		},
+		{
+			name:          "unknown policy falls back to default",
+			option:        config.PieceSizePolicyOption{Type: "unknown"},
+			contentLength: int64(unit.GB),
+			expect:        util.ComputePieceSize(int64(unit.GB)),
+		},
+		{
+			name:          "adaptive policy grows piece size for large file",
+			option:        config.PieceSizePolicyOption{Type: TypeAdaptivePieceSizePolicy},
+			contentLength: int64(32 * unit.GB),
+			expect:        uint32(32 * unit.MB),
+		},
+		{
+			name:          "adaptive policy limits piece size by max piece size",
+			option:        config.PieceSizePolicyOption{Type: TypeAdaptivePieceSizePolicy},
+			contentLength: int64(100 * unit.GB),
+			expect:        uint32(DefaultMaxPieceSize),
+		},
+		{
+			name: "adaptive policy shrinks piece size for expected peers",
+			option: config.PieceSizePolicyOption{
+				Type:          TypeAdaptivePieceSizePolicy,
+				ExpectedPeers: 32,
+			},
+			contentLength: int64(64 * unit.MB),
+			expect:        uint32(2 * unit.MB),
+		},
+		{
+			name:          "adaptive policy limits piece size by min piece size",
+			option:        config.PieceSizePolicyOption{Type: TypeAdaptivePieceSizePolicy},
+			contentLength: int64(unit.KB),
+			expect:        uint32(DefaultMinPieceSize),
+		},
+		{
+			name: "adaptive policy shrinks piece size for slow link",
+			option: config.PieceSizePolicyOption{
+				Type:         TypeAdaptivePieceSizePolicy,
+				MinPieceSize: 64 * unit.KB,
+			},
+			throughput:    int64(100 * unit.KB),
+			contentLength: int64(64 * unit.MB),
+			expect:        uint32(512 * unit.KB),
+		},
+		{
+			name:          "adaptive policy with unknown content length",
+			option:        config.PieceSizePolicyOption{Type: TypeAdaptivePieceSizePolicy},
+			contentLength: -1,
+			expect:        util.ComputePieceSize(-1),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			throughput := newThroughputEstimator()
+			throughput.Observe(tc.throughput, time.Second)
+
+			policy := NewPieceSizePolicy(tc.option, throughput)
+			assert.Equal(tc.expect, policy.PieceSize(tc.contentLength))
+		})
+	}
+}
+
+func TestPieceSizeSelector_PieceSize(t *testing.T) {
+	assert := testifyassert.New(t)
+	s := newPieceSizeSelector(config.PieceSizePolicyOption{Type: TypeDefaultPieceSizePolicy},
+		map[string]config.PieceSizePolicyOption{
+			"model": {
+				Type:         TypeAdaptivePieceSizePolicy,
+				MinPieceSize: 16 * unit.MB,
+			},
+		}, newThroughputEstimator())
+
+	assert.Equal(util.ComputePieceSize(int64(unit.GB)), s.PieceSize("", int64(unit.GB)))
+	assert.Equal(util.ComputePieceSize(int64(unit.GB)), s.PieceSize("other", int64(unit.GB)))
+	assert.Equal(uint32(16*unit.MB), s.PieceSize("model", int64(unit.GB)))
+}
+
+func TestThroughputEstimator(t *testing.T) {
+	assert := testifyassert.New(t)
+	e := newThroughputEstimator()
+	assert.Equal(float64(0), e.Throughput())
+
+	e.Observe(0, time.Second)
+	e.Observe(100, 0)
+	assert.Equal(float64(0), e.Throughput())
+
+	e.Observe(100, time.Second)
+	assert.Equal(float64(100), e.Throughput())
+
+	e.Observe(600, time.Second)
+	assert.InDelta(float64(200), e.Throughput(), 0.001)
+}
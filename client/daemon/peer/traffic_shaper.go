@@ -23,13 +23,27 @@ import (
 	"go.uber.org/atomic"
 	"golang.org/x/time/rate"
 
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/math"
+	"d7y.io/dragonfly/v2/pkg/unit"
 )
 
 const (
 	TypePlainTrafficShaper    = "plain"
 	TypeSamplingTrafficShaper = "sampling"
+	TypePriorityTrafficShaper = "priority"
+)
+
+const (
+	// preemptedBandwidthRatio is the ratio of total bandwidth kept for background tasks when they are preempted
+	preemptedBandwidthRatio = 0.05
+
+	// minTaskBandwidth is the min bandwidth of every task, so the preempted tasks are still alive
+	minTaskBandwidth = float64(unit.MB)
+
+	// saturatedBandwidthRatio indicates the task uses up its bandwidth and may need more
+	saturatedBandwidthRatio = 0.9
 )
 
 // TrafficShaper allocates bandwidth for running tasks dynamically
@@ -53,6 +67,8 @@ func NewTrafficShaper(trafficShaperType string, totalRateLimit rate.Limit, compu
 	switch trafficShaperType {
 	case TypeSamplingTrafficShaper:
 		ts = NewSamplingTrafficShaper(totalRateLimit, computePieceSize)
+	case TypePriorityTrafficShaper:
+		ts = NewPriorityTrafficShaper(totalRateLimit)
 	case TypePlainTrafficShaper:
 		ts = NewPlainTrafficShaper()
 	default:
@@ -259,3 +275,248 @@ func (ts *samplingTrafficShaper) Record(taskID string, n int) {
 func (ts *samplingTrafficShaper) GetBandwidth() int64 {
 	return ts.lastSecondBandwidth.Load()
 }
+
+type priorityTaskEntry struct {
+	ptc *peerTaskConductor
+	// used bandwidth in the past second
+	lastSecondBandwidth *atomic.Int64
+	// sampled indicates the used bandwidth has been sampled, tasks added within one second are not sampled
+	sampled bool
+	// demand is the bandwidth the task is able to use in the next second, learned from the used bandwidth
+	demand float64
+	// minimum is the bandwidth to complete the task before the deadline
+	minimum float64
+	// weight is the weight of task priority
+	weight float64
+	// allocated is the bandwidth allocated in the next second
+	allocated float64
+}
+
+// priorityTrafficShaper allocates bandwidth by the priority, remaining length and deadline of tasks.
+// The foreground tasks preempt the background tasks, such as prefetch tasks and seed tasks of preheat,
+// and the background tasks only use the bandwidth which the foreground tasks can't use.
+type priorityTrafficShaper struct {
+	*logger.SugaredLoggerOnWith
+	sync.RWMutex
+	totalRateLimit rate.Limit
+	// total used bandwidth in the past second
+	lastSecondBandwidth *atomic.Int64
+	// total used bandwidth in the current second
+	usingBandWidth *atomic.Int64
+	tasks          map[string]*priorityTaskEntry
+	stopCh         chan struct{}
+}
+
+func NewPriorityTrafficShaper(totalRateLimit rate.Limit) TrafficShaper {
+	log := logger.With("component", "TrafficShaper")
+	return &priorityTrafficShaper{
+		SugaredLoggerOnWith: log,
+		totalRateLimit:      totalRateLimit,
+		lastSecondBandwidth: atomic.NewInt64(0),
+		usingBandWidth:      atomic.NewInt64(0),
+		tasks:               make(map[string]*priorityTaskEntry),
+		stopCh:              make(chan struct{}),
+	}
+}
+
+func (ts *priorityTrafficShaper) Start() {
+	go func() {
+		// sample used bandwidth and update bandwidth of all running tasks every second
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ts.lastSecondBandwidth.Store(ts.usingBandWidth.Load())
+				ts.usingBandWidth.Store(0)
+				ts.Lock()
+				ts.allocate(true)
+				ts.Unlock()
+			case <-ts.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (ts *priorityTrafficShaper) Stop() {
+	close(ts.stopCh)
+}
+
+func (ts *priorityTrafficShaper) AddTask(taskID string, ptc *peerTaskConductor) {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.tasks[taskID] = &priorityTaskEntry{
+		ptc:                 ptc,
+		lastSecondBandwidth: atomic.NewInt64(0),
+		weight:              float64(ptc.request.UrlMeta.GetPriority()) + 1,
+	}
+	// allocate immediately, so the new foreground task preempts background tasks
+	ts.allocate(false)
+}
+
+func (ts *priorityTrafficShaper) RemoveTask(taskID string) {
+	ts.Lock()
+	defer ts.Unlock()
+	delete(ts.tasks, taskID)
+	ts.allocate(false)
+}
+
+func (ts *priorityTrafficShaper) Record(taskID string, n int) {
+	ts.usingBandWidth.Add(int64(n))
+	ts.RLock()
+	if te, ok := ts.tasks[taskID]; ok {
+		te.lastSecondBandwidth.Add(int64(n))
+	}
+	ts.RUnlock()
+}
+
+func (ts *priorityTrafficShaper) GetBandwidth() int64 {
+	return ts.lastSecondBandwidth.Load()
+}
+
+// allocate allocates bandwidth for foreground tasks first and the rest for background tasks,
+// sample indicates to update the demand of tasks by the used bandwidth, it must be called with lock held.
+func (ts *priorityTrafficShaper) allocate(sample bool) {
+	total := float64(ts.totalRateLimit)
+	var foreground, background []*priorityTaskEntry
+	for _, te := range ts.tasks {
+		if sample {
+			ts.updateDemand(te, total)
+		} else if !te.sampled {
+			te.demand = total
+		}
+
+		if te.ptc.seed || te.ptc.background.Load() {
+			background = append(background, te)
+		} else {
+			foreground = append(foreground, te)
+		}
+	}
+
+	available := total
+	var reserved float64
+	preempted := len(foreground) > 0 && len(background) > 0
+	if preempted {
+		reserved = total * preemptedBandwidthRatio
+		available -= reserved
+	}
+
+	available = allocateBandwidth(foreground, available)
+	available = allocateBandwidth(background, available+reserved)
+
+	// share the bandwidth which no task demands, so the tasks are able to speed up
+	shareBandwidth(foreground, background, available)
+
+	var foregroundBandwidth, backgroundBandwidth float64
+	for _, te := range foreground {
+		foregroundBandwidth += ts.setLimit(te)
+	}
+
+	for _, te := range background {
+		backgroundBandwidth += ts.setLimit(te)
+	}
+
+	metrics.TrafficShaperAllocatedBandwidth.WithLabelValues(metrics.TrafficShaperClassForeground).Set(foregroundBandwidth)
+	metrics.TrafficShaperAllocatedBandwidth.WithLabelValues(metrics.TrafficShaperClassBackground).Set(backgroundBandwidth)
+	if preempted {
+		metrics.TrafficShaperPreemptedTaskCount.Set(float64(len(background)))
+	} else {
+		metrics.TrafficShaperPreemptedTaskCount.Set(0)
+	}
+}
+
+// updateDemand learns the demand of task from the used bandwidth in the past second,
+// the task which uses up its bandwidth may need more, and the task never needs more than the remaining length.
+func (ts *priorityTrafficShaper) updateDemand(te *priorityTaskEntry, total float64) {
+	used := float64(te.lastSecondBandwidth.Swap(0))
+	if !te.sampled || used >= float64(te.ptc.limiter.Limit())*saturatedBandwidthRatio {
+		te.sampled = true
+		te.demand = total
+	} else {
+		te.demand = used
+	}
+
+	te.minimum = 0
+	if contentLength := te.ptc.contentLength.Load(); contentLength > 0 {
+		remainingLength := float64(math.Max(contentLength-te.ptc.completedLength.Load(), 0))
+		te.demand = math.Min(te.demand, remainingLength)
+		if !te.ptc.deadline.IsZero() {
+			if remainingTime := time.Until(te.ptc.deadline).Seconds(); remainingTime > 0 {
+				te.minimum = math.Min(remainingLength/remainingTime, te.demand)
+			}
+		}
+	}
+}
+
+// setLimit updates the limit of task with the allocated bandwidth, and returns the limit.
+func (ts *priorityTrafficShaper) setLimit(te *priorityTaskEntry) float64 {
+	limit := math.Max(te.allocated, minTaskBandwidth)
+	te.ptc.limiter.SetLimit(rate.Limit(limit))
+	ts.Debugf("task %s rate limit updated to %f, demand %f, minimum %f", te.ptc.taskID, limit, te.demand, te.minimum)
+	return limit
+}
+
+// allocateBandwidth allocates the available bandwidth to the tasks, the minimum bandwidth of tasks is allocated first,
+// then the rest is allocated by the weight of tasks until the demand is satisfied, it returns the unallocated bandwidth.
+func allocateBandwidth(entries []*priorityTaskEntry, available float64) float64 {
+	var totalMinimum float64
+	for _, te := range entries {
+		totalMinimum += te.minimum
+	}
+
+	ratio := 1.0
+	if totalMinimum > available {
+		ratio = available / totalMinimum
+	}
+
+	for _, te := range entries {
+		te.allocated = te.minimum * ratio
+		available -= te.allocated
+	}
+
+	// every round satisfies the demand of one task at least, or allocates all the available bandwidth
+	for i := 0; i < len(entries) && available > 0; i++ {
+		var totalWeight float64
+		for _, te := range entries {
+			if te.allocated < te.demand {
+				totalWeight += te.weight
+			}
+		}
+
+		if totalWeight == 0 {
+			break
+		}
+
+		var allocated float64
+		for _, te := range entries {
+			if te.allocated < te.demand {
+				n := math.Min(available*te.weight/totalWeight, te.demand-te.allocated)
+				te.allocated += n
+				allocated += n
+			}
+		}
+		available -= allocated
+	}
+	return math.Max(available, 0)
+}
+
+// shareBandwidth shares the available bandwidth among the tasks by weight.
+func shareBandwidth(foreground, background []*priorityTaskEntry, available float64) {
+	if available <= 0 {
+		return
+	}
+
+	var totalWeight float64
+	for _, entries := range [][]*priorityTaskEntry{foreground, background} {
+		for _, te := range entries {
+			totalWeight += te.weight
+		}
+	}
+
+	for _, entries := range [][]*priorityTaskEntry{foreground, background} {
+		for _, te := range entries {
+			te.allocated += available * te.weight / totalWeight
+		}
+	}
+}
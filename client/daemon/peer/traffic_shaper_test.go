@@ -32,6 +32,7 @@ import (
 	"github.com/phayes/freeport"
 	testifyassert "github.com/stretchr/testify/assert"
 	testifyrequire "github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -488,7 +489,7 @@ func TestTrafficShaper_TaskSuite(t *testing.T) {
 		t.Run(_tc.name, func(t *testing.T) {
 			assert := testifyassert.New(t)
 			require := testifyrequire.New(t)
-			for _, trafficShaperType := range []string{"plain", "sampling"} {
+			for _, trafficShaperType := range []string{"plain", "sampling", "priority"} {
 				// dup a new test case with the task type
 				logger.Infof("-------------------- test %s, %s traffic shaper started --------------------",
 					_tc.name, trafficShaperType)
@@ -628,3 +629,107 @@ func (ts *trafficShaperTestSpec) run(assert *testifyassert.Assertions, require *
 		assert.True(success, "task should success")
 	}
 }
+
+func newPriorityTestPeerTaskConductor(taskID string, priority commonv1.Priority, contentLength int64) *peerTaskConductor {
+	return &peerTaskConductor{
+		taskID: taskID,
+		request: &schedulerv1.PeerTaskRequest{
+			UrlMeta: &commonv1.UrlMeta{Priority: priority},
+		},
+		limiter:         rate.NewLimiter(rate.Limit(1024*1024*1024), 1024*1024*1024),
+		contentLength:   atomic.NewInt64(contentLength),
+		completedLength: atomic.NewInt64(0),
+	}
+}
+
+func TestPriorityTrafficShaper_Allocate(t *testing.T) {
+	const total = 100 * 1024 * 1024
+	testCases := []struct {
+		name   string
+		setup  func() []*peerTaskConductor
+		sample bool
+		expect []float64
+	}{
+		{
+			name: "allocate bandwidth by priority",
+			setup: func() []*peerTaskConductor {
+				return []*peerTaskConductor{
+					newPriorityTestPeerTaskConductor("task-0", commonv1.Priority_LEVEL0, -1),
+					newPriorityTestPeerTaskConductor("task-1", commonv1.Priority_LEVEL2, -1),
+				}
+			},
+			expect: []float64{total / 4, total * 3 / 4},
+		},
+		{
+			name: "foreground task preempts background task",
+			setup: func() []*peerTaskConductor {
+				background := newPriorityTestPeerTaskConductor("task-0", commonv1.Priority_LEVEL0, -1)
+				background.background.Store(true)
+				return []*peerTaskConductor{
+					background,
+					newPriorityTestPeerTaskConductor("task-1", commonv1.Priority_LEVEL0, -1),
+				}
+			},
+			expect: []float64{total * preemptedBandwidthRatio, total * (1 - preemptedBandwidthRatio)},
+		},
+		{
+			name: "background task uses all bandwidth without foreground task",
+			setup: func() []*peerTaskConductor {
+				background := newPriorityTestPeerTaskConductor("task-0", commonv1.Priority_LEVEL0, -1)
+				background.background.Store(true)
+				return []*peerTaskConductor{background}
+			},
+			expect: []float64{total},
+		},
+		{
+			name: "allocate bandwidth no more than remaining length",
+			setup: func() []*peerTaskConductor {
+				return []*peerTaskConductor{
+					newPriorityTestPeerTaskConductor("task-0", commonv1.Priority_LEVEL0, total/10),
+					newPriorityTestPeerTaskConductor("task-1", commonv1.Priority_LEVEL0, -1),
+				}
+			},
+			sample: true,
+			expect: []float64{total / 10, total * 9 / 10},
+		},
+		{
+			name: "allocate bandwidth to meet deadline",
+			setup: func() []*peerTaskConductor {
+				ptc := newPriorityTestPeerTaskConductor("task-0", commonv1.Priority_LEVEL0, total*8/10)
+				ptc.deadline = time.Now().Add(time.Second)
+				return []*peerTaskConductor{
+					ptc,
+					newPriorityTestPeerTaskConductor("task-1", commonv1.Priority_LEVEL0, -1),
+				}
+			},
+			sample: true,
+			expect: []float64{total * 8 / 10, total * 2 / 10},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			ts := NewPriorityTrafficShaper(total).(*priorityTrafficShaper)
+			ptcs := tc.setup()
+			for _, ptc := range ptcs {
+				ts.AddTask(ptc.taskID, ptc)
+			}
+
+			if tc.sample {
+				ts.Lock()
+				ts.allocate(true)
+				ts.Unlock()
+			}
+
+			for i, ptc := range ptcs {
+				assert.InDelta(tc.expect[i], float64(ptc.limiter.Limit()), 1024, ptc.taskID)
+			}
+
+			// remove the task and record the traffic of removed task
+			ts.RemoveTask(ptcs[0].taskID)
+			ts.Record(ptcs[0].taskID, 1024)
+			assert.Equal(len(ptcs)-1, len(ts.tasks))
+		})
+	}
+}
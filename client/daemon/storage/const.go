@@ -19,11 +19,17 @@ package storage
 import (
 	"errors"
 	"os"
+	"time"
 )
 
 const (
 	taskData     = "data"
 	taskMetadata = "metadata"
+	// contentDir is the directory of content files addressed by digest in data path
+	contentDir = ".content"
+
+	// metadataSaveInterval is the min interval to save the metadata of downloading task
+	metadataSaveInterval = 5 * time.Second
 
 	defaultFileMode      = os.FileMode(0644)
 	defaultDirectoryMode = os.FileMode(0755)
@@ -33,6 +33,7 @@ import (
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
 
+	"d7y.io/dragonfly/v2/client/config"
 	clientutil "d7y.io/dragonfly/v2/client/util"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/internal/util"
@@ -48,12 +49,21 @@ type localTaskStore struct {
 
 	dataDir          string
 	metadataFilePath string
+	// contentDir is the directory of content files for content store strategy
+	contentDir string
+	// expectedDigest is the expected digest of task data
+	expectedDigest string
 
 	expireTime    time.Duration
 	lastAccess    atomic.Int64
+	accessCount   atomic.Int64
+	lastSaved     atomic.Int64
 	reclaimMarked atomic.Bool
 	gcCallback    func(CommonTaskRequest)
 
+	// when partial task is reloaded from disk, resumable will be set until it's resumed
+	resumable atomic.Bool
+
 	// when digest not match, invalid will be set
 	invalid atomic.Bool
 
@@ -69,6 +79,7 @@ var _ Reclaimer = (*localTaskStore)(nil)
 func (t *localTaskStore) touch() {
 	access := time.Now().UnixNano()
 	t.lastAccess.Store(access)
+	t.accessCount.Inc()
 }
 
 func (t *localTaskStore) SubTask(req *RegisterSubTaskRequest) *localSubTaskStore {
@@ -159,11 +170,11 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 		}
 	}
 
-	// when Md5 is empty, try to get md5 from reader, it's useful for back source
+	// when Md5 is empty, try to get digest from reader, it's useful for back source
 	if req.PieceMetadata.Md5 == "" {
 		t.Debugf("piece %d md5 not found in metadata, read from reader", req.PieceMetadata.Num)
 		if get, ok := req.Reader.(digest.Reader); ok {
-			req.PieceMetadata.Md5 = get.Encoded()
+			req.PieceMetadata.Md5 = get.Digest()
 			t.Infof("read piece %d md5 from reader, value: %s", req.PieceMetadata.Num, req.PieceMetadata.Md5)
 		} else {
 			t.Warnf("piece %d reader is not a digest.Reader", req.PieceMetadata.Num)
@@ -173,17 +184,34 @@ func (t *localTaskStore) WritePiece(ctx context.Context, req *WritePieceRequest)
 	t.Debugf("wrote %d bytes to file %s, piece %d, start %d, length: %d",
 		n, t.DataFilePath, req.Num, req.Range.Start, req.Range.Length)
 	t.Lock()
-	defer t.Unlock()
 	// double check
 	if _, ok := t.Pieces[req.Num]; ok {
+		t.Unlock()
 		return n, nil
 	}
 	req.PieceMetadata.Cost = uint64(time.Now().UnixNano() - start)
 	t.Pieces[req.Num] = req.PieceMetadata
 	t.genMetadata(n, req)
+	t.Unlock()
+
+	// save the metadata of downloading task, so it can be resumed after daemon restarts
+	t.trySaveMetadata()
 	return n, nil
 }
 
+// trySaveMetadata saves the metadata when it's not saved in metadataSaveInterval.
+func (t *localTaskStore) trySaveMetadata() {
+	now := time.Now().UnixNano()
+	last := t.lastSaved.Load()
+	if now-last < int64(metadataSaveInterval) || !t.lastSaved.CompareAndSwap(last, now) {
+		return
+	}
+
+	if err := t.saveMetadata(); err != nil {
+		t.Warnf("save task metadata error: %s", err)
+	}
+}
+
 func (t *localTaskStore) genMetadata(n int64, req *WritePieceRequest) {
 	if req.GenMetadata == nil {
 		return
@@ -202,7 +230,7 @@ func (t *localTaskStore) genMetadata(n int64, req *WritePieceRequest) {
 		pieceDigests = append(pieceDigests, t.Pieces[i].Md5)
 	}
 
-	digest := digest.SHA256FromStrings(pieceDigests...)
+	digest := genPieceDigestSign(pieceDigests)
 	t.PieceMd5Sign = digest
 	t.Infof("generated digest: %s, total pieces: %d, content length: %d", digest, t.TotalPieces, t.ContentLength)
 }
@@ -257,7 +285,7 @@ func (t *localTaskStore) ValidateDigest(*PeerTaskMetadata) error {
 		pieceDigests = append(pieceDigests, t.Pieces[i].Md5)
 	}
 
-	digest := digest.SHA256FromStrings(pieceDigests...)
+	digest := computePieceDigestSign(t.PieceMd5Sign, pieceDigests)
 	if digest != t.PieceMd5Sign {
 		t.Errorf("invalid digest, desired: %s, actual: %s", t.PieceMd5Sign, digest)
 		t.invalid.Store(true)
@@ -351,6 +379,13 @@ func (t *localTaskStore) Store(ctx context.Context, req *StoreRequest) error {
 		t.Unlock()
 	}
 
+	if !req.StoreDataOnly && t.StoreStrategy == string(config.ContentLocalTaskStoreStrategy) {
+		if err := t.storeContent(); err != nil {
+			t.Errorf("store task content error: %s", err)
+			return err
+		}
+	}
+
 	if !req.StoreDataOnly {
 		err := t.saveMetadata()
 		if err != nil {
@@ -406,6 +441,64 @@ func (t *localTaskStore) Store(ctx context.Context, req *StoreRequest) error {
 	return err
 }
 
+// storeContent computes the digest of task data and links the data to the content file addressed by the digest,
+// when the content file exists, the task data is replaced by the link of content file, so the same data is stored once.
+func (t *localTaskStore) storeContent() error {
+	t.Lock()
+	defer t.Unlock()
+	if t.ContentDigest != "" || t.ContentLength <= 0 {
+		return nil
+	}
+
+	algorithm := digest.AlgorithmSHA256
+	var expected *digest.Digest
+	if t.expectedDigest != "" {
+		var err error
+		if expected, err = digest.Parse(t.expectedDigest); err != nil {
+			return err
+		}
+		algorithm = expected.Algorithm
+	}
+
+	encoded, err := digest.HashFile(t.DataFilePath, algorithm)
+	if err != nil {
+		return err
+	}
+
+	if expected != nil && expected.Encoded != encoded {
+		t.Errorf("invalid content digest, desired: %s, actual: %s", expected.Encoded, encoded)
+		t.invalid.Store(true)
+		return ErrInvalidDigest
+	}
+
+	contentDigest := digest.New(algorithm, encoded)
+	contentFilePath := path.Join(t.contentDir, contentDigest.Algorithm, contentDigest.Encoded)
+	if err := os.MkdirAll(path.Dir(contentFilePath), defaultDirectoryMode); err != nil {
+		return err
+	}
+
+	if err := os.Link(t.DataFilePath, contentFilePath); err != nil {
+		if !os.IsExist(err) {
+			return err
+		}
+
+		// content exists, replace task data with the link of content file
+		tmp := t.DataFilePath + ".content"
+		if err := os.Link(contentFilePath, tmp); err != nil {
+			return err
+		}
+
+		if err := os.Rename(tmp, t.DataFilePath); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		t.Infof("task data is deduplicated by content %s", contentDigest)
+	}
+
+	t.ContentDigest = contentDigest.String()
+	return nil
+}
+
 func (t *localTaskStore) GetPieces(ctx context.Context, req *commonv1.PieceTaskRequest) (*commonv1.PiecePacket, error) {
 	if req == nil {
 		return nil, ErrBadRequest
@@ -481,6 +574,10 @@ func (t *localTaskStore) CanReclaim() bool {
 	if t.invalid.Load() {
 		return true
 	}
+	// pinned task is never reclaimed
+	if t.Pinned {
+		return false
+	}
 	now := time.Now()
 	// task soft cache time reached
 	access := time.Unix(0, t.lastAccess.Load())
@@ -622,18 +719,55 @@ func (t *localTaskStore) saveMetadata() error {
 	if err != nil {
 		return err
 	}
-	metadata, err := os.OpenFile(t.metadataFilePath, os.O_CREATE|os.O_RDWR, defaultFileMode)
-	if err != nil {
+	// write to a temporary file and rename it, the metadata will not be broken when daemon crashes
+	tmp := t.metadataFilePath + ".tmp"
+	if err = os.WriteFile(tmp, data, defaultFileMode); err != nil {
+		t.Errorf("save metadata error: %s", err)
 		return err
 	}
-	defer metadata.Close()
-	_, err = metadata.Write(data)
-	if err != nil {
+
+	if err = os.Rename(tmp, t.metadataFilePath); err != nil {
 		t.Errorf("save metadata error: %s", err)
+		os.Remove(tmp)
 	}
 	return err
 }
 
+// validatePieces removes the pieces which data doesn't match the recorded digest,
+// it's used to validate the partial task reloaded from disk.
+func (t *localTaskStore) validatePieces() error {
+	t.Lock()
+	defer t.Unlock()
+
+	file, err := os.Open(t.DataFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for num, piece := range t.Pieces {
+		if piece.Md5 == "" {
+			t.Warnf("piece %d digest not found, drop it", num)
+			delete(t.Pieces, num)
+			continue
+		}
+
+		r, err := digest.NewReader(io.NewSectionReader(file, piece.Range.Start, piece.Range.Length), digest.WithDigest(piece.Md5))
+		if err != nil {
+			t.Warnf("piece %d digest %s is invalid, drop it: %s", num, piece.Md5, err)
+			delete(t.Pieces, num)
+			continue
+		}
+
+		n, err := io.Copy(io.Discard, r)
+		if err != nil || n != piece.Range.Length {
+			t.Warnf("piece %d data is broken, drop it, read %d bytes, error: %v", num, n, err)
+			delete(t.Pieces, num)
+		}
+	}
+	return nil
+}
+
 func (t *localTaskStore) partialCompleted(rg *clientutil.Range) bool {
 	t.RLock()
 	defer t.RUnlock()
@@ -652,7 +786,7 @@ func (t *localTaskStore) partialCompleted(rg *clientutil.Range) bool {
 		realRange.Length = t.ContentLength - realRange.Start
 	}
 
-	start, end := computePiecePosition(t.ContentLength, realRange, util.ComputePieceSize)
+	start, end := computePiecePosition(t.ContentLength, realRange, t.computePieceSize)
 	// fix int overflow
 	if start < 0 || end < 0 {
 		t.Warnf("wrong start and end piece num, %d, %d", start, end)
@@ -666,6 +800,22 @@ func (t *localTaskStore) partialCompleted(rg *clientutil.Range) bool {
 	return true
 }
 
+// computePieceSize returns the piece size derived from the stored pieces, the piece size may be chosen
+// by the piece size policy of the peer which downloaded the task from source, it must be called with lock held
+func (t *localTaskStore) computePieceSize(contentLength int64) uint32 {
+	for num, piece := range t.Pieces {
+		if num > 0 && piece.Range.Start > 0 {
+			return uint32(piece.Range.Start / int64(num))
+		}
+	}
+
+	// the length of the first piece is the piece size, or the content length when there is only one piece
+	if piece, ok := t.Pieces[0]; ok && piece.Range.Length > 0 {
+		return uint32(piece.Range.Length)
+	}
+	return util.ComputePieceSize(contentLength)
+}
+
 func computePiecePosition(total int64, rg *clientutil.Range, compute func(length int64) uint32) (start, end int32) {
 	pieceSize := compute(total)
 	start = int32(math.Floor(float64(rg.Start) / float64(pieceSize)))
@@ -739,3 +889,43 @@ func hardlink(log *logger.SugaredLoggerOnWith, dst, src string) error {
 	err = fmt.Errorf("target file %q exists, with different inode with underlay data %q", dst, src)
 	return err
 }
+
+// genPieceDigestSign generates the sign of piece digests, the sign is the root of sha256 merkle tree
+// when all the piece digests are sha256, otherwise it is the legacy sha256 of the piece md5s.
+func genPieceDigestSign(pieceDigests []string) string {
+	if !isSHA256PieceDigests(pieceDigests) {
+		return digest.SHA256FromStrings(pieceDigests...)
+	}
+
+	return digest.New(digest.AlgorithmMerkleSHA256, digest.SHA256MerkleRootFromStrings(pieceDigests...)).String()
+}
+
+// computePieceDigestSign computes the sign of piece digests in the same algorithm of the desired sign,
+// the merkle root is only computed by sha256 piece digests, so that the pieces can not be downgraded to md5.
+func computePieceDigestSign(desired string, pieceDigests []string) string {
+	if d, err := digest.Parse(desired); err != nil || d.Algorithm != digest.AlgorithmMerkleSHA256 {
+		return digest.SHA256FromStrings(pieceDigests...)
+	}
+
+	if !isSHA256PieceDigests(pieceDigests) {
+		return ""
+	}
+
+	return digest.New(digest.AlgorithmMerkleSHA256, digest.SHA256MerkleRootFromStrings(pieceDigests...)).String()
+}
+
+// isSHA256PieceDigests returns whether all the piece digests are sha256.
+func isSHA256PieceDigests(pieceDigests []string) bool {
+	if len(pieceDigests) == 0 {
+		return false
+	}
+
+	for _, pieceDigest := range pieceDigests {
+		d, err := digest.Parse(pieceDigest)
+		if err != nil || d.Algorithm != digest.AlgorithmSHA256 {
+			return false
+		}
+	}
+
+	return true
+}
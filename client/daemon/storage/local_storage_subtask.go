@@ -107,11 +107,11 @@ func (t *localSubTaskStore) WritePiece(ctx context.Context, req *WritePieceReque
 		}
 	}
 
-	// when Md5 is empty, try to get md5 from reader, it's useful for back source
+	// when Md5 is empty, try to get digest from reader, it's useful for back source
 	if req.PieceMetadata.Md5 == "" {
 		t.Debugf("piece %d md5 not found in metadata, read from reader", req.PieceMetadata.Num)
 		if get, ok := req.Reader.(digest.Reader); ok {
-			req.PieceMetadata.Md5 = get.Encoded()
+			req.PieceMetadata.Md5 = get.Digest()
 			t.Infof("read piece %d md5 from reader, value: %s", req.PieceMetadata.Num, req.PieceMetadata.Md5)
 		} else {
 			t.Warnf("piece %d reader is not a digest.Reader", req.PieceMetadata.Num)
@@ -347,7 +347,7 @@ func (t *localSubTaskStore) ValidateDigest(req *PeerTaskMetadata) error {
 		pieceDigests = append(pieceDigests, t.Pieces[i].Md5)
 	}
 
-	digest := digest.SHA256FromStrings(pieceDigests...)
+	digest := computePieceDigestSign(t.PieceMd5Sign, pieceDigests)
 	if digest != t.PieceMd5Sign {
 		t.Errorf("invalid digest, desired: %s, actual: %s", t.PieceMd5Sign, digest)
 		t.invalid.Store(true)
@@ -378,7 +378,7 @@ func (t *localSubTaskStore) genMetadata(n int64, req *WritePieceRequest) {
 		pieceDigests = append(pieceDigests, t.Pieces[i].Md5)
 	}
 
-	digest := digest.SHA256FromStrings(pieceDigests...)
+	digest := genPieceDigestSign(pieceDigests)
 	t.PieceMd5Sign = digest
 	t.Infof("generated digest: %s, total pieces: %d, content length: %d", digest, t.TotalPieces, t.ContentLength)
 }
@@ -485,6 +485,7 @@ func TestLocalTaskStore_partialCompleted(t *testing.T) {
 		name            string
 		ContentLength   int64
 		ReadyPieceCount int32
+		PieceSize       int64
 		Range           clientutil.Range
 		Found           bool
 	}{
@@ -518,6 +519,17 @@ func TestLocalTaskStore_partialCompleted(t *testing.T) {
 			},
 			Found: false,
 		},
+		{
+			name:            "range bytes=x-y partial completed with stored piece size",
+			ContentLength:   util.DefaultPieceSize * 10,
+			ReadyPieceCount: 2,
+			PieceSize:       util.DefaultPieceSize * 2,
+			Range: clientutil.Range{
+				Start:  1,
+				Length: util.DefaultPieceSize * 3,
+			},
+			Found: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -530,10 +542,335 @@ func TestLocalTaskStore_partialCompleted(t *testing.T) {
 				},
 			}
 			for i := int32(0); i < tc.ReadyPieceCount; i++ {
-				lts.Pieces[i] = PieceMetadata{}
+				lts.Pieces[i] = PieceMetadata{
+					Range: clientutil.Range{
+						Start:  int64(i) * tc.PieceSize,
+						Length: tc.PieceSize,
+					},
+				}
 			}
 			ok := lts.partialCompleted(&tc.Range)
 			assert.Equal(tc.Found, ok)
 		})
 	}
 }
+
+func Test_pieceDigestSign(t *testing.T) {
+	var (
+		md5Digests    = []string{"5d41402abc4b2a76b9719d911017c592", "7d793037a0760186574b0282f2f435e7"}
+		sha256Digests = []string{
+			"sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			"sha256:486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7",
+		}
+		merkleSign = "merkle-sha256:" + digest.SHA256MerkleRootFromStrings(sha256Digests...)
+	)
+
+	var testCases = []struct {
+		name         string
+		pieceDigests []string
+		desired      string
+		sign         string
+		computed     string
+	}{
+		{
+			name:         "legacy md5 piece digests",
+			pieceDigests: md5Digests,
+			desired:      digest.SHA256FromStrings(md5Digests...),
+			sign:         digest.SHA256FromStrings(md5Digests...),
+			computed:     digest.SHA256FromStrings(md5Digests...),
+		},
+		{
+			name:         "sha256 piece digests",
+			pieceDigests: sha256Digests,
+			desired:      merkleSign,
+			sign:         merkleSign,
+			computed:     merkleSign,
+		},
+		{
+			name:         "sha256 piece digests with legacy sign",
+			pieceDigests: sha256Digests,
+			desired:      digest.SHA256FromStrings(sha256Digests...),
+			sign:         merkleSign,
+			computed:     digest.SHA256FromStrings(sha256Digests...),
+		},
+		{
+			name:         "downgraded piece digests with merkle sign",
+			pieceDigests: []string{sha256Digests[0], md5Digests[1]},
+			desired:      merkleSign,
+			sign:         digest.SHA256FromStrings(sha256Digests[0], md5Digests[1]),
+			computed:     "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			assert.Equal(tc.sign, genPieceDigestSign(tc.pieceDigests))
+			assert.Equal(tc.computed, computePieceDigestSign(tc.desired, tc.pieceDigests))
+		})
+	}
+}
+
+func TestStorageManager_ContentStore(t *testing.T) {
+	assert := testifyassert.New(t)
+	testData := []byte("test content data")
+	sm, err := NewStorageManager(config.ContentLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: t.TempDir(),
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+		}, func(request CommonTaskRequest) {
+		})
+	assert.Nil(err)
+
+	var dataFiles []string
+	for _, taskID := range []string{"task-1", "task-2"} {
+		peerID := "peer-" + taskID
+		ts, err := sm.RegisterTask(context.Background(), &RegisterTaskRequest{
+			PeerTaskMetadata: PeerTaskMetadata{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: int64(len(testData)),
+			TotalPieces:   1,
+			Digest:        digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings(string(testData))).String(),
+		})
+		assert.Nil(err, "register task")
+
+		_, err = ts.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetadata: PeerTaskMetadata{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			PieceMetadata: PieceMetadata{
+				Num: 0,
+				Md5: calcPieceMd5(testData),
+				Range: clientutil.Range{
+					Length: int64(len(testData)),
+				},
+				Style: commonv1.PieceStyle_PLAIN,
+			},
+			Reader: bytes.NewBuffer(testData),
+		})
+		assert.Nil(err, "write piece")
+
+		err = ts.Store(context.Background(), &StoreRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			MetadataOnly: true,
+		})
+		assert.Nil(err, "store task")
+		dataFiles = append(dataFiles, ts.(*localTaskStore).DataFilePath)
+	}
+
+	// the task with the same content is referenced
+	reuse := sm.FindCompletedTaskByDigest("task-3", digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings(string(testData))).String())
+	assert.NotNil(reuse, "find task by digest")
+	assert.Equal("task-3", reuse.TaskID)
+	dataFiles = append(dataFiles, reuse.Storage.(*localTaskStore).DataFilePath)
+
+	first, err := os.Stat(dataFiles[0])
+	assert.Nil(err)
+	for _, dataFile := range dataFiles[1:] {
+		info, err := os.Stat(dataFile)
+		assert.Nil(err)
+		assert.True(os.SameFile(first, info), "task data must be deduplicated")
+	}
+
+	assert.Nil(sm.FindCompletedTaskByDigest("task-4", digest.New(digest.AlgorithmSHA256, digest.SHA256FromStrings("other")).String()))
+}
+
+func TestStorageManager_TryGC_Quota(t *testing.T) {
+	type task struct {
+		taskID      string
+		application string
+		size        int64
+		accessCount int64
+		pinned      bool
+	}
+	var testCases = []struct {
+		name           string
+		evictionPolicy config.EvictionPolicy
+		quotas         []config.StorageQuota
+		tasks          []task
+		reclaimed      []string
+	}{
+		{
+			name:           "quota not exceeded",
+			evictionPolicy: config.LRUEvictionPolicy,
+			quotas:         []config.StorageQuota{{Application: "foo", Limit: 30}},
+			tasks: []task{
+				{taskID: "task-1", application: "foo", size: 10},
+				{taskID: "task-2", application: "foo", size: 10},
+			},
+		},
+		{
+			name:           "lru evicts the least recently used task of quota",
+			evictionPolicy: config.LRUEvictionPolicy,
+			quotas:         []config.StorageQuota{{Application: "foo", Limit: 15}},
+			tasks: []task{
+				{taskID: "task-1", application: "foo", size: 10, accessCount: 5},
+				{taskID: "task-2", application: "foo", size: 10, accessCount: 1},
+				{taskID: "task-3", application: "bar", size: 100},
+			},
+			reclaimed: []string{"task-1"},
+		},
+		{
+			name:           "lfu evicts the least frequently used task of quota",
+			evictionPolicy: config.LFUEvictionPolicy,
+			quotas:         []config.StorageQuota{{Application: "foo", Limit: 15}},
+			tasks: []task{
+				{taskID: "task-1", application: "foo", size: 10, accessCount: 5},
+				{taskID: "task-2", application: "foo", size: 10, accessCount: 1},
+				{taskID: "task-3", application: "bar", size: 100},
+			},
+			reclaimed: []string{"task-2"},
+		},
+		{
+			name:           "pinned task is not evicted",
+			evictionPolicy: config.LRUEvictionPolicy,
+			quotas:         []config.StorageQuota{{Application: "foo", Limit: 5}},
+			tasks: []task{
+				{taskID: "task-1", application: "foo", size: 10, pinned: true},
+				{taskID: "task-2", application: "foo", size: 10},
+			},
+			reclaimed: []string{"task-2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+				&config.StorageOption{
+					DataPath: t.TempDir(),
+					TaskExpireTime: clientutil.Duration{
+						Duration: time.Hour,
+					},
+					EvictionPolicy: tc.evictionPolicy,
+					Quotas:         tc.quotas,
+				}, func(request CommonTaskRequest) {
+				})
+			assert.Nil(err)
+
+			for i, task := range tc.tasks {
+				ts, err := sm.RegisterTask(context.Background(), &RegisterTaskRequest{
+					PeerTaskMetadata: PeerTaskMetadata{
+						PeerID: "peer-" + task.taskID,
+						TaskID: task.taskID,
+					},
+					ContentLength: task.size,
+					TotalPieces:   1,
+					Application:   task.application,
+				})
+				assert.Nil(err, "register task")
+
+				lts := ts.(*localTaskStore)
+				lts.Done = true
+				lts.lastAccess.Store(time.Now().Add(time.Duration(i-len(tc.tasks)) * time.Minute).UnixNano())
+				lts.accessCount.Store(task.accessCount)
+				if task.pinned {
+					assert.Nil(sm.PinTask(task.taskID, true))
+				}
+			}
+
+			ok, err := sm.(*storageManager).TryGC()
+			assert.True(ok)
+			assert.Nil(err)
+
+			var reclaimed []string
+			for _, task := range tc.tasks {
+				ts, _ := sm.(*storageManager).LoadTask(PeerTaskMetadata{PeerID: "peer-" + task.taskID, TaskID: task.taskID})
+				if ts.(*localTaskStore).reclaimMarked.Load() {
+					reclaimed = append(reclaimed, task.taskID)
+				}
+			}
+			assert.Equal(tc.reclaimed, reclaimed)
+		})
+	}
+}
+
+func TestStorageManager_FindResumableTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	var (
+		taskID    = "task-resumable"
+		peerID    = "peer-resumable"
+		pieceSize = 16
+		dataPath  = t.TempDir()
+		testData  = bytes.Repeat([]byte("0123456789abcdef"), 3)
+	)
+	option := &config.StorageOption{
+		DataPath: dataPath,
+		TaskExpireTime: clientutil.Duration{
+			Duration: time.Minute,
+		},
+	}
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy, option, func(request CommonTaskRequest) {})
+	assert.Nil(err)
+
+	ts, err := sm.RegisterTask(context.Background(), &RegisterTaskRequest{
+		PeerTaskMetadata: PeerTaskMetadata{
+			PeerID: peerID,
+			TaskID: taskID,
+		},
+		ContentLength: int64(len(testData)),
+		TotalPieces:   3,
+	})
+	assert.Nil(err, "register task")
+
+	// write the first two pieces
+	for i := 0; i < 2; i++ {
+		start := i * pieceSize
+		_, err = ts.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetadata: PeerTaskMetadata{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			PieceMetadata: PieceMetadata{
+				Num: int32(i),
+				Md5: calcPieceMd5(testData[start : start+pieceSize]),
+				Range: clientutil.Range{
+					Start:  int64(start),
+					Length: int64(pieceSize),
+				},
+				Style: commonv1.PieceStyle_PLAIN,
+			},
+			Reader: bytes.NewBuffer(testData[start : start+pieceSize]),
+		})
+		assert.Nil(err, "write piece")
+	}
+	lts := ts.(*localTaskStore)
+	assert.Nil(lts.saveMetadata())
+
+	// corrupt the data of the second piece
+	data, err := os.OpenFile(lts.DataFilePath, os.O_RDWR, defaultFileMode)
+	assert.Nil(err)
+	_, err = data.WriteAt([]byte("broken"), int64(pieceSize))
+	assert.Nil(err)
+	data.Close()
+
+	// reload tasks from disk
+	sm, err = NewStorageManager(config.SimpleLocalTaskStoreStrategy, option, func(request CommonTaskRequest) {})
+	assert.Nil(err)
+	assert.Nil(sm.FindCompletedTask(taskID), "partial task is not completed")
+
+	reuse := sm.FindResumableTask(taskID)
+	assert.NotNil(reuse, "find resumable task")
+	assert.Equal(peerID, reuse.PeerID)
+	assert.Equal(int64(len(testData)), reuse.ContentLength)
+	assert.Equal(int32(3), reuse.TotalPieces)
+
+	piecePacket, err := reuse.Storage.GetPieces(context.Background(), &commonv1.PieceTaskRequest{
+		TaskId:   taskID,
+		StartNum: 0,
+		Limit:    3,
+	})
+	assert.Nil(err)
+	assert.Len(piecePacket.PieceInfos, 1, "broken piece is dropped")
+	assert.Equal(int32(0), piecePacket.PieceInfos[0].PieceNum)
+
+	assert.Nil(sm.FindResumableTask(taskID), "resumable task is returned only once")
+}
@@ -37,6 +37,10 @@ type persistentMetadata struct {
 	DataFilePath  string                  `json:"dataFilePath"`
 	Done          bool                    `json:"done"`
 	Header        *source.Header          `json:"header"`
+	ContentDigest string                  `json:"contentDigest,omitempty"`
+	Application   string                  `json:"application,omitempty"`
+	Tag           string                  `json:"tag,omitempty"`
+	Pinned        bool                    `json:"pinned,omitempty"`
 }
 
 type PeerTaskMetadata struct {
@@ -66,6 +70,11 @@ type RegisterTaskRequest struct {
 	ContentLength   int64
 	TotalPieces     int32
 	PieceMd5Sign    string
+	// Digest is the expected digest of task data
+	Digest string
+	// Application and Tag are used to match the storage quotas
+	Application string
+	Tag         string
 }
 
 type WritePieceRequest struct {
@@ -339,6 +339,20 @@ func (mr *MockManagerMockRecorder) FindCompletedTask(taskID interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCompletedTask", reflect.TypeOf((*MockManager)(nil).FindCompletedTask), taskID)
 }
 
+// FindCompletedTaskByDigest mocks base method.
+func (m *MockManager) FindCompletedTaskByDigest(taskID, digest string) *storage.ReusePeerTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCompletedTaskByDigest", taskID, digest)
+	ret0, _ := ret[0].(*storage.ReusePeerTask)
+	return ret0
+}
+
+// FindCompletedTaskByDigest indicates an expected call of FindCompletedTaskByDigest.
+func (mr *MockManagerMockRecorder) FindCompletedTaskByDigest(taskID, digest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCompletedTaskByDigest", reflect.TypeOf((*MockManager)(nil).FindCompletedTaskByDigest), taskID, digest)
+}
+
 // FindPartialCompletedTask mocks base method.
 func (m *MockManager) FindPartialCompletedTask(taskID string, rg *util.Range) *storage.ReusePeerTask {
 	m.ctrl.T.Helper()
@@ -353,6 +367,20 @@ func (mr *MockManagerMockRecorder) FindPartialCompletedTask(taskID, rg interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPartialCompletedTask", reflect.TypeOf((*MockManager)(nil).FindPartialCompletedTask), taskID, rg)
 }
 
+// FindResumableTask mocks base method.
+func (m *MockManager) FindResumableTask(taskID string) *storage.ReusePeerTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindResumableTask", taskID)
+	ret0, _ := ret[0].(*storage.ReusePeerTask)
+	return ret0
+}
+
+// FindResumableTask indicates an expected call of FindResumableTask.
+func (mr *MockManagerMockRecorder) FindResumableTask(taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindResumableTask", reflect.TypeOf((*MockManager)(nil).FindResumableTask), taskID)
+}
+
 // GetExtendAttribute mocks base method.
 func (m *MockManager) GetExtendAttribute(ctx context.Context, req *storage.PeerTaskMetadata) (*common.ExtendAttribute, error) {
 	m.ctrl.T.Helper()
@@ -425,6 +453,20 @@ func (mr *MockManagerMockRecorder) Keep() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keep", reflect.TypeOf((*MockManager)(nil).Keep))
 }
 
+// PinTask mocks base method.
+func (m *MockManager) PinTask(taskID string, pinned bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinTask", taskID, pinned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PinTask indicates an expected call of PinTask.
+func (mr *MockManagerMockRecorder) PinTask(taskID, pinned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinTask", reflect.TypeOf((*MockManager)(nil).PinTask), taskID, pinned)
+}
+
 // ReadAllPieces mocks base method.
 func (m *MockManager) ReadAllPieces(ctx context.Context, req *storage.ReadAllPiecesRequest) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
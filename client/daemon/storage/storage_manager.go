@@ -45,6 +45,7 @@ import (
 	"d7y.io/dragonfly/v2/client/daemon/gc"
 	"d7y.io/dragonfly/v2/client/util"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/digest"
 )
 
 type TaskStorageDriver interface {
@@ -101,10 +102,18 @@ type Manager interface {
 	UnregisterTask(ctx context.Context, req CommonTaskRequest) error
 	// FindCompletedTask try to find a completed task for fast path
 	FindCompletedTask(taskID string) *ReusePeerTask
+	// FindCompletedTaskByDigest try to find a completed task with the same content digest for fast path,
+	// the found content is referenced by the task id, it only works in content store strategy
+	FindCompletedTaskByDigest(taskID, digest string) *ReusePeerTask
 	// FindCompletedSubTask try to find a completed subtask for fast path
 	FindCompletedSubTask(taskID string) *ReusePeerTask
 	// FindPartialCompletedTask try to find a partial completed task for fast path
 	FindPartialCompletedTask(taskID string, rg *util.Range) *ReusePeerTask
+	// FindResumableTask try to find a partial task reloaded from disk to resume downloading,
+	// the found task will not be returned again
+	FindResumableTask(taskID string) *ReusePeerTask
+	// PinTask pins or unpins the stored peer tasks of the task id, pinned tasks are never reclaimed by gc
+	PinTask(taskID string, pinned bool) error
 	// CleanUp cleans all storage data
 	CleanUp()
 }
@@ -170,7 +179,7 @@ func NewStorageManager(storeStrategy config.StoreStrategy, opt *config.StorageOp
 		return nil, err
 	}
 	switch storeStrategy {
-	case config.SimpleLocalTaskStoreStrategy, config.AdvanceLocalTaskStoreStrategy:
+	case config.SimpleLocalTaskStoreStrategy, config.AdvanceLocalTaskStoreStrategy, config.ContentLocalTaskStoreStrategy:
 	case config.StoreStrategy(""):
 		storeStrategy = config.SimpleLocalTaskStoreStrategy
 	default:
@@ -402,10 +411,14 @@ func (s *storageManager) CreateTask(req *RegisterTaskRequest) (TaskStorageDriver
 			PieceMd5Sign:  req.PieceMd5Sign,
 			PeerID:        req.PeerID,
 			Pieces:        map[int32]PieceMetadata{},
+			Application:   req.Application,
+			Tag:           req.Tag,
 		},
 		gcCallback:       s.gcCallback,
 		dataDir:          dataDir,
 		metadataFilePath: path.Join(dataDir, taskMetadata),
+		contentDir:       path.Join(s.storeOption.DataPath, contentDir),
+		expectedDigest:   req.Digest,
 		expireTime:       s.storeOption.TaskExpireTime.Duration,
 		subtasks:         map[PeerTaskMetadata]*localSubTaskStore{},
 
@@ -417,12 +430,12 @@ func (s *storageManager) CreateTask(req *RegisterTaskRequest) (TaskStorageDriver
 	t.touch()
 
 	// fallback to simple strategy for proxy
-	if req.DesiredLocation == "" {
+	if req.DesiredLocation == "" && t.StoreStrategy == string(config.AdvanceLocalTaskStoreStrategy) {
 		t.StoreStrategy = string(config.SimpleLocalTaskStoreStrategy)
 	}
 	data := path.Join(dataDir, taskData)
 	switch t.StoreStrategy {
-	case string(config.SimpleLocalTaskStoreStrategy):
+	case string(config.SimpleLocalTaskStoreStrategy), string(config.ContentLocalTaskStoreStrategy):
 		t.DataFilePath = data
 		f, err := os.OpenFile(t.DataFilePath, os.O_CREATE|os.O_RDWR, defaultFileMode)
 		if err != nil {
@@ -515,6 +528,188 @@ func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
 	return nil
 }
 
+func (s *storageManager) FindCompletedTaskByDigest(taskID string, dgst string) *ReusePeerTask {
+	if s.storeStrategy != config.ContentLocalTaskStoreStrategy || dgst == "" {
+		return nil
+	}
+
+	d, err := digest.Parse(dgst)
+	if err != nil {
+		logger.Warnf("invalid digest %s: %s", dgst, err)
+		return nil
+	}
+
+	var content *localTaskStore
+	s.indexRWMutex.RLock()
+	for _, ts := range s.indexTask2PeerTask {
+		for _, t := range ts {
+			if t.invalid.Load() || t.reclaimMarked.Load() || !t.Done {
+				continue
+			}
+
+			if t.ContentDigest == d.String() {
+				content = t
+				break
+			}
+		}
+
+		if content != nil {
+			break
+		}
+	}
+	s.indexRWMutex.RUnlock()
+
+	if content == nil {
+		return nil
+	}
+
+	// touch it before marking reclaim
+	content.touch()
+	t, err := s.createReferenceTask(taskID, content)
+	if err != nil {
+		logger.Errorf("create reference task %s of content %s error: %s", taskID, content.ContentDigest, err)
+		return nil
+	}
+
+	logger.Infof("task %s references the content %s of task %s", taskID, t.ContentDigest, content.TaskID)
+	return &ReusePeerTask{
+		Storage: t,
+		PeerTaskMetadata: PeerTaskMetadata{
+			PeerID: t.PeerID,
+			TaskID: taskID,
+		},
+		ContentLength: t.ContentLength,
+		TotalPieces:   t.TotalPieces,
+		PieceMd5Sign:  t.PieceMd5Sign,
+		Header:        t.Header,
+	}
+}
+
+// createReferenceTask creates a completed task which data is linked to the content file of the content task.
+func (s *storageManager) createReferenceTask(taskID string, content *localTaskStore) (*localTaskStore, error) {
+	s.Lock()
+	defer s.Unlock()
+	if ts, ok := s.LoadTask(PeerTaskMetadata{PeerID: content.PeerID, TaskID: taskID}); ok {
+		if t, ok := ts.(*localTaskStore); ok {
+			return t, nil
+		}
+	}
+
+	content.RLock()
+	d, err := digest.Parse(content.ContentDigest)
+	if err != nil {
+		content.RUnlock()
+		return nil, err
+	}
+
+	dataDir := path.Join(s.storeOption.DataPath, taskID, content.PeerID)
+	t := &localTaskStore{
+		persistentMetadata: persistentMetadata{
+			StoreStrategy: content.StoreStrategy,
+			TaskID:        taskID,
+			TaskMeta:      map[string]string{},
+			ContentLength: content.ContentLength,
+			TotalPieces:   content.TotalPieces,
+			PeerID:        content.PeerID,
+			Pieces:        make(map[int32]PieceMetadata, len(content.Pieces)),
+			PieceMd5Sign:  content.PieceMd5Sign,
+			DataFilePath:  path.Join(dataDir, taskData),
+			Done:          true,
+			Header:        content.Header,
+			ContentDigest: content.ContentDigest,
+		},
+		gcCallback:       s.gcCallback,
+		dataDir:          dataDir,
+		metadataFilePath: path.Join(dataDir, taskMetadata),
+		contentDir:       content.contentDir,
+		expireTime:       s.storeOption.TaskExpireTime.Duration,
+		subtasks:         map[PeerTaskMetadata]*localSubTaskStore{},
+
+		SugaredLoggerOnWith: logger.With("task", taskID, "peer", content.PeerID, "component", "localTaskStore"),
+	}
+	for num, piece := range content.Pieces {
+		t.Pieces[num] = piece
+	}
+	content.RUnlock()
+
+	if err := os.MkdirAll(t.dataDir, defaultDirectoryMode); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+
+	if err := os.Link(path.Join(t.contentDir, d.Algorithm, d.Encoded), t.DataFilePath); err != nil {
+		os.RemoveAll(t.dataDir)
+		return nil, err
+	}
+
+	if err := t.saveMetadata(); err != nil {
+		os.RemoveAll(t.dataDir)
+		return nil, err
+	}
+	t.touch()
+
+	s.tasks.Store(PeerTaskMetadata{PeerID: t.PeerID, TaskID: taskID}, t)
+	s.indexRWMutex.Lock()
+	s.indexTask2PeerTask[taskID] = append(s.indexTask2PeerTask[taskID], t)
+	s.indexRWMutex.Unlock()
+	return t, nil
+}
+
+func (s *storageManager) FindResumableTask(taskID string) *ReusePeerTask {
+	s.indexRWMutex.RLock()
+	defer s.indexRWMutex.RUnlock()
+	ts, ok := s.indexTask2PeerTask[taskID]
+	if !ok {
+		return nil
+	}
+	for _, t := range ts {
+		if t.Done || t.invalid.Load() || t.reclaimMarked.Load() {
+			continue
+		}
+
+		t.RLock()
+		// only the partial task with known pieces can be resumed
+		resumable := t.TotalPieces > 0 && len(t.Pieces) > 0 && int32(len(t.Pieces)) < t.TotalPieces
+		t.RUnlock()
+		if !resumable || !t.resumable.CompareAndSwap(true, false) {
+			continue
+		}
+
+		t.touch()
+		return &ReusePeerTask{
+			Storage: t,
+			PeerTaskMetadata: PeerTaskMetadata{
+				PeerID: t.PeerID,
+				TaskID: taskID,
+			},
+			ContentLength: t.ContentLength,
+			TotalPieces:   t.TotalPieces,
+			PieceMd5Sign:  t.PieceMd5Sign,
+			Header:        t.Header,
+		}
+	}
+	return nil
+}
+
+func (s *storageManager) PinTask(taskID string, pinned bool) error {
+	s.indexRWMutex.RLock()
+	ts, ok := s.indexTask2PeerTask[taskID]
+	s.indexRWMutex.RUnlock()
+	if !ok {
+		return ErrTaskNotFound
+	}
+
+	for _, t := range ts {
+		t.Lock()
+		t.Pinned = pinned
+		t.Unlock()
+		if err := t.saveMetadata(); err != nil {
+			return err
+		}
+		t.Infof("task pinned: %t", pinned)
+	}
+	return nil
+}
+
 func (s *storageManager) FindPartialCompletedTask(taskID string, rg *util.Range) *ReusePeerTask {
 	s.indexRWMutex.RLock()
 	defer s.indexRWMutex.RUnlock()
@@ -658,6 +853,10 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 	)
 	for _, dir := range dirs {
 		taskID := dir.Name()
+		// skip content files
+		if taskID == contentDir {
+			continue
+		}
 		taskDir := path.Join(s.storeOption.DataPath, taskID)
 		peerDirs, err := os.ReadDir(taskDir)
 		if err != nil {
@@ -682,8 +881,10 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 			t := &localTaskStore{
 				dataDir:             dataDir,
 				metadataFilePath:    path.Join(dataDir, taskMetadata),
+				contentDir:          path.Join(s.storeOption.DataPath, contentDir),
 				expireTime:          s.storeOption.TaskExpireTime.Duration,
 				gcCallback:          gcCallback,
+				subtasks:            map[PeerTaskMetadata]*localSubTaskStore{},
 				SugaredLoggerOnWith: logger.With("task", taskID, "peer", peerID, "component", s.storeStrategy),
 			}
 			t.touch()
@@ -714,6 +915,19 @@ func (s *storageManager) ReloadPersistentTask(gcCallback GCCallback) error {
 					Warnf("load task from disk error: %s, data base64 encode: %s", err0, base64.StdEncoding.EncodeToString(bytes))
 				continue
 			}
+
+			// validate the pieces of partial task, so it can be resumed
+			if !t.Done {
+				if err0 = t.validatePieces(); err0 != nil {
+					loadErrs = append(loadErrs, err0)
+					loadErrDirs = append(loadErrDirs, dataDir)
+					logger.With("action", "reload", "stage", "validate pieces", "taskID", taskID, "peerID", peerID).
+						Warnf("load partial task from disk error: %s", err0)
+					continue
+				}
+				t.resumable.Store(true)
+				logger.Infof("load partial task %s/%s from disk, %d/%d pieces", taskID, peerID, len(t.Pieces), t.TotalPieces)
+			}
 			logger.Debugf("load task %s/%s from disk, metadata %s, last access: %v, expire time: %s",
 				t.persistentMetadata.TaskID, t.persistentMetadata.PeerID, t.metadataFilePath, time.Unix(0, t.lastAccess.Load()), t.expireTime)
 			s.tasks.Store(PeerTaskMetadata{
@@ -806,42 +1020,11 @@ func (s *storageManager) TryGC() (bool, error) {
 			bytesExceed = usageBytesExceed
 		}
 		logger.Infof("quota threshold reached, start gc oldest task, size: %d bytes", bytesExceed)
-		var tasks []*localTaskStore
-		s.tasks.Range(func(key, val any) bool {
-			// skip reclaimed task
-			task, ok := val.(*localTaskStore)
-			if !ok { // skip subtask
-				return true
-			}
-			if task.reclaimMarked.Load() {
-				return true
-			}
-			// task is not done, and is active in s.gcInterval
-			// next gc loop will check it again
-			if !task.Done && time.Since(time.Unix(0, task.lastAccess.Load())) < s.gcInterval {
-				return true
-			}
-			tasks = append(tasks, task)
-			return true
-		})
-		// sort by access time
-		sort.SliceStable(tasks, func(i, j int) bool {
-			return tasks[i].lastAccess.Load() < tasks[j].lastAccess.Load()
-		})
-		for _, task := range tasks {
-			task.MarkReclaim()
-			markedTasks = append(markedTasks, PeerTaskMetadata{task.PeerID, task.TaskID})
-			logger.Infof("quota threshold reached, mark task %s/%s reclaimed, last access: %s, size: %s",
-				task.TaskID, task.PeerID, time.Unix(0, task.lastAccess.Load()).Format(time.RFC3339Nano),
-				units.BytesSize(float64(task.ContentLength)))
-			bytesExceed -= task.ContentLength
-			if bytesExceed <= 0 {
-				break
-			}
-		}
-		if bytesExceed > 0 {
-			logger.Warnf("no enough tasks to gc, remind %d bytes", bytesExceed)
-		}
+		markedTasks = append(markedTasks, s.evictTasks(s.evictableTasks(nil), bytesExceed)...)
+	}
+
+	for _, quota := range s.storeOption.Quotas {
+		markedTasks = append(markedTasks, s.evictQuotaTasks(quota)...)
 	}
 
 	for _, key := range s.markedReclaimTasks {
@@ -882,9 +1065,138 @@ func (s *storageManager) TryGC() (bool, error) {
 	}
 	logger.Infof("marked %d task(s), reclaimed %d task(s)", len(markedTasks), len(s.markedReclaimTasks))
 	s.markedReclaimTasks = markedTasks
+	s.reclaimContents()
 	return true, nil
 }
 
+// evictableTasks returns the tasks which can be evicted and matched by the filter,
+// the tasks are sorted by the eviction policy.
+func (s *storageManager) evictableTasks(filter func(*localTaskStore) bool) []*localTaskStore {
+	var tasks []*localTaskStore
+	s.tasks.Range(func(key, val any) bool {
+		// skip reclaimed task
+		task, ok := val.(*localTaskStore)
+		if !ok { // skip subtask
+			return true
+		}
+		if task.reclaimMarked.Load() || task.Pinned {
+			return true
+		}
+		// task is not done, and is active in s.gcInterval
+		// next gc loop will check it again
+		if !task.Done && time.Since(time.Unix(0, task.lastAccess.Load())) < s.gcInterval {
+			return true
+		}
+		if filter != nil && !filter(task) {
+			return true
+		}
+		tasks = append(tasks, task)
+		return true
+	})
+
+	switch s.storeOption.EvictionPolicy {
+	case config.LFUEvictionPolicy:
+		// sort by access count, then access time
+		sort.SliceStable(tasks, func(i, j int) bool {
+			if ci, cj := tasks[i].accessCount.Load(), tasks[j].accessCount.Load(); ci != cj {
+				return ci < cj
+			}
+			return tasks[i].lastAccess.Load() < tasks[j].lastAccess.Load()
+		})
+	default:
+		// sort by access time
+		sort.SliceStable(tasks, func(i, j int) bool {
+			return tasks[i].lastAccess.Load() < tasks[j].lastAccess.Load()
+		})
+	}
+	return tasks
+}
+
+// evictTasks marks the tasks reclaimed in order until the exceeded bytes are reclaimed.
+func (s *storageManager) evictTasks(tasks []*localTaskStore, bytesExceed int64) []PeerTaskMetadata {
+	var markedTasks []PeerTaskMetadata
+	for _, task := range tasks {
+		task.MarkReclaim()
+		markedTasks = append(markedTasks, PeerTaskMetadata{task.PeerID, task.TaskID})
+		logger.Infof("quota threshold reached, mark task %s/%s reclaimed, last access: %s, access count: %d, size: %s",
+			task.TaskID, task.PeerID, time.Unix(0, task.lastAccess.Load()).Format(time.RFC3339Nano),
+			task.accessCount.Load(), units.BytesSize(float64(task.ContentLength)))
+		bytesExceed -= task.ContentLength
+		if bytesExceed <= 0 {
+			break
+		}
+	}
+	if bytesExceed > 0 {
+		logger.Warnf("no enough tasks to gc, remind %d bytes", bytesExceed)
+	}
+	return markedTasks
+}
+
+// evictQuotaTasks marks the tasks of the quota reclaimed when the quota is exceeded.
+func (s *storageManager) evictQuotaTasks(quota config.StorageQuota) []PeerTaskMetadata {
+	match := func(task *localTaskStore) bool {
+		return (quota.Application == "" || quota.Application == task.Application) &&
+			(quota.Tag == "" || quota.Tag == task.Tag)
+	}
+
+	var usage int64
+	s.tasks.Range(func(key, val any) bool {
+		task, ok := val.(*localTaskStore)
+		if ok && !task.reclaimMarked.Load() && match(task) {
+			usage += task.ContentLength
+		}
+		return true
+	})
+
+	bytesExceed := usage - int64(quota.Limit)
+	if bytesExceed <= 0 {
+		return nil
+	}
+
+	logger.Infof("quota of application %q tag %q exceeded, start gc tasks, size: %d bytes", quota.Application, quota.Tag, bytesExceed)
+	return s.evictTasks(s.evictableTasks(match), bytesExceed)
+}
+
+// reclaimContents removes the content files which are not linked by any task.
+func (s *storageManager) reclaimContents() {
+	root := path.Join(s.storeOption.DataPath, contentDir)
+	algorithms, err := os.ReadDir(root)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("read content directory %s error: %s", root, err)
+		}
+		return
+	}
+
+	for _, algorithm := range algorithms {
+		dir := path.Join(root, algorithm.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			logger.Warnf("read content directory %s error: %s", dir, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			stat, ok := info.Sys().(*syscall.Stat_t)
+			if !ok || uint64(stat.Nlink) > 1 {
+				continue
+			}
+
+			contentFilePath := path.Join(dir, entry.Name())
+			if err := os.Remove(contentFilePath); err != nil {
+				logger.Warnf("remove content file %s error: %s", contentFilePath, err)
+				continue
+			}
+			logger.Infof("content file %s reclaimed", contentFilePath)
+		}
+	}
+}
+
 func (s *storageManager) deleteTask(meta PeerTaskMetadata) error {
 	task, ok := s.LoadAndDeleteTask(meta)
 	if !ok {
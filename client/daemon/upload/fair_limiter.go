@@ -0,0 +1,194 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"d7y.io/dragonfly/v2/pkg/cache"
+)
+
+const (
+	// peerLimiterExpiration is the expiration of the limiter of idle requesting peer.
+	peerLimiterExpiration = time.Minute
+)
+
+// fairLimiter shares the upload bandwidth among the flows of requesting peers and tasks by
+// start-time fair queuing, the flow with higher weight gets more bandwidth when the bandwidth is insufficient,
+// and the bandwidth of every requesting peer is capped by the peer limit.
+type fairLimiter struct {
+	limiter      *rate.Limiter
+	peerLimit    rate.Limit
+	peerLimiters cache.Cache
+
+	mu sync.Mutex
+	// virtualTime is the start tag of the request in service
+	virtualTime float64
+	// finishTags is the finish tag of the last request of flows
+	finishTags map[string]float64
+	queue      fairQueue
+	// dispatching indicates whether the requests in queue are being dispatched
+	dispatching bool
+}
+
+// newFairLimiter returns a fair limiter with the total limiter and the limit of every requesting peer,
+// the peer limit is disabled when it's zero.
+func newFairLimiter(limiter *rate.Limiter, peerLimit rate.Limit) *fairLimiter {
+	return &fairLimiter{
+		limiter:      limiter,
+		peerLimit:    peerLimit,
+		peerLimiters: cache.New(peerLimiterExpiration, peerLimiterExpiration),
+		finishTags:   map[string]float64{},
+	}
+}
+
+// WaitN blocks until the n bytes of the flow of the peer are allowed, the weight must be greater than 0.
+func (l *fairLimiter) WaitN(ctx context.Context, peer, flow string, weight int, n int) error {
+	if limiter := l.getPeerLimiter(peer); limiter != nil {
+		if err := waitN(ctx, limiter, n); err != nil {
+			return err
+		}
+	}
+
+	if l.limiter == nil {
+		return nil
+	}
+
+	req := &fairRequest{
+		ctx:  ctx,
+		n:    n,
+		done: make(chan error, 1),
+	}
+
+	l.mu.Lock()
+	req.start = math.Max(l.virtualTime, l.finishTags[flow])
+	req.finish = req.start + float64(n)/float64(weight)
+	l.finishTags[flow] = req.finish
+	heap.Push(&l.queue, req)
+	if !l.dispatching {
+		l.dispatching = true
+		go l.dispatch()
+	}
+	l.mu.Unlock()
+
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dispatch serves the requests in the order of finish tag until the queue is empty.
+func (l *fairLimiter) dispatch() {
+	for {
+		l.mu.Lock()
+		if l.queue.Len() == 0 {
+			// all flows are idle, start a new busy period
+			l.dispatching = false
+			l.virtualTime = 0
+			l.finishTags = map[string]float64{}
+			l.mu.Unlock()
+			return
+		}
+
+		req := heap.Pop(&l.queue).(*fairRequest)
+		l.virtualTime = req.start
+		l.mu.Unlock()
+
+		if err := req.ctx.Err(); err != nil {
+			req.done <- err
+			continue
+		}
+		req.done <- waitN(req.ctx, l.limiter, req.n)
+	}
+}
+
+// getPeerLimiter returns the limiter of the requesting peer, it returns nil when the peer limit is disabled.
+func (l *fairLimiter) getPeerLimiter(peer string) *rate.Limiter {
+	if l.peerLimit <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limiter, ok := l.peerLimiters.Get(peer); ok {
+		l.peerLimiters.SetDefault(peer, limiter)
+		return limiter.(*rate.Limiter)
+	}
+
+	limiter := rate.NewLimiter(l.peerLimit, int(l.peerLimit))
+	l.peerLimiters.SetDefault(peer, limiter)
+	return limiter
+}
+
+// waitN waits the n tokens of limiter in batches, so n can be greater than the burst size.
+func waitN(ctx context.Context, limiter *rate.Limiter, n int) error {
+	burst := limiter.Burst()
+	for n > 0 {
+		batch := n
+		if burst > 0 && batch > burst {
+			batch = burst
+		}
+
+		if err := limiter.WaitN(ctx, batch); err != nil {
+			return err
+		}
+		n -= batch
+	}
+	return nil
+}
+
+// fairRequest is a request of bytes in the fair queue.
+type fairRequest struct {
+	ctx    context.Context
+	n      int
+	start  float64
+	finish float64
+	done   chan error
+}
+
+// fairQueue is a min heap of requests ordered by finish tag.
+type fairQueue []*fairRequest
+
+func (q fairQueue) Len() int { return len(q) }
+
+func (q fairQueue) Less(i, j int) bool {
+	if q[i].finish == q[j].finish {
+		return q[i].start < q[j].start
+	}
+	return q[i].finish < q[j].finish
+}
+
+func (q fairQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *fairQueue) Push(x any) { *q = append(*q, x.(*fairRequest)) }
+
+func (q *fairQueue) Pop() any {
+	old := *q
+	n := len(old)
+	req := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return req
+}
@@ -0,0 +1,108 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestFairLimiter_WaitN(t *testing.T) {
+	assert := testifyassert.New(t)
+	l := newFairLimiter(rate.NewLimiter(rate.Inf, 0), 0)
+	// hold the dispatcher, so the requests are queued
+	l.dispatching = true
+
+	requests := []struct {
+		flow   string
+		weight int
+		n      int
+	}{
+		{flow: "low", weight: 1, n: 100},
+		{flow: "low", weight: 1, n: 101},
+		{flow: "high", weight: 4, n: 102},
+		{flow: "high", weight: 4, n: 103},
+	}
+
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		wg.Add(1)
+		go func(flow string, weight, n int) {
+			defer wg.Done()
+			assert.Nil(l.WaitN(context.Background(), "peer", flow, weight, n))
+		}(req.flow, req.weight, req.n)
+
+		assert.Eventually(func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.queue.Len() == i+1
+		}, time.Second, time.Millisecond)
+	}
+
+	// the flow of higher weight is served first
+	var served []int
+	l.mu.Lock()
+	for l.queue.Len() > 0 {
+		req := heap.Pop(&l.queue).(*fairRequest)
+		served = append(served, req.n)
+		req.done <- nil
+	}
+	l.mu.Unlock()
+	wg.Wait()
+
+	assert.Equal([]int{102, 103, 100, 101}, served)
+}
+
+func TestFairLimiter_PeerLimit(t *testing.T) {
+	assert := testifyassert.New(t)
+	l := newFairLimiter(rate.NewLimiter(rate.Inf, 0), 1000)
+	assert.Nil(l.WaitN(context.Background(), "peer-1", "peer-1/task", 1, 1000))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	// the bandwidth of peer-1 is used up
+	assert.NotNil(l.WaitN(ctx, "peer-1", "peer-1/task", 1, 1000))
+	assert.Nil(l.WaitN(ctx, "peer-2", "peer-2/task", 1, 1000))
+}
+
+func TestFairLimiter_Dispatch(t *testing.T) {
+	assert := testifyassert.New(t)
+	l := newFairLimiter(rate.NewLimiter(1<<30, 10), 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// the n is greater than burst
+			assert.Nil(l.WaitN(context.Background(), "peer", "peer/task", 1, 25))
+		}()
+	}
+	wg.Wait()
+
+	assert.Eventually(func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return !l.dispatching && len(l.finishTags) == 0
+	}, time.Second, time.Millisecond)
+}
@@ -26,6 +26,7 @@ import (
 	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,7 +39,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"golang.org/x/time/rate"
 
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/metrics"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/client/util"
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -55,6 +59,10 @@ const (
 
 var GinLogFileName = "gin-upload.log"
 
+// responseWriterKey is the context key of the response writer of http server,
+// the writer of gin hides the io.ReaderFrom of it, which is required by zero copy.
+type responseWriterKey struct{}
+
 // Manager is the interface used for upload task.
 type Manager interface {
 	// Started upload manager server.
@@ -68,8 +76,13 @@ type Manager interface {
 type uploadManager struct {
 	*http.Server
 	*rate.Limiter
+	peerRateLimit  rate.Limit
+	fairLimiter    *fairLimiter
 	storageManager storage.Manager
 	certify        *certify.Certify
+	// zeroCopy indicates whether to send pieces from the data file with sendfile or splice syscall,
+	// it's available for simple store strategy only, the data file of other strategies may be linked to other files
+	zeroCopy bool
 }
 
 // Option is a functional option for configuring the upload manager.
@@ -82,6 +95,13 @@ func WithLimiter(limiter *rate.Limiter) func(*uploadManager) {
 	}
 }
 
+// WithPeerRateLimit sets upload rate limit of every requesting peer, zero means no limit.
+func WithPeerRateLimit(limit rate.Limit) func(*uploadManager) {
+	return func(manager *uploadManager) {
+		manager.peerRateLimit = limit
+	}
+}
+
 func WithCertify(ct *certify.Certify) func(manager *uploadManager) {
 	return func(manager *uploadManager) {
 		manager.certify = ct
@@ -92,17 +112,21 @@ func WithCertify(ct *certify.Certify) func(manager *uploadManager) {
 func NewUploadManager(cfg *config.DaemonOption, storageManager storage.Manager, logDir string, opts ...Option) (Manager, error) {
 	um := &uploadManager{
 		storageManager: storageManager,
+		zeroCopy:       cfg.Storage.StoreStrategy == config.SimpleLocalTaskStoreStrategy,
 	}
 
 	router := um.initRouter(cfg, logDir)
 	um.Server = &http.Server{
-		Handler: router,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			router.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), responseWriterKey{}, w)))
+		}),
 	}
 
 	for _, opt := range opts {
 		opt(um)
 	}
 
+	um.fairLimiter = newFairLimiter(um.Limiter, um.peerRateLimit)
 	return um, nil
 }
 
@@ -249,17 +273,28 @@ func (um *uploadManager) getDownload(ctx *gin.Context) {
 	ctx.Writer.WriteHeaderNow()
 	ctx.Writer.Flush()
 
-	if um.Limiter != nil {
-		if err = um.Limiter.WaitN(ctx, int(rg[0].Length)); err != nil {
-			log.Errorf("get limit failed: %s", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
-			return
-		}
+	// share the upload bandwidth among requesting peers and tasks, weighted by the priority of task
+	remote := ctx.ClientIP()
+	priority, err := strconv.Atoi(ctx.GetHeader(config.HeaderDragonflyPriority))
+	if err != nil || priority < int(commonv1.Priority_LEVEL0) {
+		priority = int(commonv1.Priority_LEVEL0)
 	}
 
-	// If w is a socket, golang will use sendfile or splice syscall for zero copy feature
-	// when start to transfer data, we could not call http.Error with header.
-	if n, err := io.Copy(ctx.Writer, reader); err != nil {
+	if err = um.fairLimiter.WaitN(ctx, remote, remote+"/"+taskID, priority+1, int(rg[0].Length)); err != nil {
+		log.Errorf("get limit failed: %s", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"errors": err.Error()})
+		return
+	}
+
+	// Send the piece from data file with sendfile or splice syscall when it's available, otherwise copy it.
+	// When start to transfer data, we could not call http.Error with header.
+	n, ok, err := um.sendfile(ctx, reader)
+	if !ok {
+		n, err = io.Copy(ctx.Writer, reader)
+	}
+	metrics.UploadPeerRequestCount.WithLabelValues(remote).Add(1)
+	metrics.UploadPeerTrafficCount.WithLabelValues(remote).Add(float64(n))
+	if err != nil {
 		log.Errorf("transfer data failed: %s", err)
 		return
 	} else if n != rg[0].Length {
@@ -19,6 +19,7 @@ package upload
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
 	"io"
 	"net"
@@ -104,3 +105,146 @@ func TestUploadManager_Serve(t *testing.T) {
 		assert.Equal(tt.targetPieceData, data)
 	}
 }
+
+func TestUploadManager_ZeroCopy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert := testifyassert.New(t)
+	testData, err := os.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	mockStorageManager := mocks.NewMockManager(ctrl)
+	mockStorageManager.EXPECT().ReadPiece(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, req *storage.ReadPieceRequest) (io.Reader, io.Closer, error) {
+			return openTestPiece(test.File, req)
+		})
+
+	tests := []struct {
+		name          string
+		storeStrategy config.StoreStrategy
+		zeroCopy      bool
+	}{
+		{
+			name:          "zero copy with simple store strategy",
+			storeStrategy: config.SimpleLocalTaskStoreStrategy,
+			zeroCopy:      true,
+		},
+		{
+			name:          "fall back to io copy with advance store strategy",
+			storeStrategy: config.AdvanceLocalTaskStoreStrategy,
+			zeroCopy:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			cfg := config.NewDaemonConfig()
+			cfg.Storage.StoreStrategy = tt.storeStrategy
+			um, err := NewUploadManager(cfg, mockStorageManager, os.TempDir())
+			assert.Nil(err, "NewUploadManager")
+			assert.Equal(tt.zeroCopy, um.(*uploadManager).zeroCopy)
+
+			addr := serveTestUploadManager(t, um)
+			defer um.Stop()
+
+			data := &bytes.Buffer{}
+			assert.Nil(getTestPiece(http.DefaultClient, addr, "bytes=512-1023", data), "get piece data")
+			assert.Equal(testData[512:1024], data.Bytes())
+		})
+	}
+}
+
+func BenchmarkUploadManager_getDownload(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	const pieceSize = 4 * 1024 * 1024
+	f, err := os.CreateTemp(b.TempDir(), "piece")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := io.CopyN(f, rand.Reader, pieceSize); err != nil {
+		b.Fatal(err)
+	}
+	f.Close()
+
+	mockStorageManager := mocks.NewMockManager(ctrl)
+	mockStorageManager.EXPECT().ReadPiece(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, req *storage.ReadPieceRequest) (io.Reader, io.Closer, error) {
+			return openTestPiece(f.Name(), req)
+		})
+
+	um, err := NewUploadManager(config.NewDaemonConfig(), mockStorageManager, os.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	addr := serveTestUploadManager(b, um)
+	defer um.Stop()
+
+	for _, zeroCopy := range []bool{true, false} {
+		name := "io copy"
+		if zeroCopy {
+			name = "zero copy"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			um.(*uploadManager).zeroCopy = zeroCopy
+			client := &http.Client{Transport: &http.Transport{}}
+			b.SetBytes(pieceSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := getTestPiece(client, addr, fmt.Sprintf("bytes=0-%d", pieceSize-1), io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// openTestPiece returns the piece range of file like the storage driver.
+func openTestPiece(name string, req *storage.ReadPieceRequest) (io.Reader, io.Closer, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := file.Seek(req.Range.Start, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return io.LimitReader(file, req.Range.Length), file, nil
+}
+
+func serveTestUploadManager(tb testing.TB, um Manager) string {
+	listen, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	go um.Serve(listen)
+	return listen.Addr().String()
+}
+
+func getTestPiece(client *http.Client, addr, pieceRange string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("http://%s/%s/%s/%s?peerId=%s", addr, "download", "666", "task", "peer"), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Range", pieceRange)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
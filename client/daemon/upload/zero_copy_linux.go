@@ -0,0 +1,54 @@
+//go:build linux
+
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"io"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendfile sends the piece from the data file to the requesting peer without copying it to user space,
+// the http server uses sendfile or splice syscall when it copies a file to a plain tcp connection.
+// It returns false without sending any data when zero copy is not available, and the caller falls back to io.Copy.
+func (um *uploadManager) sendfile(ctx *gin.Context, reader io.Reader) (int64, bool, error) {
+	// tls connection encrypts data in user space
+	if !um.zeroCopy || ctx.Request.TLS != nil {
+		return 0, false, nil
+	}
+
+	// the reader of piece range from storage driver is a limited reader of data file
+	lr, ok := reader.(*io.LimitedReader)
+	if !ok {
+		return 0, false, nil
+	}
+
+	if _, ok := lr.R.(*os.File); !ok {
+		return 0, false, nil
+	}
+
+	w, ok := ctx.Request.Context().Value(responseWriterKey{}).(io.ReaderFrom)
+	if !ok {
+		return 0, false, nil
+	}
+
+	n, err := w.ReadFrom(lr)
+	return n, true, err
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendfile is available on linux only, the caller always falls back to io.Copy.
+func (um *uploadManager) sendfile(ctx *gin.Context, reader io.Reader) (int64, bool, error) {
+	return 0, false, nil
+}
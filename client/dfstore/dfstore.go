@@ -21,6 +21,7 @@ package dfstore
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -68,6 +69,42 @@ type Dfstore interface {
 
 	// IsObjectExistWithContext returns whether the object exists.
 	IsObjectExistWithContext(ctx context.Context, input *IsObjectExistInput) (bool, error)
+
+	// ListObjectsRequestWithContext returns *http.Request of listing objects.
+	ListObjectsRequestWithContext(ctx context.Context, input *ListObjectsInput) (*http.Request, error)
+
+	// ListObjectsWithContext returns metadatas of objects.
+	ListObjectsWithContext(ctx context.Context, input *ListObjectsInput) ([]*pkgobjectstorage.ObjectMetadata, error)
+
+	// CreateMultipartUploadRequestWithContext returns *http.Request of creating multipart upload.
+	CreateMultipartUploadRequestWithContext(ctx context.Context, input *CreateMultipartUploadInput) (*http.Request, error)
+
+	// CreateMultipartUploadWithContext creates multipart upload and returns the upload id.
+	CreateMultipartUploadWithContext(ctx context.Context, input *CreateMultipartUploadInput) (string, error)
+
+	// UploadPartRequestWithContext returns *http.Request of uploading part.
+	UploadPartRequestWithContext(ctx context.Context, input *UploadPartInput) (*http.Request, error)
+
+	// UploadPartWithContext uploads part of multipart upload.
+	UploadPartWithContext(ctx context.Context, input *UploadPartInput) (*objectstorage.Part, error)
+
+	// ListPartsRequestWithContext returns *http.Request of listing parts.
+	ListPartsRequestWithContext(ctx context.Context, input *ListPartsInput) (*http.Request, error)
+
+	// ListPartsWithContext returns uploaded parts of multipart upload.
+	ListPartsWithContext(ctx context.Context, input *ListPartsInput) ([]objectstorage.Part, error)
+
+	// CompleteMultipartUploadRequestWithContext returns *http.Request of completing multipart upload.
+	CompleteMultipartUploadRequestWithContext(ctx context.Context, input *CompleteMultipartUploadInput) (*http.Request, error)
+
+	// CompleteMultipartUploadWithContext assembles the parts into object and puts the object.
+	CompleteMultipartUploadWithContext(ctx context.Context, input *CompleteMultipartUploadInput) error
+
+	// AbortMultipartUploadRequestWithContext returns *http.Request of aborting multipart upload.
+	AbortMultipartUploadRequestWithContext(ctx context.Context, input *AbortMultipartUploadInput) (*http.Request, error)
+
+	// AbortMultipartUploadWithContext aborts multipart upload and removes the uploaded parts.
+	AbortMultipartUploadWithContext(ctx context.Context, input *AbortMultipartUploadInput) error
 }
 
 // dfstore provides object storage function.
@@ -498,3 +535,417 @@ func (dfs *dfstore) IsObjectExistWithContext(ctx context.Context, input *IsObjec
 
 	return true, nil
 }
+
+// ListObjectsInput is used to construct request of listing objects.
+type ListObjectsInput struct {
+	// BucketName is bucket name.
+	BucketName string
+
+	// Prefix filters the objects by key prefix.
+	Prefix string
+
+	// Marker is the key to start listing after.
+	Marker string
+
+	// Limit is the max number of objects, 1000 objects are listed by default.
+	Limit int64
+}
+
+// Validate validates ListObjectsInput fields.
+func (i *ListObjectsInput) Validate() error {
+	if i.BucketName == "" {
+		return errors.New("invalid BucketName")
+	}
+
+	if i.Limit < 0 || i.Limit > 1000 {
+		return errors.New("invalid Limit")
+	}
+
+	return nil
+}
+
+// ListObjectsRequestWithContext returns *http.Request of listing objects.
+func (dfs *dfstore) ListObjectsRequestWithContext(ctx context.Context, input *ListObjectsInput) (*http.Request, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(dfs.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = filepath.Join("buckets", input.BucketName, "objects")
+
+	query := u.Query()
+	if input.Prefix != "" {
+		query.Set("prefix", input.Prefix)
+	}
+
+	if input.Marker != "" {
+		query.Set("marker", input.Marker)
+	}
+
+	if input.Limit > 0 {
+		query.Set("limit", fmt.Sprint(input.Limit))
+	}
+	u.RawQuery = query.Encode()
+
+	return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+}
+
+// ListObjectsWithContext returns metadatas of objects.
+func (dfs *dfstore) ListObjectsWithContext(ctx context.Context, input *ListObjectsInput) ([]*pkgobjectstorage.ObjectMetadata, error) {
+	req, err := dfs.ListObjectsRequestWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadatas []*pkgobjectstorage.ObjectMetadata
+	if err := dfs.doJSON(req, &metadatas); err != nil {
+		return nil, err
+	}
+
+	return metadatas, nil
+}
+
+// CreateMultipartUploadInput is used to construct request of creating multipart upload.
+type CreateMultipartUploadInput struct {
+	// BucketName is bucket name.
+	BucketName string
+
+	// ObjectKey is object key.
+	ObjectKey string
+
+	// Filter is used to generate a unique Task ID by
+	// filtering unnecessary query params in the URL,
+	// it is separated by & character.
+	Filter string
+
+	// Mode is the mode in which the backend is written,
+	// including WriteBack and AsyncWriteBack.
+	Mode int
+
+	// MaxReplicas is the maximum number of
+	// replicas of an object cache in seed peers.
+	MaxReplicas int
+}
+
+// Validate validates CreateMultipartUploadInput fields.
+func (i *CreateMultipartUploadInput) Validate() error {
+	if i.BucketName == "" {
+		return errors.New("invalid BucketName")
+	}
+
+	if i.ObjectKey == "" {
+		return errors.New("invalid ObjectKey")
+	}
+
+	if i.Mode != objectstorage.WriteBack && i.Mode != objectstorage.AsyncWriteBack {
+		return errors.New("invalid Mode")
+	}
+
+	if i.MaxReplicas < 0 || i.MaxReplicas > 100 {
+		return errors.New("invalid MaxReplicas")
+	}
+
+	return nil
+}
+
+// CreateMultipartUploadRequestWithContext returns *http.Request of creating multipart upload.
+func (dfs *dfstore) CreateMultipartUploadRequestWithContext(ctx context.Context, input *CreateMultipartUploadInput) (*http.Request, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(&objectstorage.CreateMultipartUploadRequest{
+		ObjectKey:   input.ObjectKey,
+		Mode:        uint(input.Mode),
+		Filter:      input.Filter,
+		MaxReplicas: input.MaxReplicas,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(dfs.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = filepath.Join("buckets", input.BucketName, "uploads")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add(headers.ContentType, "application/json")
+
+	return req, nil
+}
+
+// CreateMultipartUploadWithContext creates multipart upload and returns the upload id.
+func (dfs *dfstore) CreateMultipartUploadWithContext(ctx context.Context, input *CreateMultipartUploadInput) (string, error) {
+	req, err := dfs.CreateMultipartUploadRequestWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	var output objectstorage.CreateMultipartUploadResponse
+	if err := dfs.doJSON(req, &output); err != nil {
+		return "", err
+	}
+
+	return output.UploadID, nil
+}
+
+// UploadPartInput is used to construct request of uploading part.
+type UploadPartInput struct {
+	// BucketName is bucket name.
+	BucketName string
+
+	// UploadID is the id of multipart upload.
+	UploadID string
+
+	// PartNumber is the number of part, it's between 1 and 10000.
+	PartNumber int
+
+	// Reader is reader of part.
+	Reader io.Reader
+}
+
+// Validate validates UploadPartInput fields.
+func (i *UploadPartInput) Validate() error {
+	if i.BucketName == "" {
+		return errors.New("invalid BucketName")
+	}
+
+	if i.UploadID == "" {
+		return errors.New("invalid UploadID")
+	}
+
+	if i.PartNumber < 1 || i.PartNumber > objectstorage.MaxPartNumber {
+		return errors.New("invalid PartNumber")
+	}
+
+	if i.Reader == nil {
+		return errors.New("invalid Reader")
+	}
+
+	return nil
+}
+
+// UploadPartRequestWithContext returns *http.Request of uploading part,
+// the part is streamed from the reader.
+func (dfs *dfstore) UploadPartRequestWithContext(ctx context.Context, input *UploadPartInput) (*http.Request, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(dfs.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = filepath.Join("buckets", input.BucketName, "uploads", input.UploadID, "parts", fmt.Sprint(input.PartNumber))
+	return http.NewRequestWithContext(ctx, http.MethodPut, u.String(), input.Reader)
+}
+
+// UploadPartWithContext uploads part of multipart upload.
+func (dfs *dfstore) UploadPartWithContext(ctx context.Context, input *UploadPartInput) (*objectstorage.Part, error) {
+	req, err := dfs.UploadPartRequestWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var part objectstorage.Part
+	if err := dfs.doJSON(req, &part); err != nil {
+		return nil, err
+	}
+
+	return &part, nil
+}
+
+// ListPartsInput is used to construct request of listing parts.
+type ListPartsInput struct {
+	// BucketName is bucket name.
+	BucketName string
+
+	// UploadID is the id of multipart upload.
+	UploadID string
+}
+
+// Validate validates ListPartsInput fields.
+func (i *ListPartsInput) Validate() error {
+	if i.BucketName == "" {
+		return errors.New("invalid BucketName")
+	}
+
+	if i.UploadID == "" {
+		return errors.New("invalid UploadID")
+	}
+
+	return nil
+}
+
+// ListPartsRequestWithContext returns *http.Request of listing parts.
+func (dfs *dfstore) ListPartsRequestWithContext(ctx context.Context, input *ListPartsInput) (*http.Request, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(dfs.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = filepath.Join("buckets", input.BucketName, "uploads", input.UploadID, "parts")
+	return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+}
+
+// ListPartsWithContext returns uploaded parts of multipart upload.
+func (dfs *dfstore) ListPartsWithContext(ctx context.Context, input *ListPartsInput) ([]objectstorage.Part, error) {
+	req, err := dfs.ListPartsRequestWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []objectstorage.Part
+	if err := dfs.doJSON(req, &parts); err != nil {
+		return nil, err
+	}
+
+	return parts, nil
+}
+
+// CompleteMultipartUploadInput is used to construct request of completing multipart upload.
+type CompleteMultipartUploadInput struct {
+	// BucketName is bucket name.
+	BucketName string
+
+	// UploadID is the id of multipart upload.
+	UploadID string
+
+	// Parts is the parts of object in ascending order of part number.
+	Parts []objectstorage.Part
+}
+
+// Validate validates CompleteMultipartUploadInput fields.
+func (i *CompleteMultipartUploadInput) Validate() error {
+	if i.BucketName == "" {
+		return errors.New("invalid BucketName")
+	}
+
+	if i.UploadID == "" {
+		return errors.New("invalid UploadID")
+	}
+
+	if len(i.Parts) == 0 {
+		return errors.New("invalid Parts")
+	}
+
+	return nil
+}
+
+// CompleteMultipartUploadRequestWithContext returns *http.Request of completing multipart upload.
+func (dfs *dfstore) CompleteMultipartUploadRequestWithContext(ctx context.Context, input *CompleteMultipartUploadInput) (*http.Request, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(&objectstorage.CompleteMultipartUploadRequest{Parts: input.Parts})
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(dfs.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = filepath.Join("buckets", input.BucketName, "uploads", input.UploadID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add(headers.ContentType, "application/json")
+
+	return req, nil
+}
+
+// CompleteMultipartUploadWithContext assembles the parts into object and puts the object.
+func (dfs *dfstore) CompleteMultipartUploadWithContext(ctx context.Context, input *CompleteMultipartUploadInput) error {
+	req, err := dfs.CompleteMultipartUploadRequestWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return dfs.doJSON(req, nil)
+}
+
+// AbortMultipartUploadInput is used to construct request of aborting multipart upload.
+type AbortMultipartUploadInput struct {
+	// BucketName is bucket name.
+	BucketName string
+
+	// UploadID is the id of multipart upload.
+	UploadID string
+}
+
+// Validate validates AbortMultipartUploadInput fields.
+func (i *AbortMultipartUploadInput) Validate() error {
+	if i.BucketName == "" {
+		return errors.New("invalid BucketName")
+	}
+
+	if i.UploadID == "" {
+		return errors.New("invalid UploadID")
+	}
+
+	return nil
+}
+
+// AbortMultipartUploadRequestWithContext returns *http.Request of aborting multipart upload.
+func (dfs *dfstore) AbortMultipartUploadRequestWithContext(ctx context.Context, input *AbortMultipartUploadInput) (*http.Request, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(dfs.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = filepath.Join("buckets", input.BucketName, "uploads", input.UploadID)
+	return http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+}
+
+// AbortMultipartUploadWithContext aborts multipart upload and removes the uploaded parts.
+func (dfs *dfstore) AbortMultipartUploadWithContext(ctx context.Context, input *AbortMultipartUploadInput) error {
+	req, err := dfs.AbortMultipartUploadRequestWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return dfs.doJSON(req, nil)
+}
+
+// doJSON sends the request and decodes the json response into v, the response is discarded when v is nil.
+func (dfs *dfstore) doJSON(req *http.Request, v any) error {
+	resp, err := dfs.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("bad response status %s", resp.Status)
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -10,6 +10,7 @@ import (
 	http "net/http"
 	reflect "reflect"
 
+	objectstorage0 "d7y.io/dragonfly/v2/client/daemon/objectstorage"
 	dfstore "d7y.io/dragonfly/v2/client/dfstore"
 	objectstorage "d7y.io/dragonfly/v2/pkg/objectstorage"
 	gomock "github.com/golang/mock/gomock"
@@ -38,6 +39,94 @@ func (m *MockDfstore) EXPECT() *MockDfstoreMockRecorder {
 	return m.recorder
 }
 
+// AbortMultipartUploadRequestWithContext mocks base method.
+func (m *MockDfstore) AbortMultipartUploadRequestWithContext(ctx context.Context, input *dfstore.AbortMultipartUploadInput) (*http.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortMultipartUploadRequestWithContext", ctx, input)
+	ret0, _ := ret[0].(*http.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AbortMultipartUploadRequestWithContext indicates an expected call of AbortMultipartUploadRequestWithContext.
+func (mr *MockDfstoreMockRecorder) AbortMultipartUploadRequestWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortMultipartUploadRequestWithContext", reflect.TypeOf((*MockDfstore)(nil).AbortMultipartUploadRequestWithContext), ctx, input)
+}
+
+// AbortMultipartUploadWithContext mocks base method.
+func (m *MockDfstore) AbortMultipartUploadWithContext(ctx context.Context, input *dfstore.AbortMultipartUploadInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortMultipartUploadWithContext", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortMultipartUploadWithContext indicates an expected call of AbortMultipartUploadWithContext.
+func (mr *MockDfstoreMockRecorder) AbortMultipartUploadWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortMultipartUploadWithContext", reflect.TypeOf((*MockDfstore)(nil).AbortMultipartUploadWithContext), ctx, input)
+}
+
+// CompleteMultipartUploadRequestWithContext mocks base method.
+func (m *MockDfstore) CompleteMultipartUploadRequestWithContext(ctx context.Context, input *dfstore.CompleteMultipartUploadInput) (*http.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteMultipartUploadRequestWithContext", ctx, input)
+	ret0, _ := ret[0].(*http.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteMultipartUploadRequestWithContext indicates an expected call of CompleteMultipartUploadRequestWithContext.
+func (mr *MockDfstoreMockRecorder) CompleteMultipartUploadRequestWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMultipartUploadRequestWithContext", reflect.TypeOf((*MockDfstore)(nil).CompleteMultipartUploadRequestWithContext), ctx, input)
+}
+
+// CompleteMultipartUploadWithContext mocks base method.
+func (m *MockDfstore) CompleteMultipartUploadWithContext(ctx context.Context, input *dfstore.CompleteMultipartUploadInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteMultipartUploadWithContext", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteMultipartUploadWithContext indicates an expected call of CompleteMultipartUploadWithContext.
+func (mr *MockDfstoreMockRecorder) CompleteMultipartUploadWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMultipartUploadWithContext", reflect.TypeOf((*MockDfstore)(nil).CompleteMultipartUploadWithContext), ctx, input)
+}
+
+// CreateMultipartUploadRequestWithContext mocks base method.
+func (m *MockDfstore) CreateMultipartUploadRequestWithContext(ctx context.Context, input *dfstore.CreateMultipartUploadInput) (*http.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMultipartUploadRequestWithContext", ctx, input)
+	ret0, _ := ret[0].(*http.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMultipartUploadRequestWithContext indicates an expected call of CreateMultipartUploadRequestWithContext.
+func (mr *MockDfstoreMockRecorder) CreateMultipartUploadRequestWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMultipartUploadRequestWithContext", reflect.TypeOf((*MockDfstore)(nil).CreateMultipartUploadRequestWithContext), ctx, input)
+}
+
+// CreateMultipartUploadWithContext mocks base method.
+func (m *MockDfstore) CreateMultipartUploadWithContext(ctx context.Context, input *dfstore.CreateMultipartUploadInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMultipartUploadWithContext", ctx, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMultipartUploadWithContext indicates an expected call of CreateMultipartUploadWithContext.
+func (mr *MockDfstoreMockRecorder) CreateMultipartUploadWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMultipartUploadWithContext", reflect.TypeOf((*MockDfstore)(nil).CreateMultipartUploadWithContext), ctx, input)
+}
+
 // DeleteObjectRequestWithContext mocks base method.
 func (m *MockDfstore) DeleteObjectRequestWithContext(ctx context.Context, input *dfstore.DeleteObjectInput) (*http.Request, error) {
 	m.ctrl.T.Helper()
@@ -157,6 +246,66 @@ func (mr *MockDfstoreMockRecorder) IsObjectExistWithContext(ctx, input interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsObjectExistWithContext", reflect.TypeOf((*MockDfstore)(nil).IsObjectExistWithContext), ctx, input)
 }
 
+// ListObjectsRequestWithContext mocks base method.
+func (m *MockDfstore) ListObjectsRequestWithContext(ctx context.Context, input *dfstore.ListObjectsInput) (*http.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObjectsRequestWithContext", ctx, input)
+	ret0, _ := ret[0].(*http.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListObjectsRequestWithContext indicates an expected call of ListObjectsRequestWithContext.
+func (mr *MockDfstoreMockRecorder) ListObjectsRequestWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObjectsRequestWithContext", reflect.TypeOf((*MockDfstore)(nil).ListObjectsRequestWithContext), ctx, input)
+}
+
+// ListObjectsWithContext mocks base method.
+func (m *MockDfstore) ListObjectsWithContext(ctx context.Context, input *dfstore.ListObjectsInput) ([]*objectstorage.ObjectMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObjectsWithContext", ctx, input)
+	ret0, _ := ret[0].([]*objectstorage.ObjectMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListObjectsWithContext indicates an expected call of ListObjectsWithContext.
+func (mr *MockDfstoreMockRecorder) ListObjectsWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObjectsWithContext", reflect.TypeOf((*MockDfstore)(nil).ListObjectsWithContext), ctx, input)
+}
+
+// ListPartsRequestWithContext mocks base method.
+func (m *MockDfstore) ListPartsRequestWithContext(ctx context.Context, input *dfstore.ListPartsInput) (*http.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPartsRequestWithContext", ctx, input)
+	ret0, _ := ret[0].(*http.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPartsRequestWithContext indicates an expected call of ListPartsRequestWithContext.
+func (mr *MockDfstoreMockRecorder) ListPartsRequestWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPartsRequestWithContext", reflect.TypeOf((*MockDfstore)(nil).ListPartsRequestWithContext), ctx, input)
+}
+
+// ListPartsWithContext mocks base method.
+func (m *MockDfstore) ListPartsWithContext(ctx context.Context, input *dfstore.ListPartsInput) ([]objectstorage0.Part, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPartsWithContext", ctx, input)
+	ret0, _ := ret[0].([]objectstorage0.Part)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPartsWithContext indicates an expected call of ListPartsWithContext.
+func (mr *MockDfstoreMockRecorder) ListPartsWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPartsWithContext", reflect.TypeOf((*MockDfstore)(nil).ListPartsWithContext), ctx, input)
+}
+
 // PutObjectRequestWithContext mocks base method.
 func (m *MockDfstore) PutObjectRequestWithContext(ctx context.Context, input *dfstore.PutOjectInput) (*http.Request, error) {
 	m.ctrl.T.Helper()
@@ -185,3 +334,33 @@ func (mr *MockDfstoreMockRecorder) PutObjectWithContext(ctx, input interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutObjectWithContext", reflect.TypeOf((*MockDfstore)(nil).PutObjectWithContext), ctx, input)
 }
+
+// UploadPartRequestWithContext mocks base method.
+func (m *MockDfstore) UploadPartRequestWithContext(ctx context.Context, input *dfstore.UploadPartInput) (*http.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadPartRequestWithContext", ctx, input)
+	ret0, _ := ret[0].(*http.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadPartRequestWithContext indicates an expected call of UploadPartRequestWithContext.
+func (mr *MockDfstoreMockRecorder) UploadPartRequestWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadPartRequestWithContext", reflect.TypeOf((*MockDfstore)(nil).UploadPartRequestWithContext), ctx, input)
+}
+
+// UploadPartWithContext mocks base method.
+func (m *MockDfstore) UploadPartWithContext(ctx context.Context, input *dfstore.UploadPartInput) (*objectstorage0.Part, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadPartWithContext", ctx, input)
+	ret0, _ := ret[0].(*objectstorage0.Part)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadPartWithContext indicates an expected call of UploadPartWithContext.
+func (mr *MockDfstoreMockRecorder) UploadPartWithContext(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadPartWithContext", reflect.TypeOf((*MockDfstore)(nil).UploadPartWithContext), ctx, input)
+}
@@ -16,6 +16,8 @@
 
 package job
 
+import "time"
+
 // Queue Name.
 const (
 	GlobalQueue     = Queue("global")
@@ -24,7 +26,30 @@ const (
 
 // Job Name.
 const (
+	// PreheatJob is the name of preheating the task by seed peers.
 	PreheatJob = "preheat"
+
+	// GetTaskJob is the name of listing the peers which cache the task.
+	GetTaskJob = "get_task"
+
+	// DeleteTaskJob is the name of deleting the task from all peers.
+	DeleteTaskJob = "delete_task"
+
+	// EvictTaskJob is the name of evicting the task from seed peers.
+	EvictTaskJob = "evict_task"
+
+	// SyncPeersJob is the name of listing the peers in the scheduler.
+	SyncPeersJob = "sync_peers"
+
+	// PurgeJob is the name of purging the task from the scheduler and all peers.
+	PurgeJob = "purge"
+)
+
+// Job State.
+const (
+	// StateCanceled is the state of the group job canceled by user,
+	// the other states are the states of machinery.
+	StateCanceled = "CANCELED"
 )
 
 // Machinery server configuration.
@@ -36,3 +61,18 @@ const (
 	DefaultRedisWriteTimeout   = 60
 	DefaultRedisConnectTimeout = 60
 )
+
+// Group job configuration.
+const (
+	// DefaultCanceledCheckInterval is the interval of checking whether the group job is canceled.
+	DefaultCanceledCheckInterval = 5 * time.Second
+
+	// groupJobSignaturesKeyPrefix is the key prefix of signatures in the group job.
+	groupJobSignaturesKeyPrefix = "job:signatures:"
+
+	// groupJobCanceledKeyPrefix is the key prefix of marking the group job canceled.
+	groupJobCanceledKeyPrefix = "job:canceled:"
+
+	// groupJobProgressKeyPrefix is the key prefix of progress in the group job.
+	groupJobProgressKeyPrefix = "job:progress:"
+)
@@ -0,0 +1,241 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+// ErrGroupJobCanceled is returned by the job of canceled group.
+var ErrGroupJobCanceled = errors.New("group job is canceled")
+
+// Progress is the progress of a job in the group.
+type Progress struct {
+	// Bytes is the bytes downloaded by the job.
+	Bytes int64 `json:"bytes"`
+
+	// PeerCount is the count of peers handled by the job.
+	PeerCount int64 `json:"peerCount"`
+
+	// Errors is the errors of the job.
+	Errors []string `json:"errors"`
+
+	// UpdatedAt is the time of updating the progress.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SendGroupJob stores the signatures of the group for retrying, then sends the group.
+func (t *Job) SendGroupJob(ctx context.Context, group *machineryv1tasks.Group) error {
+	signatures := map[string]any{}
+	for _, signature := range group.Tasks {
+		b, err := json.Marshal(signature)
+		if err != nil {
+			return err
+		}
+
+		signatures[signature.UUID] = b
+	}
+
+	if len(signatures) > 0 {
+		key := groupJobSignaturesKey(group.GroupUUID)
+		pipe := t.rdb.TxPipeline()
+		pipe.HSet(ctx, key, signatures)
+		pipe.Expire(ctx, key, DefaultResultsExpireIn*time.Second)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	_, err := t.Server.SendGroupWithContext(ctx, group, 0)
+	return err
+}
+
+// RetryGroupJob sends the failed jobs in the group again with the same uuid,
+// so the state of group is calculated by the retried jobs. It returns the
+// count of retried jobs.
+func (t *Job) RetryGroupJob(ctx context.Context, groupUUID string) (int, error) {
+	taskStates, err := t.Server.GetBackend().GroupTaskStates(groupUUID, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, taskState := range taskStates {
+		if !taskState.IsFailure() {
+			continue
+		}
+
+		b, err := t.rdb.HGet(ctx, groupJobSignaturesKey(groupUUID), taskState.TaskUUID).Bytes()
+		if err != nil {
+			return count, err
+		}
+
+		signature := &machineryv1tasks.Signature{}
+		if err := json.Unmarshal(b, signature); err != nil {
+			return count, err
+		}
+
+		if err := t.rdb.HDel(ctx, groupJobProgressKey(groupUUID), taskState.TaskUUID).Err(); err != nil {
+			return count, err
+		}
+
+		if _, err := t.Server.SendTaskWithContext(ctx, signature); err != nil {
+			return count, err
+		}
+
+		logger.WithGroupAndTaskID(groupUUID, taskState.TaskUUID).Info("retry failed job")
+		count++
+	}
+
+	if count == 0 {
+		return 0, errors.New("no failed job in group")
+	}
+
+	return count, nil
+}
+
+// CancelGroupJob marks the group canceled. The running jobs of the group are canceled
+// by the context of WithCancel, and the pending jobs fail when they are received.
+func (t *Job) CancelGroupJob(ctx context.Context, groupUUID string) error {
+	return t.rdb.Set(ctx, groupJobCanceledKey(groupUUID), time.Now().Unix(), DefaultResultsExpireIn*time.Second).Err()
+}
+
+// IsGroupJobCanceled returns whether the group is canceled.
+func (t *Job) IsGroupJobCanceled(ctx context.Context, groupUUID string) (bool, error) {
+	n, err := t.rdb.Exists(ctx, groupJobCanceledKey(groupUUID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// WithCancel returns a copy of ctx which is canceled when the group of the job
+// in ctx is canceled, and it returns ErrGroupJobCanceled if the group has been
+// canceled. The job which is not in a group is never canceled.
+func (t *Job) WithCancel(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	signature := machineryv1tasks.SignatureFromContext(ctx)
+	if signature == nil || signature.GroupUUID == "" {
+		return ctx, cancel, nil
+	}
+
+	log := logger.WithGroupAndTaskID(signature.GroupUUID, signature.UUID)
+	if canceled, err := t.IsGroupJobCanceled(ctx, signature.GroupUUID); err != nil {
+		log.Errorf("check group canceled failed: %s", err.Error())
+	} else if canceled {
+		cancel()
+		return ctx, cancel, ErrGroupJobCanceled
+	}
+
+	go func() {
+		ticker := time.NewTicker(DefaultCanceledCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				canceled, err := t.IsGroupJobCanceled(ctx, signature.GroupUUID)
+				if err != nil {
+					log.Errorf("check group canceled failed: %s", err.Error())
+					continue
+				}
+
+				if canceled {
+					log.Info("group is canceled, cancel the running job")
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, cancel, nil
+}
+
+// SetProgress stores the progress of the job in ctx, the progress of the job
+// which is not in a group is ignored. The progress is stored even if ctx is
+// done, so that the errors of the canceled job are reported.
+func (t *Job) SetProgress(ctx context.Context, progress *Progress) error {
+	signature := machineryv1tasks.SignatureFromContext(ctx)
+	if signature == nil || signature.GroupUUID == "" {
+		return nil
+	}
+
+	progress.UpdatedAt = time.Now()
+	b, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	key := groupJobProgressKey(signature.GroupUUID)
+	pipe := t.rdb.TxPipeline()
+	pipe.HSet(context.Background(), key, signature.UUID, b)
+	pipe.Expire(context.Background(), key, DefaultResultsExpireIn*time.Second)
+	_, err = pipe.Exec(context.Background())
+	return err
+}
+
+// GetGroupJobProgress returns the progress of the jobs in the group by the job uuid.
+func (t *Job) GetGroupJobProgress(ctx context.Context, groupUUID string) (map[string]*Progress, error) {
+	values, err := t.rdb.HGetAll(ctx, groupJobProgressKey(groupUUID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalProgress(values), nil
+}
+
+// unmarshalProgress unmarshals the progress of jobs, the invalid progress is skipped.
+func unmarshalProgress(values map[string]string) map[string]*Progress {
+	progress := make(map[string]*Progress, len(values))
+	for uuid, value := range values {
+		p := &Progress{}
+		if err := json.Unmarshal([]byte(value), p); err != nil {
+			logger.WithTaskID(uuid).Errorf("unmarshal progress failed: %s", err.Error())
+			continue
+		}
+
+		progress[uuid] = p
+	}
+
+	return progress
+}
+
+// groupJobSignaturesKey returns the key of signatures in the group.
+func groupJobSignaturesKey(groupUUID string) string {
+	return groupJobSignaturesKeyPrefix + groupUUID
+}
+
+// groupJobCanceledKey returns the key of marking the group canceled.
+func groupJobCanceledKey(groupUUID string) string {
+	return groupJobCanceledKeyPrefix + groupUUID
+}
+
+// groupJobProgressKey returns the key of progress in the group.
+func groupJobProgressKey(groupUUID string) string {
+	return groupJobProgressKeyPrefix + groupUUID
+}
@@ -0,0 +1,81 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_unmarshalProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		expect func(t *testing.T, progress map[string]*Progress)
+	}{
+		{
+			name: "unmarshal progress",
+			values: map[string]string{
+				"foo": `{"bytes":1024,"peerCount":2,"errors":["bar"]}`,
+				"baz": `{"peerCount":1}`,
+			},
+			expect: func(t *testing.T, progress map[string]*Progress) {
+				assert := assert.New(t)
+				assert.Equal(len(progress), 2)
+				assert.Equal(progress["foo"].Bytes, int64(1024))
+				assert.Equal(progress["foo"].PeerCount, int64(2))
+				assert.EqualValues(progress["foo"].Errors, []string{"bar"})
+				assert.Equal(progress["baz"].Bytes, int64(0))
+				assert.Equal(progress["baz"].PeerCount, int64(1))
+			},
+		},
+		{
+			name: "skip invalid progress",
+			values: map[string]string{
+				"foo": `{"bytes":1024}`,
+				"bar": "invalid",
+			},
+			expect: func(t *testing.T, progress map[string]*Progress) {
+				assert := assert.New(t)
+				assert.Equal(len(progress), 1)
+				assert.Equal(progress["foo"].Bytes, int64(1024))
+			},
+		},
+		{
+			name:   "unmarshal empty values",
+			values: map[string]string{},
+			expect: func(t *testing.T, progress map[string]*Progress) {
+				assert := assert.New(t)
+				assert.Empty(progress)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, unmarshalProgress(tc.values))
+		})
+	}
+}
+
+func TestGroup_keys(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(groupJobSignaturesKey("foo"), "job:signatures:foo")
+	assert.Equal(groupJobCanceledKey("foo"), "job:canceled:foo")
+	assert.Equal(groupJobProgressKey("foo"), "job:progress:foo")
+}
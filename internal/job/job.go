@@ -47,6 +47,10 @@ type Job struct {
 	Server *machinery.Server
 	Worker *machinery.Worker
 	Queue  Queue
+
+	// rdb is the redis client of backend, it stores the
+	// signatures, cancellation and progress of group jobs.
+	rdb redis.UniversalClient
 }
 
 func New(cfg *Config, queue Queue) (*Job, error) {
@@ -63,13 +67,14 @@ func New(cfg *Config, queue Queue) (*Job, error) {
 		return nil, err
 	}
 
-	if err := ping(&redis.UniversalOptions{
+	rdb := redis.NewUniversalClient(&redis.UniversalOptions{
 		Addrs:      cfg.Addrs,
 		MasterName: cfg.MasterName,
 		Username:   cfg.Username,
 		Password:   cfg.Password,
 		DB:         cfg.BackendDB,
-	}); err != nil {
+	})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
 		return nil, err
 	}
 
@@ -94,6 +99,7 @@ func New(cfg *Config, queue Queue) (*Job, error) {
 	return &Job{
 		Server: server,
 		Queue:  queue,
+		rdb:    rdb,
 	}, nil
 }
 
@@ -116,6 +122,8 @@ type GroupJobState struct {
 	State     string
 	CreatedAt time.Time
 	JobStates []*machineryv1tasks.TaskState
+	Results   map[string]any
+	Progress  map[string]*Progress
 }
 
 func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
@@ -128,6 +136,12 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 		return nil, errors.New("empty group")
 	}
 
+	results := aggregateResults(taskStates)
+	progress, err := t.GetGroupJobProgress(context.Background(), groupID)
+	if err != nil {
+		logger.Errorf("get group %s progress failed: %s", groupID, err.Error())
+	}
+
 	for _, taskState := range taskStates {
 		if taskState.IsFailure() {
 			logger.WithGroupAndTaskID(groupID, taskState.TaskUUID).Errorf("task is failed: %#v", taskState)
@@ -136,6 +150,8 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 				State:     machineryv1tasks.StateFailure,
 				CreatedAt: taskState.CreatedAt,
 				JobStates: taskStates,
+				Results:   results,
+				Progress:  progress,
 			}, nil
 		}
 	}
@@ -148,6 +164,8 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 				State:     machineryv1tasks.StatePending,
 				CreatedAt: taskState.CreatedAt,
 				JobStates: taskStates,
+				Results:   results,
+				Progress:  progress,
 			}, nil
 		}
 	}
@@ -157,9 +175,50 @@ func (t *Job) GetGroupJobState(groupID string) (*GroupJobState, error) {
 		State:     machineryv1tasks.StateSuccess,
 		CreatedAt: taskStates[0].CreatedAt,
 		JobStates: taskStates,
+		Results:   results,
+		Progress:  progress,
 	}, nil
 }
 
+// aggregateResults merges the json responses of the succeeded jobs in the group,
+// the arrays are concatenated and the other values are overwritten.
+func aggregateResults(taskStates []*machineryv1tasks.TaskState) map[string]any {
+	results := map[string]any{}
+	for _, taskState := range taskStates {
+		if !taskState.IsSuccess() || len(taskState.Results) == 0 {
+			continue
+		}
+
+		value, ok := taskState.Results[0].Value.(string)
+		if !ok {
+			continue
+		}
+
+		var result map[string]any
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			logger.WithTaskID(taskState.TaskUUID).Errorf("unmarshal result failed: %s", err.Error())
+			continue
+		}
+
+		for k, v := range result {
+			prev, loaded := results[k]
+			if loaded && v == nil {
+				continue
+			}
+
+			prevElems, ok := prev.([]any)
+			if elems, isArray := v.([]any); ok && isArray {
+				results[k] = append(prevElems, elems...)
+				continue
+			}
+
+			results[k] = v
+		}
+	}
+
+	return results
+}
+
 func MarshalRequest(v any) ([]machineryv1tasks.Arg, error) {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -172,6 +231,15 @@ func MarshalRequest(v any) ([]machineryv1tasks.Arg, error) {
 	}}, nil
 }
 
+func MarshalResponse(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
 func UnmarshalResponse(data []reflect.Value, v any) error {
 	if len(data) == 0 {
 		return errors.New("empty data is not specified")
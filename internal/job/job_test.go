@@ -293,3 +293,121 @@ func TestUnmarshalRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  any
+		expect func(t *testing.T, result string, err error)
+	}{
+		{
+			name: "marshal common struct",
+			value: struct {
+				I int64  `json:"i"`
+				S string `json:"s"`
+			}{
+				I: 1,
+				S: "foo",
+			},
+			expect: func(t *testing.T, result string, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal("{\"i\":1,\"s\":\"foo\"}", result)
+			},
+		},
+		{
+			name: "marshal unsupported type",
+			value: struct {
+				C chan struct{} `json:"c"`
+			}{
+				C: make(chan struct{}),
+			},
+			expect: func(t *testing.T, result string, err error) {
+				assert := assert.New(t)
+				assert.Equal("json: unsupported type: chan struct {}", err.Error())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := MarshalResponse(tc.value)
+			tc.expect(t, result, err)
+		})
+	}
+}
+
+func TestAggregateResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		taskStates []*machineryv1tasks.TaskState
+		expect     func(t *testing.T, results map[string]any)
+	}{
+		{
+			name: "aggregate results of succeeded jobs",
+			taskStates: []*machineryv1tasks.TaskState{
+				{
+					State:   machineryv1tasks.StateSuccess,
+					Results: []*machineryv1tasks.TaskResult{{Type: "string", Value: "{\"taskID\":\"foo\",\"peers\":[{\"id\":\"bar\"}]}"}},
+				},
+				{
+					State:   machineryv1tasks.StateSuccess,
+					Results: []*machineryv1tasks.TaskResult{{Type: "string", Value: "{\"taskID\":\"foo\",\"peers\":[{\"id\":\"baz\"}]}"}},
+				},
+				{
+					State:   machineryv1tasks.StateSuccess,
+					Results: []*machineryv1tasks.TaskResult{{Type: "string", Value: "{\"taskID\":\"foo\",\"peers\":null}"}},
+				},
+			},
+			expect: func(t *testing.T, results map[string]any) {
+				assert := assert.New(t)
+				assert.Equal(map[string]any{
+					"taskID": "foo",
+					"peers":  []any{map[string]any{"id": "bar"}, map[string]any{"id": "baz"}},
+				}, results)
+			},
+		},
+		{
+			name: "skip results of failed and pending jobs",
+			taskStates: []*machineryv1tasks.TaskState{
+				{
+					State:   machineryv1tasks.StateFailure,
+					Results: []*machineryv1tasks.TaskResult{{Type: "string", Value: "{\"taskID\":\"foo\"}"}},
+				},
+				{
+					State: machineryv1tasks.StatePending,
+				},
+			},
+			expect: func(t *testing.T, results map[string]any) {
+				assert := assert.New(t)
+				assert.Empty(results)
+			},
+		},
+		{
+			name: "skip invalid results",
+			taskStates: []*machineryv1tasks.TaskState{
+				{
+					State: machineryv1tasks.StateSuccess,
+				},
+				{
+					State:   machineryv1tasks.StateSuccess,
+					Results: []*machineryv1tasks.TaskResult{{Type: "int64", Value: 1}},
+				},
+				{
+					State:   machineryv1tasks.StateSuccess,
+					Results: []*machineryv1tasks.TaskResult{{Type: "string", Value: "foo"}},
+				},
+			},
+			expect: func(t *testing.T, results map[string]any) {
+				assert := assert.New(t)
+				assert.Empty(results)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, aggregateResults(tc.taskStates))
+		})
+	}
+}
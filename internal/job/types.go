@@ -26,3 +26,103 @@ type PreheatRequest struct {
 
 type PreheatResponse struct {
 }
+
+type GetTaskRequest struct {
+	URL         string `json:"url" validate:"required,url"`
+	Tag         string `json:"tag" validate:"omitempty"`
+	Application string `json:"application" validate:"omitempty"`
+	Digest      string `json:"digest" validate:"omitempty"`
+	Filter      string `json:"filter" validate:"omitempty"`
+}
+
+type GetTaskResponse struct {
+	TaskID string  `json:"taskID"`
+	Peers  []*Peer `json:"peers"`
+}
+
+type DeleteTaskRequest struct {
+	URL         string `json:"url" validate:"required,url"`
+	Tag         string `json:"tag" validate:"omitempty"`
+	Application string `json:"application" validate:"omitempty"`
+	Digest      string `json:"digest" validate:"omitempty"`
+	Filter      string `json:"filter" validate:"omitempty"`
+}
+
+type DeleteTaskResponse struct {
+	TaskID       string         `json:"taskID"`
+	SuccessPeers []*Peer        `json:"successPeers"`
+	FailurePeers []*FailurePeer `json:"failurePeers"`
+}
+
+type EvictTaskRequest struct {
+	URL         string `json:"url" validate:"required,url"`
+	Tag         string `json:"tag" validate:"omitempty"`
+	Application string `json:"application" validate:"omitempty"`
+	Digest      string `json:"digest" validate:"omitempty"`
+	Filter      string `json:"filter" validate:"omitempty"`
+}
+
+type EvictTaskResponse struct {
+	TaskID       string         `json:"taskID"`
+	SuccessPeers []*Peer        `json:"successPeers"`
+	FailurePeers []*FailurePeer `json:"failurePeers"`
+}
+
+type SyncPeersRequest struct {
+}
+
+type SyncPeersResponse struct {
+	Hosts []*Host `json:"hosts"`
+}
+
+type PurgeRequest struct {
+	URL     string            `json:"url" validate:"required,url"`
+	Tag     string            `json:"tag" validate:"omitempty"`
+	Filter  string            `json:"filter" validate:"omitempty"`
+	Headers map[string]string `json:"headers" validate:"omitempty"`
+}
+
+type PurgeResponse struct {
+	TaskID     string            `json:"taskID"`
+	Schedulers []*PurgeScheduler `json:"schedulers"`
+}
+
+// PurgeScheduler is the result of purging the task in a scheduler,
+// the state is failure if any peer failed to delete the task.
+type PurgeScheduler struct {
+	SchedulerClusterID uint           `json:"schedulerClusterID"`
+	Hostname           string         `json:"hostname"`
+	State              string         `json:"state"`
+	SuccessPeers       []*Peer        `json:"successPeers"`
+	FailurePeers       []*FailurePeer `json:"failurePeers"`
+}
+
+// Peer is the peer of task in the scheduler.
+type Peer struct {
+	ID       string `json:"id"`
+	State    string `json:"state"`
+	HostID   string `json:"hostID"`
+	HostType string `json:"hostType"`
+	Hostname string `json:"hostname"`
+	IP       string `json:"ip"`
+	Port     int32  `json:"port"`
+}
+
+// FailurePeer is the peer failed to execute the job.
+type FailurePeer struct {
+	Peer
+	Description string `json:"description"`
+}
+
+// Host is the host announced to the scheduler.
+type Host struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Hostname     string `json:"hostname"`
+	IP           string `json:"ip"`
+	Port         int32  `json:"port"`
+	DownloadPort int32  `json:"downloadPort"`
+	IDC          string `json:"idc"`
+	Location     string `json:"location"`
+	PeerCount    int32  `json:"peerCount"`
+}
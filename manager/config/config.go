@@ -54,6 +54,9 @@ type Config struct {
 
 	// Network configuration.
 	Network NetworkConfig `yaml:"network" mapstructure:"network"`
+
+	// Job configuration.
+	Job JobConfig `yaml:"job" mapstructure:"job"`
 }
 
 type ServerConfig struct {
@@ -249,7 +252,7 @@ type ObjectStorageConfig struct {
 	// Enable object storage.
 	Enable bool `yaml:"enable" mapstructure:"enable"`
 
-	// Name is object storage name of type, it can be s3, oss or obs.
+	// Name is object storage name of type, it can be s3, oss, obs, gcs or azblob.
 	Name string `mapstructure:"name" yaml:"name"`
 
 	// Region is storage region.
@@ -309,6 +312,42 @@ type NetworkConfig struct {
 	EnableIPv6 bool `mapstructure:"enableIPv6" yaml:"enableIPv6"`
 }
 
+type JobConfig struct {
+	// Preheat configuration.
+	Preheat PreheatConfig `yaml:"preheat" mapstructure:"preheat"`
+}
+
+type PreheatConfig struct {
+	// RegistryTimeout is the timeout for requesting registry to resolve image manifests.
+	RegistryTimeout time.Duration `yaml:"registryTimeout" mapstructure:"registryTimeout"`
+
+	// TLS is the tls configuration for requesting registry.
+	TLS PreheatTLSClientConfig `yaml:"tls" mapstructure:"tls"`
+
+	// Credentials is the registry credentials, which can be referenced by name in preheat job.
+	Credentials []RegistryCredentialConfig `yaml:"credentials" mapstructure:"credentials"`
+}
+
+type PreheatTLSClientConfig struct {
+	// CACert is the CA certificate for registry tls handshake, it can be path or PEM format string.
+	// The system certificates are used when it's empty.
+	CACert types.PEMContent `yaml:"caCert" mapstructure:"caCert"`
+
+	// InsecureSkipVerify skips verifying the certificate of registry.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify" mapstructure:"insecureSkipVerify"`
+}
+
+type RegistryCredentialConfig struct {
+	// Name is the name of credential referenced by preheat job.
+	Name string `yaml:"name" mapstructure:"name"`
+
+	// Username is the username of registry.
+	Username string `yaml:"username" mapstructure:"username"`
+
+	// Password is the password of registry.
+	Password string `yaml:"password" mapstructure:"password"`
+}
+
 // New config instance.
 func New() *Config {
 	return &Config{
@@ -374,6 +413,11 @@ func New() *Config {
 		Network: NetworkConfig{
 			EnableIPv6: DefaultNetworkEnableIPv6,
 		},
+		Job: JobConfig{
+			Preheat: PreheatConfig{
+				RegistryTimeout: DefaultJobPreheatRegistryTimeout,
+			},
+		},
 	}
 }
 
@@ -504,7 +548,8 @@ func (cfg *Config) Validate() error {
 			return errors.New("objectStorage requires parameter name")
 		}
 
-		if !slices.Contains([]string{objectstorage.ServiceNameS3, objectstorage.ServiceNameOSS, objectstorage.ServiceNameOBS}, cfg.ObjectStorage.Name) {
+		if !slices.Contains([]string{objectstorage.ServiceNameS3, objectstorage.ServiceNameOSS, objectstorage.ServiceNameOBS,
+			objectstorage.ServiceNameGCS, objectstorage.ServiceNameAzureBlob}, cfg.ObjectStorage.Name) {
 			return errors.New("objectStorage requires parameter name")
 		}
 
@@ -545,6 +590,26 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.Job.Preheat.RegistryTimeout <= 0 {
+		return errors.New("preheat requires parameter registryTimeout")
+	}
+
+	credentialNames := map[string]struct{}{}
+	for _, credential := range cfg.Job.Preheat.Credentials {
+		if credential.Name == "" {
+			return errors.New("preheat credentials requires parameter name")
+		}
+
+		if _, ok := credentialNames[credential.Name]; ok {
+			return fmt.Errorf("preheat credential %s is duplicated", credential.Name)
+		}
+		credentialNames[credential.Name] = struct{}{}
+
+		if credential.Username == "" {
+			return errors.New("preheat credentials requires parameter username")
+		}
+	}
+
 	return nil
 }
 
@@ -125,6 +125,22 @@ func TestManagerConfig_Load(t *testing.T) {
 		Network: NetworkConfig{
 			EnableIPv6: true,
 		},
+		Job: JobConfig{
+			Preheat: PreheatConfig{
+				RegistryTimeout: 1 * time.Second,
+				TLS: PreheatTLSClientConfig{
+					CACert:             "foo",
+					InsecureSkipVerify: true,
+				},
+				Credentials: []RegistryCredentialConfig{
+					{
+						Name:     "foo",
+						Username: "bar",
+						Password: "baz",
+					},
+				},
+			},
+		},
 	}
 
 	managerConfigYAML := &Config{}
@@ -22,10 +22,16 @@ const (
 	AttributeID          = attribute.Key("d7y.manager.id")
 	AttributePreheatType = attribute.Key("d7y.manager.preheat.type")
 	AttributePreheatURL  = attribute.Key("d7y.manager.preheat.url")
+	AttributeTaskURL     = attribute.Key("d7y.manager.task.url")
 )
 
 const (
 	SpanPreheat          = "preheat"
 	SpanGetLayers        = "get-layers"
 	SpanAuthWithRegistry = "auth-with-registry"
+	SpanGetTask          = "get-task"
+	SpanDeleteTask       = "delete-task"
+	SpanEvictTask        = "evict-task"
+	SpanSyncPeers        = "sync-peers"
+	SpanPurge            = "purge"
 )
@@ -109,3 +109,8 @@ var (
 	// DefaultNetworkEnableIPv6 is default value of enableIPv6.
 	DefaultNetworkEnableIPv6 = false
 )
+
+const (
+	// DefaultJobPreheatRegistryTimeout is the default timeout for requesting registry of preheat job.
+	DefaultJobPreheatRegistryTimeout = 1 * time.Minute
+)
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,6 +9,7 @@ import (
 
 	"d7y.io/dragonfly/v2/internal/job"
 	_ "d7y.io/dragonfly/v2/manager/model" // nolint
+	"d7y.io/dragonfly/v2/manager/service"
 	"d7y.io/dragonfly/v2/manager/types"
 )
 
@@ -43,6 +45,76 @@ func (h *Handlers) CreateJob(ctx *gin.Context) {
 			return
 		}
 
+		ctx.JSON(http.StatusOK, job)
+	case job.GetTaskJob:
+		var json types.CreateGetTaskJobRequest
+		if err := ctx.ShouldBindBodyWith(&json, binding.JSON); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+			return
+		}
+
+		job, err := h.service.CreateGetTaskJob(ctx.Request.Context(), json)
+		if err != nil {
+			ctx.Error(err) // nolint: errcheck
+			return
+		}
+
+		ctx.JSON(http.StatusOK, job)
+	case job.DeleteTaskJob:
+		var json types.CreateDeleteTaskJobRequest
+		if err := ctx.ShouldBindBodyWith(&json, binding.JSON); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+			return
+		}
+
+		job, err := h.service.CreateDeleteTaskJob(ctx.Request.Context(), json)
+		if err != nil {
+			ctx.Error(err) // nolint: errcheck
+			return
+		}
+
+		ctx.JSON(http.StatusOK, job)
+	case job.EvictTaskJob:
+		var json types.CreateEvictTaskJobRequest
+		if err := ctx.ShouldBindBodyWith(&json, binding.JSON); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+			return
+		}
+
+		job, err := h.service.CreateEvictTaskJob(ctx.Request.Context(), json)
+		if err != nil {
+			ctx.Error(err) // nolint: errcheck
+			return
+		}
+
+		ctx.JSON(http.StatusOK, job)
+	case job.SyncPeersJob:
+		var json types.CreateSyncPeersJobRequest
+		if err := ctx.ShouldBindBodyWith(&json, binding.JSON); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+			return
+		}
+
+		job, err := h.service.CreateSyncPeersJob(ctx.Request.Context(), json)
+		if err != nil {
+			ctx.Error(err) // nolint: errcheck
+			return
+		}
+
+		ctx.JSON(http.StatusOK, job)
+	case job.PurgeJob:
+		var json types.CreatePurgeJobRequest
+		if err := ctx.ShouldBindBodyWith(&json, binding.JSON); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+			return
+		}
+
+		job, err := h.service.CreatePurgeJob(ctx.Request.Context(), json)
+		if err != nil {
+			ctx.Error(err) // nolint: errcheck
+			return
+		}
+
 		ctx.JSON(http.StatusOK, job)
 	default:
 		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": "Unknow type"})
@@ -75,6 +147,72 @@ func (h *Handlers) DestroyJob(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+// @Summary Cancel Job
+// @Description Cancel by id
+// @Tags Job
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} model.Job
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router /jobs/{id}/cancel [post]
+func (h *Handlers) CancelJob(ctx *gin.Context) {
+	var params types.JobParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	job, err := h.service.CancelJob(ctx.Request.Context(), params.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidJobState) {
+			ctx.JSON(http.StatusConflict, gin.H{"errors": err.Error()})
+			return
+		}
+
+		ctx.Error(err) // nolint: errcheck
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job)
+}
+
+// @Summary Retry Job
+// @Description Retry the failed jobs by id
+// @Tags Job
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} model.Job
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router /jobs/{id}/retry [post]
+func (h *Handlers) RetryJob(ctx *gin.Context) {
+	var params types.JobParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"errors": err.Error()})
+		return
+	}
+
+	job, err := h.service.RetryJob(ctx.Request.Context(), params.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidJobState) {
+			ctx.JSON(http.StatusConflict, gin.H{"errors": err.Error()})
+			return
+		}
+
+		ctx.Error(err) // nolint: errcheck
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job)
+}
+
 // @Summary Update Job
 // @Description Update by json config
 // @Tags Job
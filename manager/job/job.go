@@ -24,6 +24,7 @@ import (
 type Job struct {
 	*internaljob.Job
 	Preheat
+	Task
 }
 
 func New(cfg *config.Config) (*Job, error) {
@@ -39,7 +40,7 @@ func New(cfg *config.Config) (*Job, error) {
 		return nil, err
 	}
 
-	p, err := newPreheat(j)
+	p, err := newPreheat(j, cfg.Job.Preheat)
 	if err != nil {
 		return nil, err
 	}
@@ -47,5 +48,6 @@ func New(cfg *config.Config) (*Job, error) {
 	return &Job{
 		Job:     j,
 		Preheat: p,
+		Task:    newTask(j),
 	}, nil
 }
@@ -21,18 +21,16 @@ package job
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
+	"crypto/x509"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
-	"strings"
 	"time"
 
 	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
 	"github.com/distribution/distribution/v3"
-	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/go-http-utils/headers"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
@@ -43,6 +41,7 @@ import (
 	"d7y.io/dragonfly/v2/manager/model"
 	"d7y.io/dragonfly/v2/manager/types"
 	nethttp "d7y.io/dragonfly/v2/pkg/net/http"
+	"d7y.io/dragonfly/v2/pkg/registry"
 )
 
 var tracer = otel.Tracer("manager")
@@ -57,11 +56,6 @@ const (
 	PreheatFileType PreheatType = "file"
 )
 
-const (
-	// defaultHTTPRequesttimeout is the default timeout of http client.
-	defaultHTTPRequesttimeout = 1 * time.Minute
-)
-
 var accessURLPattern, _ = regexp.Compile("^(.*)://(.*)/v2/(.*)/manifests/(.*)")
 
 type Preheat interface {
@@ -69,7 +63,9 @@ type Preheat interface {
 }
 
 type preheat struct {
-	job *internaljob.Job
+	job         *internaljob.Job
+	httpClient  *http.Client
+	credentials map[string]config.RegistryCredentialConfig
 }
 
 type preheatImage struct {
@@ -79,9 +75,31 @@ type preheatImage struct {
 	tag      string
 }
 
-func newPreheat(job *internaljob.Job) (Preheat, error) {
+func newPreheat(job *internaljob.Job, cfg config.PreheatConfig) (Preheat, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipVerify}
+	if cfg.TLS.CACert != "" {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM([]byte(cfg.TLS.CACert)) {
+			return nil, errors.New("invalid ca cert of preheat registry")
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	credentials := map[string]config.RegistryCredentialConfig{}
+	for _, credential := range cfg.Credentials {
+		credentials[credential.Name] = credential
+	}
+
 	return &preheat{
 		job: job,
+		httpClient: &http.Client{
+			Timeout: cfg.RegistryTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		credentials: credentials,
 	}, nil
 }
 
@@ -110,7 +128,17 @@ func (p *preheat) CreatePreheat(ctx context.Context, schedulers []model.Schedule
 			return nil, err
 		}
 
-		files, err = p.getLayers(ctx, url, tag, filter, nethttp.MapToHeader(rawheader), image)
+		platforms, err := registry.ParsePlatforms(json.Platforms)
+		if err != nil {
+			return nil, err
+		}
+
+		credentials, err := p.getCredentialStore(json)
+		if err != nil {
+			return nil, err
+		}
+
+		files, err = p.getLayers(ctx, url, tag, filter, nethttp.MapToHeader(rawheader), image, platforms, credentials)
 		if err != nil {
 			return nil, err
 		}
@@ -160,7 +188,7 @@ func (p *preheat) createGroupJob(ctx context.Context, files []internaljob.Prehea
 	}
 
 	logger.Infof("create preheat group %s in queues %v, tasks: %#v", group.GroupUUID, queues, tasks)
-	if _, err := p.job.Server.SendGroupWithContext(ctx, group, 0); err != nil {
+	if err := p.job.SendGroupJob(ctx, group); err != nil {
 		logger.Errorf("create preheat group %s failed", group.GroupUUID, err)
 		return nil, err
 	}
@@ -172,149 +200,86 @@ func (p *preheat) createGroupJob(ctx context.Context, files []internaljob.Prehea
 	}, nil
 }
 
-func (p *preheat) getLayers(ctx context.Context, url, tag, filter string, header http.Header, image *preheatImage) ([]internaljob.PreheatRequest, error) {
+func (p *preheat) getLayers(ctx context.Context, url, tag, filter string, header http.Header, image *preheatImage,
+	platforms []registry.Platform, credentials registry.CredentialStore) ([]internaljob.PreheatRequest, error) {
 	ctx, span := tracer.Start(ctx, config.SpanGetLayers, trace.WithSpanKind(trace.SpanKindProducer))
 	defer span.End()
 
-	resp, err := p.getManifests(ctx, url, header)
+	client := registry.NewClient(
+		registry.WithHTTPClient(p.httpClient),
+		registry.WithCredentialStore(credentials),
+		registry.WithHeader(header),
+	)
+
+	manifests, err := client.ResolveManifests(ctx, &registry.Reference{
+		Scheme:     image.protocol,
+		Host:       image.domain,
+		Repository: image.name,
+		Reference:  image.tag,
+	}, platforms)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode/100 != 2 {
-		if resp.StatusCode == http.StatusUnauthorized {
-			token, err := getAuthToken(ctx, resp.Header)
-			if err != nil {
-				return nil, err
-			}
-
-			bearer := "Bearer " + token
-			header.Add("Authorization", bearer)
-
-			resp, err = p.getManifests(ctx, url, header)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, fmt.Errorf("request registry %d", resp.StatusCode)
+	// the peers download the layers with the authorization of registry
+	if header.Get(headers.Authorization) == "" {
+		if authorization := client.Authorization(image.domain, image.name); authorization != "" {
+			header.Set(headers.Authorization, authorization)
 		}
 	}
 
-	layers, err := p.parseLayers(resp, url, tag, filter, header, image)
-	if err != nil {
-		return nil, err
-	}
-
-	return layers, nil
+	return p.parseLayers(manifests, tag, filter, header, image), nil
 }
 
-func (p *preheat) getManifests(ctx context.Context, url string, header http.Header) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header = header
-	req.Header.Add("Accept", schema2.MediaTypeManifest)
-
-	client := &http.Client{
-		Timeout: defaultHTTPRequesttimeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	return resp, nil
-}
+// parseLayers returns the preheat requests of the configs and the layers of manifests,
+// the blobs shared by the manifests of platforms are preheated once.
+func (p *preheat) parseLayers(manifests []registry.Manifest, tag, filter string, header http.Header, image *preheatImage) []internaljob.PreheatRequest {
+	var (
+		layers  []internaljob.PreheatRequest
+		digests = map[string]struct{}{}
+	)
+	for _, manifest := range manifests {
+		for _, v := range append([]distribution.Descriptor{manifest.Config}, manifest.Layers...) {
+			digest := v.Digest.String()
+			if digest == "" {
+				continue
+			}
 
-func (p *preheat) parseLayers(resp *http.Response, url, tag, filter string, header http.Header, image *preheatImage) ([]internaljob.PreheatRequest, error) {
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+			if _, ok := digests[digest]; ok {
+				continue
+			}
+			digests[digest] = struct{}{}
 
-	manifest, _, err := distribution.UnmarshalManifest(schema2.MediaTypeManifest, body)
-	if err != nil {
-		return nil, err
-	}
+			layer := internaljob.PreheatRequest{
+				URL:     layerURL(image.protocol, image.domain, image.name, digest),
+				Tag:     tag,
+				Filter:  filter,
+				Headers: nethttp.HeaderToMap(header),
+			}
 
-	var layers []internaljob.PreheatRequest
-	for _, v := range manifest.References() {
-		digest := v.Digest.String()
-		layer := internaljob.PreheatRequest{
-			URL:     layerURL(image.protocol, image.domain, image.name, digest),
-			Tag:     tag,
-			Filter:  filter,
-			Headers: nethttp.HeaderToMap(header),
+			layers = append(layers, layer)
 		}
-
-		layers = append(layers, layer)
 	}
 
-	return layers, nil
+	return layers
 }
 
-func getAuthToken(ctx context.Context, header http.Header) (string, error) {
-	ctx, span := tracer.Start(ctx, config.SpanAuthWithRegistry, trace.WithSpanKind(trace.SpanKindProducer))
-	defer span.End()
-
-	authURL := authURL(header.Values("WWW-Authenticate"))
-	if len(authURL) == 0 {
-		return "", errors.New("authURL is empty")
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", authURL, nil)
-	if err != nil {
-		return "", err
-	}
-
-	client := &http.Client{
-		Timeout: defaultHTTPRequesttimeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	var result map[string]any
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+// getCredentialStore returns the credential store of registry, the credential is referenced by name
+// in manager config or provided by username and password of args.
+func (p *preheat) getCredentialStore(args types.PreheatArgs) (registry.CredentialStore, error) {
+	if args.CredentialName == "" {
+		return registry.NewStaticCredentialStore(args.Username, args.Password), nil
 	}
 
-	if result["token"] == nil {
-		return "", errors.New("token is empty")
+	if args.Username != "" {
+		return nil, errors.New("credential name and username are exclusive")
 	}
 
-	token := fmt.Sprintf("%v", result["token"])
-	return token, nil
-
-}
-
-func authURL(wwwAuth []string) string {
-	// Bearer realm="<auth-service-url>",service="<service>",scope="repository:<name>:pull"
-	if len(wwwAuth) == 0 {
-		return ""
-	}
-	polished := make([]string, 0)
-	for _, it := range wwwAuth {
-		polished = append(polished, strings.ReplaceAll(it, "\"", ""))
+	credential, ok := p.credentials[args.CredentialName]
+	if !ok {
+		return nil, fmt.Errorf("credential %s not found", args.CredentialName)
 	}
-	fileds := strings.Split(polished[0], ",")
-	host := strings.Split(fileds[0], "=")[1]
-	query := strings.Join(fileds[1:], "&")
-	return fmt.Sprintf("%s?%s", host, query)
+	return registry.NewStaticCredentialStore(credential.Username, credential.Password), nil
 }
 
 func layerURL(protocol string, domain string, name string, digest string) string {
@@ -0,0 +1,195 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/pkg/registry"
+	pkgtypes "d7y.io/dragonfly/v2/pkg/types"
+)
+
+// newTestRegistry returns a registry serving the image index of linux/amd64 and linux/arm64 by tag latest,
+// the layer base is shared by the platforms and the token is issued to user:pass only.
+func newTestRegistry(t *testing.T) *httptest.Server {
+	manifests := map[string]string{
+		"latest": `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.list.v2+json","manifests":[
+			{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:amd64","size":1,"platform":{"os":"linux","architecture":"amd64"}},
+			{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:arm64","size":1,"platform":{"os":"linux","architecture":"arm64"}}]}`,
+		"sha256:amd64": `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",
+			"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:amd64-config","size":1},
+			"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:base","size":1},
+				{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:amd64-layer","size":1}]}`,
+		"sha256:arm64": `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",
+			"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:arm64-config","size":1},
+			"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:base","size":1},
+				{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:arm64-layer","size":1}]}`,
+	}
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `{"token":"foo"}`)
+			return
+		}
+
+		if req.Header.Get("Authorization") != "Bearer foo" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		m, ok := manifests[strings.TrimPrefix(req.URL.Path, "/v2/library/app/manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, m)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPreheat_GetLayers(t *testing.T) {
+	tests := []struct {
+		name       string
+		platforms  string
+		args       types.PreheatArgs
+		expect     []string
+		expectErr  string
+		untrustCA  bool
+		expectAuth string
+	}{
+		{
+			name:      "all platforms",
+			args:      types.PreheatArgs{Username: "user", Password: "pass"},
+			platforms: "",
+			expect: []string{
+				"sha256:amd64-config", "sha256:amd64-layer", "sha256:arm64-config", "sha256:arm64-layer", "sha256:base",
+			},
+			expectAuth: "Bearer foo",
+		},
+		{
+			name:       "select platforms",
+			args:       types.PreheatArgs{CredentialName: "registry"},
+			platforms:  "linux/arm64",
+			expect:     []string{"sha256:arm64-config", "sha256:arm64-layer", "sha256:base"},
+			expectAuth: "Bearer foo",
+		},
+		{
+			name:      "unknown credential",
+			args:      types.PreheatArgs{CredentialName: "unknown"},
+			expectErr: "credential unknown not found",
+		},
+		{
+			name:      "anonymous",
+			args:      types.PreheatArgs{},
+			expectErr: "401",
+		},
+		{
+			name:      "untrusted certificate",
+			args:      types.PreheatArgs{Username: "user", Password: "pass"},
+			untrustCA: true,
+			expectErr: "certificate",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			server := newTestRegistry(t)
+
+			cfg := config.PreheatConfig{
+				RegistryTimeout: time.Minute,
+				Credentials: []config.RegistryCredentialConfig{
+					{Name: "registry", Username: "user", Password: "pass"},
+				},
+			}
+			if !tc.untrustCA {
+				cfg.TLS.CACert = certificatePEM(server)
+			}
+
+			p, err := newPreheat(nil, cfg)
+			assert.Nil(err)
+
+			image, err := parseAccessURL(server.URL + "/v2/library/app/manifests/latest")
+			assert.Nil(err)
+
+			platforms, err := registry.ParsePlatforms(tc.platforms)
+			assert.Nil(err)
+
+			var files []string
+			err = func() error {
+				credentials, err := p.(*preheat).getCredentialStore(tc.args)
+				if err != nil {
+					return err
+				}
+
+				layers, err := p.(*preheat).getLayers(context.Background(), server.URL, "", "", http.Header{}, image, platforms, credentials)
+				if err != nil {
+					return err
+				}
+
+				for _, layer := range layers {
+					assert.Equal(tc.expectAuth, layer.Headers["Authorization"])
+					files = append(files, strings.TrimPrefix(layer.URL, server.URL+"/v2/library/app/blobs/"))
+				}
+				return nil
+			}()
+			if tc.expectErr != "" {
+				assert.ErrorContains(err, tc.expectErr)
+				return
+			}
+
+			assert.Nil(err)
+			sort.Strings(files)
+			assert.Equal(tc.expect, files)
+		})
+	}
+}
+
+func TestNewPreheat_InvalidCACert(t *testing.T) {
+	assert := testifyassert.New(t)
+	_, err := newPreheat(nil, config.PreheatConfig{
+		RegistryTimeout: time.Minute,
+		TLS:             config.PreheatTLSClientConfig{CACert: "invalid"},
+	})
+	assert.Error(err)
+}
+
+// certificatePEM returns the PEM of the certificate of test server.
+func certificatePEM(server *httptest.Server) pkgtypes.PEMContent {
+	return pkgtypes.PEMContent(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
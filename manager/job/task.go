@@ -0,0 +1,163 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination mocks/task_mock.go -source task.go -package mocks
+
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	internaljob "d7y.io/dragonfly/v2/internal/job"
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/manager/model"
+	"d7y.io/dragonfly/v2/manager/types"
+)
+
+type Task interface {
+	CreateGetTask(context.Context, []model.Scheduler, types.GetTaskArgs) (*internaljob.GroupJobState, error)
+	CreateDeleteTask(context.Context, []model.Scheduler, types.DeleteTaskArgs) (*internaljob.GroupJobState, error)
+	CreateEvictTask(context.Context, []model.Scheduler, types.EvictTaskArgs) (*internaljob.GroupJobState, error)
+	CreateSyncPeers(context.Context, []model.Scheduler) (*internaljob.GroupJobState, error)
+	CreatePurge(context.Context, []model.Scheduler, types.PurgeArgs) (*internaljob.GroupJobState, error)
+}
+
+type task struct {
+	job *internaljob.Job
+}
+
+func newTask(job *internaljob.Job) Task {
+	return &task{
+		job: job,
+	}
+}
+
+func (t *task) CreateGetTask(ctx context.Context, schedulers []model.Scheduler, json types.GetTaskArgs) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanGetTask, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(config.AttributeTaskURL.String(json.URL))
+	defer span.End()
+
+	return t.createGroupJob(ctx, internaljob.GetTaskJob, internaljob.GetTaskRequest{
+		URL:         json.URL,
+		Tag:         json.Tag,
+		Application: json.Application,
+		Digest:      json.Digest,
+		Filter:      json.Filter,
+	}, getSchedulerQueues(schedulers))
+}
+
+func (t *task) CreateDeleteTask(ctx context.Context, schedulers []model.Scheduler, json types.DeleteTaskArgs) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanDeleteTask, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(config.AttributeTaskURL.String(json.URL))
+	defer span.End()
+
+	return t.createGroupJob(ctx, internaljob.DeleteTaskJob, internaljob.DeleteTaskRequest{
+		URL:         json.URL,
+		Tag:         json.Tag,
+		Application: json.Application,
+		Digest:      json.Digest,
+		Filter:      json.Filter,
+	}, getSchedulerQueues(schedulers))
+}
+
+func (t *task) CreateEvictTask(ctx context.Context, schedulers []model.Scheduler, json types.EvictTaskArgs) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanEvictTask, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(config.AttributeTaskURL.String(json.URL))
+	defer span.End()
+
+	return t.createGroupJob(ctx, internaljob.EvictTaskJob, internaljob.EvictTaskRequest{
+		URL:         json.URL,
+		Tag:         json.Tag,
+		Application: json.Application,
+		Digest:      json.Digest,
+		Filter:      json.Filter,
+	}, getSchedulerQueues(schedulers))
+}
+
+func (t *task) CreateSyncPeers(ctx context.Context, schedulers []model.Scheduler) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanSyncPeers, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
+	return t.createGroupJob(ctx, internaljob.SyncPeersJob, internaljob.SyncPeersRequest{}, getSchedulerQueues(schedulers))
+}
+
+func (t *task) CreatePurge(ctx context.Context, schedulers []model.Scheduler, json types.PurgeArgs) (*internaljob.GroupJobState, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, config.SpanPurge, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(config.AttributeTaskURL.String(json.URL))
+	defer span.End()
+
+	return t.createGroupJob(ctx, internaljob.PurgeJob, internaljob.PurgeRequest{
+		URL:     json.URL,
+		Tag:     json.Tag,
+		Filter:  json.Filter,
+		Headers: json.Headers,
+	}, getSchedulerQueues(schedulers))
+}
+
+// createGroupJob sends the same request to every scheduler queue, the machinery
+// broker delivers a message to one worker only, so the schedulers queue shared by
+// all schedulers can not fan out the request.
+func (t *task) createGroupJob(ctx context.Context, name string, req any, queues []internaljob.Queue) (*internaljob.GroupJobState, error) {
+	if len(queues) == 0 {
+		return nil, errors.New("can not find available schedulers")
+	}
+
+	args, err := internaljob.MarshalRequest(req)
+	if err != nil {
+		logger.Errorf("%s marshal request: %v, error: %v", name, req, err)
+		return nil, err
+	}
+
+	var signatures []*machineryv1tasks.Signature
+	for _, queue := range queues {
+		signatures = append(signatures, &machineryv1tasks.Signature{
+			UUID:       fmt.Sprintf("task_%s", uuid.New().String()),
+			Name:       name,
+			RoutingKey: queue.String(),
+			Args:       args,
+		})
+	}
+
+	group, err := machineryv1tasks.NewGroup(signatures...)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("create %s group %s in queues %v", name, group.GroupUUID, queues)
+	if err := t.job.SendGroupJob(ctx, group); err != nil {
+		logger.Errorf("create %s group %s failed: %s", name, group.GroupUUID, err.Error())
+		return nil, err
+	}
+
+	return &internaljob.GroupJobState{
+		GroupUUID: group.GroupUUID,
+		State:     machineryv1tasks.StatePending,
+		CreatedAt: time.Now(),
+	}, nil
+}
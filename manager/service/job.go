@@ -20,16 +20,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	internaljob "d7y.io/dragonfly/v2/internal/job"
 	"d7y.io/dragonfly/v2/manager/model"
 	"d7y.io/dragonfly/v2/manager/types"
-	"d7y.io/dragonfly/v2/pkg/retry"
 	"d7y.io/dragonfly/v2/pkg/structure"
 )
 
+const (
+	// defaultJobPollingInterval is the interval of polling the state of group job.
+	defaultJobPollingInterval = 5 * time.Second
+)
+
+// ErrInvalidJobState is the error of the operation which is not allowed in the job state.
+var ErrInvalidJobState = errors.New("invalid job state")
+
 func (s *service) CreatePreheatJob(ctx context.Context, json types.CreatePreheatJobRequest) (*model.Job, error) {
 	var schedulers []model.Scheduler
 	var schedulerClusters []model.SchedulerCluster
@@ -74,18 +83,150 @@ func (s *service) CreatePreheatJob(ctx context.Context, json types.CreatePreheat
 		return nil, err
 	}
 
+	// the password of registry is not stored
+	json.Args.Password = ""
+	args, err := structure.StructToMap(json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJob(ctx, groupJobState, json.BIO, json.Type, args, json.UserID, schedulerClusters)
+}
+
+func (s *service) CreateGetTaskJob(ctx context.Context, json types.CreateGetTaskJobRequest) (*model.Job, error) {
+	schedulers, schedulerClusters, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreateGetTask(ctx, schedulers, json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := structure.StructToMap(json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJob(ctx, groupJobState, json.BIO, json.Type, args, json.UserID, schedulerClusters)
+}
+
+func (s *service) CreateDeleteTaskJob(ctx context.Context, json types.CreateDeleteTaskJobRequest) (*model.Job, error) {
+	schedulers, schedulerClusters, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreateDeleteTask(ctx, schedulers, json.Args)
+	if err != nil {
+		return nil, err
+	}
+
 	args, err := structure.StructToMap(json.Args)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.createJob(ctx, groupJobState, json.BIO, json.Type, args, json.UserID, schedulerClusters)
+}
+
+func (s *service) CreateEvictTaskJob(ctx context.Context, json types.CreateEvictTaskJobRequest) (*model.Job, error) {
+	schedulers, schedulerClusters, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreateEvictTask(ctx, schedulers, json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := structure.StructToMap(json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJob(ctx, groupJobState, json.BIO, json.Type, args, json.UserID, schedulerClusters)
+}
+
+func (s *service) CreateSyncPeersJob(ctx context.Context, json types.CreateSyncPeersJobRequest) (*model.Job, error) {
+	schedulers, schedulerClusters, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreateSyncPeers(ctx, schedulers)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJob(ctx, groupJobState, json.BIO, json.Type, map[string]any{}, json.UserID, schedulerClusters)
+}
+
+func (s *service) CreatePurgeJob(ctx context.Context, json types.CreatePurgeJobRequest) (*model.Job, error) {
+	schedulers, schedulerClusters, err := s.findActiveSchedulers(ctx, json.SchedulerClusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJobState, err := s.job.CreatePurge(ctx, schedulers, json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := structure.StructToMap(json.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createJob(ctx, groupJobState, json.BIO, json.Type, args, json.UserID, schedulerClusters)
+}
+
+// findActiveSchedulers finds all active schedulers in the scheduler clusters, the peers
+// of a task may be scheduled by any scheduler in the cluster, so unlike preheat, every
+// active scheduler runs the job. All scheduler clusters are used if ids is empty.
+func (s *service) findActiveSchedulers(ctx context.Context, schedulerClusterIDs []uint) ([]model.Scheduler, []model.SchedulerCluster, error) {
+	var schedulerClusters []model.SchedulerCluster
+	if len(schedulerClusterIDs) != 0 {
+		for _, schedulerClusterID := range schedulerClusterIDs {
+			schedulerCluster := model.SchedulerCluster{}
+			if err := s.db.WithContext(ctx).First(&schedulerCluster, schedulerClusterID).Error; err != nil {
+				return nil, nil, err
+			}
+			schedulerClusters = append(schedulerClusters, schedulerCluster)
+		}
+	} else {
+		if err := s.db.WithContext(ctx).Find(&schedulerClusters).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var schedulers []model.Scheduler
+	for _, schedulerCluster := range schedulerClusters {
+		var activeSchedulers []model.Scheduler
+		if err := s.db.WithContext(ctx).Find(&activeSchedulers, model.Scheduler{
+			SchedulerClusterID: schedulerCluster.ID,
+			State:              model.SchedulerStateActive,
+		}).Error; err != nil {
+			return nil, nil, err
+		}
+
+		schedulers = append(schedulers, activeSchedulers...)
+	}
+
+	return schedulers, schedulerClusters, nil
+}
+
+// createJob stores the job of group and polls the state of group in the background.
+func (s *service) createJob(ctx context.Context, groupJobState *internaljob.GroupJobState, bio, typ string, args map[string]any, userID uint, schedulerClusters []model.SchedulerCluster) (*model.Job, error) {
 	job := model.Job{
 		TaskID:            groupJobState.GroupUUID,
-		BIO:               json.BIO,
-		Type:              json.Type,
+		BIO:               bio,
+		Type:              typ,
 		State:             groupJobState.State,
 		Args:              args,
-		UserID:            json.UserID,
+		UserID:            userID,
 		SchedulerClusters: schedulerClusters,
 	}
 
@@ -100,56 +241,126 @@ func (s *service) CreatePreheatJob(ctx context.Context, json types.CreatePreheat
 
 func (s *service) pollingJob(ctx context.Context, id uint, groupID string) {
 	var (
-		job model.Job
-		log = logger.WithGroupAndJobID(groupID, fmt.Sprint(id))
+		log      = logger.WithGroupAndJobID(groupID, fmt.Sprint(id))
+		deadline = time.Now().Add(internaljob.DefaultResultsExpireIn * time.Second)
+		ticker   = time.NewTicker(defaultJobPollingInterval)
 	)
-	if _, _, err := retry.Run(ctx, 5, 10, 480, func() (any, bool, error) {
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		job := model.Job{}
+		if err := s.db.WithContext(ctx).First(&job, id).Error; err != nil {
+			log.Errorf("polling group failed: %s", err.Error())
+			return
+		}
+
+		// Job is canceled or finished by others.
+		if isJobTerminated(job.State) {
+			log.Infof("polling group stopped, job state is %s", job.State)
+			return
+		}
+
+		// The results of group are expired in the backend.
+		if time.Now().After(deadline) {
+			if err := s.updateJob(ctx, id, model.Job{State: machineryv1tasks.StateFailure}); err != nil {
+				log.Errorf("polling group failed: %s", err.Error())
+			}
+			log.Error("polling group timeout")
+			return
+		}
+
 		groupJob, err := s.job.GetGroupJobState(groupID)
 		if err != nil {
 			log.Errorf("polling group failed: %s", err.Error())
-			return nil, false, err
+			continue
 		}
 
 		result, err := structure.StructToMap(groupJob)
 		if err != nil {
 			log.Errorf("polling group failed: %s", err.Error())
-			return nil, false, err
+			continue
 		}
 
-		if err := s.db.WithContext(ctx).First(&job, id).Updates(model.Job{
+		if err := s.updateJob(ctx, id, model.Job{
 			State:  groupJob.State,
 			Result: result,
-		}).Error; err != nil {
+		}); err != nil {
 			log.Errorf("polling group failed: %s", err.Error())
-			return nil, true, err
+			continue
 		}
 
-		switch job.State {
+		switch groupJob.State {
 		case machineryv1tasks.StateSuccess:
 			log.Info("polling group succeeded")
-			return nil, true, nil
+			return
 		case machineryv1tasks.StateFailure:
 			log.Error("polling group failed")
-			return nil, true, nil
+			return
 		default:
-			msg := fmt.Sprintf("polling job state is %s", job.State)
-			log.Info(msg)
-			return nil, false, errors.New(msg)
+			log.Infof("polling job state is %s", groupJob.State)
 		}
-	}); err != nil {
-		log.Errorf("polling group failed: %s", err.Error())
 	}
+}
 
-	// Polling timeout and failed.
-	if job.State != machineryv1tasks.StateSuccess && job.State != machineryv1tasks.StateFailure {
-		job := model.Job{}
-		if err := s.db.WithContext(ctx).First(&job, id).Updates(model.Job{
-			State: machineryv1tasks.StateFailure,
-		}).Error; err != nil {
-			log.Errorf("polling group failed: %s", err.Error())
-		}
-		log.Error("polling group timeout")
+// updateJob updates the job which is not canceled, so that the
+// polling does not override the state of canceled job.
+func (s *service) updateJob(ctx context.Context, id uint, job model.Job) error {
+	return s.db.WithContext(ctx).Model(&model.Job{}).Where("id = ? AND state <> ?", id, internaljob.StateCanceled).Updates(job).Error
+}
+
+// isJobTerminated returns whether the job is in the terminal state.
+func isJobTerminated(state string) bool {
+	return state == machineryv1tasks.StateSuccess ||
+		state == machineryv1tasks.StateFailure ||
+		state == internaljob.StateCanceled
+}
+
+func (s *service) CancelJob(ctx context.Context, id uint) (*model.Job, error) {
+	job := model.Job{}
+	if err := s.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+
+	if isJobTerminated(job.State) {
+		return nil, fmt.Errorf("%w: job state is %s", ErrInvalidJobState, job.State)
+	}
+
+	if err := s.job.CancelGroupJob(ctx, job.TaskID); err != nil {
+		return nil, err
 	}
+
+	if err := s.db.WithContext(ctx).Preload("SeedPeerClusters").Preload("SchedulerClusters").First(&job, id).Updates(model.Job{
+		State: internaljob.StateCanceled,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (s *service) RetryJob(ctx context.Context, id uint) (*model.Job, error) {
+	job := model.Job{}
+	if err := s.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+
+	if job.State != machineryv1tasks.StateFailure {
+		return nil, fmt.Errorf("%w: job state is %s", ErrInvalidJobState, job.State)
+	}
+
+	if _, err := s.job.RetryGroupJob(ctx, job.TaskID); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Preload("SeedPeerClusters").Preload("SchedulerClusters").First(&job, id).Updates(model.Job{
+		State: machineryv1tasks.StatePending,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	go s.pollingJob(context.Background(), job.ID, job.TaskID)
+
+	return &job, nil
 }
 
 func (s *service) DestroyJob(ctx context.Context, id uint) error {
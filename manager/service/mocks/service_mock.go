@@ -153,6 +153,21 @@ func (mr *MockServiceMockRecorder) AddSeedPeerToSeedPeerCluster(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSeedPeerToSeedPeerCluster", reflect.TypeOf((*MockService)(nil).AddSeedPeerToSeedPeerCluster), arg0, arg1, arg2)
 }
 
+// CancelJob mocks base method.
+func (m *MockService) CancelJob(arg0 context.Context, arg1 uint) (*model.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockServiceMockRecorder) CancelJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockService)(nil).CancelJob), arg0, arg1)
+}
+
 // CreateApplication mocks base method.
 func (m *MockService) CreateApplication(arg0 context.Context, arg1 types.CreateApplicationRequest) (*model.Application, error) {
 	m.ctrl.T.Helper()
@@ -197,6 +212,51 @@ func (mr *MockServiceMockRecorder) CreateConfig(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateConfig", reflect.TypeOf((*MockService)(nil).CreateConfig), arg0, arg1)
 }
 
+// CreateDeleteTaskJob mocks base method.
+func (m *MockService) CreateDeleteTaskJob(arg0 context.Context, arg1 types.CreateDeleteTaskJobRequest) (*model.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeleteTaskJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDeleteTaskJob indicates an expected call of CreateDeleteTaskJob.
+func (mr *MockServiceMockRecorder) CreateDeleteTaskJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeleteTaskJob", reflect.TypeOf((*MockService)(nil).CreateDeleteTaskJob), arg0, arg1)
+}
+
+// CreateEvictTaskJob mocks base method.
+func (m *MockService) CreateEvictTaskJob(arg0 context.Context, arg1 types.CreateEvictTaskJobRequest) (*model.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEvictTaskJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEvictTaskJob indicates an expected call of CreateEvictTaskJob.
+func (mr *MockServiceMockRecorder) CreateEvictTaskJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEvictTaskJob", reflect.TypeOf((*MockService)(nil).CreateEvictTaskJob), arg0, arg1)
+}
+
+// CreateGetTaskJob mocks base method.
+func (m *MockService) CreateGetTaskJob(arg0 context.Context, arg1 types.CreateGetTaskJobRequest) (*model.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGetTaskJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGetTaskJob indicates an expected call of CreateGetTaskJob.
+func (mr *MockServiceMockRecorder) CreateGetTaskJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGetTaskJob", reflect.TypeOf((*MockService)(nil).CreateGetTaskJob), arg0, arg1)
+}
+
 // CreateModel mocks base method.
 func (m *MockService) CreateModel(arg0 context.Context, arg1 types.CreateModelParams, arg2 types.CreateModelRequest) (*types.Model, error) {
 	m.ctrl.T.Helper()
@@ -257,6 +317,21 @@ func (mr *MockServiceMockRecorder) CreatePreheatJob(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePreheatJob", reflect.TypeOf((*MockService)(nil).CreatePreheatJob), arg0, arg1)
 }
 
+// CreatePurgeJob mocks base method.
+func (m *MockService) CreatePurgeJob(arg0 context.Context, arg1 types.CreatePurgeJobRequest) (*model.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePurgeJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePurgeJob indicates an expected call of CreatePurgeJob.
+func (mr *MockServiceMockRecorder) CreatePurgeJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePurgeJob", reflect.TypeOf((*MockService)(nil).CreatePurgeJob), arg0, arg1)
+}
+
 // CreateRole mocks base method.
 func (m *MockService) CreateRole(arg0 context.Context, arg1 types.CreateRoleRequest) error {
 	m.ctrl.T.Helper()
@@ -361,6 +436,21 @@ func (mr *MockServiceMockRecorder) CreateSeedPeerCluster(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSeedPeerCluster", reflect.TypeOf((*MockService)(nil).CreateSeedPeerCluster), arg0, arg1)
 }
 
+// CreateSyncPeersJob mocks base method.
+func (m *MockService) CreateSyncPeersJob(arg0 context.Context, arg1 types.CreateSyncPeersJobRequest) (*model.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSyncPeersJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSyncPeersJob indicates an expected call of CreateSyncPeersJob.
+func (mr *MockServiceMockRecorder) CreateSyncPeersJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSyncPeersJob", reflect.TypeOf((*MockService)(nil).CreateSyncPeersJob), arg0, arg1)
+}
+
 // CreateV1Preheat mocks base method.
 func (m *MockService) CreateV1Preheat(arg0 context.Context, arg1 types.CreateV1PreheatRequest) (*types.CreateV1PreheatResponse, error) {
 	m.ctrl.T.Helper()
@@ -1179,6 +1269,21 @@ func (mr *MockServiceMockRecorder) ResetPassword(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockService)(nil).ResetPassword), arg0, arg1, arg2)
 }
 
+// RetryJob mocks base method.
+func (m *MockService) RetryJob(arg0 context.Context, arg1 uint) (*model.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryJob indicates an expected call of RetryJob.
+func (mr *MockServiceMockRecorder) RetryJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryJob", reflect.TypeOf((*MockService)(nil).RetryJob), arg0, arg1)
+}
+
 // SignIn mocks base method.
 func (m *MockService) SignIn(arg0 context.Context, arg1 types.SignInRequest) (*model.User, error) {
 	m.ctrl.T.Helper()
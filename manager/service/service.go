@@ -120,7 +120,14 @@ type Service interface {
 	GetConfigs(context.Context, types.GetConfigsQuery) ([]model.Config, int64, error)
 
 	CreatePreheatJob(context.Context, types.CreatePreheatJobRequest) (*model.Job, error)
+	CreateGetTaskJob(context.Context, types.CreateGetTaskJobRequest) (*model.Job, error)
+	CreateDeleteTaskJob(context.Context, types.CreateDeleteTaskJobRequest) (*model.Job, error)
+	CreateEvictTaskJob(context.Context, types.CreateEvictTaskJobRequest) (*model.Job, error)
+	CreateSyncPeersJob(context.Context, types.CreateSyncPeersJobRequest) (*model.Job, error)
+	CreatePurgeJob(context.Context, types.CreatePurgeJobRequest) (*model.Job, error)
 	DestroyJob(context.Context, uint) error
+	CancelJob(context.Context, uint) (*model.Job, error)
+	RetryJob(context.Context, uint) (*model.Job, error)
 	UpdateJob(context.Context, uint, types.UpdateJobRequest) (*model.Job, error)
 	GetJob(context.Context, uint) (*model.Job, error)
 	GetJobs(context.Context, types.GetJobsQuery) ([]model.Job, int64, error)
@@ -37,7 +37,7 @@ type JobParams struct {
 
 type GetJobsQuery struct {
 	Type    string `form:"type" binding:"omitempty"`
-	State   string `form:"state" binding:"omitempty,oneof=PENDING RECEIVED STARTED RETRY SUCCESS FAILURE"`
+	State   string `form:"state" binding:"omitempty,oneof=PENDING RECEIVED STARTED RETRY SUCCESS FAILURE CANCELED"`
 	UserID  uint   `form:"user_id" binding:"omitempty"`
 	Page    int    `form:"page" binding:"omitempty,gte=1"`
 	PerPage int    `form:"per_page" binding:"omitempty,gte=1,lte=50"`
@@ -58,4 +58,92 @@ type PreheatArgs struct {
 	Tag     string            `json:"tag" binding:"omitempty"`
 	Filter  string            `json:"filter" binding:"omitempty"`
 	Headers map[string]string `json:"headers" binding:"omitempty"`
+
+	// Platforms is the comma separated platforms of image index to preheat, like linux/amd64,linux/arm64.
+	// All platforms of image index are preheated when it's empty.
+	Platforms string `json:"platforms" binding:"omitempty"`
+
+	// Username is the username of registry.
+	Username string `json:"username" binding:"omitempty"`
+
+	// Password is the password of registry, it's not stored in job.
+	Password string `json:"password" binding:"omitempty"`
+
+	// CredentialName is the name of registry credential in manager config, it's exclusive with username.
+	CredentialName string `json:"credential_name" binding:"omitempty"`
+}
+
+type CreateGetTaskJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Args                GetTaskArgs    `json:"args" binding:"omitempty"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type GetTaskArgs struct {
+	URL         string `json:"url" binding:"required"`
+	Tag         string `json:"tag" binding:"omitempty"`
+	Application string `json:"application" binding:"omitempty"`
+	Digest      string `json:"digest" binding:"omitempty"`
+	Filter      string `json:"filter" binding:"omitempty"`
+}
+
+type CreateDeleteTaskJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Args                DeleteTaskArgs `json:"args" binding:"omitempty"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type DeleteTaskArgs struct {
+	URL         string `json:"url" binding:"required"`
+	Tag         string `json:"tag" binding:"omitempty"`
+	Application string `json:"application" binding:"omitempty"`
+	Digest      string `json:"digest" binding:"omitempty"`
+	Filter      string `json:"filter" binding:"omitempty"`
+}
+
+type CreateEvictTaskJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Args                EvictTaskArgs  `json:"args" binding:"omitempty"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type EvictTaskArgs struct {
+	URL         string `json:"url" binding:"required"`
+	Tag         string `json:"tag" binding:"omitempty"`
+	Application string `json:"application" binding:"omitempty"`
+	Digest      string `json:"digest" binding:"omitempty"`
+	Filter      string `json:"filter" binding:"omitempty"`
+}
+
+type CreateSyncPeersJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type CreatePurgeJobRequest struct {
+	BIO                 string         `json:"bio" binding:"omitempty"`
+	Type                string         `json:"type" binding:"required"`
+	Args                PurgeArgs      `json:"args" binding:"omitempty"`
+	Result              map[string]any `json:"result" binding:"omitempty"`
+	UserID              uint           `json:"user_id" binding:"omitempty"`
+	SchedulerClusterIDs []uint         `json:"scheduler_cluster_ids" binding:"omitempty"`
+}
+
+type PurgeArgs struct {
+	URL     string            `json:"url" binding:"required"`
+	Tag     string            `json:"tag" binding:"omitempty"`
+	Filter  string            `json:"filter" binding:"omitempty"`
+	Headers map[string]string `json:"headers" binding:"omitempty"`
 }
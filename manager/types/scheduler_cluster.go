@@ -54,8 +54,9 @@ type GetSchedulerClustersQuery struct {
 }
 
 type SchedulerClusterConfig struct {
-	FilterParentLimit      uint32 `yaml:"filterParentLimit" mapstructure:"filterParentLimit" json:"filter_parent_limit" binding:"omitempty,gte=1,lte=20"`
-	FilterParentRangeLimit uint32 `yaml:"filterParentRangeLimit" mapstructure:"filterParentRangeLimit" json:"filter_parent_range_limit" binding:"omitempty,gte=10,lte=1000"`
+	FilterParentLimit      uint32             `yaml:"filterParentLimit" mapstructure:"filterParentLimit" json:"filter_parent_limit" binding:"omitempty,gte=1,lte=20"`
+	FilterParentRangeLimit uint32             `yaml:"filterParentRangeLimit" mapstructure:"filterParentRangeLimit" json:"filter_parent_range_limit" binding:"omitempty,gte=10,lte=1000"`
+	EvaluatorWeights       map[string]float64 `yaml:"evaluatorWeights" mapstructure:"evaluatorWeights" json:"evaluator_weights" binding:"omitempty,dive,gte=0"`
 }
 
 type SchedulerClusterClientConfig struct {
@@ -0,0 +1,171 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package azblob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+)
+
+const (
+	// PermissionRead is the permission of SAS to read blob.
+	PermissionRead = "r"
+
+	// PermissionWrite is the permission of SAS to create or write blob.
+	PermissionWrite = "cw"
+
+	// PermissionDelete is the permission of SAS to delete blob.
+	PermissionDelete = "d"
+
+	// sasTimeFormat is the time format of SAS.
+	sasTimeFormat = "2006-01-02T15:04:05Z"
+)
+
+// SignURL returns the url of blob with service SAS, which grants the permissions until expiry.
+func (c *Client) SignURL(containerName, blobName, permissions string, expiry time.Time) (string, error) {
+	if len(c.accountKey) == 0 {
+		return "", errors.New("account key is required to sign url")
+	}
+
+	expiryTime := expiry.UTC().Format(sasTimeFormat)
+	stringToSign := strings.Join([]string{
+		permissions,
+		"", // signedStart
+		expiryTime,
+		"/blob/" + c.accountName + "/" + containerName + "/" + blobName,
+		"", // signedIdentifier
+		"", // signedIP
+		"", // signedProtocol
+		Version,
+		"b", // signedResource
+		"",  // signedSnapshotTime
+		"",  // rscc
+		"",  // rscd
+		"",  // rsce
+		"",  // rscl
+		"",  // rsct
+	}, "\n")
+
+	u := c.URL(containerName, blobName)
+	u.RawQuery = url.Values{
+		"sv":  {Version},
+		"sr":  {"b"},
+		"sp":  {permissions},
+		"se":  {expiryTime},
+		"sig": {signature(c.accountKey, stringToSign)},
+	}.Encode()
+	return u.String(), nil
+}
+
+// stringToSign returns the string to sign of Shared Key authorization.
+func stringToSign(req *http.Request, accountName string) string {
+	var contentLength string
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get(headers.ContentEncoding),
+		req.Header.Get(headers.ContentLanguage),
+		contentLength,
+		req.Header.Get(headers.ContentMD5),
+		req.Header.Get(headers.ContentType),
+		"", // Date is empty because x-ms-date is set.
+		req.Header.Get(headers.IfModifiedSince),
+		req.Header.Get(headers.IfMatch),
+		req.Header.Get(headers.IfNoneMatch),
+		req.Header.Get(headers.IfUnmodifiedSince),
+		req.Header.Get(headers.Range),
+		canonicalizedHeaders(req.Header),
+		canonicalizedResource(req.URL, accountName),
+	}, "\n")
+}
+
+// canonicalizedHeaders returns the x-ms- headers in the order of lowercase name.
+func canonicalizedHeaders(header http.Header) string {
+	var names []string
+	for name := range header {
+		if strings.HasPrefix(strings.ToLower(name), "x-ms-") {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		values := make([]string, 0, len(header[name]))
+		for _, value := range header[name] {
+			values = append(values, strings.TrimSpace(value))
+		}
+		lines = append(lines, strings.ToLower(name)+":"+strings.Join(values, ","))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// canonicalizedResource returns the account name, the encoded path and the query parameters
+// in the order of lowercase name.
+func canonicalizedResource(u *url.URL, accountName string) string {
+	var b strings.Builder
+	b.WriteString("/")
+	b.WriteString(accountName)
+	if path := u.EscapedPath(); path != "" {
+		b.WriteString(path)
+	} else {
+		b.WriteString("/")
+	}
+
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		b.WriteString("\n")
+		b.WriteString(strings.ToLower(name))
+		b.WriteString(":")
+		b.WriteString(strings.Join(values, ","))
+	}
+
+	return b.String()
+}
+
+// signature returns the base64 encoded HMAC-SHA256 of string to sign.
+func signature(key []byte, stringToSign string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,501 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package azblobtest provides an in-memory Azure Blob service for testing.
+package azblobtest
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an in-memory Blob service serving the path style urls of storage account like Azurite,
+// it supports the subset of operations used by dragonfly. The requests must carry the Shared Key
+// authorization or the SAS of storage account, but the signatures are not verified.
+type Server struct {
+	*httptest.Server
+
+	// AccountName is the name of storage account.
+	AccountName string
+
+	mu         sync.Mutex
+	containers map[string]*container
+	etag       int
+}
+
+type container struct {
+	lastModified time.Time
+	blobs        map[string]*blob
+	blocks       map[string]map[string][]byte
+}
+
+type blob struct {
+	data         []byte
+	contentType  string
+	metadata     map[string]string
+	etag         string
+	lastModified time.Time
+}
+
+// NewServer starts and returns a new Server of storage account, the caller should call Close when finished.
+func NewServer(accountName string) *Server {
+	s := &Server{
+		AccountName: accountName,
+		containers:  map[string]*container{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint returns the blob endpoint of storage account.
+func (s *Server) Endpoint() string {
+	return s.URL + "/" + s.AccountName
+}
+
+// PutBlob stores the blob in the container, the container is created if it doesn't exist.
+func (s *Server) PutBlob(containerName, blobName string, data []byte, metadata map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.containers[containerName]
+	if !ok {
+		c = s.newContainer()
+		s.containers[containerName] = c
+	}
+	c.blobs[blobName] = s.newBlob(data, "", metadata)
+}
+
+// Blob returns the data and metadata of blob.
+func (s *Server) Blob(containerName, blobName string) ([]byte, map[string]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.containers[containerName]
+	if !ok {
+		return nil, nil, false
+	}
+
+	b, ok := c.blobs[blobName]
+	if !ok {
+		return nil, nil, false
+	}
+	return b.data, b.metadata, true
+}
+
+func (s *Server) newContainer() *container {
+	return &container{
+		lastModified: time.Now(),
+		blobs:        map[string]*blob{},
+		blocks:       map[string]map[string][]byte{},
+	}
+}
+
+func (s *Server) newBlob(data []byte, contentType string, metadata map[string]string) *blob {
+	s.etag++
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &blob{
+		data:         data,
+		contentType:  contentType,
+		metadata:     metadata,
+		etag:         fmt.Sprintf("\"0x8D%014X\"", s.etag),
+		lastModified: time.Now(),
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/"+s.AccountName+"/") {
+		writeError(w, http.StatusBadRequest, "InvalidUri", "The requested URI does not represent any resource on the server.")
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+s.AccountName+":") && r.URL.Query().Get("sig") == "" {
+		writeError(w, http.StatusForbidden, "NoAuthenticationInformation", "Server failed to authenticate the request.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	containerName, blobName, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"+s.AccountName+"/"), "/")
+	query := r.URL.Query()
+	switch {
+	case containerName == "":
+		s.listContainers(w, r)
+	case blobName == "" && query.Get("restype") == "container":
+		s.serveContainer(w, r, containerName)
+	default:
+		s.serveBlob(w, r, containerName, blobName)
+	}
+}
+
+func (s *Server) listContainers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.URL.Query().Get("comp") != "list" {
+		writeError(w, http.StatusBadRequest, "UnsupportedHttpVerb", "The resource doesn't support specified Http Verb.")
+		return
+	}
+
+	names := make([]string, 0, len(s.containers))
+	for name := range s.containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type properties struct {
+		LastModified string `xml:"Last-Modified"`
+		ETag         string `xml:"Etag"`
+	}
+	type containerElement struct {
+		Name       string     `xml:"Name"`
+		Properties properties `xml:"Properties"`
+	}
+	result := struct {
+		XMLName    xml.Name           `xml:"EnumerationResults"`
+		Containers []containerElement `xml:"Containers>Container"`
+		NextMarker string             `xml:"NextMarker"`
+	}{}
+	for _, name := range names {
+		result.Containers = append(result.Containers, containerElement{
+			Name: name,
+			Properties: properties{
+				LastModified: s.containers[name].lastModified.UTC().Format(http.TimeFormat),
+				ETag:         "\"0x8D000000000000\"",
+			},
+		})
+	}
+
+	writeXML(w, result)
+}
+
+func (s *Server) serveContainer(w http.ResponseWriter, r *http.Request, containerName string) {
+	c, ok := s.containers[containerName]
+	switch {
+	case r.Method == http.MethodPut:
+		if ok {
+			writeError(w, http.StatusConflict, "ContainerAlreadyExists", "The specified container already exists.")
+			return
+		}
+
+		s.containers[containerName] = s.newContainer()
+		w.WriteHeader(http.StatusCreated)
+		return
+	case !ok:
+		writeError(w, http.StatusNotFound, "ContainerNotFound", "The specified container does not exist.")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodDelete:
+		delete(s.containers, containerName)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list":
+		s.listBlobs(w, r, c)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		w.Header().Set("Last-Modified", c.lastModified.UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", "\"0x8D000000000000\"")
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusBadRequest, "UnsupportedHttpVerb", "The resource doesn't support specified Http Verb.")
+	}
+}
+
+func (s *Server) listBlobs(w http.ResponseWriter, r *http.Request, c *container) {
+	var (
+		query     = r.URL.Query()
+		prefix    = query.Get("prefix")
+		delimiter = query.Get("delimiter")
+		marker    = query.Get("marker")
+	)
+
+	maxResults := 5000
+	if value := query.Get("maxresults"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "OutOfRangeQueryParameterValue", "One of the query parameters specified in the request URI is outside the permissible range.")
+			return
+		}
+		maxResults = n
+	}
+
+	names := make([]string, 0, len(c.blobs))
+	for name := range c.blobs {
+		if strings.HasPrefix(name, prefix) && name >= marker {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	type properties struct {
+		LastModified  string `xml:"Last-Modified"`
+		ETag          string `xml:"Etag"`
+		ContentLength int    `xml:"Content-Length"`
+		ContentType   string `xml:"Content-Type"`
+		ContentMD5    string `xml:"Content-MD5"`
+	}
+	type metadataElement struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	}
+	type blobElement struct {
+		Name       string            `xml:"Name"`
+		Properties properties        `xml:"Properties"`
+		Metadata   []metadataElement `xml:"Metadata>Item"`
+	}
+	type blobPrefixElement struct {
+		Name string `xml:"Name"`
+	}
+	result := struct {
+		XMLName      xml.Name            `xml:"EnumerationResults"`
+		Prefix       string              `xml:"Prefix"`
+		Marker       string              `xml:"Marker"`
+		MaxResults   int                 `xml:"MaxResults"`
+		Delimiter    string              `xml:"Delimiter"`
+		Blobs        []blobElement       `xml:"Blobs>Blob"`
+		BlobPrefixes []blobPrefixElement `xml:"Blobs>BlobPrefix"`
+		NextMarker   string              `xml:"NextMarker"`
+	}{
+		Prefix:     prefix,
+		Marker:     marker,
+		MaxResults: maxResults,
+		Delimiter:  delimiter,
+	}
+
+	var (
+		count      int
+		lastPrefix string
+	)
+	for _, name := range names {
+		var blobPrefix string
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				blobPrefix = name[:len(prefix)+i+len(delimiter)]
+			}
+		}
+
+		if blobPrefix != "" && blobPrefix == lastPrefix {
+			continue
+		}
+
+		if count == maxResults {
+			result.NextMarker = name
+			break
+		}
+		count++
+
+		if blobPrefix != "" {
+			result.BlobPrefixes = append(result.BlobPrefixes, blobPrefixElement{Name: blobPrefix})
+			lastPrefix = blobPrefix
+			continue
+		}
+
+		b := c.blobs[name]
+		element := blobElement{
+			Name: name,
+			Properties: properties{
+				LastModified:  b.lastModified.UTC().Format(http.TimeFormat),
+				ETag:          b.etag,
+				ContentLength: len(b.data),
+				ContentType:   b.contentType,
+				ContentMD5:    contentMD5(b.data),
+			},
+		}
+
+		keys := make([]string, 0, len(b.metadata))
+		for key := range b.metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			element.Metadata = append(element.Metadata, metadataElement{XMLName: xml.Name{Local: key}, Value: b.metadata[key]})
+		}
+
+		result.Blobs = append(result.Blobs, element)
+	}
+
+	writeXML(w, result)
+}
+
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request, containerName, blobName string) {
+	c, ok := s.containers[containerName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ContainerNotFound", "The specified container does not exist.")
+		return
+	}
+
+	query := r.URL.Query()
+	if r.Method == http.MethodPut {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidInput", err.Error())
+			return
+		}
+
+		switch query.Get("comp") {
+		case "":
+			if r.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+				writeError(w, http.StatusBadRequest, "MissingRequiredHeader", "An HTTP header that's mandatory for this request is not specified.")
+				return
+			}
+
+			c.blobs[blobName] = s.newBlob(data, r.Header.Get("Content-Type"), blobMetadata(r.Header))
+			delete(c.blocks, blobName)
+		case "block":
+			if c.blocks[blobName] == nil {
+				c.blocks[blobName] = map[string][]byte{}
+			}
+			c.blocks[blobName][query.Get("blockid")] = data
+		case "blocklist":
+			var blockList struct {
+				Latest []string `xml:"Latest"`
+			}
+			if err := xml.Unmarshal(data, &blockList); err != nil {
+				writeError(w, http.StatusBadRequest, "InvalidXmlDocument", "XML specified is not syntactically valid.")
+				return
+			}
+
+			var content []byte
+			for _, blockID := range blockList.Latest {
+				block, ok := c.blocks[blobName][blockID]
+				if !ok {
+					writeError(w, http.StatusBadRequest, "InvalidBlockList", "The specified block list is invalid.")
+					return
+				}
+				content = append(content, block...)
+			}
+
+			c.blobs[blobName] = s.newBlob(content, r.Header.Get("Content-Type"), blobMetadata(r.Header))
+			delete(c.blocks, blobName)
+		default:
+			writeError(w, http.StatusBadRequest, "InvalidQueryParameterValue", "Value for one of the query parameters specified in the request URI is invalid.")
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	b, ok := c.blobs[blobName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "BlobNotFound", "The specified blob does not exist.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		delete(c.blobs, blobName)
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodGet, http.MethodHead:
+		for key, value := range b.metadata {
+			w.Header().Set("X-Ms-Meta-"+key, value)
+		}
+		w.Header().Set("Content-Type", b.contentType)
+		w.Header().Set("Content-MD5", contentMD5(b.data))
+		w.Header().Set("ETag", b.etag)
+		w.Header().Set("Last-Modified", b.lastModified.UTC().Format(http.TimeFormat))
+		w.Header().Set("Accept-Ranges", "bytes")
+
+		data, statusCode := b.data, http.StatusOK
+		if value := r.Header.Get("Range"); value != "" {
+			start, end, ok := parseRange(value, int64(len(b.data)))
+			if !ok {
+				writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The range specified is invalid for the current size of the resource.")
+				return
+			}
+
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(b.data)))
+			data, statusCode = b.data[start:end+1], http.StatusPartialContent
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(statusCode)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "UnsupportedHttpVerb", "The resource doesn't support specified Http Verb.")
+	}
+}
+
+// parseRange parses the range of bytes=start-end or bytes=start-.
+func parseRange(value string, size int64) (int64, int64, bool) {
+	if !strings.HasPrefix(value, "bytes=") {
+		return 0, 0, false
+	}
+
+	first, last, ok := strings.Cut(strings.TrimPrefix(value, "bytes="), "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end, true
+}
+
+func blobMetadata(header http.Header) map[string]string {
+	metadata := map[string]string{}
+	for key := range header {
+		if strings.HasPrefix(key, "X-Ms-Meta-") {
+			metadata[strings.ToLower(strings.TrimPrefix(key, "X-Ms-Meta-"))] = header.Get(key)
+		}
+	}
+	return metadata
+}
+
+func contentMD5(data []byte) string {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("X-Ms-Error-Code", code)
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, message)
+}
@@ -0,0 +1,597 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package azblob
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+)
+
+const (
+	// Version is the version of Blob service REST API.
+	Version = "2020-10-02"
+
+	// MetaHeaderPrefix is the header prefix of blob metadata.
+	MetaHeaderPrefix = "X-Ms-Meta-"
+
+	// defaultEndpointFormat is the endpoint format of storage account in Azure public cloud.
+	defaultEndpointFormat = "https://%s.blob.core.windows.net"
+
+	// blockSize is the size of blocks when the blob is uploaded in blocks.
+	blockSize = 4 << 20
+
+	// maxErrorSize is the max size of error response read from Blob service.
+	maxErrorSize = 4096
+)
+
+const (
+	headerDate        = "X-Ms-Date"
+	headerVersion     = "X-Ms-Version"
+	headerBlobType    = "X-Ms-Blob-Type"
+	headerErrorCode   = "X-Ms-Error-Code"
+	blobTypeBlockBlob = "BlockBlob"
+)
+
+// ContainerProperties is the properties of container.
+type ContainerProperties struct {
+	// Name is container name.
+	Name string
+
+	// LastModified is the last modified time of container.
+	LastModified time.Time
+
+	// ETag is the etag of container.
+	ETag string
+}
+
+// BlobProperties is the properties of blob.
+type BlobProperties struct {
+	// Name is blob name.
+	Name string
+
+	// ContentLength is the size of blob.
+	ContentLength int64
+
+	// ContentType is Content-Type header of blob.
+	ContentType string
+
+	// ContentEncoding is Content-Encoding header of blob.
+	ContentEncoding string
+
+	// ContentLanguage is Content-Language header of blob.
+	ContentLanguage string
+
+	// ContentDisposition is Content-Disposition header of blob.
+	ContentDisposition string
+
+	// ContentMD5 is the base64 encoded md5 of blob.
+	ContentMD5 string
+
+	// ETag is the etag of blob.
+	ETag string
+
+	// LastModified is the last modified time of blob.
+	LastModified time.Time
+
+	// Metadata is the user defined metadata of blob.
+	Metadata map[string]string
+}
+
+// ListBlobsOptions is the options of listing blobs.
+type ListBlobsOptions struct {
+	// Prefix filters the blobs whose names begin with prefix.
+	Prefix string
+
+	// Delimiter rolls up the blobs whose names contain delimiter after prefix into blob prefixes.
+	Delimiter string
+
+	// Marker is the NextMarker returned by previous listing.
+	Marker string
+
+	// MaxResults is the max number of blobs and blob prefixes returned, 5000 by default.
+	MaxResults int
+}
+
+// ListBlobsResult is the result of listing blobs.
+type ListBlobsResult struct {
+	// Blobs is the blobs in the order of name.
+	Blobs []*BlobProperties
+
+	// BlobPrefixes is the blob prefixes rolled up by delimiter.
+	BlobPrefixes []string
+
+	// NextMarker is the marker of next page, it's empty when all blobs are listed.
+	NextMarker string
+}
+
+// ListContainersResult is the result of listing containers.
+type ListContainersResult struct {
+	// Containers is the containers in the order of name.
+	Containers []*ContainerProperties
+
+	// NextMarker is the marker of next page, it's empty when all containers are listed.
+	NextMarker string
+}
+
+// Client is the client of Azure Blob service REST API, the requests are authorized by Shared Key.
+type Client struct {
+	httpClient  *http.Client
+	endpoint    *url.URL
+	accountName string
+	accountKey  []byte
+	now         func() time.Time
+}
+
+// Option is the option of Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the http client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New returns a client of storage account. The endpoint is the blob endpoint of storage account,
+// such as http://127.0.0.1:10000/devstoreaccount1 of Azurite, the endpoint of Azure public cloud
+// is used if it's empty. The requests are anonymous if accountKey is empty.
+func New(accountName, accountKey, endpoint string, opts ...Option) (*Client, error) {
+	if accountName == "" {
+		return nil, errors.New("account name is empty")
+	}
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf(defaultEndpointFormat, accountName)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %w", endpoint, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %s: scheme must be http or https", endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account key: %w", err)
+	}
+
+	c := &Client{
+		httpClient:  http.DefaultClient,
+		endpoint:    u,
+		accountName: accountName,
+		accountKey:  key,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// URL returns the url of container, or the url of blob if blobName is not empty.
+// It returns the url of storage account if containerName is empty.
+func (c *Client) URL(containerName, blobName string) *url.URL {
+	u := *c.endpoint
+	segments := []string{u.Path, containerName}
+	if blobName != "" {
+		segments = append(segments, blobName)
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+	return &u
+}
+
+// GetContainerProperties returns the properties of container.
+func (c *Client) GetContainerProperties(ctx context.Context, containerName string) (*ContainerProperties, error) {
+	resp, err := c.do(ctx, http.MethodHead, containerName, "", url.Values{"restype": {"container"}}, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	lastModified, _ := http.ParseTime(resp.Header.Get(headers.LastModified))
+	return &ContainerProperties{
+		Name:         containerName,
+		LastModified: lastModified,
+		ETag:         resp.Header.Get(headers.ETag),
+	}, nil
+}
+
+// CreateContainer creates the container.
+func (c *Client) CreateContainer(ctx context.Context, containerName string) error {
+	resp, err := c.do(ctx, http.MethodPut, containerName, "", url.Values{"restype": {"container"}}, nil, nil, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// DeleteContainer deletes the container and its blobs.
+func (c *Client) DeleteContainer(ctx context.Context, containerName string) error {
+	resp, err := c.do(ctx, http.MethodDelete, containerName, "", url.Values{"restype": {"container"}}, nil, nil, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// ListContainers lists the containers of storage account after marker.
+func (c *Client) ListContainers(ctx context.Context, marker string) (*ListContainersResult, error) {
+	query := url.Values{"comp": {"list"}}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+
+	var result struct {
+		Containers []struct {
+			Name       string     `xml:"Name"`
+			Properties properties `xml:"Properties"`
+		} `xml:"Containers>Container"`
+		NextMarker string `xml:"NextMarker"`
+	}
+	if err := c.doXML(ctx, "", query, &result); err != nil {
+		return nil, err
+	}
+
+	containers := make([]*ContainerProperties, 0, len(result.Containers))
+	for _, container := range result.Containers {
+		lastModified, _ := http.ParseTime(container.Properties.LastModified)
+		containers = append(containers, &ContainerProperties{
+			Name:         container.Name,
+			LastModified: lastModified,
+			ETag:         container.Properties.ETag,
+		})
+	}
+
+	return &ListContainersResult{
+		Containers: containers,
+		NextMarker: result.NextMarker,
+	}, nil
+}
+
+// GetBlobProperties returns the properties of blob.
+func (c *Client) GetBlobProperties(ctx context.Context, containerName, blobName string) (*BlobProperties, error) {
+	resp, err := c.do(ctx, http.MethodHead, containerName, blobName, nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return newBlobProperties(blobName, resp.Header), nil
+}
+
+// GetBlob returns the response of getting blob, the header is sent with request, such as Range and If-Match.
+// The caller must close the body of response.
+func (c *Client) GetBlob(ctx context.Context, containerName, blobName string, header http.Header) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, containerName, blobName, nil, header, nil, http.StatusOK, http.StatusPartialContent)
+}
+
+// PutBlob uploads the block blob with metadata, the blob is uploaded in blocks when it's larger than block size.
+func (c *Client) PutBlob(ctx context.Context, containerName, blobName string, reader io.Reader, metadata map[string]string) error {
+	header := http.Header{}
+	for key, value := range metadata {
+		header.Set(MetaHeaderPrefix+key, value)
+	}
+
+	buf := make([]byte, blockSize)
+	var blockIDs []string
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		// The blob smaller than block size is uploaded by single request.
+		if len(blockIDs) == 0 && n < blockSize {
+			header.Set(headerBlobType, blobTypeBlockBlob)
+			resp, err := c.do(ctx, http.MethodPut, containerName, blobName, nil, header, buf[:n], http.StatusCreated)
+			if err != nil {
+				return err
+			}
+
+			return resp.Body.Close()
+		}
+
+		if n > 0 {
+			// The block ids of blob must be in the same length.
+			blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", len(blockIDs))))
+			resp, err := c.do(ctx, http.MethodPut, containerName, blobName, url.Values{"comp": {"block"}, "blockid": {blockID}},
+				nil, buf[:n], http.StatusCreated)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			blockIDs = append(blockIDs, blockID)
+		}
+
+		if n < blockSize {
+			break
+		}
+	}
+
+	blockList := struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{Latest: blockIDs}
+
+	body, err := xml.Marshal(blockList)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, containerName, blobName, url.Values{"comp": {"blocklist"}}, header,
+		append([]byte(xml.Header), body...), http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// DeleteBlob deletes the blob.
+func (c *Client) DeleteBlob(ctx context.Context, containerName, blobName string) error {
+	resp, err := c.do(ctx, http.MethodDelete, containerName, blobName, nil, nil, nil, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// ListBlobs lists the blobs of container in the order of name.
+func (c *Client) ListBlobs(ctx context.Context, containerName string, opts ListBlobsOptions) (*ListBlobsResult, error) {
+	query := url.Values{"restype": {"container"}, "comp": {"list"}, "include": {"metadata"}}
+	if opts.Prefix != "" {
+		query.Set("prefix", opts.Prefix)
+	}
+
+	if opts.Delimiter != "" {
+		query.Set("delimiter", opts.Delimiter)
+	}
+
+	if opts.Marker != "" {
+		query.Set("marker", opts.Marker)
+	}
+
+	if opts.MaxResults > 0 {
+		query.Set("maxresults", strconv.Itoa(opts.MaxResults))
+	}
+
+	var result struct {
+		Blobs []struct {
+			Name       string     `xml:"Name"`
+			Properties properties `xml:"Properties"`
+			Metadata   metadata   `xml:"Metadata"`
+		} `xml:"Blobs>Blob"`
+		BlobPrefixes []struct {
+			Name string `xml:"Name"`
+		} `xml:"Blobs>BlobPrefix"`
+		NextMarker string `xml:"NextMarker"`
+	}
+	if err := c.doXML(ctx, containerName, query, &result); err != nil {
+		return nil, err
+	}
+
+	blobs := make([]*BlobProperties, 0, len(result.Blobs))
+	for _, blob := range result.Blobs {
+		lastModified, _ := http.ParseTime(blob.Properties.LastModified)
+		blobs = append(blobs, &BlobProperties{
+			Name:               blob.Name,
+			ContentLength:      blob.Properties.ContentLength,
+			ContentType:        blob.Properties.ContentType,
+			ContentEncoding:    blob.Properties.ContentEncoding,
+			ContentLanguage:    blob.Properties.ContentLanguage,
+			ContentDisposition: blob.Properties.ContentDisposition,
+			ContentMD5:         blob.Properties.ContentMD5,
+			ETag:               blob.Properties.ETag,
+			LastModified:       lastModified,
+			Metadata:           blob.Metadata,
+		})
+	}
+
+	blobPrefixes := make([]string, 0, len(result.BlobPrefixes))
+	for _, blobPrefix := range result.BlobPrefixes {
+		blobPrefixes = append(blobPrefixes, blobPrefix.Name)
+	}
+
+	return &ListBlobsResult{
+		Blobs:        blobs,
+		BlobPrefixes: blobPrefixes,
+		NextMarker:   result.NextMarker,
+	}, nil
+}
+
+// properties is the properties element of listing results.
+type properties struct {
+	LastModified       string `xml:"Last-Modified"`
+	ETag               string `xml:"Etag"`
+	ContentLength      int64  `xml:"Content-Length"`
+	ContentType        string `xml:"Content-Type"`
+	ContentEncoding    string `xml:"Content-Encoding"`
+	ContentLanguage    string `xml:"Content-Language"`
+	ContentDisposition string `xml:"Content-Disposition"`
+	ContentMD5         string `xml:"Content-MD5"`
+}
+
+// metadata is the metadata element of listing results, the element names are metadata keys.
+type metadata map[string]string
+
+func (m *metadata) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*m = metadata{}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*m)[strings.ToLower(t.Name.Local)] = value
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// newBlobProperties returns the properties of blob from response header.
+func newBlobProperties(blobName string, header http.Header) *BlobProperties {
+	contentLength, _ := strconv.ParseInt(header.Get(headers.ContentLength), 10, 64)
+	lastModified, _ := http.ParseTime(header.Get(headers.LastModified))
+
+	metadata := map[string]string{}
+	for key := range header {
+		if strings.HasPrefix(key, MetaHeaderPrefix) {
+			metadata[strings.ToLower(strings.TrimPrefix(key, MetaHeaderPrefix))] = header.Get(key)
+		}
+	}
+
+	return &BlobProperties{
+		Name:               blobName,
+		ContentLength:      contentLength,
+		ContentType:        header.Get(headers.ContentType),
+		ContentEncoding:    header.Get(headers.ContentEncoding),
+		ContentLanguage:    header.Get(headers.ContentLanguage),
+		ContentDisposition: header.Get(headers.ContentDisposition),
+		ContentMD5:         header.Get(headers.ContentMD5),
+		ETag:               header.Get(headers.ETag),
+		LastModified:       lastModified,
+		Metadata:           metadata,
+	}
+}
+
+// doXML sends the get request of container, or storage account if containerName is empty,
+// and decodes the xml response into v.
+func (c *Client) doXML(ctx context.Context, containerName string, query url.Values, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, containerName, "", query, nil, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := xml.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response failed: %w", err)
+	}
+
+	return nil
+}
+
+// do sends the request authorized by Shared Key, it returns the error of response if the status code isn't expected.
+func (c *Client) do(ctx context.Context, method, containerName, blobName string, query url.Values, header http.Header,
+	body []byte, expectedStatusCodes ...int) (*http.Response, error) {
+	u := c.URL(containerName, blobName)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set(headerDate, c.now().UTC().Format(http.TimeFormat))
+	req.Header.Set(headerVersion, Version)
+
+	if len(c.accountKey) > 0 {
+		req.Header.Set(headers.Authorization, fmt.Sprintf("SharedKey %s:%s", c.accountName, signature(c.accountKey, stringToSign(req, c.accountName))))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, statusCode := range expectedStatusCodes {
+		if resp.StatusCode == statusCode {
+			return resp, nil
+		}
+	}
+
+	defer resp.Body.Close()
+	return nil, newResponseError(resp)
+}
+
+// ResponseError is the error of unexpected Blob service response.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Code       string
+	Message    string
+}
+
+func newResponseError(resp *http.Response) *ResponseError {
+	e := &ResponseError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Code:       resp.Header.Get(headerErrorCode),
+	}
+
+	// The response of HEAD request has no body, so the error code is read from header.
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxErrorSize)).Decode(&body); err == nil {
+		if body.Code != "" {
+			e.Code = body.Code
+		}
+		e.Message = strings.TrimSpace(body.Message)
+	}
+
+	return e
+}
+
+func (e *ResponseError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status code from blob service: %s: %s", e.Status, e.Code)
+	}
+	return fmt.Sprintf("unexpected status code from blob service: %s: %s: %s", e.Status, e.Code, e.Message)
+}
+
+// IsNotFound returns whether the error is caused by the container or blob not found.
+func IsNotFound(err error) bool {
+	var respErr *ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,259 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package azblob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/azblob/azblobtest"
+)
+
+const (
+	// testAccountName and testAccountKey are the well-known account of Azurite.
+	testAccountName = "devstoreaccount1"
+	testAccountKey  = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		accountName    string
+		accountKey     string
+		endpoint       string
+		expectEndpoint string
+		expectErr      bool
+	}{
+		{
+			name:           "public cloud endpoint",
+			accountName:    "account",
+			expectEndpoint: "https://account.blob.core.windows.net",
+		},
+		{
+			name:           "azurite endpoint",
+			accountName:    testAccountName,
+			accountKey:     testAccountKey,
+			endpoint:       "http://127.0.0.1:10000/devstoreaccount1/",
+			expectEndpoint: "http://127.0.0.1:10000/devstoreaccount1",
+		},
+		{
+			name:      "account name is empty",
+			expectErr: true,
+		},
+		{
+			name:        "invalid account key",
+			accountName: "account",
+			accountKey:  "foo!",
+			expectErr:   true,
+		},
+		{
+			name:        "invalid endpoint scheme",
+			accountName: "account",
+			endpoint:    "ftp://127.0.0.1",
+			expectErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			client, err := New(tc.accountName, tc.accountKey, tc.endpoint)
+			if tc.expectErr {
+				assert.Error(err)
+				return
+			}
+
+			assert.Nil(err)
+			assert.Equal(tc.expectEndpoint, client.endpoint.String())
+			assert.Equal(tc.expectEndpoint+"/container/dir/blob%201", client.URL("container", "dir/blob 1").String())
+		})
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	assert := testifyassert.New(t)
+	req, err := http.NewRequest(http.MethodPut, "http://127.0.0.1:10000/devstoreaccount1/container/dir/blob?comp=block&blockid=YQ%3D%3D", strings.NewReader("foo"))
+	assert.Nil(err)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Range", "bytes=0-1")
+	req.Header.Set("X-Ms-Version", Version)
+	req.Header.Set("X-Ms-Date", "Fri, 24 Feb 2023 00:00:00 GMT")
+	req.Header.Set("X-Ms-Meta-Digest", " md5:foo ")
+
+	assert.Equal(strings.Join([]string{
+		"PUT",
+		"",
+		"",
+		"3",
+		"",
+		"text/plain",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"bytes=0-1",
+		"x-ms-date:Fri, 24 Feb 2023 00:00:00 GMT",
+		"x-ms-meta-digest:md5:foo",
+		"x-ms-version:" + Version,
+		"/devstoreaccount1/devstoreaccount1/container/dir/blob",
+		"blockid:YQ==",
+		"comp:block",
+	}, "\n"), stringToSign(req, testAccountName))
+}
+
+func TestClient_SignURL(t *testing.T) {
+	assert := testifyassert.New(t)
+	client, err := New(testAccountName, testAccountKey, "http://127.0.0.1:10000/devstoreaccount1")
+	assert.Nil(err)
+
+	signURL, err := client.SignURL("container", "dir/blob", PermissionRead, time.Date(2023, 2, 24, 1, 0, 0, 0, time.FixedZone("CST", 8*3600)))
+	assert.Nil(err)
+
+	u, err := url.Parse(signURL)
+	assert.Nil(err)
+	assert.Equal("/devstoreaccount1/container/dir/blob", u.Path)
+
+	query := u.Query()
+	assert.Equal(Version, query.Get("sv"))
+	assert.Equal("b", query.Get("sr"))
+	assert.Equal("r", query.Get("sp"))
+	assert.Equal("2023-02-23T17:00:00Z", query.Get("se"))
+	assert.Equal(signature(client.accountKey,
+		"r\n\n2023-02-23T17:00:00Z\n/blob/devstoreaccount1/container/dir/blob\n\n\n\n"+Version+"\nb\n\n\n\n\n\n"), query.Get("sig"))
+
+	anonymous, err := New(testAccountName, "", "http://127.0.0.1:10000/devstoreaccount1")
+	assert.Nil(err)
+	_, err = anonymous.SignURL("container", "dir/blob", PermissionRead, time.Now())
+	assert.Error(err)
+}
+
+func TestClient(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctx := context.Background()
+	server := azblobtest.NewServer(testAccountName)
+	defer server.Close()
+
+	client, err := New(testAccountName, testAccountKey, server.Endpoint())
+	assert.Nil(err)
+
+	// Containers
+	assert.Nil(client.CreateContainer(ctx, "foo"))
+	assert.Nil(client.CreateContainer(ctx, "bar"))
+
+	var respErr *ResponseError
+	err = client.CreateContainer(ctx, "foo")
+	assert.True(errors.As(err, &respErr))
+	assert.Equal(http.StatusConflict, respErr.StatusCode)
+	assert.Equal("ContainerAlreadyExists", respErr.Code)
+
+	containers, err := client.ListContainers(ctx, "")
+	assert.Nil(err)
+	assert.Len(containers.Containers, 2)
+	assert.Equal("bar", containers.Containers[0].Name)
+	assert.False(containers.Containers[0].LastModified.IsZero())
+
+	properties, err := client.GetContainerProperties(ctx, "foo")
+	assert.Nil(err)
+	assert.Equal("foo", properties.Name)
+
+	_, err = client.GetContainerProperties(ctx, "baz")
+	assert.True(IsNotFound(err))
+
+	assert.Nil(client.DeleteContainer(ctx, "bar"))
+	assert.True(IsNotFound(client.DeleteContainer(ctx, "bar")))
+
+	// Blobs
+	assert.Nil(client.PutBlob(ctx, "foo", "dir/small", strings.NewReader("small blob"), map[string]string{"digest": "md5:foo"}))
+	large := bytes.Repeat([]byte("0123456789"), blockSize/10*2+1)
+	assert.Nil(client.PutBlob(ctx, "foo", "dir/sub/large", bytes.NewReader(large), map[string]string{"digest": "md5:bar"}))
+	assert.Nil(client.PutBlob(ctx, "foo", "empty", bytes.NewReader(nil), nil))
+
+	data, metadata, ok := server.Blob("foo", "dir/sub/large")
+	assert.True(ok)
+	assert.Equal(large, data)
+	assert.Equal(map[string]string{"digest": "md5:bar"}, metadata)
+
+	blob, err := client.GetBlobProperties(ctx, "foo", "dir/small")
+	assert.Nil(err)
+	assert.Equal("dir/small", blob.Name)
+	assert.Equal(int64(10), blob.ContentLength)
+	assert.Equal(map[string]string{"digest": "md5:foo"}, blob.Metadata)
+	assert.NotEmpty(blob.ETag)
+	assert.NotEmpty(blob.ContentMD5)
+
+	_, err = client.GetBlobProperties(ctx, "foo", "dir/unknown")
+	assert.True(IsNotFound(err))
+
+	resp, err := client.GetBlob(ctx, "foo", "dir/small", http.Header{"Range": {"bytes=6-9"}})
+	assert.Nil(err)
+	assert.Equal(http.StatusPartialContent, resp.StatusCode)
+	data, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(err)
+	assert.Equal("blob", string(data))
+
+	// List with delimiter in pages
+	var (
+		names    []string
+		prefixes []string
+		marker   string
+	)
+	for {
+		result, err := client.ListBlobs(ctx, "foo", ListBlobsOptions{Delimiter: "/", Marker: marker, MaxResults: 1})
+		assert.Nil(err)
+		for _, blob := range result.Blobs {
+			names = append(names, blob.Name)
+		}
+		prefixes = append(prefixes, result.BlobPrefixes...)
+
+		if result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+	assert.Equal([]string{"empty"}, names)
+	assert.Equal([]string{"dir/"}, prefixes)
+
+	result, err := client.ListBlobs(ctx, "foo", ListBlobsOptions{Prefix: "dir/"})
+	assert.Nil(err)
+	assert.Len(result.Blobs, 2)
+	assert.Equal("dir/small", result.Blobs[0].Name)
+	assert.Equal(int64(10), result.Blobs[0].ContentLength)
+	assert.Equal(map[string]string{"digest": "md5:foo"}, result.Blobs[0].Metadata)
+	assert.Equal(int64(len(large)), result.Blobs[1].ContentLength)
+
+	assert.Nil(client.DeleteBlob(ctx, "foo", "dir/small"))
+	assert.True(IsNotFound(client.DeleteBlob(ctx, "foo", "dir/small")))
+
+	// Anonymous requests are rejected by private container.
+	anonymous, err := New(testAccountName, "", server.Endpoint())
+	assert.Nil(err)
+	_, err = anonymous.GetBlobProperties(ctx, "foo", "empty")
+	assert.True(errors.As(err, &respErr))
+	assert.Equal(http.StatusForbidden, respErr.StatusCode)
+	assert.Equal("NoAuthenticationInformation", respErr.Code)
+}
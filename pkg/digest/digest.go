@@ -43,6 +43,9 @@ const (
 
 	// AlgorithmMD5 is md5 algorithm name of hash.
 	AlgorithmMD5 = "md5"
+
+	// AlgorithmMerkleSHA256 is the algorithm name of sha256 merkle tree root.
+	AlgorithmMerkleSHA256 = "merkle-sha256"
 )
 
 // Digest provides digest operation function.
@@ -151,3 +154,40 @@ func SHA256FromStrings(data ...string) string {
 
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// SHA256MerkleRootFromStrings computes the root of sha256 merkle tree with the strings as leaves,
+// the leaf and node hashes are prefixed differently to avoid second preimage attack,
+// and the last node of odd level is promoted to the upper level.
+func SHA256MerkleRootFromStrings(data ...string) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	nodes := make([][]byte, 0, len(data))
+	for _, s := range data {
+		h := sha256.New()
+		h.Write([]byte{0x00})
+		h.Write([]byte(s))
+		nodes = append(nodes, h.Sum(nil))
+	}
+
+	for len(nodes) > 1 {
+		parents := make([][]byte, 0, (len(nodes)+1)/2)
+		for i := 0; i < len(nodes); i += 2 {
+			if i+1 == len(nodes) {
+				parents = append(parents, nodes[i])
+				continue
+			}
+
+			h := sha256.New()
+			h.Write([]byte{0x01})
+			h.Write(nodes[i])
+			h.Write(nodes[i+1])
+			parents = append(parents, h.Sum(nil))
+		}
+
+		nodes = parents
+	}
+
+	return hex.EncodeToString(nodes[0])
+}
@@ -36,15 +36,17 @@ import (
 type Reader interface {
 	io.Reader
 	Encoded() string
+	Digest() string
 }
 
 // reader reads stream with RateLimiter.
 type reader struct {
-	r       io.Reader
-	hash    hash.Hash
-	digest  string
-	encoded string
-	logger  *logger.SugaredLoggerOnWith
+	r         io.Reader
+	hash      hash.Hash
+	algorithm string
+	digest    string
+	encoded   string
+	logger    *logger.SugaredLoggerOnWith
 }
 
 // Option is a functional option for digest reader.
@@ -64,12 +66,21 @@ func WithDigest(digest string) Option {
 	}
 }
 
+// WithAlgorithm sets the algorithm of hash when the digest is not set, default is md5.
+func WithAlgorithm(algorithm string) Option {
+	return func(reader *reader) {
+		if algorithm != "" {
+			reader.algorithm = algorithm
+		}
+	}
+}
+
 // TODO add AF_ALG digest https://github.com/golang/sys/commit/e24f485414aeafb646f6fca458b0bf869c0880a1
 func NewReader(r io.Reader, options ...Option) (io.Reader, error) {
 	reader := &reader{
-		r:      r,
-		hash:   md5.New(),
-		logger: &logger.SugaredLoggerOnWith{},
+		r:         r,
+		algorithm: AlgorithmMD5,
+		logger:    &logger.SugaredLoggerOnWith{},
 	}
 
 	for _, opt := range options {
@@ -82,24 +93,25 @@ func NewReader(r io.Reader, options ...Option) (io.Reader, error) {
 			return nil, errors.New("invalid digest")
 		}
 
-		var h hash.Hash
-		switch d.Algorithm {
-		case AlgorithmSHA1:
-			h = sha1.New()
-		case AlgorithmSHA256:
-			h = sha256.New()
-		case AlgorithmSHA512:
-			h = sha512.New()
-		case AlgorithmMD5:
-			h = md5.New()
-		default:
-			return nil, fmt.Errorf("unsupport digest method: %s", d.Algorithm)
-		}
-
+		reader.algorithm = d.Algorithm
 		reader.encoded = d.Encoded
-		reader.hash = h
 	}
 
+	var h hash.Hash
+	switch reader.algorithm {
+	case AlgorithmSHA1:
+		h = sha1.New()
+	case AlgorithmSHA256:
+		h = sha256.New()
+	case AlgorithmSHA512:
+		h = sha512.New()
+	case AlgorithmMD5:
+		h = md5.New()
+	default:
+		return nil, fmt.Errorf("unsupport digest method: %s", reader.algorithm)
+	}
+
+	reader.hash = h
 	return reader, nil
 }
 
@@ -131,3 +143,13 @@ func (r *reader) Read(p []byte) (int, error) {
 func (r *reader) Encoded() string {
 	return hex.EncodeToString(r.hash.Sum(nil))
 }
+
+// Digest returns the digest string of algorithm and encoded,
+// md5 digest returns the encoded only to be compatible with the legacy md5 digest.
+func (r *reader) Digest() string {
+	if r.algorithm == AlgorithmMD5 {
+		return r.Encoded()
+	}
+
+	return New(r.algorithm, r.Encoded()).String()
+}
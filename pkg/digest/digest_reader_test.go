@@ -98,3 +98,54 @@ func TestNewReader(t *testing.T) {
 		})
 	}
 }
+
+func TestNewReader_Algorithm(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		expect    func(t *testing.T, reader Reader, err error)
+	}{
+		{
+			name:      "md5 digest is encoded only",
+			algorithm: AlgorithmMD5,
+			expect: func(t *testing.T, reader Reader, err error) {
+				assert := testifyassert.New(t)
+				assert.NoError(err)
+				assert.Equal(reader.Digest(), "5eb63bbbe01eeed093cb22bb8f5acdc3")
+			},
+		},
+		{
+			name:      "sha256 digest",
+			algorithm: AlgorithmSHA256,
+			expect: func(t *testing.T, reader Reader, err error) {
+				assert := testifyassert.New(t)
+				assert.NoError(err)
+				assert.Equal(reader.Digest(), "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9")
+			},
+		},
+		{
+			name:      "unsupport algorithm",
+			algorithm: "foo",
+			expect: func(t *testing.T, reader Reader, err error) {
+				assert := testifyassert.New(t)
+				assert.EqualError(err, "unsupport digest method: foo")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewBufferString("hello world"), WithAlgorithm(tc.algorithm))
+			if err != nil {
+				tc.expect(t, nil, err)
+				return
+			}
+
+			if _, err := io.ReadAll(r); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.expect(t, r.(Reader), nil)
+		})
+	}
+}
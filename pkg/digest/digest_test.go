@@ -18,6 +18,7 @@ package digest
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"io/fs"
 	"os"
@@ -66,3 +67,47 @@ func TestHashFile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, encoded)
 }
+
+func TestSHA256MerkleRootFromStrings(t *testing.T) {
+	leaf := func(s string) []byte {
+		h := sha256.Sum256(append([]byte{0x00}, s...))
+		return h[:]
+	}
+	node := func(left, right []byte) []byte {
+		h := sha256.Sum256(append(append([]byte{0x01}, left...), right...))
+		return h[:]
+	}
+
+	tests := []struct {
+		name   string
+		data   []string
+		expect string
+	}{
+		{
+			name:   "empty strings",
+			data:   nil,
+			expect: "",
+		},
+		{
+			name:   "single string",
+			data:   []string{"foo"},
+			expect: hex.EncodeToString(leaf("foo")),
+		},
+		{
+			name:   "even strings",
+			data:   []string{"foo", "bar"},
+			expect: hex.EncodeToString(node(leaf("foo"), leaf("bar"))),
+		},
+		{
+			name:   "odd strings",
+			data:   []string{"foo", "bar", "baz"},
+			expect: hex.EncodeToString(node(node(leaf("foo"), leaf("bar")), leaf("baz"))),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, SHA256MerkleRootFromStrings(tc.data...))
+		})
+	}
+}
@@ -33,6 +33,20 @@ func (m *MockReader) EXPECT() *MockReaderMockRecorder {
 	return m.recorder
 }
 
+// Digest mocks base method.
+func (m *MockReader) Digest() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Digest")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Digest indicates an expected call of Digest.
+func (mr *MockReaderMockRecorder) Digest() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Digest", reflect.TypeOf((*MockReader)(nil).Digest))
+}
+
 // Encoded mocks base method.
 func (m *MockReader) Encoded() string {
 	m.ctrl.T.Helper()
@@ -23,6 +23,11 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// SeedPeerIDSuffix is the suffix of seed peer id.
+	SeedPeerIDSuffix = "_Seed"
+)
+
 // PeerID generates a peer id.
 func PeerID(ip string) string {
 	return fmt.Sprintf("%s-%d-%s", ip, os.Getpid(), uuid.New())
@@ -30,5 +35,5 @@ func PeerID(ip string) string {
 
 // SeedPeerID generates a seed peer id.
 func SeedPeerID(ip string) string {
-	return fmt.Sprintf("%s_%s", PeerID(ip), "Seed")
+	return fmt.Sprintf("%s%s", PeerID(ip), SeedPeerIDSuffix)
 }
@@ -27,7 +27,8 @@ import (
 )
 
 const (
-	filterSeparator = "&"
+	// FilterSeparator is filter separator for url.
+	FilterSeparator = "&"
 )
 
 // TaskID generates a task id.
@@ -86,5 +87,5 @@ func parseFilters(rawFilters string) []string {
 		return nil
 	}
 
-	return strings.Split(rawFilters, filterSeparator)
+	return strings.Split(rawFilters, FilterSeparator)
 }
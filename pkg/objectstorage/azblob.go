@@ -0,0 +1,221 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"d7y.io/dragonfly/v2/pkg/azblob"
+)
+
+// azblobListPageSize is the page size of listing blobs when skipping the blobs before marker.
+const azblobListPageSize = 1000
+
+type azureBlob struct {
+	// Azure Blob client.
+	client *azblob.Client
+}
+
+// New azure blob instance, the access key is storage account name and the secret key is account key.
+func newAzureBlob(region, endpoint, accessKey, secretKey string) (ObjectStorage, error) {
+	client, err := azblob.New(accessKey, secretKey, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("new azure blob client failed: %s", err)
+	}
+
+	return &azureBlob{
+		client: client,
+	}, nil
+}
+
+// GetBucketMetadata returns metadata of bucket.
+func (a *azureBlob) GetBucketMetadata(ctx context.Context, bucketName string) (*BucketMetadata, error) {
+	properties, err := a.client.GetContainerProperties(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BucketMetadata{
+		Name:     bucketName,
+		CreateAt: properties.LastModified,
+	}, nil
+}
+
+// CreateBucket creates bucket of object storage.
+func (a *azureBlob) CreateBucket(ctx context.Context, bucketName string) error {
+	return a.client.CreateContainer(ctx, bucketName)
+}
+
+// DeleteBucket deletes bucket of object storage.
+func (a *azureBlob) DeleteBucket(ctx context.Context, bucketName string) error {
+	return a.client.DeleteContainer(ctx, bucketName)
+}
+
+// ListBucketMetadatas list bucket meta data of object storage.
+func (a *azureBlob) ListBucketMetadatas(ctx context.Context) ([]*BucketMetadata, error) {
+	var (
+		metadatas []*BucketMetadata
+		marker    string
+	)
+	for {
+		resp, err := a.client.ListContainers(ctx, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, container := range resp.Containers {
+			metadatas = append(metadatas, &BucketMetadata{
+				Name:     container.Name,
+				CreateAt: container.LastModified,
+			})
+		}
+
+		if resp.NextMarker == "" {
+			return metadatas, nil
+		}
+		marker = resp.NextMarker
+	}
+}
+
+// GetObjectMetadata returns metadata of object.
+func (a *azureBlob) GetObjectMetadata(ctx context.Context, bucketName, objectKey string) (*ObjectMetadata, bool, error) {
+	properties, err := a.client.GetBlobProperties(ctx, bucketName, objectKey)
+	if err != nil {
+		if azblob.IsNotFound(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	return &ObjectMetadata{
+		Key:                objectKey,
+		ContentDisposition: properties.ContentDisposition,
+		ContentEncoding:    properties.ContentEncoding,
+		ContentLanguage:    properties.ContentLanguage,
+		ContentLength:      properties.ContentLength,
+		ContentType:        properties.ContentType,
+		ETag:               properties.ETag,
+		Digest:             properties.Metadata[MetaDigest],
+	}, true, nil
+}
+
+// GetOject returns data of object.
+func (a *azureBlob) GetOject(ctx context.Context, bucketName, objectKey string) (io.ReadCloser, error) {
+	resp, err := a.client.GetBlob(ctx, bucketName, objectKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// PutObject puts data of object.
+func (a *azureBlob) PutObject(ctx context.Context, bucketName, objectKey, digest string, reader io.Reader) error {
+	return a.client.PutBlob(ctx, bucketName, objectKey, reader, map[string]string{
+		MetaDigest: digest,
+	})
+}
+
+// DeleteObject deletes data of object.
+func (a *azureBlob) DeleteObject(ctx context.Context, bucketName, objectKey string) error {
+	return a.client.DeleteBlob(ctx, bucketName, objectKey)
+}
+
+// ListObjectMetadatas returns metadata of objects. The marker of Blob service is opaque,
+// so the blobs are listed from the beginning of prefix and the blobs before marker are skipped.
+func (a *azureBlob) ListObjectMetadatas(ctx context.Context, bucketName, prefix, marker string, limit int64) ([]*ObjectMetadata, error) {
+	var (
+		metadatas  []*ObjectMetadata
+		nextMarker string
+	)
+	for {
+		resp, err := a.client.ListBlobs(ctx, bucketName, azblob.ListBlobsOptions{
+			Prefix:     prefix,
+			Marker:     nextMarker,
+			MaxResults: azblobListPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blob := range resp.Blobs {
+			if blob.Name <= marker {
+				continue
+			}
+
+			if int64(len(metadatas)) >= limit {
+				return metadatas, nil
+			}
+
+			metadatas = append(metadatas, &ObjectMetadata{
+				Key:                blob.Name,
+				ContentDisposition: blob.ContentDisposition,
+				ContentEncoding:    blob.ContentEncoding,
+				ContentLanguage:    blob.ContentLanguage,
+				ContentLength:      blob.ContentLength,
+				ContentType:        blob.ContentType,
+				ETag:               blob.ETag,
+				Digest:             blob.Metadata[MetaDigest],
+			})
+		}
+
+		if resp.NextMarker == "" {
+			return metadatas, nil
+		}
+		nextMarker = resp.NextMarker
+	}
+}
+
+// IsObjectExist returns whether the object exists.
+func (a *azureBlob) IsObjectExist(ctx context.Context, bucketName, objectKey string) (bool, error) {
+	_, isExist, err := a.GetObjectMetadata(ctx, bucketName, objectKey)
+	return isExist, err
+}
+
+// IsBucketExist returns whether the bucket exists.
+func (a *azureBlob) IsBucketExist(ctx context.Context, bucketName string) (bool, error) {
+	if _, err := a.client.GetContainerProperties(ctx, bucketName); err != nil {
+		if azblob.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetSignURL returns sign url of object.
+func (a *azureBlob) GetSignURL(ctx context.Context, bucketName, objectKey string, method Method, expire time.Duration) (string, error) {
+	var permissions string
+	switch method {
+	case MethodGet, MethodHead:
+		permissions = azblob.PermissionRead
+	case MethodPut, MethodPost:
+		permissions = azblob.PermissionWrite
+	case MethodDelete:
+		permissions = azblob.PermissionDelete
+	default:
+		return "", fmt.Errorf("not support method %s", method)
+	}
+
+	return a.client.SignURL(bucketName, objectKey, permissions, time.Now().Add(expire))
+}
@@ -0,0 +1,126 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/azblob/azblobtest"
+)
+
+func TestAzureBlob(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctx := context.Background()
+	server := azblobtest.NewServer("devstoreaccount1")
+	defer server.Close()
+
+	client, err := New(ServiceNameAzureBlob, "", server.Endpoint(), "devstoreaccount1",
+		"Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==")
+	assert.Nil(err)
+
+	// Buckets
+	assert.Nil(client.CreateBucket(ctx, "foo"))
+	isExist, err := client.IsBucketExist(ctx, "foo")
+	assert.Nil(err)
+	assert.True(isExist)
+
+	isExist, err = client.IsBucketExist(ctx, "bar")
+	assert.Nil(err)
+	assert.False(isExist)
+
+	bucket, err := client.GetBucketMetadata(ctx, "foo")
+	assert.Nil(err)
+	assert.Equal("foo", bucket.Name)
+
+	buckets, err := client.ListBucketMetadatas(ctx)
+	assert.Nil(err)
+	assert.Len(buckets, 1)
+	assert.Equal("foo", buckets[0].Name)
+
+	// Objects
+	for i := 0; i < azblobListPageSize+2; i++ {
+		key := fmt.Sprintf("dir/%04d", i)
+		assert.Nil(client.PutObject(ctx, "foo", key, "md5:"+key, strings.NewReader("data of "+key)))
+	}
+
+	object, isExist, err := client.GetObjectMetadata(ctx, "foo", "dir/0001")
+	assert.Nil(err)
+	assert.True(isExist)
+	assert.Equal("dir/0001", object.Key)
+	assert.Equal(int64(len("data of dir/0001")), object.ContentLength)
+	assert.Equal("md5:dir/0001", object.Digest)
+	assert.NotEmpty(object.ETag)
+
+	_, isExist, err = client.GetObjectMetadata(ctx, "foo", "dir/x")
+	assert.Nil(err)
+	assert.False(isExist)
+
+	reader, err := client.GetOject(ctx, "foo", "dir/0001")
+	assert.Nil(err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	assert.Nil(err)
+	assert.Equal("data of dir/0001", string(data))
+
+	// The marker is after the first page of blob service.
+	objects, err := client.ListObjectMetadatas(ctx, "foo", "dir/", "dir/1000", 10)
+	assert.Nil(err)
+	assert.Len(objects, 1)
+	assert.Equal("dir/1001", objects[0].Key)
+	assert.Equal("md5:dir/1001", objects[0].Digest)
+
+	objects, err = client.ListObjectMetadatas(ctx, "foo", "dir/", "", 2)
+	assert.Nil(err)
+	assert.Len(objects, 2)
+	assert.Equal("dir/0000", objects[0].Key)
+
+	assert.Nil(client.DeleteObject(ctx, "foo", "dir/0000"))
+	isExist, err = client.IsObjectExist(ctx, "foo", "dir/0000")
+	assert.Nil(err)
+	assert.False(isExist)
+
+	// Sign url
+	signURL, err := client.GetSignURL(ctx, "foo", "dir/0001", MethodGet, time.Hour)
+	assert.Nil(err)
+	u, err := url.Parse(signURL)
+	assert.Nil(err)
+	assert.Equal("r", u.Query().Get("sp"))
+
+	resp, err := http.Get(signURL)
+	assert.Nil(err)
+	data, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(err)
+	assert.Equal("data of dir/0001", string(data))
+
+	_, err = client.GetSignURL(ctx, "foo", "dir/0001", MethodList, time.Hour)
+	assert.Error(err)
+
+	assert.Nil(client.DeleteBucket(ctx, "foo"))
+	isExist, err = client.IsBucketExist(ctx, "foo")
+	assert.Nil(err)
+	assert.False(isExist)
+}
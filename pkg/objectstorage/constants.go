@@ -25,6 +25,12 @@ const (
 
 	// ServiceNameOBS is name of obs storage.
 	ServiceNameOBS = "obs"
+
+	// ServiceNameGCS is name of google cloud storage.
+	ServiceNameGCS = "gcs"
+
+	// ServiceNameAzureBlob is name of azure blob storage.
+	ServiceNameAzureBlob = "azblob"
 )
 
 const (
@@ -0,0 +1,418 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+const (
+	// GCSDefaultEndpoint is the default endpoint of google cloud storage.
+	GCSDefaultEndpoint = "https://storage.googleapis.com"
+
+	// gcsJSONAPIPath is the base path of JSON API.
+	gcsJSONAPIPath = "/storage/v1/"
+
+	// gcsMaxPageSize is the max page size of listing objects.
+	gcsMaxPageSize = 1000
+
+	// gcsSignAlgorithm is the algorithm of V4 signature signed by service account.
+	gcsSignAlgorithm = "GOOG4-RSA-SHA256"
+
+	// gcsSignMaxExpires is the max expires of signed url.
+	gcsSignMaxExpires = 7 * 24 * time.Hour
+)
+
+type gcs struct {
+	// GCS client.
+	client *storage.Service
+
+	// projectID is the project of buckets.
+	projectID string
+
+	// region is the location of created buckets.
+	region string
+
+	// endpoint is the endpoint of XML API, which serves signed urls.
+	endpoint *url.URL
+
+	// signer signs urls, it's nil if the client is not authenticated.
+	signer *gcsSigner
+}
+
+// gcsSigner is the service account signing urls.
+type gcsSigner struct {
+	email      string
+	privateKey *rsa.PrivateKey
+}
+
+// New gcs instance, the access key is project id and the secret key is the json key of service account.
+// The client is not authenticated if the secret key is empty, it's used for emulators and public buckets.
+func newGCS(region, endpoint, accessKey, secretKey string) (ObjectStorage, error) {
+	if endpoint == "" {
+		endpoint = GCSDefaultEndpoint
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gcs endpoint %s: %s", endpoint, err)
+	}
+
+	opts := []option.ClientOption{option.WithEndpoint(u.String() + gcsJSONAPIPath)}
+	var signer *gcsSigner
+	if secretKey != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(secretKey)))
+		if signer, err = newGCSSigner([]byte(secretKey)); err != nil {
+			return nil, err
+		}
+	} else {
+		opts = append(opts, option.WithoutAuthentication())
+	}
+
+	client, err := storage.NewService(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("new gcs client failed: %s", err)
+	}
+
+	return &gcs{
+		client:    client,
+		projectID: accessKey,
+		region:    region,
+		endpoint:  u,
+		signer:    signer,
+	}, nil
+}
+
+// newGCSSigner returns the signer of service account in json key.
+func newGCSSigner(jsonKey []byte) (*gcsSigner, error) {
+	cfg, err := google.JWTConfigFromJSON(jsonKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account key: %s", err)
+	}
+
+	block, _ := pem.Decode(cfg.PrivateKey)
+	if block == nil {
+		return nil, errors.New("invalid private key of service account")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("invalid private key of service account: %s", err)
+		}
+	}
+
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key of service account is not rsa key")
+	}
+
+	return &gcsSigner{
+		email:      cfg.Email,
+		privateKey: privateKey,
+	}, nil
+}
+
+// GetBucketMetadata returns metadata of bucket.
+func (g *gcs) GetBucketMetadata(ctx context.Context, bucketName string) (*BucketMetadata, error) {
+	bucket, err := g.client.Buckets.Get(bucketName).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	return newGCSBucketMetadata(bucket), nil
+}
+
+// CreateBucket creates bucket of object storage.
+func (g *gcs) CreateBucket(ctx context.Context, bucketName string) error {
+	_, err := g.client.Buckets.Insert(g.projectID, &storage.Bucket{
+		Name:     bucketName,
+		Location: g.region,
+	}).Context(ctx).Do()
+	return err
+}
+
+// DeleteBucket deletes bucket of object storage.
+func (g *gcs) DeleteBucket(ctx context.Context, bucketName string) error {
+	return g.client.Buckets.Delete(bucketName).Context(ctx).Do()
+}
+
+// ListBucketMetadatas list bucket meta data of object storage.
+func (g *gcs) ListBucketMetadatas(ctx context.Context) ([]*BucketMetadata, error) {
+	var metadatas []*BucketMetadata
+	if err := g.client.Buckets.List(g.projectID).Pages(ctx, func(buckets *storage.Buckets) error {
+		for _, bucket := range buckets.Items {
+			metadatas = append(metadatas, newGCSBucketMetadata(bucket))
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return metadatas, nil
+}
+
+// GetObjectMetadata returns metadata of object.
+func (g *gcs) GetObjectMetadata(ctx context.Context, bucketName, objectKey string) (*ObjectMetadata, bool, error) {
+	object, err := g.client.Objects.Get(bucketName, objectKey).Context(ctx).Do()
+	if err != nil {
+		if isGCSNotFound(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	return newGCSObjectMetadata(object), true, nil
+}
+
+// GetOject returns data of object.
+func (g *gcs) GetOject(ctx context.Context, bucketName, objectKey string) (io.ReadCloser, error) {
+	resp, err := g.client.Objects.Get(bucketName, objectKey).Context(ctx).Download()
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// PutObject puts data of object.
+func (g *gcs) PutObject(ctx context.Context, bucketName, objectKey, digest string, reader io.Reader) error {
+	_, err := g.client.Objects.Insert(bucketName, &storage.Object{
+		Name: objectKey,
+		Metadata: map[string]string{
+			MetaDigest: digest,
+		},
+	}).Media(reader).Context(ctx).Do()
+	return err
+}
+
+// DeleteObject deletes data of object.
+func (g *gcs) DeleteObject(ctx context.Context, bucketName, objectKey string) error {
+	return g.client.Objects.Delete(bucketName, objectKey).Context(ctx).Do()
+}
+
+// ListObjectMetadatas returns metadata of objects. The listing starts from marker inclusively,
+// so the object of marker is skipped.
+func (g *gcs) ListObjectMetadatas(ctx context.Context, bucketName, prefix, marker string, limit int64) ([]*ObjectMetadata, error) {
+	var (
+		metadatas []*ObjectMetadata
+		pageToken string
+	)
+	for {
+		pageSize := limit - int64(len(metadatas)) + 1
+		if pageSize > gcsMaxPageSize {
+			pageSize = gcsMaxPageSize
+		}
+
+		call := g.client.Objects.List(bucketName).Prefix(prefix).MaxResults(pageSize).PageToken(pageToken)
+		if marker != "" {
+			call = call.StartOffset(marker)
+		}
+
+		objects, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects.Items {
+			if object.Name == marker {
+				continue
+			}
+
+			if int64(len(metadatas)) >= limit {
+				return metadatas, nil
+			}
+
+			metadatas = append(metadatas, newGCSObjectMetadata(object))
+		}
+
+		if objects.NextPageToken == "" {
+			return metadatas, nil
+		}
+		pageToken = objects.NextPageToken
+	}
+}
+
+// IsObjectExist returns whether the object exists.
+func (g *gcs) IsObjectExist(ctx context.Context, bucketName, objectKey string) (bool, error) {
+	_, isExist, err := g.GetObjectMetadata(ctx, bucketName, objectKey)
+	return isExist, err
+}
+
+// IsBucketExist returns whether the bucket exists.
+func (g *gcs) IsBucketExist(ctx context.Context, bucketName string) (bool, error) {
+	if _, err := g.client.Buckets.Get(bucketName).Context(ctx).Do(); err != nil {
+		if isGCSNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetSignURL returns sign url of object, the url is signed by V4 signing process of service account.
+// The url isn't signed if the client is not authenticated.
+func (g *gcs) GetSignURL(ctx context.Context, bucketName, objectKey string, method Method, expire time.Duration) (string, error) {
+	var httpMethod string
+	switch method {
+	case MethodGet:
+		httpMethod = http.MethodGet
+	case MethodPut:
+		httpMethod = http.MethodPut
+	case MethodHead:
+		httpMethod = http.MethodHead
+	case MethodDelete:
+		httpMethod = http.MethodDelete
+	default:
+		return "", fmt.Errorf("not support method %s", method)
+	}
+
+	u := *g.endpoint
+	path := u.Path + "/" + bucketName + "/" + objectKey
+	if g.signer == nil {
+		u.Path = path
+		return u.String(), nil
+	}
+
+	return g.signer.signURL(&u, path, httpMethod, time.Now(), expire)
+}
+
+// signURL returns the url signed by V4 signing process, refer to
+// https://cloud.google.com/storage/docs/access-control/signing-urls-manually.
+func (s *gcsSigner) signURL(u *url.URL, path, method string, now time.Time, expire time.Duration) (string, error) {
+	if expire <= 0 || expire > gcsSignMaxExpires {
+		return "", fmt.Errorf("expire must be between 1s and %s", gcsSignMaxExpires)
+	}
+
+	var (
+		timestamp = now.UTC().Format("20060102T150405Z")
+		scope     = timestamp[:8] + "/auto/storage/goog4_request"
+		query     = map[string]string{
+			"X-Goog-Algorithm":     gcsSignAlgorithm,
+			"X-Goog-Credential":    s.email + "/" + scope,
+			"X-Goog-Date":          timestamp,
+			"X-Goog-Expires":       strconv.FormatInt(int64(expire/time.Second), 10),
+			"X-Goog-SignedHeaders": "host",
+		}
+	)
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, gcsURIEncode(name, true)+"="+gcsURIEncode(query[name], true))
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+	canonicalPath := gcsURIEncode(path, false)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalPath,
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		gcsSignAlgorithm,
+		timestamp,
+		scope,
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	hashed := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s://%s%s?%s&X-Goog-Signature=%s", u.Scheme, u.Host, canonicalPath, canonicalQuery, hex.EncodeToString(signature)), nil
+}
+
+// gcsURIEncode percent-encodes the characters except unreserved characters of RFC 3986,
+// the slash isn't encoded in path.
+func gcsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func newGCSBucketMetadata(bucket *storage.Bucket) *BucketMetadata {
+	createAt, _ := time.Parse(time.RFC3339, bucket.TimeCreated)
+	return &BucketMetadata{
+		Name:     bucket.Name,
+		CreateAt: createAt,
+	}
+}
+
+func newGCSObjectMetadata(object *storage.Object) *ObjectMetadata {
+	return &ObjectMetadata{
+		Key:                object.Name,
+		ContentDisposition: object.ContentDisposition,
+		ContentEncoding:    object.ContentEncoding,
+		ContentLanguage:    object.ContentLanguage,
+		ContentLength:      int64(object.Size),
+		ContentType:        object.ContentType,
+		ETag:               object.Etag,
+		Digest:             object.Metadata[MetaDigest],
+	}
+}
+
+func isGCSNotFound(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && gerr.Code == http.StatusNotFound
+}
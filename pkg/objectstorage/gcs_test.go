@@ -0,0 +1,228 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package objectstorage
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/objectstorage/gcstest"
+)
+
+func TestGCS(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctx := context.Background()
+	server := gcstest.NewServer()
+	defer server.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(err)
+
+	tests := []struct {
+		name      string
+		secretKey func() string
+	}{
+		{
+			name: "without authentication",
+			secretKey: func() string {
+				return ""
+			},
+		},
+		{
+			name: "service account",
+			secretKey: func() string {
+				return newTestServiceAccountKey(t, privateKey, server.URL+"/token")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			client, err := New(ServiceNameGCS, "", server.URL, "project", tc.secretKey())
+			assert.Nil(err)
+
+			// Buckets
+			assert.Nil(client.CreateBucket(ctx, "foo"))
+			isExist, err := client.IsBucketExist(ctx, "foo")
+			assert.Nil(err)
+			assert.True(isExist)
+
+			isExist, err = client.IsBucketExist(ctx, "bar")
+			assert.Nil(err)
+			assert.False(isExist)
+
+			bucket, err := client.GetBucketMetadata(ctx, "foo")
+			assert.Nil(err)
+			assert.Equal("foo", bucket.Name)
+			assert.False(bucket.CreateAt.IsZero())
+
+			buckets, err := client.ListBucketMetadatas(ctx)
+			assert.Nil(err)
+			assert.Len(buckets, 1)
+			assert.Equal("foo", buckets[0].Name)
+
+			// Objects
+			for _, key := range []string{"a", "dir/b", "dir/c", "dir/d", "e"} {
+				assert.Nil(client.PutObject(ctx, "foo", key, "md5:"+key, strings.NewReader("data of "+key)))
+			}
+
+			object, isExist, err := client.GetObjectMetadata(ctx, "foo", "dir/b")
+			assert.Nil(err)
+			assert.True(isExist)
+			assert.Equal("dir/b", object.Key)
+			assert.Equal(int64(len("data of dir/b")), object.ContentLength)
+			assert.Equal("md5:dir/b", object.Digest)
+			assert.NotEmpty(object.ETag)
+
+			_, isExist, err = client.GetObjectMetadata(ctx, "foo", "dir/x")
+			assert.Nil(err)
+			assert.False(isExist)
+
+			reader, err := client.GetOject(ctx, "foo", "dir/b")
+			assert.Nil(err)
+			data, err := io.ReadAll(reader)
+			reader.Close()
+			assert.Nil(err)
+			assert.Equal("data of dir/b", string(data))
+
+			objects, err := client.ListObjectMetadatas(ctx, "foo", "dir/", "dir/b", 1)
+			assert.Nil(err)
+			assert.Len(objects, 1)
+			assert.Equal("dir/c", objects[0].Key)
+			assert.Equal("md5:dir/c", objects[0].Digest)
+
+			objects, err = client.ListObjectMetadatas(ctx, "foo", "", "", 1000)
+			assert.Nil(err)
+			assert.Len(objects, 5)
+
+			assert.Nil(client.DeleteObject(ctx, "foo", "dir/b"))
+			isExist, err = client.IsObjectExist(ctx, "foo", "dir/b")
+			assert.Nil(err)
+			assert.False(isExist)
+
+			// Sign url
+			signURL, err := client.GetSignURL(ctx, "foo", "dir/c", MethodGet, time.Hour)
+			assert.Nil(err)
+			resp, err := http.Get(signURL)
+			assert.Nil(err)
+			data, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Nil(err)
+			assert.Equal("data of dir/c", string(data))
+
+			_, err = client.GetSignURL(ctx, "foo", "dir/c", MethodList, time.Hour)
+			assert.Error(err)
+
+			assert.Nil(client.DeleteBucket(ctx, "foo"))
+		})
+	}
+}
+
+func TestGCSSigner_SignURL(t *testing.T) {
+	assert := testifyassert.New(t)
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(err)
+
+	signer, err := newGCSSigner([]byte(newTestServiceAccountKey(t, privateKey, "https://oauth2.googleapis.com/token")))
+	assert.Nil(err)
+	assert.Equal("dragonfly@project.iam.gserviceaccount.com", signer.email)
+
+	endpoint, err := url.Parse(GCSDefaultEndpoint)
+	assert.Nil(err)
+
+	signURL, err := signer.signURL(endpoint, "/bucket/dir/object name.txt", http.MethodGet, time.Date(2023, 2, 24, 8, 0, 0, 0, time.UTC), time.Hour)
+	assert.Nil(err)
+
+	u, err := url.Parse(signURL)
+	assert.Nil(err)
+	assert.Equal("storage.googleapis.com", u.Host)
+	assert.Equal("/bucket/dir/object%20name.txt", u.EscapedPath())
+
+	query := u.Query()
+	assert.Equal("GOOG4-RSA-SHA256", query.Get("X-Goog-Algorithm"))
+	assert.Equal("dragonfly@project.iam.gserviceaccount.com/20230224/auto/storage/goog4_request", query.Get("X-Goog-Credential"))
+	assert.Equal("20230224T080000Z", query.Get("X-Goog-Date"))
+	assert.Equal("3600", query.Get("X-Goog-Expires"))
+	assert.Equal("host", query.Get("X-Goog-SignedHeaders"))
+
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/bucket/dir/object%20name.txt",
+		"X-Goog-Algorithm=GOOG4-RSA-SHA256" +
+			"&X-Goog-Credential=dragonfly%40project.iam.gserviceaccount.com%2F20230224%2Fauto%2Fstorage%2Fgoog4_request" +
+			"&X-Goog-Date=20230224T080000Z&X-Goog-Expires=3600&X-Goog-SignedHeaders=host",
+		"host:storage.googleapis.com",
+		"",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	hashed := sha256.Sum256([]byte(fmt.Sprintf("GOOG4-RSA-SHA256\n20230224T080000Z\n20230224/auto/storage/goog4_request\n%x", hashedRequest)))
+
+	signature, err := hex.DecodeString(query.Get("X-Goog-Signature"))
+	assert.Nil(err)
+	assert.Nil(rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hashed[:], signature))
+
+	_, err = signer.signURL(endpoint, "/bucket/object", http.MethodGet, time.Now(), 8*24*time.Hour)
+	assert.Error(err)
+}
+
+func TestNewGCS_InvalidServiceAccountKey(t *testing.T) {
+	assert := testifyassert.New(t)
+	_, err := New(ServiceNameGCS, "", "", "project", "{}")
+	assert.Error(err)
+}
+
+// newTestServiceAccountKey returns the json key of service account with private key.
+func newTestServiceAccountKey(t *testing.T, privateKey *rsa.PrivateKey, tokenURI string) string {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "project",
+		"private_key_id": "key",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "dragonfly@project.iam.gserviceaccount.com",
+		"client_id":      "1",
+		"token_uri":      tokenURI,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(key)
+}
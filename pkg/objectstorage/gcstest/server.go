@@ -0,0 +1,464 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gcstest provides an in-memory Google Cloud Storage for testing.
+package gcstest
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an in-memory Google Cloud Storage like fake-gcs-server, it serves the subset of JSON API
+// used by dragonfly and the object downloading of XML API. The requests are not authenticated,
+// and the token endpoint /token issues access tokens to any service account.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	timeCreated time.Time
+	objects     map[string]*object
+}
+
+type object struct {
+	data        []byte
+	contentType string
+	metadata    map[string]string
+	generation  int64
+	updated     time.Time
+}
+
+// NewServer starts and returns a new Server, the caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		buckets: map[string]*bucket{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// PutObject stores the object in the bucket, the bucket is created if it doesn't exist.
+func (s *Server) PutObject(bucketName, objectName string, data []byte, metadata map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		b = newBucket()
+		s.buckets[bucketName] = b
+	}
+	b.objects[objectName] = newObject(data, "", metadata)
+}
+
+// Object returns the data and metadata of object.
+func (s *Server) Object(bucketName, objectName string) ([]byte, map[string]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, nil, false
+	}
+
+	o, ok := b.objects[objectName]
+	if !ok {
+		return nil, nil, false
+	}
+	return o.data, o.metadata, true
+}
+
+func newBucket() *bucket {
+	return &bucket{
+		timeCreated: time.Now(),
+		objects:     map[string]*object{},
+	}
+}
+
+func newObject(data []byte, contentType string, metadata map[string]string) *object {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &object{
+		data:        data,
+		contentType: contentType,
+		metadata:    metadata,
+		generation:  time.Now().UnixNano(),
+		updated:     time.Now(),
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/") {
+		value, err := url.PathUnescape(segment)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		segments = append(segments, value)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/token":
+		writeJSON(w, http.StatusOK, map[string]any{"access_token": "token", "token_type": "Bearer", "expires_in": 3600})
+	case len(segments) == 3 && segments[0] == "storage" && segments[2] == "b":
+		s.serveBuckets(w, r)
+	case len(segments) == 4 && segments[0] == "storage" && segments[2] == "b":
+		s.serveBucket(w, r, segments[3])
+	case len(segments) == 5 && segments[0] == "storage" && segments[4] == "o":
+		s.listObjects(w, r, segments[3])
+	case len(segments) == 6 && segments[0] == "storage" && segments[4] == "o":
+		s.serveObject(w, r, segments[3], segments[5], r.URL.Query().Get("alt") == "media")
+	case len(segments) == 6 && segments[0] == "upload" && segments[5] == "o":
+		s.uploadObject(w, r, segments[4])
+	case len(segments) >= 2 && segments[0] != "storage" && segments[0] != "upload":
+		// The object url of XML API.
+		s.serveObject(w, r, segments[0], strings.Join(segments[1:], "/"), true)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (s *Server) serveBuckets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		names := make([]string, 0, len(s.buckets))
+		for name := range s.buckets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		items := make([]map[string]any, 0, len(names))
+		for _, name := range names {
+			items = append(items, bucketResource(name, s.buckets[name]))
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"kind": "storage#buckets", "items": items})
+	case http.MethodPost:
+		var resource struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&resource); err != nil || resource.Name == "" {
+			writeError(w, http.StatusBadRequest, "Invalid bucket name")
+			return
+		}
+
+		if _, ok := s.buckets[resource.Name]; ok {
+			writeError(w, http.StatusConflict, "The requested bucket name is not available.")
+			return
+		}
+
+		s.buckets[resource.Name] = newBucket()
+		writeJSON(w, http.StatusOK, bucketResource(resource.Name, s.buckets[resource.Name]))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "The specified bucket does not exist.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, bucketResource(bucketName, b))
+	case http.MethodDelete:
+		delete(s.buckets, bucketName)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "The specified bucket does not exist.")
+		return
+	}
+
+	var (
+		query       = r.URL.Query()
+		prefix      = query.Get("prefix")
+		delimiter   = query.Get("delimiter")
+		startOffset = query.Get("startOffset")
+		pageToken   = query.Get("pageToken")
+	)
+
+	maxResults := 1000
+	if value := query.Get("maxResults"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "Invalid maxResults")
+			return
+		}
+
+		if n < maxResults {
+			maxResults = n
+		}
+	}
+
+	names := make([]string, 0, len(b.objects))
+	for name := range b.objects {
+		if strings.HasPrefix(name, prefix) && name >= startOffset && name >= pageToken {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var (
+		items         = []map[string]any{}
+		prefixes      = []string{}
+		nextPageToken string
+		lastPrefix    string
+	)
+	for _, name := range names {
+		var commonPrefix string
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				commonPrefix = name[:len(prefix)+i+len(delimiter)]
+			}
+		}
+
+		if commonPrefix != "" && commonPrefix == lastPrefix {
+			continue
+		}
+
+		if len(items)+len(prefixes) == maxResults {
+			nextPageToken = name
+			break
+		}
+
+		if commonPrefix != "" {
+			prefixes = append(prefixes, commonPrefix)
+			lastPrefix = commonPrefix
+			continue
+		}
+
+		items = append(items, objectResource(bucketName, name, b.objects[name]))
+	}
+
+	resp := map[string]any{"kind": "storage#objects", "items": items, "prefixes": prefixes}
+	if nextPageToken != "" {
+		resp["nextPageToken"] = nextPageToken
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucketName, objectName string, media bool) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "The specified bucket does not exist.")
+		return
+	}
+
+	o, ok := b.objects[objectName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "No such object: "+bucketName+"/"+objectName)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodDelete && !media:
+		delete(b.objects, objectName)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && !media:
+		writeJSON(w, http.StatusOK, objectResource(bucketName, objectName, o))
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		w.Header().Set("Content-Type", o.contentType)
+		w.Header().Set("ETag", etag(o))
+		w.Header().Set("Last-Modified", o.updated.UTC().Format(http.TimeFormat))
+		w.Header().Set("Accept-Ranges", "bytes")
+		for key, value := range o.metadata {
+			w.Header().Set("X-Goog-Meta-"+key, value)
+		}
+
+		data, statusCode := o.data, http.StatusOK
+		if value := r.Header.Get("Range"); value != "" {
+			start, end, ok := parseRange(value, int64(len(o.data)))
+			if !ok {
+				writeError(w, http.StatusRequestedRangeNotSatisfiable, "The requested range cannot be satisfied.")
+				return
+			}
+
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(o.data)))
+			data, statusCode = o.data[start:end+1], http.StatusPartialContent
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(statusCode)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (s *Server) uploadObject(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "The specified bucket does not exist.")
+		return
+	}
+
+	if r.Method != http.MethodPost || r.URL.Query().Get("uploadType") != "multipart" {
+		writeError(w, http.StatusBadRequest, "Only multipart upload is supported")
+		return
+	}
+
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	part, err := reader.NextPart()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var resource struct {
+		Name        string            `json:"name"`
+		ContentType string            `json:"contentType"`
+		Metadata    map[string]string `json:"metadata"`
+	}
+	if err := json.NewDecoder(part).Decode(&resource); err != nil || resource.Name == "" {
+		writeError(w, http.StatusBadRequest, "Invalid object resource")
+		return
+	}
+
+	part, err = reader.NextPart()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := io.ReadAll(part)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	contentType := resource.ContentType
+	if contentType == "" {
+		contentType = part.Header.Get("Content-Type")
+	}
+
+	b.objects[resource.Name] = newObject(data, contentType, resource.Metadata)
+	writeJSON(w, http.StatusOK, objectResource(bucketName, resource.Name, b.objects[resource.Name]))
+}
+
+func bucketResource(name string, b *bucket) map[string]any {
+	return map[string]any{
+		"kind":        "storage#bucket",
+		"id":          name,
+		"name":        name,
+		"timeCreated": b.timeCreated.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func objectResource(bucketName, name string, o *object) map[string]any {
+	sum := md5.Sum(o.data)
+	return map[string]any{
+		"kind":        "storage#object",
+		"id":          bucketName + "/" + name + "/" + strconv.FormatInt(o.generation, 10),
+		"bucket":      bucketName,
+		"name":        name,
+		"size":        strconv.Itoa(len(o.data)),
+		"contentType": o.contentType,
+		"md5Hash":     base64.StdEncoding.EncodeToString(sum[:]),
+		"etag":        etag(o),
+		"generation":  strconv.FormatInt(o.generation, 10),
+		"metadata":    o.metadata,
+		"updated":     o.updated.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func etag(o *object) string {
+	return base64.StdEncoding.EncodeToString([]byte(strconv.FormatInt(o.generation, 16)))
+}
+
+// parseRange parses the range of bytes=start-end or bytes=start-.
+func parseRange(value string, size int64) (int64, int64, bool) {
+	if !strings.HasPrefix(value, "bytes=") {
+		return 0, 0, false
+	}
+
+	first, last, ok := strings.Cut(strings.TrimPrefix(value, "bytes="), "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end, true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]any{
+		"error": map[string]any{
+			"code":    statusCode,
+			"message": message,
+		},
+	})
+}
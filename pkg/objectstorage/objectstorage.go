@@ -102,7 +102,7 @@ type ObjectStorage interface {
 
 // objectStorage provides object storage.
 type objectStorage struct {
-	// name is object storage name of type, it can be s3, oss or obs.
+	// name is object storage name of type, it can be s3, oss, obs, gcs or azblob.
 	name string
 
 	// region is storage region.
@@ -153,6 +153,10 @@ func New(name, region, endpoint, accessKey, secretKey string, options ...Option)
 		return newOSS(o.region, o.endpoint, o.accessKey, o.secretKey)
 	case ServiceNameOBS:
 		return newOBS(o.region, o.endpoint, o.accessKey, o.secretKey)
+	case ServiceNameGCS:
+		return newGCS(o.region, o.endpoint, o.accessKey, o.secretKey)
+	case ServiceNameAzureBlob:
+		return newAzureBlob(o.region, o.endpoint, o.accessKey, o.secretKey)
 	}
 
 	return nil, fmt.Errorf("unknow service name %s", name)
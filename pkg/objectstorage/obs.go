@@ -173,8 +173,9 @@ func (o *obs) ListObjectMetadatas(ctx context.Context, bucketName, prefix, marke
 	var metadatas []*ObjectMetadata
 	for _, object := range resp.Contents {
 		metadatas = append(metadatas, &ObjectMetadata{
-			Key:  object.Key,
-			ETag: object.ETag,
+			Key:           object.Key,
+			ContentLength: object.Size,
+			ETag:          object.ETag,
 		})
 	}
 
@@ -167,8 +167,9 @@ func (o *oss) ListObjectMetadatas(ctx context.Context, bucketName, prefix, marke
 	var metadatas []*ObjectMetadata
 	for _, object := range resp.Objects {
 		metadatas = append(metadatas, &ObjectMetadata{
-			Key:  object.Key,
-			ETag: object.ETag,
+			Key:           object.Key,
+			ContentLength: object.Size,
+			ETag:          object.ETag,
 		})
 	}
 
@@ -170,8 +170,9 @@ func (s *s3) ListObjectMetadatas(ctx context.Context, bucketName, prefix, marker
 	var metadatas []*ObjectMetadata
 	for _, object := range resp.Contents {
 		metadatas = append(metadatas, &ObjectMetadata{
-			Key:  aws.StringValue(object.Key),
-			ETag: aws.StringValue(object.ETag),
+			Key:           aws.StringValue(object.Key),
+			ContentLength: aws.Int64Value(object.Size),
+			ETag:          aws.StringValue(object.ETag),
 		})
 	}
 
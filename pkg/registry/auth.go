@@ -0,0 +1,248 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/distribution/v3/registry/client/auth/challenge"
+	"github.com/go-http-utils/headers"
+)
+
+const (
+	// defaultTokenExpiration is the expiration of token when the token server doesn't return expires_in.
+	defaultTokenExpiration = 60 * time.Second
+
+	// tokenExpirationMargin is the margin to refresh the token before it expires.
+	tokenExpirationMargin = 10 * time.Second
+
+	// clientID is the client id sent to the token server.
+	clientID = "dragonfly"
+)
+
+// authorization is the cached authorization header.
+type authorization struct {
+	value string
+	// expireAt is zero for the authorization never expires, like basic authorization
+	expireAt time.Time
+}
+
+// authorizer answers the WWW-Authenticate challenges of registry with the credentials,
+// the bearer token is cached by the host and the repository.
+type authorizer struct {
+	httpClient  *http.Client
+	credentials CredentialStore
+
+	mu             sync.RWMutex
+	authorizations map[string]authorization
+}
+
+func newAuthorizer(httpClient *http.Client, credentials CredentialStore) *authorizer {
+	return &authorizer{
+		httpClient:     httpClient,
+		credentials:    credentials,
+		authorizations: map[string]authorization{},
+	}
+}
+
+// do sends the request with the cached authorization, it authorizes and retries once when the response is unauthorized.
+func (a *authorizer) do(req *http.Request, repository string) (*http.Response, error) {
+	host := req.URL.Host
+	if auth := a.cachedAuthorization(host, repository); auth != "" {
+		req.Header.Set(headers.Authorization, auth)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenges := challenge.ResponseChallenges(resp)
+	resp.Body.Close()
+	if len(challenges) == 0 {
+		return nil, fmt.Errorf("registry %s responds unauthorized without challenge", host)
+	}
+
+	auth, err := a.authorize(req.Context(), host, repository, challenges)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Header.Set(headers.Authorization, auth.value)
+	return a.httpClient.Do(retry)
+}
+
+// cachedAuthorization returns the unexpired authorization of the repository.
+func (a *authorizer) cachedAuthorization(host, repository string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	auth, ok := a.authorizations[authorizationKey(host, repository)]
+	if !ok {
+		return ""
+	}
+
+	if !auth.expireAt.IsZero() && time.Now().After(auth.expireAt) {
+		return ""
+	}
+	return auth.value
+}
+
+// authorize answers the first supported challenge and caches the authorization.
+func (a *authorizer) authorize(ctx context.Context, host, repository string, challenges []challenge.Challenge) (authorization, error) {
+	credential, err := a.credentials.Get(ctx, host)
+	if err != nil {
+		return authorization{}, err
+	}
+
+	var auth authorization
+	for _, c := range challenges {
+		switch strings.ToLower(c.Scheme) {
+		case "bearer":
+			scope := fmt.Sprintf("repository:%s:pull", repository)
+			auth, err = a.fetchToken(ctx, c.Parameters["realm"], c.Parameters["service"], scope, credential)
+		case "basic":
+			if credential.Username == "" && credential.Password == "" {
+				return authorization{}, fmt.Errorf("registry %s requires basic authorization, but no credential", host)
+			}
+
+			auth = authorization{
+				value: "Basic " + basicAuth(credential.Username, credential.Password),
+			}
+		default:
+			continue
+		}
+
+		if err != nil {
+			return authorization{}, err
+		}
+
+		a.mu.Lock()
+		a.authorizations[authorizationKey(host, repository)] = auth
+		a.mu.Unlock()
+		return auth, nil
+	}
+	return authorization{}, fmt.Errorf("registry %s responds unsupported challenges", host)
+}
+
+// tokenResponse is the response of token server, the access_token is used by OAuth2 token server.
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// fetchToken fetches the bearer token from the token server, the identity token is exchanged by OAuth2 refresh token grant,
+// otherwise the token is fetched with basic authorization of credential, or anonymously without credential.
+func (a *authorizer) fetchToken(ctx context.Context, realm, service, scope string, credential Credential) (authorization, error) {
+	if realm == "" {
+		return authorization{}, errors.New("bearer challenge without realm")
+	}
+
+	var (
+		req *http.Request
+		err error
+	)
+	if credential.IdentityToken != "" {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", credential.IdentityToken)
+		form.Set("service", service)
+		form.Set("scope", scope)
+		form.Set("client_id", clientID)
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, realm, strings.NewReader(form.Encode()))
+		if err != nil {
+			return authorization{}, err
+		}
+		req.Header.Set(headers.ContentType, "application/x-www-form-urlencoded")
+	} else {
+		u, err := url.Parse(realm)
+		if err != nil {
+			return authorization{}, fmt.Errorf("invalid realm %q: %w", realm, err)
+		}
+
+		query := u.Query()
+		if service != "" {
+			query.Set("service", service)
+		}
+		query.Set("scope", scope)
+		u.RawQuery = query.Encode()
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return authorization{}, err
+		}
+
+		if credential.Username != "" || credential.Password != "" {
+			req.SetBasicAuth(credential.Username, credential.Password)
+		}
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return authorization{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return authorization{}, fmt.Errorf("fetch token from %s error: %w", realm, newResponseError(resp))
+	}
+
+	var result tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return authorization{}, fmt.Errorf("parse token from %s error: %w", realm, err)
+	}
+
+	token := result.Token
+	if token == "" {
+		token = result.AccessToken
+	}
+
+	if token == "" {
+		return authorization{}, fmt.Errorf("token from %s is empty", realm)
+	}
+
+	expiration := defaultTokenExpiration
+	if result.ExpiresIn > 0 {
+		expiration = time.Duration(result.ExpiresIn) * time.Second
+	}
+
+	return authorization{
+		value:    "Bearer " + token,
+		expireAt: time.Now().Add(expiration - tokenExpirationMargin),
+	}, nil
+}
+
+func authorizationKey(host, repository string) string {
+	return host + "/" + repository
+}
+
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
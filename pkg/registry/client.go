@@ -0,0 +1,352 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/ocischema"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/go-http-utils/headers"
+)
+
+const (
+	// MediaTypeDockerManifest is the media type of docker image manifest.
+	MediaTypeDockerManifest = schema2.MediaTypeManifest
+
+	// MediaTypeDockerManifestList is the media type of docker manifest list.
+	MediaTypeDockerManifestList = manifestlist.MediaTypeManifestList
+
+	// MediaTypeOCIManifest is the media type of oci image manifest.
+	MediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+
+	// MediaTypeOCIIndex is the media type of oci image index.
+	MediaTypeOCIIndex = "application/vnd.oci.image.index.v1+json"
+
+	// DockerContentDigestHeader is the header of manifest digest in registry response.
+	DockerContentDigestHeader = "Docker-Content-Digest"
+
+	// maxManifestSize is the max size of manifest read from registry.
+	maxManifestSize = 4 << 20
+)
+
+// manifestMediaTypes is the accepted media types of manifest.
+var manifestMediaTypes = []string{
+	MediaTypeOCIIndex,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeDockerManifest,
+}
+
+// Manifest is the image manifest resolved from registry.
+type Manifest struct {
+	// Digest is the digest of manifest.
+	Digest string
+
+	// MediaType is the media type of manifest.
+	MediaType string
+
+	// Platform is the platform of manifest in image index, it's nil when the reference is a manifest.
+	Platform *Platform
+
+	// Config is the config of manifest.
+	Config distribution.Descriptor
+
+	// Layers is the layers of manifest, the config is not included.
+	Layers []distribution.Descriptor
+}
+
+// Client is the client of OCI distribution registry.
+type Client struct {
+	httpClient  *http.Client
+	credentials CredentialStore
+	header      http.Header
+	authorizer  *authorizer
+}
+
+// ClientOption is the option of Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the http client, the TLS verification is configured by the transport of http client.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithCredentialStore sets the credential store of registries.
+func WithCredentialStore(credentials CredentialStore) ClientOption {
+	return func(c *Client) {
+		c.credentials = credentials
+	}
+}
+
+// WithHeader sets the header sent with registry requests, the header set by the client takes precedence.
+// The authorization in header is used until the registry responds unauthorized.
+func WithHeader(header http.Header) ClientOption {
+	return func(c *Client) {
+		c.header = header
+	}
+}
+
+// NewClient returns a registry client, it accesses registries anonymously by default.
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		httpClient:  http.DefaultClient,
+		credentials: NewStaticCredentialStore("", ""),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.authorizer = newAuthorizer(c.httpClient, c.credentials)
+	return c
+}
+
+// Do sends the request of the repository to registry, it answers the WWW-Authenticate challenge of
+// the unauthorized response and retries the request with the authorization.
+func (c *Client) Do(req *http.Request, repository string) (*http.Response, error) {
+	for key, values := range c.header {
+		if req.Header.Get(key) != "" {
+			continue
+		}
+
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	return c.authorizer.do(req, repository)
+}
+
+// Authorization returns the cached authorization header of the repository,
+// it returns empty string before the repository is accessed or when the registry doesn't require authorization.
+func (c *Client) Authorization(host, repository string) string {
+	return c.authorizer.cachedAuthorization(host, repository)
+}
+
+// GetManifest fetches the manifest of reference, it returns the media type, the content and the digest of manifest.
+func (c *Client) GetManifest(ctx context.Context, ref *Reference) (string, []byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.ManifestURL(), nil)
+	if err != nil {
+		return "", nil, "", err
+	}
+	req.Header.Set(headers.Accept, strings.Join(manifestMediaTypes, ", "))
+
+	resp, err := c.Do(req, ref.Repository)
+	if err != nil {
+		return "", nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, "", newResponseError(resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	mediaType, err := manifestMediaType(resp.Header.Get(headers.ContentType), body)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	digest := resp.Header.Get(DockerContentDigestHeader)
+	if digest == "" && ref.IsDigest() {
+		digest = ref.Reference
+	}
+	return mediaType, body, digest, nil
+}
+
+// ResolveManifests resolves the image manifests of reference. The image index or manifest list is resolved to
+// the manifests of platforms, all manifests of the index are returned when platforms is empty.
+func (c *Client) ResolveManifests(ctx context.Context, ref *Reference, platforms []Platform) ([]Manifest, error) {
+	mediaType, body, digest, err := c.GetManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	m, desc, err := distribution.UnmarshalManifest(mediaType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if digest == "" {
+		digest = desc.Digest.String()
+	}
+
+	index, ok := m.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		config, layers, err := manifestContent(m)
+		if err != nil {
+			return nil, err
+		}
+		return []Manifest{{Digest: digest, MediaType: mediaType, Config: config, Layers: layers}}, nil
+	}
+
+	var manifests []Manifest
+	for _, desc := range selectManifests(index.Manifests, platforms) {
+		subRef := &Reference{
+			Scheme:     ref.Scheme,
+			Host:       ref.Host,
+			Repository: ref.Repository,
+			Reference:  desc.Digest.String(),
+		}
+
+		subMediaType, body, _, err := c.GetManifest(ctx, subRef)
+		if err != nil {
+			return nil, err
+		}
+
+		m, _, err := distribution.UnmarshalManifest(subMediaType, body)
+		if err != nil {
+			return nil, err
+		}
+
+		config, layers, err := manifestContent(m)
+		if err != nil {
+			return nil, err
+		}
+
+		manifests = append(manifests, Manifest{
+			Digest:    desc.Digest.String(),
+			MediaType: subMediaType,
+			Platform: &Platform{
+				OS:           desc.Platform.OS,
+				Architecture: desc.Platform.Architecture,
+				Variant:      desc.Platform.Variant,
+			},
+			Config: config,
+			Layers: layers,
+		})
+	}
+
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no manifest of platforms %v in %s", platforms, ref)
+	}
+	return manifests, nil
+}
+
+// GetBlob fetches the blob of the repository, the rangeHeader is the value of Range header
+// and the whole blob is fetched when it's empty. The caller must close the body of response.
+func (c *Client) GetBlob(ctx context.Context, ref *Reference, digest string, rangeHeader string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.BlobURL(digest), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if rangeHeader != "" {
+		req.Header.Set(headers.Range, rangeHeader)
+	}
+	return c.Do(req, ref.Repository)
+}
+
+// selectManifests selects the manifests of platforms in the index, the manifests are deduplicated
+// and keep the order of index.
+func selectManifests(descs []manifestlist.ManifestDescriptor, platforms []Platform) []manifestlist.ManifestDescriptor {
+	if len(platforms) == 0 {
+		return descs
+	}
+
+	var selected []manifestlist.ManifestDescriptor
+	for _, desc := range descs {
+		for _, p := range platforms {
+			if p.Match(desc.Platform.OS, desc.Platform.Architecture, desc.Platform.Variant) {
+				selected = append(selected, desc)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// manifestContent returns the config and the layers of the image manifest.
+func manifestContent(m distribution.Manifest) (distribution.Descriptor, []distribution.Descriptor, error) {
+	switch m := m.(type) {
+	case *schema2.DeserializedManifest:
+		return m.Config, m.Layers, nil
+	case *ocischema.DeserializedManifest:
+		return m.Config, m.Layers, nil
+	case *manifestlist.DeserializedManifestList:
+		return distribution.Descriptor{}, nil, errors.New("nested image index is not supported")
+	default:
+		return distribution.Descriptor{}, nil, fmt.Errorf("unsupported manifest %T", m)
+	}
+}
+
+// manifestMediaType returns the media type of manifest, the media type field of manifest is used when
+// the registry responds a generic content type.
+func manifestMediaType(contentType string, body []byte) (string, error) {
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return "", err
+		}
+
+		for _, t := range manifestMediaTypes {
+			if t == mediaType {
+				return mediaType, nil
+			}
+		}
+	}
+
+	var versioned struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(body, &versioned); err != nil {
+		return "", fmt.Errorf("parse manifest error: %w", err)
+	}
+
+	if versioned.MediaType == "" {
+		return "", fmt.Errorf("unknown media type of manifest, content type is %q", contentType)
+	}
+	return versioned.MediaType, nil
+}
+
+// ResponseError is the error of unexpected registry response.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func newResponseError(resp *http.Response) *ResponseError {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &ResponseError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Message:    strings.TrimSpace(string(message)),
+	}
+}
+
+func (e *ResponseError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status code from registry: %s", e.Status)
+	}
+	return fmt.Sprintf("unexpected status code from registry: %s: %s", e.Status, e.Message)
+}
@@ -0,0 +1,268 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+const (
+	testRepository = "library/app"
+	testUsername   = "user"
+	testPassword   = "pass"
+	testToken      = "token"
+)
+
+// testRegistry is a fake registry serving an image index of linux/amd64 and linux/arm64,
+// the manifests and blobs require the bearer token issued to the test credential.
+type testRegistry struct {
+	*httptest.Server
+	tokenRequests int
+	blobs         map[string]string
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{
+		blobs: map[string]string{
+			"sha256:amd64-layer": "amd64 layer content",
+			"sha256:arm64-layer": "arm64 layer content",
+		},
+	}
+
+	manifests := map[string]struct {
+		mediaType string
+		content   string
+	}{
+		"latest": {
+			mediaType: MediaTypeOCIIndex,
+			content: `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+				{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:amd64","size":1,"platform":{"os":"linux","architecture":"amd64"}},
+				{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","digest":"sha256:arm64","size":1,"platform":{"os":"linux","architecture":"arm64","variant":"v8"}}]}`,
+		},
+		"sha256:amd64": {
+			mediaType: MediaTypeOCIManifest,
+			content: `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",
+				"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:config","size":1},
+				"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar","digest":"sha256:amd64-layer","size":19}]}`,
+		},
+		"sha256:arm64": {
+			// the registry responds generic content type
+			mediaType: "application/json",
+			content: `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json",
+				"config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"sha256:config","size":1},
+				"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"sha256:arm64-layer","size":19}]}`,
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		r.tokenRequests++
+		username, password, ok := req.BasicAuth()
+		if !ok || username != testUsername || password != testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if req.URL.Query().Get("scope") != fmt.Sprintf("repository:%s:pull", testRepository) ||
+			req.URL.Query().Get("service") != "test-registry" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"token": testToken, "expires_in": 300})
+	})
+
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer "+testToken {
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, r.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		prefix := fmt.Sprintf("/v2/%s/", testRepository)
+		if !strings.HasPrefix(req.URL.Path, prefix) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		kind, reference, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, prefix), "/")
+		switch kind {
+		case "manifests":
+			m, ok := manifests[reference]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", m.mediaType)
+			w.Header().Set(DockerContentDigestHeader, "sha256:"+strings.TrimPrefix(reference, "sha256:"))
+			io.WriteString(w, m.content)
+		case "blobs":
+			content, ok := r.blobs[reference]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			http.ServeContent(w, req, "", time.Time{}, strings.NewReader(content))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	r.Server = httptest.NewServer(mux)
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *testRegistry) reference(tag string) *Reference {
+	u, _ := url.Parse(r.URL)
+	return &Reference{
+		Scheme:     u.Scheme,
+		Host:       u.Host,
+		Repository: testRepository,
+		Reference:  tag,
+	}
+}
+
+func TestClient_ResolveManifests(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		platforms []Platform
+		expect    map[string]string
+	}{
+		{
+			name:      "all platforms",
+			reference: "latest",
+			expect: map[string]string{
+				"linux/amd64":    "sha256:amd64-layer",
+				"linux/arm64/v8": "sha256:arm64-layer",
+			},
+		},
+		{
+			name:      "select platform",
+			reference: "latest",
+			platforms: []Platform{{OS: "linux", Architecture: "arm64"}},
+			expect: map[string]string{
+				"linux/arm64/v8": "sha256:arm64-layer",
+			},
+		},
+		{
+			name:      "manifest",
+			reference: "sha256:amd64",
+			platforms: []Platform{{OS: "linux", Architecture: "arm64"}},
+			expect: map[string]string{
+				"": "sha256:amd64-layer",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			r := newTestRegistry(t)
+			client := NewClient(WithCredentialStore(NewStaticCredentialStore(testUsername, testPassword)))
+
+			manifests, err := client.ResolveManifests(context.Background(), r.reference(tc.reference), tc.platforms)
+			assert.Nil(err)
+
+			layers := map[string]string{}
+			for _, m := range manifests {
+				assert.Len(m.Layers, 1)
+				var platform string
+				if m.Platform != nil {
+					platform = m.Platform.String()
+				}
+				layers[platform] = m.Layers[0].Digest.String()
+			}
+			assert.Equal(tc.expect, layers)
+
+			// the token is cached
+			assert.Equal(1, r.tokenRequests)
+			assert.Equal("Bearer "+testToken, client.Authorization(r.reference("").Host, testRepository))
+		})
+	}
+}
+
+func TestClient_ResolveManifests_Errors(t *testing.T) {
+	assert := testifyassert.New(t)
+	r := newTestRegistry(t)
+
+	_, err := NewClient().ResolveManifests(context.Background(), r.reference("latest"), nil)
+	assert.ErrorContains(err, "401")
+
+	client := NewClient(WithCredentialStore(NewStaticCredentialStore(testUsername, testPassword)))
+	_, err = client.ResolveManifests(context.Background(), r.reference("latest"), []Platform{{OS: "windows", Architecture: "amd64"}})
+	assert.ErrorContains(err, "no manifest of platforms")
+
+	_, err = client.ResolveManifests(context.Background(), r.reference("unknown"), nil)
+	var respErr *ResponseError
+	assert.ErrorAs(err, &respErr)
+	assert.Equal(http.StatusNotFound, respErr.StatusCode)
+}
+
+func TestClient_GetBlob(t *testing.T) {
+	assert := testifyassert.New(t)
+	r := newTestRegistry(t)
+	client := NewClient(WithCredentialStore(NewStaticCredentialStore(testUsername, testPassword)))
+
+	resp, err := client.GetBlob(context.Background(), r.reference("latest"), "sha256:amd64-layer", "bytes=6-10")
+	assert.Nil(err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	assert.Nil(err)
+	assert.Equal(http.StatusPartialContent, resp.StatusCode)
+	assert.Equal("layer", string(data))
+}
+
+func TestClient_BasicChallenge(t *testing.T) {
+	assert := testifyassert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		username, password, ok := req.BasicAuth()
+		if !ok || username != testUsername || password != testPassword {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test-registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, "blob")
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	ref := &Reference{Scheme: u.Scheme, Host: u.Host, Repository: testRepository, Reference: DefaultTag}
+
+	_, err := NewClient().GetBlob(context.Background(), ref, "sha256:blob", "")
+	assert.ErrorContains(err, "no credential")
+
+	resp, err := NewClient(WithCredentialStore(NewStaticCredentialStore(testUsername, testPassword))).
+		GetBlob(context.Background(), ref, "sha256:blob", "")
+	assert.Nil(err)
+	defer resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+}
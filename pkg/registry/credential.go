@@ -0,0 +1,255 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DockerConfigEnv is the env of the directory of docker config.
+	DockerConfigEnv = "DOCKER_CONFIG"
+
+	// credentialHelperPrefix is the prefix of the docker credential helper binary.
+	credentialHelperPrefix = "docker-credential-"
+
+	// identityTokenUsername is the username returned by credential helper for identity token.
+	identityTokenUsername = "<token>"
+
+	// dockerHubHost is the host of docker hub in docker config.
+	dockerHubHost = "index.docker.io"
+
+	// dockerHubServerURL is the server url of docker hub in credential helpers.
+	dockerHubServerURL = "https://index.docker.io/v1/"
+)
+
+// Credential is the credential of registry.
+type Credential struct {
+	Username string
+	Password string
+
+	// IdentityToken is the refresh token exchanged for the access token, it's used instead of password.
+	IdentityToken string
+}
+
+// IsEmpty returns whether the credential is anonymous.
+func (c Credential) IsEmpty() bool {
+	return c.Username == "" && c.Password == "" && c.IdentityToken == ""
+}
+
+// CredentialStore provides the credential of registry.
+type CredentialStore interface {
+	// Get returns the credential of the registry host, it returns empty credential for anonymous access.
+	Get(ctx context.Context, host string) (Credential, error)
+}
+
+// staticCredentialStore returns the same credential for all registries.
+type staticCredentialStore struct {
+	credential Credential
+}
+
+// NewStaticCredentialStore returns a credential store with the username and password.
+func NewStaticCredentialStore(username, password string) CredentialStore {
+	return &staticCredentialStore{
+		credential: Credential{
+			Username: username,
+			Password: password,
+		},
+	}
+}
+
+func (s *staticCredentialStore) Get(ctx context.Context, host string) (Credential, error) {
+	return s.credential, nil
+}
+
+// DefaultDockerConfigPath returns the path of docker config, the directory can be changed by DOCKER_CONFIG.
+func DefaultDockerConfigPath() string {
+	if dir := os.Getenv(DockerConfigEnv); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// dockerConfig is the credential part of docker config.
+type dockerConfig struct {
+	Auths       map[string]dockerAuthConfig `json:"auths"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+	CredsStore  string                      `json:"credsStore"`
+}
+
+type dockerAuthConfig struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// dockerConfigCredentialStore reads the credential from docker config and credential helpers.
+type dockerConfigCredentialStore struct {
+	paths []string
+}
+
+// NewDockerConfigCredentialStore returns a credential store reading the first existing docker config of paths,
+// the config is read in every Get, so the changes of config take effect without restarting.
+func NewDockerConfigCredentialStore(paths ...string) CredentialStore {
+	return &dockerConfigCredentialStore{
+		paths: paths,
+	}
+}
+
+func (s *dockerConfigCredentialStore) Get(ctx context.Context, host string) (Credential, error) {
+	config, err := s.load()
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if config == nil {
+		return Credential{}, nil
+	}
+
+	host = normalizeHost(host)
+	for key, helper := range config.CredHelpers {
+		if normalizeHost(key) == host {
+			return getHelperCredential(ctx, helper, key)
+		}
+	}
+
+	for key, auth := range config.Auths {
+		if normalizeHost(key) == host {
+			return auth.credential()
+		}
+	}
+
+	if config.CredsStore != "" {
+		if host == dockerHubHost {
+			return getHelperCredential(ctx, config.CredsStore, dockerHubServerURL)
+		}
+		return getHelperCredential(ctx, config.CredsStore, host)
+	}
+	return Credential{}, nil
+}
+
+// load returns the first existing docker config, it returns nil when no config exists.
+func (s *dockerConfigCredentialStore) load() (*dockerConfig, error) {
+	for _, path := range s.paths {
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		config := &dockerConfig{}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parse docker config %s error: %w", path, err)
+		}
+		return config, nil
+	}
+	return nil, nil
+}
+
+func (a dockerAuthConfig) credential() (Credential, error) {
+	credential := Credential{
+		Username:      a.Username,
+		Password:      a.Password,
+		IdentityToken: a.IdentityToken,
+	}
+
+	if a.Auth != "" {
+		data, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return Credential{}, fmt.Errorf("decode auth error: %w", err)
+		}
+
+		username, password, ok := strings.Cut(string(data), ":")
+		if !ok {
+			return Credential{}, errors.New("invalid auth, it must be username:password")
+		}
+		credential.Username, credential.Password = username, password
+	}
+	return credential, nil
+}
+
+// helperCredential is the output of credential helper.
+type helperCredential struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+// getHelperCredential gets the credential by the docker credential helper protocol.
+func getHelperCredential(ctx context.Context, helper, host string) (Credential, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, credentialHelperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// the credential helper reports the missing credential in output
+		if strings.Contains(stdout.String()+stderr.String(), "credentials not found") {
+			return Credential{}, nil
+		}
+		return Credential{}, fmt.Errorf("credential helper %s error: %w: %s", helper, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output helperCredential
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return Credential{}, fmt.Errorf("parse output of credential helper %s error: %w", helper, err)
+	}
+
+	if output.Username == identityTokenUsername {
+		return Credential{IdentityToken: output.Secret}, nil
+	}
+	return Credential{
+		Username: output.Username,
+		Password: output.Secret,
+	}, nil
+}
+
+// normalizeHost returns the host of the key in docker config, the key may contain scheme and path,
+// like https://index.docker.io/v1/.
+func normalizeHost(key string) string {
+	host := key
+	if _, h, ok := strings.Cut(host, "://"); ok {
+		host = h
+	}
+	host, _, _ = strings.Cut(host, "/")
+
+	switch host {
+	case "docker.io", "registry-1.docker.io":
+		return dockerHubHost
+	}
+	return host
+}
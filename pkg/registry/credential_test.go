@@ -0,0 +1,105 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestDockerConfigCredentialStore_Get(t *testing.T) {
+	// dXNlcjpwYXNz is the base64 of user:pass
+	config := `{
+		"auths": {
+			"registry.example.com": {"auth": "dXNlcjpwYXNz"},
+			"https://index.docker.io/v1/": {"username": "hub", "password": "secret"},
+			"token.example.com": {"identitytoken": "refresh"},
+			"invalid.example.com": {"auth": "invalid"}
+		}
+	}`
+
+	tests := []struct {
+		name      string
+		host      string
+		expect    Credential
+		expectErr bool
+	}{
+		{
+			name:   "auth",
+			host:   "registry.example.com",
+			expect: Credential{Username: "user", Password: "pass"},
+		},
+		{
+			name:   "docker hub",
+			host:   "registry-1.docker.io",
+			expect: Credential{Username: "hub", Password: "secret"},
+		},
+		{
+			name:   "identity token",
+			host:   "token.example.com",
+			expect: Credential{IdentityToken: "refresh"},
+		},
+		{
+			name:   "anonymous",
+			host:   "other.example.com",
+			expect: Credential{},
+		},
+		{
+			name:      "invalid auth",
+			host:      "invalid.example.com",
+			expectErr: true,
+		},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			store := NewDockerConfigCredentialStore(filepath.Join(dir, "missing.json"), path)
+			credential, err := store.Get(context.Background(), tc.host)
+			if tc.expectErr {
+				assert.Error(err)
+				return
+			}
+
+			assert.Nil(err)
+			assert.Equal(tc.expect, credential)
+		})
+	}
+}
+
+func TestDockerConfigCredentialStore_NoConfig(t *testing.T) {
+	assert := testifyassert.New(t)
+	credential, err := NewDockerConfigCredentialStore(filepath.Join(t.TempDir(), "config.json")).Get(context.Background(), "registry.example.com")
+	assert.Nil(err)
+	assert.True(credential.IsEmpty())
+}
+
+func TestDefaultDockerConfigPath(t *testing.T) {
+	assert := testifyassert.New(t)
+	t.Setenv(DockerConfigEnv, "/etc/docker")
+	assert.Equal("/etc/docker/config.json", DefaultDockerConfigPath())
+}
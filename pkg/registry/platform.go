@@ -0,0 +1,87 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Platform is the platform of image in the image index, like linux/amd64 or linux/arm64/v8.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// DefaultPlatform returns the platform of the running process.
+func DefaultPlatform() Platform {
+	return Platform{
+		OS:           runtime.GOOS,
+		Architecture: runtime.GOARCH,
+	}
+}
+
+// ParsePlatform parses the platform like linux/amd64 or linux/arm64/v8.
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q", s)
+	}
+
+	p := Platform{
+		OS:           parts[0],
+		Architecture: parts[1],
+	}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// ParsePlatforms parses the comma separated platforms like linux/amd64,linux/arm64.
+func ParsePlatforms(s string) ([]Platform, error) {
+	var platforms []Platform
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		p, err := ParsePlatform(part)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, nil
+}
+
+// Match returns whether the platform in image index matches, the variant matches any when it's empty.
+func (p Platform) Match(os, architecture, variant string) bool {
+	if p.OS != os || p.Architecture != architecture {
+		return false
+	}
+	return p.Variant == "" || p.Variant == variant
+}
+
+func (p Platform) String() string {
+	if p.Variant == "" {
+		return fmt.Sprintf("%s/%s", p.OS, p.Architecture)
+	}
+	return fmt.Sprintf("%s/%s/%s", p.OS, p.Architecture, p.Variant)
+}
@@ -0,0 +1,99 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultScheme is the default scheme of registry.
+	DefaultScheme = "https"
+
+	// DefaultTag is the tag used when the reference has neither tag nor digest.
+	DefaultTag = "latest"
+)
+
+// Reference is the reference of manifest in registry,
+// like registry.example.com/library/alpine:3.17 or registry.example.com/library/alpine@sha256:xxx.
+type Reference struct {
+	// Scheme is the scheme of registry, https is used when it's empty.
+	Scheme string
+
+	// Host is the host of registry.
+	Host string
+
+	// Repository is the name of repository.
+	Repository string
+
+	// Reference is the tag or digest of manifest.
+	Reference string
+}
+
+// ParseReference parses the reference without scheme.
+func ParseReference(s string) (*Reference, error) {
+	host, name, ok := strings.Cut(s, "/")
+	if !ok || host == "" || name == "" {
+		return nil, fmt.Errorf("invalid reference %q", s)
+	}
+
+	ref := &Reference{Host: host}
+	if repository, digest, ok := strings.Cut(name, "@"); ok {
+		ref.Repository, ref.Reference = repository, digest
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Repository, ref.Reference = name[:i], name[i+1:]
+	} else {
+		ref.Repository, ref.Reference = name, DefaultTag
+	}
+
+	if ref.Repository == "" || ref.Reference == "" {
+		return nil, fmt.Errorf("invalid reference %q", s)
+	}
+	return ref, nil
+}
+
+// IsDigest returns whether the reference is a digest, the digest is content addressable and never changes.
+func (r *Reference) IsDigest() bool {
+	// tag can't contain colon
+	return strings.Contains(r.Reference, ":")
+}
+
+// ManifestURL returns the url of the manifest.
+func (r *Reference) ManifestURL() string {
+	return fmt.Sprintf("%s://%s/v2/%s/manifests/%s", r.scheme(), r.Host, r.Repository, r.Reference)
+}
+
+// BlobURL returns the url of the blob in the repository.
+func (r *Reference) BlobURL(digest string) string {
+	return fmt.Sprintf("%s://%s/v2/%s/blobs/%s", r.scheme(), r.Host, r.Repository, digest)
+}
+
+// String returns the reference without scheme.
+func (r *Reference) String() string {
+	if r.IsDigest() {
+		return fmt.Sprintf("%s/%s@%s", r.Host, r.Repository, r.Reference)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Host, r.Repository, r.Reference)
+}
+
+func (r *Reference) scheme() string {
+	if r.Scheme == "" {
+		return DefaultScheme
+	}
+	return r.Scheme
+}
@@ -0,0 +1,103 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		expect    *Reference
+		expectErr bool
+	}{
+		{
+			name:   "tag",
+			s:      "registry.example.com:5000/library/alpine:3.17",
+			expect: &Reference{Host: "registry.example.com:5000", Repository: "library/alpine", Reference: "3.17"},
+		},
+		{
+			name:   "digest",
+			s:      "registry.example.com/library/alpine@sha256:abc",
+			expect: &Reference{Host: "registry.example.com", Repository: "library/alpine", Reference: "sha256:abc"},
+		},
+		{
+			name:   "default tag",
+			s:      "registry.example.com:5000/alpine",
+			expect: &Reference{Host: "registry.example.com:5000", Repository: "alpine", Reference: DefaultTag},
+		},
+		{
+			name:      "without repository",
+			s:         "registry.example.com",
+			expectErr: true,
+		},
+		{
+			name:      "empty tag",
+			s:         "registry.example.com/alpine:",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			ref, err := ParseReference(tc.s)
+			if tc.expectErr {
+				assert.Error(err)
+				return
+			}
+
+			assert.Nil(err)
+			assert.Equal(tc.expect, ref)
+		})
+	}
+}
+
+func TestReference_URL(t *testing.T) {
+	assert := testifyassert.New(t)
+	ref := &Reference{Host: "registry.example.com", Repository: "library/alpine", Reference: "3.17"}
+	assert.False(ref.IsDigest())
+	assert.Equal("https://registry.example.com/v2/library/alpine/manifests/3.17", ref.ManifestURL())
+
+	ref.Scheme = "http"
+	assert.Equal("http://registry.example.com/v2/library/alpine/blobs/sha256:abc", ref.BlobURL("sha256:abc"))
+
+	ref.Reference = "sha256:abc"
+	assert.True(ref.IsDigest())
+}
+
+func TestParsePlatforms(t *testing.T) {
+	assert := testifyassert.New(t)
+	platforms, err := ParsePlatforms("linux/amd64, linux/arm64/v8,")
+	assert.Nil(err)
+	assert.Equal([]Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8"},
+	}, platforms)
+	assert.Equal("linux/arm64/v8", platforms[1].String())
+
+	assert.True(platforms[0].Match("linux", "amd64", "v3"))
+	assert.False(platforms[1].Match("linux", "arm64", "v7"))
+	assert.False(platforms[0].Match("windows", "amd64", ""))
+
+	_, err = ParsePlatforms("linux")
+	assert.Error(err)
+}
@@ -23,3 +23,10 @@ var (
 	// BeginOfPiece is the number of begin piece.
 	BeginOfPiece = int32(-1)
 )
+
+const (
+	// HostIDMetadataKey is the grpc metadata key of host id. RegisterPeerRequest
+	// of v2 version does not carry the host id, so peers announce it by
+	// the metadata of AnnouncePeer stream.
+	HostIDMetadataKey = "dragonfly-host-id"
+)
@@ -0,0 +1,314 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package azblobprotocol
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-http-utils/headers"
+
+	"d7y.io/dragonfly/v2/pkg/azblob"
+	"d7y.io/dragonfly/v2/pkg/digest"
+	"d7y.io/dragonfly/v2/pkg/source"
+	pkgstrings "d7y.io/dragonfly/v2/pkg/strings"
+)
+
+const AzureBlobScheme = "azblob"
+
+const (
+	// Azure Blob endpoint, default is https://<account>.blob.core.windows.net
+	endpoint = "azureEndpoint"
+	// Azure storage account name
+	accountName = "azureAccountName"
+	// Azure storage account key, the request is anonymous if it's empty
+	accountKey = "azureAccountKey"
+)
+
+var _ source.ResourceClient = (*azureBlobSourceClient)(nil)
+var _ source.ResourceLister = (*azureBlobSourceClient)(nil)
+var _ source.ResourceMetadataGetter = (*azureBlobSourceClient)(nil)
+
+func init() {
+	if err := source.Register(AzureBlobScheme, NewAzureBlobSourceClient(), adaptor); err != nil {
+		panic(err)
+	}
+}
+
+func adaptor(request *source.Request) *source.Request {
+	clonedRequest := request.Clone(request.Context())
+	if request.Header.Get(source.Range) != "" {
+		clonedRequest.Header.Set(headers.Range, fmt.Sprintf("bytes=%s", request.Header.Get(source.Range)))
+		clonedRequest.Header.Del(source.Range)
+	}
+	return clonedRequest
+}
+
+func NewAzureBlobSourceClient(opts ...AzureBlobSourceClientOption) source.ResourceClient {
+	return newAzureBlobSourceClient(opts...)
+}
+
+func newAzureBlobSourceClient(opts ...AzureBlobSourceClientOption) *azureBlobSourceClient {
+	sourceClient := &azureBlobSourceClient{
+		clientMap: sync.Map{},
+	}
+	for i := range opts {
+		opts[i](sourceClient)
+	}
+	return sourceClient
+}
+
+type AzureBlobSourceClientOption func(p *azureBlobSourceClient)
+
+// azureBlobSourceClient is an implementation of the interface of source.ResourceClient,
+// the host of url is container name and the path of url is blob name.
+type azureBlobSourceClient struct {
+	// endpoint_accountName_sha256(accountKey) -> azblob.Client
+	clientMap sync.Map
+}
+
+func (a *azureBlobSourceClient) GetContentLength(request *source.Request) (int64, error) {
+	properties, err := a.getBlobProperties(request)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	return properties.ContentLength, nil
+}
+
+func (a *azureBlobSourceClient) IsSupportRange(request *source.Request) (bool, error) {
+	if _, err := a.getBlobProperties(request); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *azureBlobSourceClient) GetMetadata(request *source.Request) (*source.Metadata, error) {
+	properties, err := a.getBlobProperties(request)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := source.Header{}
+	hdr.Set(headers.ContentType, properties.ContentType)
+	hdr.Set(headers.ETag, properties.ETag)
+	if !properties.LastModified.IsZero() {
+		hdr.Set(headers.LastModified, properties.LastModified.UTC().Format(source.TimeFormat))
+	}
+	for k, v := range properties.Metadata {
+		hdr.Set(azblob.MetaHeaderPrefix+k, v)
+	}
+
+	return &source.Metadata{
+		Header:             hdr,
+		Status:             http.StatusText(http.StatusOK),
+		StatusCode:         http.StatusOK,
+		SupportRange:       true,
+		TotalContentLength: properties.ContentLength,
+		Validate: func() error {
+			return nil
+		},
+		Temporary: true,
+	}, nil
+}
+
+func (a *azureBlobSourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	properties, err := a.getBlobProperties(request)
+	if err != nil {
+		return false, err
+	}
+	return !(properties.ETag == info.ETag || properties.LastModified.UTC().Format(source.TimeFormat) == info.LastModified), nil
+}
+
+func (a *azureBlobSourceClient) Download(request *source.Request) (*source.Response, error) {
+	client, err := a.getClient(request.Header)
+	if err != nil {
+		return nil, fmt.Errorf("get azure blob client: %w", err)
+	}
+
+	header := http.Header{}
+	if r := request.Header.Get(headers.Range); r != "" {
+		header.Set(headers.Range, r)
+	}
+
+	resp, err := client.GetBlob(request.Context(), request.URL.Host, blobName(request.URL), header)
+	if err != nil {
+		return nil, fmt.Errorf("get azure blob %s: %w", request.URL.Path, convertError(err))
+	}
+
+	return source.NewResponse(
+		resp.Body,
+		source.WithStatus(resp.StatusCode, resp.Status),
+		source.WithContentLength(resp.ContentLength),
+		source.WithExpireInfo(
+			source.ExpireInfo{
+				LastModified: resp.Header.Get(headers.LastModified),
+				ETag:         resp.Header.Get(headers.ETag),
+			},
+		)), nil
+}
+
+func (a *azureBlobSourceClient) GetLastModified(request *source.Request) (int64, error) {
+	properties, err := a.getBlobProperties(request)
+	if err != nil {
+		return -1, err
+	}
+	return properties.LastModified.UnixMilli(), nil
+}
+
+func (a *azureBlobSourceClient) List(request *source.Request) (urls []source.URLEntry, err error) {
+	client, err := a.getClient(request.Header)
+	if err != nil {
+		return nil, fmt.Errorf("get azure blob client: %w", err)
+	}
+
+	isDir, err := a.isDirectory(client, request)
+	if err != nil {
+		return nil, err
+	}
+	// if request is a single file, just return
+	if !isDir {
+		return []source.URLEntry{buildURLEntry(false, request.URL)}, nil
+	}
+
+	// list all files and subdirectory
+	prefix := strings.TrimPrefix(addTrailingSlash(request.URL.Path), "/")
+	var marker string
+	for {
+		resp, err := client.ListBlobs(request.Context(), request.URL.Host, azblob.ListBlobsOptions{
+			Prefix:    prefix,
+			Delimiter: "/",
+			Marker:    marker,
+		})
+		if err != nil {
+			return urls, fmt.Errorf("list azure blob %s/%s: %w", request.URL.Host, prefix, convertError(err))
+		}
+
+		for _, blob := range resp.Blobs {
+			if blob.Name != prefix {
+				url := *request.URL
+				url.Path = addLeadingSlash(blob.Name)
+				urls = append(urls, buildURLEntry(false, &url))
+			}
+		}
+
+		for _, p := range resp.BlobPrefixes {
+			url := *request.URL
+			url.Path = addLeadingSlash(p)
+			urls = append(urls, buildURLEntry(true, &url))
+		}
+
+		if resp.NextMarker == "" {
+			break
+		}
+		marker = resp.NextMarker
+	}
+	return urls, nil
+}
+
+func (a *azureBlobSourceClient) isDirectory(client *azblob.Client, request *source.Request) (bool, error) {
+	prefix := strings.TrimPrefix(addTrailingSlash(request.URL.Path), "/")
+	resp, err := client.ListBlobs(request.Context(), request.URL.Host, azblob.ListBlobsOptions{
+		Prefix:     prefix,
+		Delimiter:  "/",
+		MaxResults: 1,
+	})
+	if err != nil {
+		return false, fmt.Errorf("list azure blob %s/%s: %w", request.URL.Host, prefix, convertError(err))
+	}
+	if len(resp.Blobs)+len(resp.BlobPrefixes) > 0 {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (a *azureBlobSourceClient) getBlobProperties(request *source.Request) (*azblob.BlobProperties, error) {
+	client, err := a.getClient(request.Header)
+	if err != nil {
+		return nil, fmt.Errorf("get azure blob client: %w", err)
+	}
+
+	properties, err := client.GetBlobProperties(request.Context(), request.URL.Host, blobName(request.URL))
+	if err != nil {
+		return nil, fmt.Errorf("get azure blob %s properties: %w", request.URL.Path, convertError(err))
+	}
+	return properties, nil
+}
+
+func (a *azureBlobSourceClient) getClient(header source.Header) (*azblob.Client, error) {
+	accountName := header.Get(accountName)
+	if pkgstrings.IsBlank(accountName) {
+		return nil, errors.New("azureAccountName is empty")
+	}
+	endpoint := header.Get(endpoint)
+	accountKey := header.Get(accountKey)
+
+	clientKey := buildClientKey(endpoint, accountName, accountKey)
+	if client, ok := a.clientMap.Load(clientKey); ok {
+		return client.(*azblob.Client), nil
+	}
+	client, err := azblob.New(accountName, accountKey, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := a.clientMap.LoadOrStore(clientKey, client)
+	return actual.(*azblob.Client), nil
+}
+
+func buildClientKey(endpoint, accountName, accountKey string) string {
+	return fmt.Sprintf("%s_%s_%s", endpoint, accountName, digest.SHA256FromStrings(accountKey))
+}
+
+// convertError converts not found error of azure blob to source.ErrResourceNotReachable.
+func convertError(err error) error {
+	if azblob.IsNotFound(err) {
+		return fmt.Errorf("%s: %w", err.Error(), source.ErrResourceNotReachable)
+	}
+	return err
+}
+
+func blobName(u *url.URL) string {
+	return strings.TrimPrefix(u.Path, "/")
+}
+
+func buildURLEntry(isDir bool, url *url.URL) source.URLEntry {
+	if isDir {
+		url.Path = addTrailingSlash(url.Path)
+		list := strings.Split(url.Path, "/")
+		return source.URLEntry{URL: url, Name: list[len(list)-2], IsDir: true}
+	}
+	_, name := filepath.Split(url.Path)
+	return source.URLEntry{URL: url, Name: name, IsDir: false}
+}
+
+func addLeadingSlash(s string) string {
+	if strings.HasPrefix(s, "/") {
+		return s
+	}
+	return "/" + s
+}
+
+func addTrailingSlash(s string) string {
+	if strings.HasSuffix(s, "/") {
+		return s
+	}
+	return s + "/"
+}
@@ -0,0 +1,125 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package azblobprotocol
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/go-http-utils/headers"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/azblob/azblobtest"
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+func TestAzureBlobSourceClient(t *testing.T) {
+	assert := testifyassert.New(t)
+	server := azblobtest.NewServer("devstoreaccount1")
+	defer server.Close()
+
+	server.PutBlob("container", "dir/a", []byte("hello world"), map[string]string{"foo": "bar"})
+	server.PutBlob("container", "dir/b", []byte("b"), nil)
+	server.PutBlob("container", "dir/sub/c", []byte("c"), nil)
+
+	client := newAzureBlobSourceClient()
+	newRequest := func(rawURL string) *source.Request {
+		request, err := source.NewRequest(rawURL)
+		assert.Nil(err)
+		request.Header.Set(endpoint, server.Endpoint())
+		request.Header.Set(accountName, "devstoreaccount1")
+		request.Header.Set(accountKey, "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==")
+		return request
+	}
+
+	request := newRequest("azblob://container/dir/a")
+	contentLength, err := client.GetContentLength(request)
+	assert.Nil(err)
+	assert.Equal(int64(len("hello world")), contentLength)
+
+	supportRange, err := client.IsSupportRange(request)
+	assert.Nil(err)
+	assert.True(supportRange)
+
+	metadata, err := client.GetMetadata(request)
+	assert.Nil(err)
+	assert.Equal(int64(len("hello world")), metadata.TotalContentLength)
+	assert.Equal("bar", metadata.Header.Get("X-Ms-Meta-foo"))
+	assert.NotEmpty(metadata.Header.Get(headers.ETag))
+
+	lastModified, err := client.GetLastModified(request)
+	assert.Nil(err)
+	assert.Greater(lastModified, int64(0))
+
+	resp, err := client.Download(request)
+	assert.Nil(err)
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(err)
+	assert.Equal("hello world", string(data))
+
+	expired, err := client.IsExpired(request, &source.ExpireInfo{
+		ETag:         resp.Header.Get(headers.ETag),
+		LastModified: resp.Header.Get(headers.LastModified),
+	})
+	assert.Nil(err)
+	assert.False(expired)
+
+	expired, err = client.IsExpired(request, &source.ExpireInfo{ETag: "etag", LastModified: "last-modified"})
+	assert.Nil(err)
+	assert.True(expired)
+
+	// Download with range.
+	request = newRequest("azblob://container/dir/a")
+	request.Header.Set(source.Range, "6-10")
+	resp, err = client.Download(adaptor(request))
+	assert.Nil(err)
+	assert.Equal(http.StatusPartialContent, resp.StatusCode)
+	data, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(err)
+	assert.Equal("world", string(data))
+
+	// Blob is not found.
+	_, err = client.GetContentLength(newRequest("azblob://container/dir/x"))
+	assert.ErrorIs(err, source.ErrResourceNotReachable)
+
+	// Account name is required.
+	request = newRequest("azblob://container/dir/a")
+	request.Header.Del(accountName)
+	_, err = client.GetContentLength(request)
+	assert.Error(err)
+
+	// List directory.
+	entries, err := client.List(newRequest("azblob://container/dir"))
+	assert.Nil(err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.URL.String())
+	}
+	sort.Strings(names)
+	assert.Equal([]string{"azblob://container/dir/a", "azblob://container/dir/b", "azblob://container/dir/sub/"}, names)
+
+	// List blob.
+	entries, err = client.List(newRequest("azblob://container/dir/a"))
+	assert.Nil(err)
+	assert.Len(entries, 1)
+	assert.Equal("a", entries[0].Name)
+	assert.False(entries[0].IsDir)
+}
@@ -0,0 +1,325 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gcsprotocol
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+
+	"d7y.io/dragonfly/v2/pkg/digest"
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+const GCSScheme = "gs"
+
+const (
+	// GCS endpoint, default is https://storage.googleapis.com
+	endpoint = "gcsEndpoint"
+	// GCS json key of service account, the request is not authenticated if it's empty
+	credentials = "gcsCredentials"
+
+	defaultEndpoint = "https://storage.googleapis.com"
+)
+
+var _ source.ResourceClient = (*gcsSourceClient)(nil)
+var _ source.ResourceLister = (*gcsSourceClient)(nil)
+var _ source.ResourceMetadataGetter = (*gcsSourceClient)(nil)
+
+func init() {
+	if err := source.Register(GCSScheme, NewGCSSourceClient(), adaptor); err != nil {
+		panic(err)
+	}
+}
+
+func adaptor(request *source.Request) *source.Request {
+	clonedRequest := request.Clone(request.Context())
+	if request.Header.Get(source.Range) != "" {
+		clonedRequest.Header.Set(headers.Range, fmt.Sprintf("bytes=%s", request.Header.Get(source.Range)))
+		clonedRequest.Header.Del(source.Range)
+	}
+	return clonedRequest
+}
+
+func NewGCSSourceClient(opts ...GCSSourceClientOption) source.ResourceClient {
+	return newGCSSourceClient(opts...)
+}
+
+func newGCSSourceClient(opts ...GCSSourceClientOption) *gcsSourceClient {
+	sourceClient := &gcsSourceClient{
+		clientMap: sync.Map{},
+	}
+	for i := range opts {
+		opts[i](sourceClient)
+	}
+	return sourceClient
+}
+
+type GCSSourceClientOption func(p *gcsSourceClient)
+
+// gcsSourceClient is an implementation of the interface of source.ResourceClient.
+type gcsSourceClient struct {
+	// endpoint_sha256(credentials) -> storage.Service
+	clientMap sync.Map
+}
+
+func (g *gcsSourceClient) GetContentLength(request *source.Request) (int64, error) {
+	object, err := g.getObject(request)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	return int64(object.Size), nil
+}
+
+func (g *gcsSourceClient) IsSupportRange(request *source.Request) (bool, error) {
+	if _, err := g.getObject(request); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (g *gcsSourceClient) GetMetadata(request *source.Request) (*source.Metadata, error) {
+	object, err := g.getObject(request)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := source.Header{}
+	hdr.Set(headers.ContentType, object.ContentType)
+	hdr.Set(headers.ETag, object.Etag)
+	if lastModified := formatLastModified(object.Updated); lastModified != "" {
+		hdr.Set(headers.LastModified, lastModified)
+	}
+	for k, v := range object.Metadata {
+		hdr.Set("X-Goog-Meta-"+k, v)
+	}
+
+	return &source.Metadata{
+		Header:             hdr,
+		Status:             http.StatusText(http.StatusOK),
+		StatusCode:         http.StatusOK,
+		SupportRange:       true,
+		TotalContentLength: int64(object.Size),
+		Validate: func() error {
+			return nil
+		},
+		Temporary: true,
+	}, nil
+}
+
+func (g *gcsSourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	object, err := g.getObject(request)
+	if err != nil {
+		return false, err
+	}
+	return !(object.Etag == info.ETag || formatLastModified(object.Updated) == info.LastModified), nil
+}
+
+func (g *gcsSourceClient) Download(request *source.Request) (*source.Response, error) {
+	client, err := g.getClient(request.Header)
+	if err != nil {
+		return nil, fmt.Errorf("get gcs client: %w", err)
+	}
+
+	call := client.Objects.Get(request.URL.Host, objectName(request.URL)).Context(request.Context())
+	if r := request.Header.Get(headers.Range); r != "" {
+		call.Header().Set(headers.Range, r)
+	}
+
+	resp, err := call.Download()
+	if err != nil {
+		return nil, fmt.Errorf("get gcs object %s: %w", request.URL.Path, convertError(err))
+	}
+
+	if err := source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK, http.StatusPartialContent}); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return source.NewResponse(
+		resp.Body,
+		source.WithStatus(resp.StatusCode, resp.Status),
+		source.WithContentLength(resp.ContentLength),
+		source.WithExpireInfo(
+			source.ExpireInfo{
+				LastModified: resp.Header.Get(headers.LastModified),
+				ETag:         resp.Header.Get(headers.ETag),
+			},
+		)), nil
+}
+
+func (g *gcsSourceClient) GetLastModified(request *source.Request) (int64, error) {
+	object, err := g.getObject(request)
+	if err != nil {
+		return -1, err
+	}
+
+	t, err := time.Parse(time.RFC3339, object.Updated)
+	if err != nil {
+		return -1, err
+	}
+
+	return t.UnixMilli(), nil
+}
+
+func (g *gcsSourceClient) List(request *source.Request) (urls []source.URLEntry, err error) {
+	client, err := g.getClient(request.Header)
+	if err != nil {
+		return nil, fmt.Errorf("get gcs client: %w", err)
+	}
+
+	isDir, err := g.isDirectory(client, request)
+	if err != nil {
+		return nil, err
+	}
+	// if request is a single file, just return
+	if !isDir {
+		return []source.URLEntry{buildURLEntry(false, request.URL)}, nil
+	}
+
+	// list all files and subdirectory
+	prefix := strings.TrimPrefix(addTrailingSlash(request.URL.Path), "/")
+	if err := client.Objects.List(request.URL.Host).Prefix(prefix).Delimiter("/").Pages(request.Context(), func(objects *storage.Objects) error {
+		for _, object := range objects.Items {
+			if object.Name != prefix {
+				url := *request.URL
+				url.Path = addLeadingSlash(object.Name)
+				urls = append(urls, buildURLEntry(false, &url))
+			}
+		}
+
+		for _, p := range objects.Prefixes {
+			url := *request.URL
+			url.Path = addLeadingSlash(p)
+			urls = append(urls, buildURLEntry(true, &url))
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("list gcs object %s/%s: %w", request.URL.Host, prefix, convertError(err))
+	}
+	return urls, nil
+}
+
+func (g *gcsSourceClient) isDirectory(client *storage.Service, request *source.Request) (bool, error) {
+	prefix := strings.TrimPrefix(addTrailingSlash(request.URL.Path), "/")
+	objects, err := client.Objects.List(request.URL.Host).Prefix(prefix).Delimiter("/").MaxResults(1).Context(request.Context()).Do()
+	if err != nil {
+		return false, fmt.Errorf("list gcs object %s/%s: %w", request.URL.Host, prefix, convertError(err))
+	}
+	if len(objects.Items)+len(objects.Prefixes) > 0 {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (g *gcsSourceClient) getObject(request *source.Request) (*storage.Object, error) {
+	client, err := g.getClient(request.Header)
+	if err != nil {
+		return nil, fmt.Errorf("get gcs client: %w", err)
+	}
+
+	object, err := client.Objects.Get(request.URL.Host, objectName(request.URL)).Context(request.Context()).Do()
+	if err != nil {
+		return nil, fmt.Errorf("get gcs object %s meta: %w", request.URL.Path, convertError(err))
+	}
+	return object, nil
+}
+
+func (g *gcsSourceClient) getClient(header source.Header) (*storage.Service, error) {
+	endpoint := strings.TrimSuffix(header.Get(endpoint), "/")
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	credentials := header.Get(credentials)
+
+	clientKey := buildClientKey(endpoint, credentials)
+	if client, ok := g.clientMap.Load(clientKey); ok {
+		return client.(*storage.Service), nil
+	}
+
+	opts := []option.ClientOption{option.WithEndpoint(endpoint + "/storage/v1/")}
+	if credentials != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(credentials)))
+	} else {
+		opts = append(opts, option.WithoutAuthentication())
+	}
+
+	client, err := storage.NewService(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := g.clientMap.LoadOrStore(clientKey, client)
+	return actual.(*storage.Service), nil
+}
+
+func buildClientKey(endpoint, credentials string) string {
+	return fmt.Sprintf("%s_%s", endpoint, digest.SHA256FromStrings(credentials))
+}
+
+// convertError converts not found error of gcs to source.ErrResourceNotReachable.
+func convertError(err error) error {
+	if e, ok := err.(*googleapi.Error); ok && e.Code == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", e.Message, source.ErrResourceNotReachable)
+	}
+	return err
+}
+
+func formatLastModified(updated string) string {
+	t, err := time.Parse(time.RFC3339, updated)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(source.TimeFormat)
+}
+
+func objectName(u *url.URL) string {
+	return strings.TrimPrefix(u.Path, "/")
+}
+
+func buildURLEntry(isDir bool, url *url.URL) source.URLEntry {
+	if isDir {
+		url.Path = addTrailingSlash(url.Path)
+		list := strings.Split(url.Path, "/")
+		return source.URLEntry{URL: url, Name: list[len(list)-2], IsDir: true}
+	}
+	_, name := filepath.Split(url.Path)
+	return source.URLEntry{URL: url, Name: name, IsDir: false}
+}
+
+func addLeadingSlash(s string) string {
+	if strings.HasPrefix(s, "/") {
+		return s
+	}
+	return "/" + s
+}
+
+func addTrailingSlash(s string) string {
+	if strings.HasSuffix(s, "/") {
+		return s
+	}
+	return s + "/"
+}
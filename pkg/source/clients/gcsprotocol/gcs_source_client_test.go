@@ -0,0 +1,117 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gcsprotocol
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/go-http-utils/headers"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/objectstorage/gcstest"
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+func TestGCSSourceClient(t *testing.T) {
+	assert := testifyassert.New(t)
+	server := gcstest.NewServer()
+	defer server.Close()
+
+	server.PutObject("bucket", "dir/a", []byte("hello world"), map[string]string{"foo": "bar"})
+	server.PutObject("bucket", "dir/b", []byte("b"), nil)
+	server.PutObject("bucket", "dir/sub/c", []byte("c"), nil)
+
+	client := newGCSSourceClient()
+	newRequest := func(rawURL string) *source.Request {
+		request, err := source.NewRequest(rawURL)
+		assert.Nil(err)
+		request.Header.Set(endpoint, server.URL)
+		return request
+	}
+
+	request := newRequest("gs://bucket/dir/a")
+	contentLength, err := client.GetContentLength(request)
+	assert.Nil(err)
+	assert.Equal(int64(len("hello world")), contentLength)
+
+	supportRange, err := client.IsSupportRange(request)
+	assert.Nil(err)
+	assert.True(supportRange)
+
+	metadata, err := client.GetMetadata(request)
+	assert.Nil(err)
+	assert.Equal(int64(len("hello world")), metadata.TotalContentLength)
+	assert.Equal("bar", metadata.Header.Get("X-Goog-Meta-foo"))
+	assert.NotEmpty(metadata.Header.Get(headers.ETag))
+
+	lastModified, err := client.GetLastModified(request)
+	assert.Nil(err)
+	assert.Greater(lastModified, int64(0))
+
+	resp, err := client.Download(request)
+	assert.Nil(err)
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(err)
+	assert.Equal("hello world", string(data))
+
+	expired, err := client.IsExpired(request, &source.ExpireInfo{
+		ETag:         resp.Header.Get(headers.ETag),
+		LastModified: resp.Header.Get(headers.LastModified),
+	})
+	assert.Nil(err)
+	assert.False(expired)
+
+	expired, err = client.IsExpired(request, &source.ExpireInfo{ETag: "etag", LastModified: "last-modified"})
+	assert.Nil(err)
+	assert.True(expired)
+
+	// Download with range.
+	request = newRequest("gs://bucket/dir/a")
+	request.Header.Set(source.Range, "6-10")
+	resp, err = client.Download(adaptor(request))
+	assert.Nil(err)
+	assert.Equal(http.StatusPartialContent, resp.StatusCode)
+	data, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(err)
+	assert.Equal("world", string(data))
+
+	// Object is not found.
+	_, err = client.GetContentLength(newRequest("gs://bucket/dir/x"))
+	assert.ErrorIs(err, source.ErrResourceNotReachable)
+
+	// List directory.
+	entries, err := client.List(newRequest("gs://bucket/dir"))
+	assert.Nil(err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.URL.String())
+	}
+	sort.Strings(names)
+	assert.Equal([]string{"gs://bucket/dir/a", "gs://bucket/dir/b", "gs://bucket/dir/sub/"}, names)
+
+	// List object.
+	entries, err = client.List(newRequest("gs://bucket/dir/a"))
+	assert.Nil(err)
+	assert.Len(entries, 1)
+	assert.Equal("a", entries[0].Name)
+	assert.False(entries[0].IsDir)
+}
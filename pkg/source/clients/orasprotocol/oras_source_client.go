@@ -1,230 +1,291 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
 package orasprotocol
 
 import (
-	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net"
 	"net/http"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/go-http-utils/headers"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/cache"
+	nethttp "d7y.io/dragonfly/v2/pkg/net/http"
+	"d7y.io/dragonfly/v2/pkg/registry"
 	"d7y.io/dragonfly/v2/pkg/source"
 )
 
 const (
-	ociAcceptHeader  = "application/vnd.oci.image.manifest.v1+json"
-	jsonAcceptHeader = "application/json"
-	scheme           = "oras"
-	authFilePath     = "/.singularity/docker-config.json"
-)
+	scheme = "oras"
 
-var (
-	_defaultHTTPClient *http.Client
-	_                  source.ResourceClient = (*orasSourceClient)(nil)
+	// PlatformQuery is the query to select the platform of image index, like oras://host/repo:tag?platform=linux/arm64,
+	// the platform of the running process is selected by default.
+	PlatformQuery = "platform"
+
+	// singularityDockerConfigPath is the docker config of singularity under home, it's read when docker config doesn't exist.
+	singularityDockerConfigPath = ".singularity/docker-config.json"
+
+	// layerCacheExpiration is the expiration of the resolved layer, the pieces of a task are downloaded
+	// by range requests concurrently, so the manifest is not resolved for every piece.
+	layerCacheExpiration = 30 * time.Second
 )
 
-type Blob struct {
-	Digest string `json:"digest"`
-}
+var (
+	_ source.ResourceClient         = (*orasSourceClient)(nil)
+	_ source.ResourceMetadataGetter = (*orasSourceClient)(nil)
 
-type Manifest struct {
-	Layers []Blob `json:"layers"`
-}
+	notTemporaryStatusCode = []int{
+		http.StatusUnauthorized,
+		http.StatusForbidden,
+		http.StatusNotFound,
+		http.StatusProxyAuthRequired,
+	}
+)
 
 func init() {
-	_defaultHTTPClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		ExpectContinueTimeout: 10 * time.Second,
 	}
-	client := &orasSourceClient{
-		httpClient: _defaultHTTPClient,
+
+	configPaths := []string{registry.DefaultDockerConfigPath()}
+	if home, err := os.UserHomeDir(); err == nil {
+		configPaths = append(configPaths, filepath.Join(home, singularityDockerConfigPath))
 	}
+
+	client := newOrasSourceClient(registry.NewClient(
+		registry.WithHTTPClient(&http.Client{Transport: transport}),
+		registry.WithCredentialStore(registry.NewDockerConfigCredentialStore(configPaths...)),
+	))
 	if err := source.Register(scheme, client, client.adaptor); err != nil {
 		panic(err)
 	}
+}
+
+// orasSourceClient downloads the artifact from OCI distribution registry by url like oras://host/repository:tag
+// or oras://host/repository@digest. The last layer of the manifest is downloaded as the artifact,
+// and the manifest of the platform is selected when the reference is an image index.
+type orasSourceClient struct {
+	registry *registry.Client
+	layers   cache.Cache
+}
 
+func newOrasSourceClient(registryClient *registry.Client) *orasSourceClient {
+	return &orasSourceClient{
+		registry: registryClient,
+		layers:   cache.New(layerCacheExpiration, layerCacheExpiration),
+	}
 }
 
 func (client *orasSourceClient) adaptor(request *source.Request) *source.Request {
 	clonedRequest := request.Clone(request.Context())
+	if request.Header.Get(source.Range) != "" {
+		clonedRequest.Header.Set(headers.Range, fmt.Sprintf("bytes=%s", request.Header.Get(source.Range)))
+		clonedRequest.Header.Del(source.Range)
+	}
 	return clonedRequest
 }
 
-type orasSourceClient struct {
-	httpClient *http.Client
-}
-
 func (client *orasSourceClient) GetContentLength(request *source.Request) (int64, error) {
-	return -1, nil
-}
+	_, layer, err := client.resolveLayer(request, true)
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
 
-func (client *orasSourceClient) IsSupportRange(request *source.Request) (bool, error) {
-	return false, nil
-}
+	rangeHeader := request.Header.Get(headers.Range)
+	if rangeHeader == "" {
+		return layer.Size, nil
+	}
 
-func (client *orasSourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
-	return false, fmt.Errorf("not implemented")
+	rg, err := nethttp.ParseRange(strings.TrimPrefix(rangeHeader, "bytes="), uint64(layer.Size))
+	if err != nil {
+		return source.UnknownSourceFileLen, err
+	}
+	return int64(rg.Length()), nil
 }
 
-func (client *orasSourceClient) Download(request *source.Request) (*source.Response, error) {
-	path, tag, err := parseURL(request, request.URL.Path)
+func (client *orasSourceClient) IsSupportRange(request *source.Request) (bool, error) {
+	ref, layer, err := client.resolveLayer(request, true)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	token, err := client.fetchToken(request, path)
+	resp, err := client.registry.GetBlob(request.Context(), ref, layer.Digest.String(), "bytes=0-0")
 	if err != nil {
-		return nil, err
+		return false, err
 	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusPartialContent, nil
+}
 
-	sha256, err := client.fetchManifest(request, token, path, tag)
+func (client *orasSourceClient) GetMetadata(request *source.Request) (*source.Metadata, error) {
+	ref, layer, err := client.resolveLayer(request, true)
 	if err != nil {
 		return nil, err
 	}
 
-	imageFetchResponse, err := client.fetchImage(request, token, sha256, path, tag)
+	// the registry may redirect the blob to object storage, probe whether the storage supports range request
+	resp, err := client.registry.GetBlob(request.Context(), ref, layer.Digest.String(), "bytes=0-0")
 	if err != nil {
 		return nil, err
 	}
-	return imageFetchResponse, nil
+	defer resp.Body.Close()
 
+	// discard the only one byte for reuse underlay connection
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, 1)); err != nil {
+		return nil, err
+	}
+
+	return &source.Metadata{
+		Header:             source.Header{},
+		Status:             resp.Status,
+		StatusCode:         resp.StatusCode,
+		SupportRange:       resp.StatusCode == http.StatusPartialContent,
+		TotalContentLength: layer.Size,
+		Validate: func() error {
+			return source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK, http.StatusPartialContent})
+		},
+		Temporary: detectTemporary(resp.StatusCode),
+	}, nil
 }
 
-func (client *orasSourceClient) fetchToken(request *source.Request, path string) (string, error) {
-	var response *http.Response
-	var err error
-	tokenFetchURL := fmt.Sprintf("https://%s/service/token/?scope=repository:%s:pull&service=harbor-registry", request.URL.Host, path)
-	if fileExists(os.Getenv("HOME") + authFilePath) {
-		var auth string = ""
-		databytes, err := ioutil.ReadFile(os.Getenv("HOME") + authFilePath)
-		if err != nil {
-			return "", err
-		}
-		var jsonData map[string]interface{}
-		if err := json.Unmarshal(databytes, &jsonData); err != nil {
-			return "", err
-		}
-		for _, v := range jsonData {
-			for registry, v1 := range (v).(map[string]interface{}) {
-				for _, credentials := range (v1).(map[string]interface{}) {
-					if registry == request.URL.Host {
-						auth = credentials.(string)
-					}
-				}
-			}
-		}
-		authHeaderVal := "Basic " + auth
-		response, err = client.doRequest(request, jsonAcceptHeader, authHeaderVal, tokenFetchURL)
-		if err != nil {
-			return "", err
-		}
+// IsExpired compares the digest of current layer with the digest downloaded, the layer of digest reference never expires.
+func (client *orasSourceClient) IsExpired(request *source.Request, info *source.ExpireInfo) (bool, error) {
+	ref, err := parseReference(request)
+	if err != nil {
+		return false, err
+	}
 
-	} else {
-		response, err = client.doRequest(request, "", "", tokenFetchURL)
-		if err != nil {
-			return "", err
-		}
+	if ref.IsDigest() {
+		return false, nil
+	}
 
+	if info == nil || info.ETag == "" {
+		return true, nil
 	}
-	token, err := ioutil.ReadAll(response.Body)
+
+	_, layer, err := client.resolveLayer(request, false)
 	if err != nil {
-		return "", err
+		return false, err
 	}
-	defer response.Body.Close()
-	var tokenData map[string]interface{}
-	if err := json.Unmarshal(token, &tokenData); err != nil {
-		return "", err
-	}
-	logger.Info(fmt.Sprintf("fetching token for %s  successful", request.URL))
-	tokenVal := fmt.Sprintf("%v", tokenData["token"])
-	return tokenVal, nil
+	return layer.Digest.String() != info.ETag, nil
 }
 
-func (client *orasSourceClient) fetchManifest(request *source.Request, accesstoken, path, tag string) (string, error) {
-	var sha string
-	var bloblayers Manifest
-	manifestFetchURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", request.URL.Host, path, tag)
-	authHeaderVal := "Bearer " + accesstoken
-	resp, err := client.doRequest(request, ociAcceptHeader, authHeaderVal, manifestFetchURL)
+func (client *orasSourceClient) Download(request *source.Request) (*source.Response, error) {
+	ref, layer, err := client.resolveLayer(request, true)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
-	manifest, err := ioutil.ReadAll(resp.Body)
+
+	resp, err := client.registry.GetBlob(request.Context(), ref, layer.Digest.String(), request.Header.Get(headers.Range))
 	if err != nil {
-		return "", err
-	}
-	if err := json.Unmarshal(manifest, &bloblayers); err != nil {
-		return "", err
-	}
-	for _, value := range bloblayers.Layers {
-		sha = string(value.Digest)
+		return nil, err
 	}
-	if sha != "" {
-		logger.Info(fmt.Sprintf("fetching manifests for %s  successful", request.URL))
-		return sha, nil
+
+	logger.Debugf("download layer %s of %s, status: %s", layer.Digest, request.URL, resp.Status)
+	response := source.NewResponse(
+		resp.Body,
+		source.WithStatus(resp.StatusCode, resp.Status),
+		source.WithValidate(func() error {
+			return source.CheckResponseCode(resp.StatusCode, []int{http.StatusOK, http.StatusPartialContent})
+		}),
+		source.WithTemporary(detectTemporary(resp.StatusCode)),
+		source.WithExpireInfo(
+			source.ExpireInfo{
+				ETag: layer.Digest.String(),
+			},
+		),
+	)
+	if resp.ContentLength > 0 {
+		response.ContentLength = resp.ContentLength
 	}
-	return "", errors.New("manifest is empty")
+	return response, nil
+}
 
+// GetLastModified returns -1, the registry doesn't provide the last modified time of blob.
+func (client *orasSourceClient) GetLastModified(request *source.Request) (int64, error) {
+	return -1, nil
 }
 
-func (client *orasSourceClient) fetchImage(request *source.Request, token, sha256, path, tag string) (*source.Response, error) {
-	imageFetchURL := fmt.Sprintf("https://%s/v2/%s/blobs/%s", request.URL.Host, path, sha256)
-	authHeaderVal := "Bearer " + token
-	resp, err := client.doRequest(request, ociAcceptHeader, authHeaderVal, imageFetchURL)
+// resolveLayer resolves the reference of request to the layer to download, the resolved layer is cached when useCache is true.
+func (client *orasSourceClient) resolveLayer(request *source.Request, useCache bool) (*registry.Reference, distribution.Descriptor, error) {
+	ref, err := parseReference(request)
 	if err != nil {
-		return nil, errors.New("failed to fetch image")
+		return nil, distribution.Descriptor{}, err
 	}
-	logger.Info(fmt.Sprintf("Fetched %s image successfully", request.URL))
-	return source.NewResponse(resp.Body), nil
-}
 
-func (client *orasSourceClient) doRequest(request *source.Request, acceptHeaderVal, authHeaderVal, myurl string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(request.Context(), http.MethodGet, myurl, nil)
-	if err != nil {
-		return nil, err
+	key := request.URL.String()
+	if useCache {
+		if layer, ok := client.layers.Get(key); ok {
+			return ref, layer.(distribution.Descriptor), nil
+		}
 	}
-	if authHeaderVal != "" {
-		req.Header.Add("Accept", acceptHeaderVal)
-		req.Header.Add("Authorization", authHeaderVal)
 
+	platform := registry.DefaultPlatform()
+	if s := request.URL.Query().Get(PlatformQuery); s != "" {
+		if platform, err = registry.ParsePlatform(s); err != nil {
+			return nil, distribution.Descriptor{}, err
+		}
 	}
-	resp, err := client.httpClient.Do(req)
+
+	manifests, err := client.registry.ResolveManifests(request.Context(), ref, []registry.Platform{platform})
 	if err != nil {
-		return nil, err
+		return nil, distribution.Descriptor{}, err
 	}
-	return resp, nil
-}
 
-func fileExists(filepath string) bool {
-	_, err := os.Stat(filepath)
-	if os.IsNotExist(err) {
-		return false
+	layers := manifests[0].Layers
+	if len(layers) == 0 {
+		return nil, distribution.Descriptor{}, errors.New("manifest is empty")
 	}
-	return true
 
+	layer := layers[len(layers)-1]
+	client.layers.SetDefault(key, layer)
+	logger.Debugf("resolve layer %s of %s, manifest: %s", layer.Digest, request.URL, manifests[0].Digest)
+	return ref, layer, nil
 }
 
-func parseURL(request *source.Request, urlPath string) (string, string, error) {
-	parseURLPattern, err := regexp.Compile("(.*):(.*)")
+// parseReference parses the reference from url like oras://host/repository:tag.
+func parseReference(request *source.Request) (*registry.Reference, error) {
+	ref, err := registry.ParseReference(request.URL.Host + request.URL.Path)
 	if err != nil {
-		return "", "", err
-	}
-	r := parseURLPattern.FindStringSubmatch(urlPath)
-	if len(r) != 3 {
-		return "", "", errors.New("Failed to parse URL")
+		return nil, fmt.Errorf("parse oras url %s error: %w", request.URL, err)
 	}
-	path := strings.TrimPrefix(r[1], "/")
-	tag := r[2]
-	return path, tag, nil
-
+	return ref, nil
 }
 
-func (client *orasSourceClient) GetLastModified(request *source.Request) (int64, error) {
-	panic("implement me")
+func detectTemporary(statusCode int) bool {
+	for _, code := range notTemporaryStatusCode {
+		if code == statusCode {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,214 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orasprotocol
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/registry"
+	"d7y.io/dragonfly/v2/pkg/source"
+)
+
+const (
+	testAMD64Content = "amd64 artifact content"
+	testARM64Content = "arm64 artifact content"
+)
+
+// newTestRegistry returns a registry serving the artifact of linux/amd64 and linux/arm64 by the tag latest,
+// the tag is moved to the amd64 manifest when retag is called.
+func newTestRegistry(t *testing.T) (*httptest.Server, func()) {
+	manifests := map[string]string{
+		"latest": `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+			{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:amd64","size":1,"platform":{"os":"linux","architecture":"amd64"}},
+			{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:arm64","size":1,"platform":{"os":"linux","architecture":"arm64"}}]}`,
+		"sha256:amd64": fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",
+			"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:config","size":1},
+			"layers":[{"mediaType":"text/plain","digest":"sha256:readme","size":6},{"mediaType":"text/plain","digest":"sha256:amd64-artifact","size":%d}]}`,
+			len(testAMD64Content)),
+		"sha256:arm64": fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",
+			"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:config","size":1},
+			"layers":[{"mediaType":"text/plain","digest":"sha256:arm64-artifact","size":%d}]}`,
+			len(testARM64Content)),
+	}
+	blobs := map[string]string{
+		"sha256:amd64-artifact": testAMD64Content,
+		"sha256:arm64-artifact": testARM64Content,
+	}
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			io.WriteString(w, `{"token":"anonymous"}`)
+			return
+		}
+
+		if req.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		name := strings.TrimPrefix(req.URL.Path, "/v2/library/artifact/")
+		if strings.HasPrefix(name, "manifests/") {
+			if m, ok := manifests[strings.TrimPrefix(name, "manifests/")]; ok {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, m)
+				return
+			}
+		}
+
+		if strings.HasPrefix(name, "blobs/") {
+			if b, ok := blobs[strings.TrimPrefix(name, "blobs/")]; ok {
+				http.ServeContent(w, req, "", time.Time{}, strings.NewReader(b))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	retag := func() {
+		manifests["latest"] = manifests["sha256:amd64"]
+	}
+	return server, retag
+}
+
+func newTestRequest(t *testing.T, server *httptest.Server, reference string, header map[string]string) *source.Request {
+	request, err := source.NewRequestWithHeader(
+		fmt.Sprintf("oras://%s/library/artifact%s", strings.TrimPrefix(server.URL, "https://"), reference), header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func TestOrasSourceClient(t *testing.T) {
+	tests := []struct {
+		name                string
+		reference           string
+		rangeHeader         string
+		expectContentLength int64
+		expectContent       string
+	}{
+		{
+			name:                "select platform",
+			reference:           ":latest?platform=linux/arm64",
+			expectContentLength: int64(len(testARM64Content)),
+			expectContent:       testARM64Content,
+		},
+		{
+			name:                "last layer of manifest",
+			reference:           "@sha256:amd64",
+			expectContentLength: int64(len(testAMD64Content)),
+			expectContent:       testAMD64Content,
+		},
+		{
+			name:                "range",
+			reference:           "@sha256:arm64",
+			rangeHeader:         "6-13",
+			expectContentLength: 8,
+			expectContent:       "artifact",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			server, _ := newTestRegistry(t)
+			client := newOrasSourceClient(registry.NewClient(registry.WithHTTPClient(server.Client())))
+
+			header := map[string]string{}
+			if tc.rangeHeader != "" {
+				header[source.Range] = tc.rangeHeader
+			}
+			request := client.adaptor(newTestRequest(t, server, tc.reference, header))
+
+			contentLength, err := client.GetContentLength(request)
+			assert.Nil(err)
+			assert.Equal(tc.expectContentLength, contentLength)
+
+			supportRange, err := client.IsSupportRange(request)
+			assert.Nil(err)
+			assert.True(supportRange)
+
+			metadata, err := client.GetMetadata(request)
+			assert.Nil(err)
+			assert.True(metadata.SupportRange)
+			assert.Nil(metadata.Validate())
+			assert.GreaterOrEqual(metadata.TotalContentLength, contentLength)
+
+			response, err := client.Download(request)
+			assert.Nil(err)
+			defer response.Body.Close()
+			assert.Nil(response.Validate())
+
+			data, err := io.ReadAll(response.Body)
+			assert.Nil(err)
+			assert.Equal(tc.expectContent, string(data))
+		})
+	}
+}
+
+func TestOrasSourceClient_IsExpired(t *testing.T) {
+	assert := testifyassert.New(t)
+	server, retag := newTestRegistry(t)
+	client := newOrasSourceClient(registry.NewClient(registry.WithHTTPClient(server.Client())))
+
+	request := newTestRequest(t, server, ":latest?platform=linux/arm64", nil)
+	response, err := client.Download(request)
+	assert.Nil(err)
+	response.Body.Close()
+
+	info := response.ExpireInfo()
+	assert.Equal("sha256:arm64-artifact", info.ETag)
+
+	expired, err := client.IsExpired(request, &info)
+	assert.Nil(err)
+	assert.False(expired)
+
+	retag()
+	expired, err = client.IsExpired(request, &info)
+	assert.Nil(err)
+	assert.True(expired)
+
+	// the digest reference never expires
+	expired, err = client.IsExpired(newTestRequest(t, server, "@sha256:arm64", nil), &source.ExpireInfo{})
+	assert.Nil(err)
+	assert.False(expired)
+}
+
+func TestOrasSourceClient_NotFound(t *testing.T) {
+	assert := testifyassert.New(t)
+	server, _ := newTestRegistry(t)
+	client := newOrasSourceClient(registry.NewClient(registry.WithHTTPClient(server.Client())))
+
+	_, err := client.GetContentLength(newTestRequest(t, server, ":unknown", nil))
+	var respErr *registry.ResponseError
+	assert.ErrorAs(err, &respErr)
+	assert.Equal(http.StatusNotFound, respErr.StatusCode)
+
+	_, err = client.GetContentLength(newTestRequest(t, server, ":latest?platform=windows/amd64", nil))
+	assert.ErrorContains(err, "no manifest of platforms")
+}
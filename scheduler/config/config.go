@@ -19,6 +19,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"time"
 
@@ -60,6 +61,9 @@ type Config struct {
 	// Metrics configuration.
 	Metrics MetricsConfig `yaml:"metrics" mapstructure:"metrics"`
 
+	// Admin configuration.
+	Admin AdminConfig `yaml:"admin" mapstructure:"admin"`
+
 	// Security configuration.
 	Security SecurityConfig `yaml:"security" mapstructure:"security"`
 
@@ -129,6 +133,30 @@ type SchedulerConfig struct {
 
 	// Training configuration.
 	Training TrainingConfig `yaml:"training" mapstructure:"training"`
+
+	// Evaluator configuration.
+	Evaluator EvaluatorConfig `yaml:"evaluator" mapstructure:"evaluator"`
+
+	// HotTask configuration.
+	HotTask HotTaskConfig `yaml:"hotTask" mapstructure:"hotTask"`
+}
+
+type HotTaskConfig struct {
+	// Window is the sliding window of task statistics.
+	Window time.Duration `yaml:"window" mapstructure:"window"`
+
+	// TopN is the count of hot tasks exposed by metrics and api.
+	TopN int `yaml:"topN" mapstructure:"topN"`
+
+	// WarmUpThreshold is the count of registered peers in the window which the task
+	// is regarded as hot and seed peer is triggered to download it, zero disables it.
+	WarmUpThreshold int64 `yaml:"warmUpThreshold" mapstructure:"warmUpThreshold"`
+}
+
+type EvaluatorConfig struct {
+	// Weights is the weights of evaluator factors by name,
+	// factors not set use the default weights.
+	Weights map[string]float64 `yaml:"weights" mapstructure:"weights"`
 }
 
 type TrainingConfig struct {
@@ -143,6 +171,9 @@ type TrainingConfig struct {
 
 	// CPU limit while training.
 	CPU int `yaml:"cpu" mapstructure:"cpu"`
+
+	// Interval is the interval of training model by the download records.
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
 }
 
 type GCConfig struct {
@@ -265,6 +296,19 @@ type MetricsConfig struct {
 	EnablePeerHost bool `yaml:"enablePeerHost" mapstructure:"enablePeerHost"`
 }
 
+type AdminConfig struct {
+	// Enable admin service, it serves the debug information of scheduler
+	// without authentication, so it should listen on the internal address.
+	Enable bool `yaml:"enable" mapstructure:"enable"`
+
+	// Admin service address.
+	Addr string `yaml:"addr" mapstructure:"addr"`
+
+	// Enable exporting the download records by admin service, the records
+	// contain the hosts and tasks of cluster.
+	EnableRecordsExport bool `yaml:"enableRecordsExport" mapstructure:"enableRecordsExport"`
+}
+
 type SecurityConfig struct {
 	// AutoIssueCert indicates to issue client certificates for all grpc call
 	// if AutoIssueCert is false, any other option in Security will be ignored.
@@ -327,6 +371,12 @@ func New() *Config {
 				EnableAutoRefresh:    false,
 				RefreshModelInterval: DefaultRefreshModelInterval,
 				CPU:                  DefaultCPU,
+				Interval:             DefaultTrainingInterval,
+			},
+			HotTask: HotTaskConfig{
+				Window:          DefaultHotTaskWindow,
+				TopN:            DefaultHotTaskTopN,
+				WarmUpThreshold: 0,
 			},
 		},
 		DynConfig: DynConfig{
@@ -362,6 +412,11 @@ func New() *Config {
 			Addr:           DefaultMetricsAddr,
 			EnablePeerHost: false,
 		},
+		Admin: AdminConfig{
+			Enable:              false,
+			Addr:                DefaultAdminAddr,
+			EnableRecordsExport: false,
+		},
 		Security: SecurityConfig{
 			AutoIssueCert: false,
 			TLSVerify:     true,
@@ -441,11 +496,33 @@ func (cfg *Config) Validate() error {
 			return errors.New("training requires parameter cpu")
 		}
 
+		if cfg.Scheduler.Training.Interval <= 0 {
+			return errors.New("training requires parameter interval")
+		}
+
 		if cfg.Scheduler.Training.EnableAutoRefresh && cfg.Scheduler.Training.RefreshModelInterval <= 0 {
 			return errors.New("training requires parameter refreshModelInterval")
 		}
 	}
 
+	if cfg.Scheduler.HotTask.Window <= 0 {
+		return errors.New("hotTask requires parameter window")
+	}
+
+	if cfg.Scheduler.HotTask.TopN <= 0 {
+		return errors.New("hotTask requires parameter topN")
+	}
+
+	if cfg.Scheduler.HotTask.WarmUpThreshold < 0 {
+		return errors.New("hotTask requires parameter warmUpThreshold")
+	}
+
+	for name, weight := range cfg.Scheduler.Evaluator.Weights {
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return fmt.Errorf("evaluator requires parameter weight of %s", name)
+		}
+	}
+
 	if cfg.DynConfig.RefreshInterval <= 0 {
 		return errors.New("dynconfig requires parameter refreshInterval")
 	}
@@ -508,6 +585,12 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.Admin.Enable {
+		if cfg.Admin.Addr == "" {
+			return errors.New("admin requires parameter addr")
+		}
+	}
+
 	if cfg.Security.AutoIssueCert {
 		if cfg.Security.CACert == "" {
 			return errors.New("security requires parameter caCert")
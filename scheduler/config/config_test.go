@@ -48,6 +48,17 @@ func TestConfig_Load(t *testing.T) {
 				EnableAutoRefresh:    true,
 				RefreshModelInterval: 10 * time.Second,
 				CPU:                  2,
+				Interval:             1 * time.Hour,
+			},
+			Evaluator: EvaluatorConfig{
+				Weights: map[string]float64{
+					"finishedPiece": 0.3,
+				},
+			},
+			HotTask: HotTaskConfig{
+				Window:          time.Minute,
+				TopN:            20,
+				WarmUpThreshold: 100,
 			},
 		},
 		Server: ServerConfig{
@@ -104,6 +115,11 @@ func TestConfig_Load(t *testing.T) {
 			Addr:           ":8000",
 			EnablePeerHost: false,
 		},
+		Admin: AdminConfig{
+			Enable:              true,
+			Addr:                ":8004",
+			EnableRecordsExport: true,
+		},
 		Security: SecurityConfig{
 			AutoIssueCert: true,
 			CACert:        "foo",
@@ -79,8 +79,17 @@ const (
 	// DefaultRefreshModelInterval is model refresh interval.
 	DefaultRefreshModelInterval = 168 * time.Hour
 
+	// DefaultTrainingInterval is model training interval.
+	DefaultTrainingInterval = 24 * time.Hour
+
 	// DefaultCPU is default cpu usage.
 	DefaultCPU = 1
+
+	// DefaultHotTaskWindow is default sliding window of task statistics.
+	DefaultHotTaskWindow = 5 * time.Minute
+
+	// DefaultHotTaskTopN is default count of hot tasks.
+	DefaultHotTaskTopN = 10
 )
 
 const (
@@ -116,6 +125,9 @@ const (
 const (
 	// DefaultMetricsAddr is default address for metrics server.
 	DefaultMetricsAddr = ":8000"
+
+	// DefaultAdminAddr is default address for admin server.
+	DefaultAdminAddr = ":8004"
 )
 
 var (
@@ -21,19 +21,28 @@ package job
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/RichardKnop/machinery/v1"
+	machineryv1tasks "github.com/RichardKnop/machinery/v1/tasks"
 	"github.com/go-http-utils/headers"
 	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 
 	cdnsystemv1 "d7y.io/api/pkg/apis/cdnsystem/v1"
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	dfdaemonv1 "d7y.io/api/pkg/apis/dfdaemon/v1"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	internaljob "d7y.io/dragonfly/v2/internal/job"
 	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/common"
+	dfdaemonclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 )
@@ -41,6 +50,12 @@ import (
 const (
 	// preheatTimeout is timeout of preheating.
 	preheatTimeout = 20 * time.Minute
+
+	// deleteTaskTimeout is timeout of deleting task in peers.
+	deleteTaskTimeout = 20 * time.Minute
+
+	// progressReportInterval is the interval of reporting the progress of preheating.
+	progressReportInterval = 5 * time.Second
 )
 
 type Job interface {
@@ -53,9 +68,23 @@ type job struct {
 	localJob     *internaljob.Job
 	resource     resource.Resource
 	config       *config.Config
+
+	// transportCredentials stores the Authenticator required to setup a client connection.
+	transportCredentials credentials.TransportCredentials
+}
+
+// Option is a functional option for configuring the job.
+type Option func(j *job)
+
+// WithTransportCredentials returns a DialOption which configures a connection
+// level security credentials (e.g., TLS/SSL).
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(j *job) {
+		j.transportCredentials = creds
+	}
 }
 
-func New(cfg *config.Config, resource resource.Resource) (Job, error) {
+func New(cfg *config.Config, resource resource.Resource, options ...Option) (Job, error) {
 	redisConfig := &internaljob.Config{
 		Addrs:      cfg.Job.Redis.Addrs,
 		MasterName: cfg.Job.Redis.MasterName,
@@ -100,12 +129,21 @@ func New(cfg *config.Config, resource resource.Resource) (Job, error) {
 		config:       cfg,
 	}
 
+	for _, opt := range options {
+		opt(t)
+	}
+
 	namedJobFuncs := map[string]any{
-		internaljob.PreheatJob: t.preheat,
+		internaljob.PreheatJob:    t.preheat,
+		internaljob.GetTaskJob:    t.getTask,
+		internaljob.DeleteTaskJob: t.deleteTask,
+		internaljob.EvictTaskJob:  t.evictTask,
+		internaljob.SyncPeersJob:  t.syncPeers,
+		internaljob.PurgeJob:      t.purge,
 	}
 
 	if err := localJob.RegisterJob(namedJobFuncs); err != nil {
-		logger.Errorf("register preheat job to local queue error: %s", err.Error())
+		logger.Errorf("register jobs to local queue error: %s", err.Error())
 		return nil, err
 	}
 
@@ -145,6 +183,12 @@ func (j *job) preheat(ctx context.Context, req string) error {
 	ctx, cancel := context.WithTimeout(ctx, preheatTimeout)
 	defer cancel()
 
+	ctx, cancelGroup, err := j.localJob.WithCancel(ctx)
+	defer cancelGroup()
+	if err != nil {
+		return err
+	}
+
 	if !j.config.SeedPeer.Enable {
 		return errors.New("scheduler has disabled seed peer")
 	}
@@ -160,24 +204,14 @@ func (j *job) preheat(ctx context.Context, req string) error {
 		return err
 	}
 
-	urlMeta := &commonv1.UrlMeta{
-		Header: preheat.Headers,
-		Tag:    preheat.Tag,
-		Filter: preheat.Filter,
-		Digest: preheat.Digest,
-	}
-	if preheat.Headers != nil {
-		if r, ok := preheat.Headers[headers.Range]; ok {
-			// Range in dragonfly is without "bytes=".
-			urlMeta.Range = strings.TrimLeft(r, "bytes=")
-		}
-	}
+	urlMeta := newURLMeta(preheat.Headers, preheat.Tag, preheat.Filter, preheat.Digest)
 
 	// Trigger seed peer download seeds.
 	taskID := idgen.TaskID(preheat.URL, urlMeta)
 	log := logger.WithTask(taskID, preheat.URL)
 	log.Infof("preheat %s headers: %#v, tag: %s, range: %s, filter: %s, digest: %s",
 		preheat.URL, urlMeta.Header, urlMeta.Tag, urlMeta.Range, urlMeta.Filter, urlMeta.Digest)
+	progress := &internaljob.Progress{PeerCount: 1}
 	stream, err := j.resource.SeedPeer().Client().ObtainSeeds(ctx, &cdnsystemv1.SeedRequest{
 		TaskId:  taskID,
 		Url:     preheat.URL,
@@ -185,19 +219,375 @@ func (j *job) preheat(ctx context.Context, req string) error {
 	})
 	if err != nil {
 		log.Errorf("preheat %s failed: %s", preheat.URL, err.Error())
+		progress.Errors = append(progress.Errors, err.Error())
+		j.setProgress(ctx, progress)
 		return err
 	}
 
+	var lastReportedAt time.Time
 	for {
 		piece, err := stream.Recv()
 		if err != nil {
 			log.Errorf("preheat %s recive piece failed: %s", preheat.URL, err.Error())
+			progress.Errors = append(progress.Errors, err.Error())
+			j.setProgress(ctx, progress)
 			return err
 		}
 
+		if piece.PieceInfo != nil && piece.PieceInfo.PieceNum != common.EndOfPiece {
+			progress.Bytes += int64(piece.PieceInfo.RangeSize)
+		}
+
 		if piece.Done == true {
 			log.Infof("preheat %s succeeded", preheat.URL)
+			j.setProgress(ctx, progress)
 			return nil
 		}
+
+		if time.Since(lastReportedAt) >= progressReportInterval {
+			lastReportedAt = time.Now()
+			j.setProgress(ctx, progress)
+		}
+	}
+}
+
+// getTask lists the peers which have downloaded the task successfully.
+func (j *job) getTask(ctx context.Context, req string) (string, error) {
+	ctx, cancelGroup, err := j.localJob.WithCancel(ctx)
+	defer cancelGroup()
+	if err != nil {
+		return "", err
+	}
+
+	getTask := &internaljob.GetTaskRequest{}
+	if err := internaljob.UnmarshalRequest(req, getTask); err != nil {
+		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
+		return "", err
+	}
+
+	if err := validator.New().Struct(getTask); err != nil {
+		logger.Errorf("get task %s validate failed: %s", getTask.URL, err.Error())
+		return "", err
+	}
+
+	taskID := idgen.TaskID(getTask.URL, &commonv1.UrlMeta{
+		Tag:         getTask.Tag,
+		Application: getTask.Application,
+		Digest:      getTask.Digest,
+		Filter:      getTask.Filter,
+	})
+
+	var peers []*internaljob.Peer
+	for _, peer := range j.loadSucceededPeers(taskID, false) {
+		peers = append(peers, newPeer(peer))
+	}
+	j.setProgress(ctx, &internaljob.Progress{PeerCount: int64(len(peers))})
+
+	return internaljob.MarshalResponse(&internaljob.GetTaskResponse{
+		TaskID: taskID,
+		Peers:  peers,
+	})
+}
+
+// deleteTask deletes the task from all peers which have downloaded the task successfully.
+func (j *job) deleteTask(ctx context.Context, req string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, deleteTaskTimeout)
+	defer cancel()
+
+	ctx, cancelGroup, err := j.localJob.WithCancel(ctx)
+	defer cancelGroup()
+	if err != nil {
+		return "", err
+	}
+
+	deleteTask := &internaljob.DeleteTaskRequest{}
+	if err := internaljob.UnmarshalRequest(req, deleteTask); err != nil {
+		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
+		return "", err
+	}
+
+	if err := validator.New().Struct(deleteTask); err != nil {
+		logger.Errorf("delete task %s validate failed: %s", deleteTask.URL, err.Error())
+		return "", err
+	}
+
+	urlMeta := &commonv1.UrlMeta{
+		Tag:         deleteTask.Tag,
+		Application: deleteTask.Application,
+		Digest:      deleteTask.Digest,
+		Filter:      deleteTask.Filter,
+	}
+	taskID := idgen.TaskID(deleteTask.URL, urlMeta)
+	successPeers, failurePeers := j.deleteTaskInPeers(ctx, deleteTask.URL, urlMeta, j.loadSucceededPeers(taskID, false))
+
+	return internaljob.MarshalResponse(&internaljob.DeleteTaskResponse{
+		TaskID:       taskID,
+		SuccessPeers: successPeers,
+		FailurePeers: failurePeers,
+	})
+}
+
+// evictTask deletes the task from the seed peers which have downloaded the task successfully.
+func (j *job) evictTask(ctx context.Context, req string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, deleteTaskTimeout)
+	defer cancel()
+
+	ctx, cancelGroup, err := j.localJob.WithCancel(ctx)
+	defer cancelGroup()
+	if err != nil {
+		return "", err
+	}
+
+	evictTask := &internaljob.EvictTaskRequest{}
+	if err := internaljob.UnmarshalRequest(req, evictTask); err != nil {
+		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
+		return "", err
+	}
+
+	if err := validator.New().Struct(evictTask); err != nil {
+		logger.Errorf("evict task %s validate failed: %s", evictTask.URL, err.Error())
+		return "", err
+	}
+
+	urlMeta := &commonv1.UrlMeta{
+		Tag:         evictTask.Tag,
+		Application: evictTask.Application,
+		Digest:      evictTask.Digest,
+		Filter:      evictTask.Filter,
+	}
+	taskID := idgen.TaskID(evictTask.URL, urlMeta)
+	successPeers, failurePeers := j.deleteTaskInPeers(ctx, evictTask.URL, urlMeta, j.loadSucceededPeers(taskID, true))
+
+	return internaljob.MarshalResponse(&internaljob.EvictTaskResponse{
+		TaskID:       taskID,
+		SuccessPeers: successPeers,
+		FailurePeers: failurePeers,
+	})
+}
+
+// syncPeers lists the hosts announced to the scheduler.
+func (j *job) syncPeers(ctx context.Context, req string) (string, error) {
+	ctx, cancelGroup, err := j.localJob.WithCancel(ctx)
+	defer cancelGroup()
+	if err != nil {
+		return "", err
+	}
+
+	var hosts []*internaljob.Host
+	j.resource.HostManager().Range(func(_, value any) bool {
+		host, ok := value.(*resource.Host)
+		if !ok {
+			return true
+		}
+
+		h := &internaljob.Host{
+			ID:           host.ID,
+			Type:         host.Type.Name(),
+			Hostname:     host.Hostname,
+			IP:           host.IP,
+			Port:         host.Port,
+			DownloadPort: host.DownloadPort,
+			PeerCount:    host.PeerCount.Load(),
+		}
+
+		if host.Network != nil {
+			h.IDC = host.Network.Idc
+			h.Location = host.Network.Location
+		}
+
+		hosts = append(hosts, h)
+		return true
+	})
+	j.setProgress(ctx, &internaljob.Progress{PeerCount: int64(len(hosts))})
+
+	return internaljob.MarshalResponse(&internaljob.SyncPeersResponse{
+		Hosts: hosts,
+	})
+}
+
+// purge deletes the task from all peers which have downloaded the task successfully,
+// then the other peers of the task leave and the task is dropped from the task manager,
+// so the next download of the url fetches the content from the source again.
+func (j *job) purge(ctx context.Context, req string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, deleteTaskTimeout)
+	defer cancel()
+
+	ctx, cancelGroup, err := j.localJob.WithCancel(ctx)
+	defer cancelGroup()
+	if err != nil {
+		return "", err
+	}
+
+	purge := &internaljob.PurgeRequest{}
+	if err := internaljob.UnmarshalRequest(req, purge); err != nil {
+		logger.Errorf("unmarshal request err: %s, request body: %s", err.Error(), req)
+		return "", err
+	}
+
+	if err := validator.New().Struct(purge); err != nil {
+		logger.Errorf("purge %s validate failed: %s", purge.URL, err.Error())
+		return "", err
+	}
+
+	urlMeta := newURLMeta(purge.Headers, purge.Tag, purge.Filter, "")
+	taskID := idgen.TaskID(purge.URL, urlMeta)
+	log := logger.WithTask(taskID, purge.URL)
+	log.Infof("purge %s headers: %#v, tag: %s, range: %s, filter: %s",
+		purge.URL, urlMeta.Header, urlMeta.Tag, urlMeta.Range, urlMeta.Filter)
+
+	successPeers, failurePeers := j.deleteTaskInPeers(ctx, purge.URL, urlMeta, j.loadSucceededPeers(taskID, false))
+	if err := ctx.Err(); err != nil {
+		log.Errorf("purge %s failed: %s", purge.URL, err.Error())
+		return "", err
+	}
+
+	if task, loaded := j.resource.TaskManager().Load(taskID); loaded {
+		for _, vertex := range task.DAG.GetVertices() {
+			peer := vertex.Value
+			if peer == nil || peer.FSM.Is(resource.PeerStateLeave) {
+				continue
+			}
+
+			if err := peer.FSM.Event(ctx, resource.PeerEventLeave); err != nil {
+				peer.Log.Errorf("peer fsm event failed: %s", err.Error())
+			}
+		}
+
+		j.resource.TaskManager().Delete(taskID)
+	}
+
+	state := machineryv1tasks.StateSuccess
+	if len(failurePeers) > 0 {
+		state = machineryv1tasks.StateFailure
+	}
+	log.Infof("purge %s %s, %d peers succeeded and %d peers failed", purge.URL, state, len(successPeers), len(failurePeers))
+
+	return internaljob.MarshalResponse(&internaljob.PurgeResponse{
+		TaskID: taskID,
+		Schedulers: []*internaljob.PurgeScheduler{
+			{
+				SchedulerClusterID: j.config.Manager.SchedulerClusterID,
+				Hostname:           j.config.Server.Host,
+				State:              state,
+				SuccessPeers:       successPeers,
+				FailurePeers:       failurePeers,
+			},
+		},
+	})
+}
+
+// loadSucceededPeers returns the peers which have downloaded the task successfully,
+// only the peers of seed peer hosts are returned if seedPeerOnly is true.
+func (j *job) loadSucceededPeers(taskID string, seedPeerOnly bool) []*resource.Peer {
+	task, loaded := j.resource.TaskManager().Load(taskID)
+	if !loaded {
+		return nil
+	}
+
+	var peers []*resource.Peer
+	for _, vertex := range task.DAG.GetVertices() {
+		peer := vertex.Value
+		if peer == nil || !peer.FSM.Is(resource.PeerStateSucceeded) {
+			continue
+		}
+
+		if seedPeerOnly && peer.Host.Type == types.HostTypeNormal {
+			continue
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers
+}
+
+// deleteTaskInPeers deletes the task in the peers by dfdaemon, and the peers
+// which have deleted the task leave in the scheduler.
+func (j *job) deleteTaskInPeers(ctx context.Context, url string, urlMeta *commonv1.UrlMeta, peers []*resource.Peer) ([]*internaljob.Peer, []*internaljob.FailurePeer) {
+	var (
+		successPeers []*internaljob.Peer
+		failurePeers []*internaljob.FailurePeer
+	)
+	progress := &internaljob.Progress{}
+	defer j.setProgress(ctx, progress)
+
+	for _, peer := range peers {
+		progress.PeerCount++
+		if err := j.deleteTaskInPeer(ctx, url, urlMeta, peer); err != nil {
+			peer.Log.Errorf("delete task in peer failed: %s", err.Error())
+			failurePeers = append(failurePeers, &internaljob.FailurePeer{
+				Peer:        *newPeer(peer),
+				Description: err.Error(),
+			})
+			progress.Errors = append(progress.Errors, fmt.Sprintf("peer %s: %s", peer.ID, err.Error()))
+			continue
+		}
+
+		peer.Log.Info("delete task in peer succeeded")
+		successPeers = append(successPeers, newPeer(peer))
+		if err := peer.FSM.Event(ctx, resource.PeerEventLeave); err != nil {
+			peer.Log.Errorf("peer fsm event failed: %s", err.Error())
+		}
+	}
+
+	return successPeers, failurePeers
+}
+
+// deleteTaskInPeer deletes the task in the peer by dfdaemon.
+func (j *job) deleteTaskInPeer(ctx context.Context, url string, urlMeta *commonv1.UrlMeta, peer *resource.Peer) error {
+	dialOptions := []grpc.DialOption{}
+	if j.transportCredentials != nil {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(j.transportCredentials))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	client, err := dfdaemonclient.GetClient(ctx, fmt.Sprintf("%s:%d", peer.Host.IP, peer.Host.Port), dialOptions...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.DeleteTask(ctx, &dfdaemonv1.DeleteTaskRequest{
+		Url:     url,
+		UrlMeta: urlMeta,
+	})
+}
+
+// setProgress stores the progress of the job.
+func (j *job) setProgress(ctx context.Context, progress *internaljob.Progress) {
+	if err := j.localJob.SetProgress(ctx, progress); err != nil {
+		logger.Errorf("set progress failed: %s", err.Error())
+	}
+}
+
+// newURLMeta returns the url meta of task, the range is parsed from the headers.
+func newURLMeta(header map[string]string, tag, filter, digest string) *commonv1.UrlMeta {
+	urlMeta := &commonv1.UrlMeta{
+		Header: header,
+		Tag:    tag,
+		Filter: filter,
+		Digest: digest,
+	}
+	if header != nil {
+		if r, ok := header[headers.Range]; ok {
+			// Range in dragonfly is without "bytes=".
+			urlMeta.Range = strings.TrimLeft(r, "bytes=")
+		}
+	}
+
+	return urlMeta
+}
+
+// newPeer returns the peer of job response.
+func newPeer(peer *resource.Peer) *internaljob.Peer {
+	return &internaljob.Peer{
+		ID:       peer.ID,
+		State:    peer.FSM.Current(),
+		HostID:   peer.Host.ID,
+		HostType: peer.Host.Type.Name(),
+		Hostname: peer.Host.Hostname,
+		IP:       peer.Host.IP,
+		Port:     peer.Host.Port,
 	}
 }
@@ -18,11 +18,14 @@ package resource
 
 import (
 	"context"
+	"net"
+	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/atomic"
 
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
@@ -108,6 +111,9 @@ type Host struct {
 	// PeerCount is peer count.
 	PeerCount *atomic.Int32
 
+	// Probes is the network distances from host to the other hosts.
+	Probes *Probes
+
 	// CreatedAt is host create time.
 	CreatedAt *atomic.Time
 
@@ -143,6 +149,7 @@ func NewHost(req *schedulerv1.AnnounceHostRequest, options ...HostOption) *Host
 		UploadFailedCount:     atomic.NewInt64(0),
 		Peers:                 &sync.Map{},
 		PeerCount:             atomic.NewInt32(0),
+		Probes:                newProbes(),
 		CreatedAt:             atomic.NewTime(time.Now()),
 		UpdatedAt:             atomic.NewTime(time.Now()),
 		Log:                   logger.WithHost(req.Id, req.Hostname, req.Ip),
@@ -201,3 +208,33 @@ func (h *Host) LeavePeers() {
 func (h *Host) FreeUploadCount() int32 {
 	return h.ConcurrentUploadLimit.Load() - h.ConcurrentUploadCount.Load()
 }
+
+// ToV2 converts host to host of v2 version.
+func (h *Host) ToV2() *commonv2.Host {
+	resp := &commonv2.Host{
+		Id:           h.ID,
+		Hostname:     h.Hostname,
+		Port:         h.Port,
+		DownloadPort: h.DownloadPort,
+	}
+
+	if ip := net.ParseIP(h.IP); ip != nil && ip.To4() == nil {
+		resp.Ipv6 = h.IP
+	} else {
+		resp.Ipv4 = h.IP
+	}
+
+	if h.Network != nil {
+		resp.SecurityDomain = h.Network.SecurityDomain
+		resp.Idc = h.Network.Idc
+		if h.Network.Location != "" {
+			resp.Location = strings.Split(h.Network.Location, types.AffinitySeparator)
+		}
+
+		if h.Network.NetTopology != "" {
+			resp.NetTopology = strings.Split(h.Network.NetTopology, types.AffinitySeparator)
+		}
+	}
+
+	return resp
+}
@@ -46,6 +46,10 @@ type HostManager interface {
 	// Delete deletes host for a key.
 	Delete(string)
 
+	// Range calls f sequentially for each key and value present in the map.
+	// If f returns false, range stops the iteration.
+	Range(f func(any, any) bool)
+
 	// Try to reclaim host.
 	RunGC() error
 }
@@ -108,8 +112,13 @@ func (h *hostManager) RunGC() error {
 			host.Type == types.HostTypeNormal {
 			host.Log.Info("host has been reclaimed")
 			h.Delete(host.ID)
+			return true
 		}
 
+		// Reclaim the probes which are not updated, and the probes
+		// to the reclaimed hosts are expired eventually.
+		host.Probes.DeleteExpired(ProbeTTL)
+
 		return true
 	})
 
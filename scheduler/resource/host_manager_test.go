@@ -299,6 +299,67 @@ func TestHostManager_Delete(t *testing.T) {
 	}
 }
 
+func TestHostManager_Range(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(m *gc.MockGCMockRecorder)
+		expect func(t *testing.T, hostManager HostManager, mockHost *Host)
+	}{
+		{
+			name: "range hosts",
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, hostManager HostManager, mockHost *Host) {
+				assert := assert.New(t)
+				hostManager.Store(mockHost)
+				hostManager.Range(func(key, value any) bool {
+					host, ok := value.(*Host)
+					assert.Equal(ok, true)
+					assert.Equal(key, mockHost.ID)
+					assert.Equal(host.ID, mockHost.ID)
+					return true
+				})
+			},
+		},
+		{
+			name: "range stops iteration",
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, hostManager HostManager, mockHost *Host) {
+				assert := assert.New(t)
+				hostManager.Store(mockHost)
+				hostManager.Store(NewHost(mockRawSeedHost))
+
+				var n int
+				hostManager.Range(func(key, value any) bool {
+					n++
+					return false
+				})
+				assert.Equal(n, 1)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			gc := gc.NewMockGC(ctl)
+			tc.mock(gc.EXPECT())
+
+			mockHost := NewHost(mockRawHost)
+			hostManager, err := newHostManager(mockHostGCConfig, gc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.expect(t, hostManager, mockHost)
+		})
+	}
+}
+
 func TestHostManager_RunGC(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -337,6 +398,27 @@ func TestHostManager_RunGC(t *testing.T) {
 				assert.Equal(host.ID, mockHost.ID)
 			},
 		},
+		{
+			name: "host reclaims expired probes",
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, hostManager HostManager, mockHost *Host, mockPeer *Peer) {
+				assert := assert.New(t)
+				hostManager.Store(mockHost)
+				mockHost.StorePeer(mockPeer)
+				mockHost.Probes.Store("foo", Probe{Bandwidth: 100})
+				mockHost.Probes.Store("bar", Probe{Bandwidth: 100, UpdatedAt: time.Now().Add(-2 * ProbeTTL)})
+				err := hostManager.RunGC()
+				assert.NoError(err)
+
+				host, ok := hostManager.Load(mockHost.ID)
+				assert.Equal(ok, true)
+				assert.Equal(host.Probes.Len(), 1)
+				_, ok = host.Probes.Load("foo")
+				assert.Equal(ok, true)
+			},
+		},
 		{
 			name: "host has upload peers",
 			mock: func(m *gc.MockGCMockRecorder) {
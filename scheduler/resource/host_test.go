@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 
 	"d7y.io/dragonfly/v2/pkg/idgen"
@@ -379,3 +380,68 @@ func TestHost_FreeUploadCount(t *testing.T) {
 		})
 	}
 }
+
+func TestHost_ToV2(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawHost *schedulerv1.AnnounceHostRequest
+		expect  func(t *testing.T, host *commonv2.Host)
+	}{
+		{
+			name:    "convert host",
+			rawHost: mockRawHost,
+			expect: func(t *testing.T, host *commonv2.Host) {
+				assert := assert.New(t)
+				assert.EqualValues(host, &commonv2.Host{
+					Id:             mockRawHost.Id,
+					Ipv4:           mockRawHost.Ip,
+					Hostname:       mockRawHost.Hostname,
+					Port:           mockRawHost.Port,
+					DownloadPort:   mockRawHost.DownloadPort,
+					SecurityDomain: mockRawHost.Network.SecurityDomain,
+					Location:       []string{mockRawHost.Network.Location},
+					Idc:            mockRawHost.Network.Idc,
+					NetTopology:    []string{mockRawHost.Network.NetTopology},
+				})
+			},
+		},
+		{
+			name: "convert host with ipv6 and affinities",
+			rawHost: &schedulerv1.AnnounceHostRequest{
+				Id: mockRawHost.Id,
+				Ip: "::1",
+				Network: &schedulerv1.Network{
+					Location:    "foo|bar",
+					NetTopology: "baz|qux",
+				},
+			},
+			expect: func(t *testing.T, host *commonv2.Host) {
+				assert := assert.New(t)
+				assert.Equal(host.Ipv6, "::1")
+				assert.Empty(host.Ipv4)
+				assert.Equal(host.Location, []string{"foo", "bar"})
+				assert.Equal(host.NetTopology, []string{"baz", "qux"})
+			},
+		},
+		{
+			name: "convert host without network",
+			rawHost: &schedulerv1.AnnounceHostRequest{
+				Id: mockRawHost.Id,
+				Ip: mockRawHost.Ip,
+			},
+			expect: func(t *testing.T, host *commonv2.Host) {
+				assert := assert.New(t)
+				assert.Equal(host.Ipv4, mockRawHost.Ip)
+				assert.Empty(host.SecurityDomain)
+				assert.Nil(host.Location)
+				assert.Nil(host.NetTopology)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, NewHost(tc.rawHost).ToV2())
+		})
+	}
+}
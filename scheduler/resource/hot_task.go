@@ -0,0 +1,157 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"d7y.io/dragonfly/v2/pkg/types"
+)
+
+// HotTask is the statistics of hot task in the sliding window.
+type HotTask struct {
+	// ID is task id.
+	ID string `json:"id"`
+
+	// URL is task download url.
+	URL string `json:"url"`
+
+	// Tag is task tag.
+	Tag string `json:"tag"`
+
+	// Application is task application.
+	Application string `json:"application"`
+
+	// Requests is the count of peers registered in the window.
+	Requests int64 `json:"requests"`
+
+	// RequestRate is the count of peers registered per second in the window.
+	RequestRate float64 `json:"requestRate"`
+
+	// PeerCount is the count of peers of task.
+	PeerCount int `json:"peerCount"`
+
+	// BackToSources is the count of peers downloaded back-to-source in the window.
+	BackToSources int64 `json:"backToSources"`
+
+	// ServedBytes is the bytes of pieces served by parents in the window.
+	ServedBytes int64 `json:"servedBytes"`
+}
+
+// hotTaskHandler writes the hot tasks.
+type hotTaskHandler struct {
+	taskManager TaskManager
+	topN        int
+}
+
+// NewHotTaskHandler returns a http handler that writes the top n hot tasks,
+// n is the query parameter and the default value is topN, e.g. /tasks/hot?n=10.
+func NewHotTaskHandler(taskManager TaskManager, topN int) http.Handler {
+	return &hotTaskHandler{taskManager: taskManager, topN: topN}
+}
+
+// ServeHTTP writes the hot tasks in json.
+func (h *hotTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	n := h.topN
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid n %s", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	hotTasks := h.taskManager.HotTasks(n)
+	if hotTasks == nil {
+		hotTasks = []HotTask{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hotTasks); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Descriptions of hot task metrics.
+var (
+	hotTaskLabels = []string{"task_id", "tag", "app"}
+
+	hotTaskRequestsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(types.MetricsNamespace, types.SchedulerMetricsName, "hot_task_requests"),
+		"Gauge of the number of registered peers of hot task in the sliding window.",
+		hotTaskLabels, nil,
+	)
+
+	hotTaskPeersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(types.MetricsNamespace, types.SchedulerMetricsName, "hot_task_peers"),
+		"Gauge of the number of peers of hot task.",
+		hotTaskLabels, nil,
+	)
+
+	hotTaskBackToSourcesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(types.MetricsNamespace, types.SchedulerMetricsName, "hot_task_back_to_sources"),
+		"Gauge of the number of back-to-source peers of hot task in the sliding window.",
+		hotTaskLabels, nil,
+	)
+
+	hotTaskServedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(types.MetricsNamespace, types.SchedulerMetricsName, "hot_task_served_bytes"),
+		"Gauge of the bytes served by parents of hot task in the sliding window.",
+		hotTaskLabels, nil,
+	)
+)
+
+// hotTaskCollector collects the metrics of the top n hot tasks when scraped,
+// so only the hot tasks are exported as the labels.
+type hotTaskCollector struct {
+	taskManager TaskManager
+	topN        int
+}
+
+// NewHotTaskCollector returns a prometheus collector of the top n hot tasks.
+func NewHotTaskCollector(taskManager TaskManager, topN int) prometheus.Collector {
+	return &hotTaskCollector{taskManager: taskManager, topN: topN}
+}
+
+// Describe sends the descriptions of hot task metrics.
+func (c *hotTaskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- hotTaskRequestsDesc
+	ch <- hotTaskPeersDesc
+	ch <- hotTaskBackToSourcesDesc
+	ch <- hotTaskServedBytesDesc
+}
+
+// Collect sends the metrics of the top n hot tasks.
+func (c *hotTaskCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, hotTask := range c.taskManager.HotTasks(c.topN) {
+		labels := []string{hotTask.ID, hotTask.Tag, hotTask.Application}
+		ch <- prometheus.MustNewConstMetric(hotTaskRequestsDesc, prometheus.GaugeValue, float64(hotTask.Requests), labels...)
+		ch <- prometheus.MustNewConstMetric(hotTaskPeersDesc, prometheus.GaugeValue, float64(hotTask.PeerCount), labels...)
+		ch <- prometheus.MustNewConstMetric(hotTaskBackToSourcesDesc, prometheus.GaugeValue, float64(hotTask.BackToSources), labels...)
+		ch <- prometheus.MustNewConstMetric(hotTaskServedBytesDesc, prometheus.GaugeValue, float64(hotTask.ServedBytes), labels...)
+	}
+}
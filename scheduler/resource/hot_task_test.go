@@ -0,0 +1,155 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockHotTasks = []HotTask{
+	{
+		ID:            mockTaskID,
+		URL:           mockTaskURL,
+		Tag:           "d7y",
+		Application:   "foo",
+		Requests:      10,
+		RequestRate:   1,
+		PeerCount:     2,
+		BackToSources: 1,
+		ServedBytes:   1024,
+	},
+}
+
+func TestHotTask_Handler(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		mock   func(m *MockTaskManagerMockRecorder)
+		expect func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "get hot tasks",
+			method: http.MethodGet,
+			target: "/debug/tasks/hot",
+			mock: func(m *MockTaskManagerMockRecorder) {
+				m.HotTasks(gomock.Eq(10)).Return(mockHotTasks).Times(1)
+			},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusOK)
+				assert.Equal(w.Header().Get("Content-Type"), "application/json")
+
+				var hotTasks []HotTask
+				assert.NoError(json.Unmarshal(w.Body.Bytes(), &hotTasks))
+				assert.EqualValues(hotTasks, mockHotTasks)
+			},
+		},
+		{
+			name:   "get hot tasks with n",
+			method: http.MethodGet,
+			target: "/debug/tasks/hot?n=1",
+			mock: func(m *MockTaskManagerMockRecorder) {
+				m.HotTasks(gomock.Eq(1)).Return(mockHotTasks).Times(1)
+			},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusOK)
+			},
+		},
+		{
+			name:   "get empty hot tasks",
+			method: http.MethodGet,
+			target: "/debug/tasks/hot",
+			mock: func(m *MockTaskManagerMockRecorder) {
+				m.HotTasks(gomock.Eq(10)).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusOK)
+				assert.Equal(strings.TrimSpace(w.Body.String()), "[]")
+			},
+		},
+		{
+			name:   "get hot tasks with invalid n",
+			method: http.MethodGet,
+			target: "/debug/tasks/hot?n=foo",
+			mock:   func(m *MockTaskManagerMockRecorder) {},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+			},
+		},
+		{
+			name:   "get hot tasks with negative n",
+			method: http.MethodGet,
+			target: "/debug/tasks/hot?n=-1",
+			mock:   func(m *MockTaskManagerMockRecorder) {},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+			},
+		},
+		{
+			name:   "method not allowed",
+			method: http.MethodPost,
+			target: "/debug/tasks/hot",
+			mock:   func(m *MockTaskManagerMockRecorder) {},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusMethodNotAllowed)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			taskManager := NewMockTaskManager(ctl)
+			tc.mock(taskManager.EXPECT())
+
+			w := httptest.NewRecorder()
+			NewHotTaskHandler(taskManager, 10).ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+			tc.expect(t, w)
+		})
+	}
+}
+
+func TestHotTask_Collector(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	taskManager := NewMockTaskManager(ctl)
+	taskManager.EXPECT().HotTasks(gomock.Eq(10)).Return(mockHotTasks).AnyTimes()
+
+	collector := NewHotTaskCollector(taskManager, 10)
+	assert := assert.New(t)
+	assert.Equal(testutil.CollectAndCount(collector), 4)
+	assert.NoError(testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP dragonfly_scheduler_hot_task_requests Gauge of the number of registered peers of hot task in the sliding window.
+# TYPE dragonfly_scheduler_hot_task_requests gauge
+dragonfly_scheduler_hot_task_requests{app="foo",tag="d7y",task_id="`+mockTaskID+`"} 10
+`), "dragonfly_scheduler_hot_task_requests"))
+}
@@ -29,10 +29,13 @@ import (
 	"github.com/go-http-utils/headers"
 	"github.com/looplab/fsm"
 	"go.uber.org/atomic"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
 	managerv1 "d7y.io/api/pkg/apis/manager/v1"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/container/set"
@@ -155,6 +158,9 @@ type Peer struct {
 	// Stream is grpc stream instance.
 	Stream *atomic.Value
 
+	// AnnouncePeerStream is the grpc stream of v2 version AnnouncePeer.
+	AnnouncePeerStream *atomic.Value
+
 	// Task state machine.
 	FSM *fsm.FSM
 
@@ -197,23 +203,24 @@ type Peer struct {
 // New Peer instance.
 func NewPeer(id string, task *Task, host *Host, options ...PeerOption) *Peer {
 	p := &Peer{
-		ID:               id,
-		Tag:              DefaultTag,
-		Application:      DefaultApplication,
-		Pieces:           set.NewSafeSet[*schedulerv1.PieceResult](),
-		FinishedPieces:   &bitset.BitSet{},
-		pieceCosts:       []int64{},
-		Cost:             atomic.NewDuration(0),
-		Stream:           &atomic.Value{},
-		Task:             task,
-		Host:             host,
-		BlockParents:     set.NewSafeSet[string](),
-		NeedBackToSource: atomic.NewBool(false),
-		IsBackToSource:   atomic.NewBool(false),
-		PieceUpdatedAt:   atomic.NewTime(time.Now()),
-		CreatedAt:        atomic.NewTime(time.Now()),
-		UpdatedAt:        atomic.NewTime(time.Now()),
-		Log:              logger.WithPeer(host.ID, task.ID, id),
+		ID:                 id,
+		Tag:                DefaultTag,
+		Application:        DefaultApplication,
+		Pieces:             set.NewSafeSet[*schedulerv1.PieceResult](),
+		FinishedPieces:     &bitset.BitSet{},
+		pieceCosts:         []int64{},
+		Cost:               atomic.NewDuration(0),
+		Stream:             &atomic.Value{},
+		AnnouncePeerStream: &atomic.Value{},
+		Task:               task,
+		Host:               host,
+		BlockParents:       set.NewSafeSet[string](),
+		NeedBackToSource:   atomic.NewBool(false),
+		IsBackToSource:     atomic.NewBool(false),
+		PieceUpdatedAt:     atomic.NewTime(time.Now()),
+		CreatedAt:          atomic.NewTime(time.Now()),
+		UpdatedAt:          atomic.NewTime(time.Now()),
+		Log:                logger.WithPeer(host.ID, task.ID, id),
 	}
 
 	// Initialize state machine.
@@ -265,6 +272,7 @@ func NewPeer(id string, task *Task, host *Host, options ...PeerOption) *Peer {
 			PeerEventDownloadBackToSource: func(ctx context.Context, e *fsm.Event) {
 				p.IsBackToSource.Store(true)
 				p.Task.BackToSourcePeers.Add(p.ID)
+				p.Task.Stats.AddBackToSource()
 
 				if err := p.Task.DeletePeerInEdges(p.ID); err != nil {
 					p.Log.Errorf("delete peer inedges failed: %s", err.Error())
@@ -347,6 +355,34 @@ func (p *Peer) DeleteStream() {
 	p.Stream = &atomic.Value{}
 }
 
+// announcePeerStream wraps the grpc stream of v2 version AnnouncePeer,
+// the empty value indicates that the stream has been deleted.
+type announcePeerStream struct {
+	stream schedulerv2.Scheduler_AnnouncePeerServer
+}
+
+// LoadAnnouncePeerStream return the grpc stream of v2 version AnnouncePeer.
+func (p *Peer) LoadAnnouncePeerStream() (schedulerv2.Scheduler_AnnouncePeerServer, bool) {
+	rawStream, ok := p.AnnouncePeerStream.Load().(announcePeerStream)
+	if !ok || rawStream.stream == nil {
+		return nil, false
+	}
+
+	return rawStream.stream, true
+}
+
+// StoreAnnouncePeerStream set the grpc stream of v2 version AnnouncePeer.
+func (p *Peer) StoreAnnouncePeerStream(stream schedulerv2.Scheduler_AnnouncePeerServer) {
+	p.AnnouncePeerStream.Store(announcePeerStream{stream})
+}
+
+// DeleteAnnouncePeerStream deletes the grpc stream of v2 version AnnouncePeer
+// only if it is still the stored stream, because the peer may have
+// registered again with a newer stream.
+func (p *Peer) DeleteAnnouncePeerStream(stream schedulerv2.Scheduler_AnnouncePeerServer) bool {
+	return p.AnnouncePeerStream.CompareAndSwap(announcePeerStream{stream}, announcePeerStream{})
+}
+
 // Parents returns parents of peer.
 func (p *Peer) Parents() []*Peer {
 	vertex, err := p.Task.DAG.GetVertex(p.ID)
@@ -470,3 +506,25 @@ func (p *Peer) GetPriority(dynconfig config.DynconfigInterface) commonv1.Priorit
 
 	return application.Priority.Value
 }
+
+// ToV2 converts peer to peer of v2 version.
+func (p *Peer) ToV2() *commonv2.Peer {
+	resp := &commonv2.Peer{
+		Id:        p.ID,
+		Task:      p.Task.ToV2(),
+		Host:      p.Host.ToV2(),
+		State:     p.FSM.Current(),
+		CreatedAt: timestamppb.New(p.CreatedAt.Load()),
+		UpdatedAt: timestamppb.New(p.UpdatedAt.Load()),
+	}
+
+	for _, piece := range p.Pieces.Values() {
+		if piece.PieceInfo == nil {
+			continue
+		}
+
+		resp.Pieces = append(resp.Pieces, PieceResultToV2(piece))
+	}
+
+	return resp
+}
@@ -34,6 +34,8 @@ import (
 	managerv1 "d7y.io/api/pkg/apis/manager/v1"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 	"d7y.io/api/pkg/apis/scheduler/v1/mocks"
+	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
+	schedulerv2mocks "d7y.io/api/pkg/apis/scheduler/v2/mocks"
 
 	"d7y.io/dragonfly/v2/client/util"
 	"d7y.io/dragonfly/v2/pkg/idgen"
@@ -273,6 +275,129 @@ func TestPeer_DeleteStream(t *testing.T) {
 	}
 }
 
+func TestPeer_LoadAnnouncePeerStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer)
+	}{
+		{
+			name: "load stream",
+			expect: func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer) {
+				assert := assert.New(t)
+				peer.StoreAnnouncePeerStream(stream)
+				newStream, ok := peer.LoadAnnouncePeerStream()
+				assert.Equal(ok, true)
+				assert.EqualValues(newStream, stream)
+			},
+		},
+		{
+			name: "stream does not exist",
+			expect: func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer) {
+				assert := assert.New(t)
+				_, ok := peer.LoadAnnouncePeerStream()
+				assert.Equal(ok, false)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			stream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(ctl)
+
+			mockHost := NewHost(mockRawHost)
+			mockTask := NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := NewPeer(mockPeerID, mockTask, mockHost)
+			tc.expect(t, peer, stream)
+		})
+	}
+}
+
+func TestPeer_StoreAnnouncePeerStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer)
+	}{
+		{
+			name: "store stream",
+			expect: func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer) {
+				assert := assert.New(t)
+				peer.StoreAnnouncePeerStream(stream)
+				newStream, ok := peer.LoadAnnouncePeerStream()
+				assert.Equal(ok, true)
+				assert.EqualValues(newStream, stream)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			stream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(ctl)
+
+			mockHost := NewHost(mockRawHost)
+			mockTask := NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := NewPeer(mockPeerID, mockTask, mockHost)
+			tc.expect(t, peer, stream)
+		})
+	}
+}
+
+func TestPeer_DeleteAnnouncePeerStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer)
+	}{
+		{
+			name: "delete stream",
+			expect: func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer) {
+				assert := assert.New(t)
+				peer.StoreAnnouncePeerStream(stream)
+				assert.True(peer.DeleteAnnouncePeerStream(stream))
+				_, ok := peer.LoadAnnouncePeerStream()
+				assert.Equal(ok, false)
+			},
+		},
+		{
+			name: "stream has been replaced by a newer stream",
+			expect: func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer) {
+				assert := assert.New(t)
+				newStream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(gomock.NewController(t))
+				peer.StoreAnnouncePeerStream(stream)
+				peer.StoreAnnouncePeerStream(newStream)
+				assert.False(peer.DeleteAnnouncePeerStream(stream))
+				loadedStream, ok := peer.LoadAnnouncePeerStream()
+				assert.Equal(ok, true)
+				assert.Equal(loadedStream, newStream)
+			},
+		},
+		{
+			name: "stream does not exist",
+			expect: func(t *testing.T, peer *Peer, stream schedulerv2.Scheduler_AnnouncePeerServer) {
+				assert := assert.New(t)
+				assert.False(peer.DeleteAnnouncePeerStream(stream))
+				_, ok := peer.LoadAnnouncePeerStream()
+				assert.Equal(ok, false)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			stream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(ctl)
+
+			mockHost := NewHost(mockRawHost)
+			mockTask := NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := NewPeer(mockPeerID, mockTask, mockHost)
+			tc.expect(t, peer, stream)
+		})
+	}
+}
+
 func TestPeer_Parents(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -573,3 +698,44 @@ func TestPeer_GetPriority(t *testing.T) {
 		})
 	}
 }
+
+func TestPeer_ToV2(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(t *testing.T, peer *Peer)
+	}{
+		{
+			name: "convert peer",
+			expect: func(t *testing.T, peer *Peer) {
+				assert := assert.New(t)
+				resp := peer.ToV2()
+				assert.Equal(resp.Id, peer.ID)
+				assert.Equal(resp.State, PeerStatePending)
+				assert.Equal(resp.Task.Id, peer.Task.ID)
+				assert.Equal(resp.Host.Id, peer.Host.ID)
+				assert.Empty(resp.Pieces)
+			},
+		},
+		{
+			name: "convert peer with pieces",
+			expect: func(t *testing.T, peer *Peer) {
+				peer.Pieces.Add(&schedulerv1.PieceResult{PieceInfo: mockPieceInfo})
+				peer.Pieces.Add(&schedulerv1.PieceResult{})
+
+				assert := assert.New(t)
+				resp := peer.ToV2()
+				assert.Equal(len(resp.Pieces), 1)
+				assert.Equal(resp.Pieces[0].Number, uint32(mockPieceInfo.PieceNum))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockHost := NewHost(mockRawHost)
+			mockTask := NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := NewPeer(mockPeerID, mockTask, mockHost)
+			tc.expect(t, peer)
+		})
+	}
+}
@@ -17,10 +17,45 @@
 package resource
 
 import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+
+	"d7y.io/dragonfly/v2/pkg/digest"
 )
 
 // IsPieceBackToSource returns whether the piece is downloaded back-to-source.
 func IsPieceBackToSource(piece *schedulerv1.PieceResult) bool {
 	return piece.DstPid == ""
 }
+
+// PieceResultToV2 converts piece result to piece of v2 version.
+func PieceResultToV2(piece *schedulerv1.PieceResult) *commonv2.Piece {
+	resp := &commonv2.Piece{
+		Number:      uint32(piece.PieceInfo.PieceNum),
+		ParentId:    piece.DstPid,
+		Offset:      piece.PieceInfo.RangeStart,
+		Size:        uint64(piece.PieceInfo.RangeSize),
+		TrafficType: commonv2.TrafficType_REMOTE_PEER,
+		Cost:        durationpb.New(time.Duration(piece.PieceInfo.DownloadCost) * time.Millisecond),
+	}
+
+	if IsPieceBackToSource(piece) {
+		resp.TrafficType = commonv2.TrafficType_BACK_TO_SOURCE
+	}
+
+	if piece.PieceInfo.PieceMd5 != "" {
+		resp.Digest = digest.New(digest.AlgorithmMD5, piece.PieceInfo.PieceMd5).String()
+	}
+
+	// The piece begins to download at BeginTime.
+	if piece.BeginTime > 0 {
+		resp.CreatedAt = timestamppb.New(time.Unix(0, int64(piece.BeginTime)))
+	}
+
+	return resp
+}
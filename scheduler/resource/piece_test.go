@@ -18,9 +18,14 @@ package resource
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 )
 
@@ -58,3 +63,63 @@ func TestResource_IsPieceBackToSource(t *testing.T) {
 		})
 	}
 }
+
+func TestResource_PieceResultToV2(t *testing.T) {
+	createdAt := time.Unix(0, 1000)
+	tests := []struct {
+		name   string
+		piece  *schedulerv1.PieceResult
+		expect func(t *testing.T, piece *commonv2.Piece)
+	}{
+		{
+			name: "piece is downloaded from parent",
+			piece: &schedulerv1.PieceResult{
+				DstPid:    "foo",
+				BeginTime: uint64(createdAt.UnixNano()),
+				PieceInfo: &commonv1.PieceInfo{
+					PieceNum:     1,
+					RangeStart:   100,
+					RangeSize:    100,
+					PieceMd5:     "ad83a945518a4ef007d8b2db2ef165b3",
+					DownloadCost: 10,
+				},
+			},
+			expect: func(t *testing.T, piece *commonv2.Piece) {
+				assert := assert.New(t)
+				assert.EqualValues(piece, &commonv2.Piece{
+					Number:      1,
+					ParentId:    "foo",
+					Offset:      100,
+					Size:        100,
+					Digest:      "md5:ad83a945518a4ef007d8b2db2ef165b3",
+					TrafficType: commonv2.TrafficType_REMOTE_PEER,
+					Cost:        durationpb.New(10 * time.Millisecond),
+					CreatedAt:   timestamppb.New(createdAt),
+				})
+			},
+		},
+		{
+			name: "piece is downloaded back-to-source",
+			piece: &schedulerv1.PieceResult{
+				PieceInfo: &commonv1.PieceInfo{
+					PieceNum:   2,
+					RangeStart: 200,
+					RangeSize:  100,
+				},
+			},
+			expect: func(t *testing.T, piece *commonv2.Piece) {
+				assert := assert.New(t)
+				assert.Equal(piece.TrafficType, commonv2.TrafficType_BACK_TO_SOURCE)
+				assert.Empty(piece.ParentId)
+				assert.Empty(piece.Digest)
+				assert.Nil(piece.CreatedAt)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, PieceResultToV2(tc.piece))
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// ProbeDecayFactor is the weight of the latest probe in the decaying average,
+	// the larger the value, the faster the old probes decay.
+	ProbeDecayFactor = 0.3
+
+	// ProbeTTL is the time to live of probe, the probe is expired
+	// when it has not been updated within the ttl.
+	ProbeTTL = 30 * time.Minute
+)
+
+// Probe is the network distance from the host to the destination host.
+type Probe struct {
+	// Bandwidth is the achieved bandwidth in bytes per second.
+	Bandwidth float64
+
+	// UpdatedAt is the time of the latest probe.
+	UpdatedAt time.Time
+}
+
+// Probes is the row of the network distance matrix, it keeps the decaying
+// average of probes from the host to the destination hosts.
+type Probes struct {
+	mu     sync.RWMutex
+	probes map[string]Probe
+}
+
+// newProbes returns a new Probes.
+func newProbes() *Probes {
+	return &Probes{probes: make(map[string]Probe)}
+}
+
+// Store merges the probe to the destination host into the decaying average,
+// the probe without bandwidth is ignored.
+func (p *Probes) Store(destHostID string, probe Probe) {
+	if probe.Bandwidth <= 0 {
+		return
+	}
+
+	if probe.UpdatedAt.IsZero() {
+		probe.UpdatedAt = time.Now()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current, ok := p.probes[destHostID]
+	if !ok {
+		p.probes[destHostID] = probe
+		return
+	}
+
+	current.Bandwidth = ProbeDecayFactor*probe.Bandwidth + (1-ProbeDecayFactor)*current.Bandwidth
+	current.UpdatedAt = probe.UpdatedAt
+	p.probes[destHostID] = current
+}
+
+// Load returns the probe to the destination host.
+func (p *Probes) Load(destHostID string) (Probe, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	probe, ok := p.probes[destHostID]
+	return probe, ok
+}
+
+// Delete deletes the probe to the destination host.
+func (p *Probes) Delete(destHostID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.probes, destHostID)
+}
+
+// Len returns the count of destination hosts.
+func (p *Probes) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.probes)
+}
+
+// DeleteExpired deletes the probes which have not been updated within the ttl.
+func (p *Probes) DeleteExpired(ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for destHostID, probe := range p.probes {
+		if time.Since(probe.UpdatedAt) > ttl {
+			delete(p.probes, destHostID)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbes_Store(t *testing.T) {
+	tests := []struct {
+		name   string
+		probes []Probe
+		expect func(t *testing.T, probe Probe, ok bool)
+	}{
+		{
+			name:   "store probe",
+			probes: []Probe{{Bandwidth: 100}},
+			expect: func(t *testing.T, probe Probe, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(probe.Bandwidth, float64(100))
+				assert.False(probe.UpdatedAt.IsZero())
+			},
+		},
+		{
+			name: "merge probes into decaying average",
+			probes: []Probe{
+				{Bandwidth: 100},
+				{Bandwidth: 200},
+			},
+			expect: func(t *testing.T, probe Probe, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.InDelta(probe.Bandwidth, 130, 1e-9)
+			},
+		},
+		{
+			name: "probe without bandwidth is ignored",
+			probes: []Probe{
+				{Bandwidth: 100},
+				{},
+			},
+			expect: func(t *testing.T, probe Probe, ok bool) {
+				assert := assert.New(t)
+				assert.True(ok)
+				assert.Equal(probe.Bandwidth, float64(100))
+			},
+		},
+		{
+			name:   "first probe without bandwidth is ignored",
+			probes: []Probe{{}},
+			expect: func(t *testing.T, probe Probe, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+		{
+			name:   "probe is not found",
+			probes: []Probe{},
+			expect: func(t *testing.T, probe Probe, ok bool) {
+				assert := assert.New(t)
+				assert.False(ok)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			probes := newProbes()
+			for _, probe := range tc.probes {
+				probes.Store(mockRawHost.Id, probe)
+			}
+
+			probe, ok := probes.Load(mockRawHost.Id)
+			tc.expect(t, probe, ok)
+		})
+	}
+}
+
+func TestProbes_Delete(t *testing.T) {
+	probes := newProbes()
+	probes.Store(mockRawHost.Id, Probe{Bandwidth: 100})
+	probes.Store("foo", Probe{Bandwidth: 100})
+
+	assert := assert.New(t)
+	assert.Equal(probes.Len(), 2)
+	probes.Delete("foo")
+	assert.Equal(probes.Len(), 1)
+	_, ok := probes.Load("foo")
+	assert.False(ok)
+}
+
+func TestProbes_DeleteExpired(t *testing.T) {
+	probes := newProbes()
+	probes.Store(mockRawHost.Id, Probe{Bandwidth: 100})
+	probes.Store("foo", Probe{Bandwidth: 100, UpdatedAt: time.Now().Add(-2 * ProbeTTL)})
+
+	probes.DeleteExpired(ProbeTTL)
+	assert := assert.New(t)
+	assert.Equal(probes.Len(), 1)
+	_, ok := probes.Load(mockRawHost.Id)
+	assert.True(ok)
+}
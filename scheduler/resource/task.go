@@ -19,19 +19,27 @@ package resource
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/looplab/fsm"
 	"go.uber.org/atomic"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	errordetailsv2 "d7y.io/api/pkg/apis/errordetails/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
 
 	logger "d7y.io/dragonfly/v2/internal/dflog"
 	"d7y.io/dragonfly/v2/pkg/container/set"
 	"d7y.io/dragonfly/v2/pkg/dag"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	nethttp "d7y.io/dragonfly/v2/pkg/net/http"
 	"d7y.io/dragonfly/v2/pkg/types"
 )
 
@@ -92,6 +100,13 @@ func WithBackToSourceLimit(limit int32) TaskOption {
 	}
 }
 
+// WithStatsWindow set the sliding window of Stats for task.
+func WithStatsWindow(window time.Duration) TaskOption {
+	return func(task *Task) {
+		task.Stats = NewTaskStats(window)
+	}
+}
+
 type Task struct {
 	// ID is task id.
 	ID string
@@ -133,6 +148,9 @@ type Task struct {
 	// if one peer succeeds, the value is reset to zero.
 	PeerFailedCount *atomic.Int32
 
+	// Stats is the statistics of task in the sliding window.
+	Stats *TaskStats
+
 	// CreatedAt is task create time.
 	CreatedAt *atomic.Time
 
@@ -158,6 +176,7 @@ func NewTask(id, url string, taskType commonv1.TaskType, meta *commonv1.UrlMeta,
 		Pieces:            &sync.Map{},
 		DAG:               dag.NewDAG[*Peer](),
 		PeerFailedCount:   atomic.NewInt32(0),
+		Stats:             NewTaskStats(DefaultTaskStatsWindow),
 		CreatedAt:         atomic.NewTime(time.Now()),
 		UpdatedAt:         atomic.NewTime(time.Now()),
 		Log:               logger.WithTask(id, url),
@@ -439,7 +458,8 @@ func (t *Task) CanReuseDirectPiece() bool {
 	return len(t.DirectPiece) > 0 && int64(len(t.DirectPiece)) == t.ContentLength.Load()
 }
 
-// NotifyPeers notify all peers in the task with the state code.
+// NotifyPeers notify all peers in the task with the state code,
+// peers of v2 version are notified of the SchedulePeerFailed.
 func (t *Task) NotifyPeers(peerPacket *schedulerv1.PeerPacket, event string) {
 	for _, vertex := range t.DAG.GetVertices() {
 		peer := vertex.Value
@@ -448,13 +468,23 @@ func (t *Task) NotifyPeers(peerPacket *schedulerv1.PeerPacket, event string) {
 		}
 
 		if peer.FSM.Is(PeerStateRunning) {
-			stream, ok := peer.LoadStream()
-			if !ok {
-				continue
-			}
-
-			if err := stream.Send(peerPacket); err != nil {
-				t.Log.Errorf("send packet to peer %s failed: %s", peer.ID, err.Error())
+			if stream, ok := peer.LoadStream(); ok {
+				if err := stream.Send(peerPacket); err != nil {
+					t.Log.Errorf("send packet to peer %s failed: %s", peer.ID, err.Error())
+					continue
+				}
+			} else if stream, ok := peer.LoadAnnouncePeerStream(); ok {
+				if err := stream.Send(&schedulerv2.AnnouncePeerResponse{
+					Errordetails: &schedulerv2.AnnouncePeerResponse_SchedulePeerFailed{
+						SchedulePeerFailed: &errordetailsv2.SchedulePeerFailed{
+							Description: fmt.Sprintf("task notify peer failed, code is %s", peerPacket.Code),
+						},
+					},
+				}); err != nil {
+					t.Log.Errorf("send response to peer %s failed: %s", peer.ID, err.Error())
+					continue
+				}
+			} else {
 				continue
 			}
 			t.Log.Infof("task notify peer %s code %s", peer.ID, peerPacket.Code)
@@ -466,3 +496,45 @@ func (t *Task) NotifyPeers(peerPacket *schedulerv1.PeerPacket, event string) {
 		}
 	}
 }
+
+// ToV2 converts task to task of v2 version.
+func (t *Task) ToV2() *commonv2.Task {
+	resp := &commonv2.Task{
+		Id:               t.ID,
+		Type:             t.Type.String(),
+		State:            t.FSM.Current(),
+		ContentLength:    t.ContentLength.Load(),
+		PeerCount:        int32(t.PeerCount()),
+		HasAvailablePeer: t.HasAvailablePeer(set.NewSafeSet[string]()),
+		Metadata: &commonv2.Metadata{
+			Url:  t.URL,
+			Type: commonv2.TaskType(t.Type),
+		},
+		CreatedAt: timestamppb.New(t.CreatedAt.Load()),
+		UpdatedAt: timestamppb.New(t.UpdatedAt.Load()),
+	}
+
+	if sizeScope, err := t.SizeScope(); err == nil {
+		resp.SizeScope = commonv2.SizeScope(sizeScope)
+	}
+
+	if t.URLMeta != nil {
+		resp.Metadata.Digest = t.URLMeta.Digest
+		resp.Metadata.Tag = t.URLMeta.Tag
+		resp.Metadata.Application = t.URLMeta.Application
+		resp.Metadata.Priority = commonv2.Priority(t.URLMeta.Priority)
+		resp.Metadata.Header = t.URLMeta.Header
+		if t.URLMeta.Filter != "" {
+			resp.Metadata.Filters = strings.Split(t.URLMeta.Filter, idgen.FilterSeparator)
+		}
+
+		if rg, err := nethttp.GetRange(t.URLMeta.Range); err == nil {
+			resp.Metadata.Range = &commonv2.Range{
+				Begin: rg.StartIndex,
+				End:   rg.EndIndex,
+			}
+		}
+	}
+
+	return resp
+}
@@ -20,6 +20,7 @@ package resource
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	pkggc "d7y.io/dragonfly/v2/pkg/gc"
@@ -46,6 +47,9 @@ type TaskManager interface {
 	// Delete deletes task for a key.
 	Delete(string)
 
+	// HotTasks returns the top n tasks ranked by the requests in the sliding window.
+	HotTasks(int) []HotTask
+
 	// Try to reclaim task.
 	RunGC() error
 }
@@ -95,6 +99,49 @@ func (t *taskManager) Delete(key string) {
 	t.Map.Delete(key)
 }
 
+func (t *taskManager) HotTasks(n int) []HotTask {
+	var hotTasks []HotTask
+	t.Map.Range(func(_, value any) bool {
+		task, ok := value.(*Task)
+		if !ok {
+			return true
+		}
+
+		// Task which is not requested in the window is not hot.
+		requests := task.Stats.Requests()
+		if requests == 0 {
+			return true
+		}
+
+		hotTasks = append(hotTasks, HotTask{
+			ID:            task.ID,
+			URL:           task.URL,
+			Tag:           task.URLMeta.GetTag(),
+			Application:   task.URLMeta.GetApplication(),
+			Requests:      requests,
+			RequestRate:   task.Stats.RequestRate(),
+			PeerCount:     task.PeerCount(),
+			BackToSources: task.Stats.BackToSources(),
+			ServedBytes:   task.Stats.ServedBytes(),
+		})
+		return true
+	})
+
+	sort.Slice(hotTasks, func(i, j int) bool {
+		if hotTasks[i].Requests != hotTasks[j].Requests {
+			return hotTasks[i].Requests > hotTasks[j].Requests
+		}
+
+		return hotTasks[i].PeerCount > hotTasks[j].PeerCount
+	})
+
+	if len(hotTasks) > n {
+		hotTasks = hotTasks[:n]
+	}
+
+	return hotTasks
+}
+
 func (t *taskManager) RunGC() error {
 	t.Map.Range(func(_, value any) bool {
 		task, ok := value.(*Task)
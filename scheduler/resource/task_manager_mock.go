@@ -45,6 +45,20 @@ func (mr *MockTaskManagerMockRecorder) Delete(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskManager)(nil).Delete), arg0)
 }
 
+// HotTasks mocks base method.
+func (m *MockTaskManager) HotTasks(arg0 int) []HotTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HotTasks", arg0)
+	ret0, _ := ret[0].([]HotTask)
+	return ret0
+}
+
+// HotTasks indicates an expected call of HotTasks.
+func (mr *MockTaskManagerMockRecorder) HotTasks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HotTasks", reflect.TypeOf((*MockTaskManager)(nil).HotTasks), arg0)
+}
+
 // Load mocks base method.
 func (m *MockTaskManager) Load(arg0 string) (*Task, bool) {
 	m.ctrl.T.Helper()
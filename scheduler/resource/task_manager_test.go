@@ -299,6 +299,78 @@ func TestTaskManager_Delete(t *testing.T) {
 	}
 }
 
+func TestTaskManager_HotTasks(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		mock   func(m *gc.MockGCMockRecorder)
+		expect func(t *testing.T, taskManager TaskManager, hotTasks []HotTask)
+	}{
+		{
+			name: "tasks ranked by requests",
+			n:    2,
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, taskManager TaskManager, hotTasks []HotTask) {
+				assert := assert.New(t)
+				assert.Equal(len(hotTasks), 2)
+				assert.Equal(hotTasks[0].ID, "baz")
+				assert.Equal(hotTasks[0].Requests, int64(3))
+				assert.Equal(hotTasks[0].URL, mockTaskURL)
+				assert.Equal(hotTasks[0].Tag, mockTaskURLMeta.Tag)
+				assert.Equal(hotTasks[0].Application, mockTaskURLMeta.Application)
+				assert.Equal(hotTasks[0].BackToSources, int64(1))
+				assert.Equal(hotTasks[0].ServedBytes, int64(1024))
+				assert.Equal(hotTasks[1].ID, "bar")
+				assert.Equal(hotTasks[1].Requests, int64(2))
+			},
+		},
+		{
+			name: "tasks without requests are not hot",
+			n:    10,
+			mock: func(m *gc.MockGCMockRecorder) {
+				m.Add(gomock.Any()).Return(nil).Times(1)
+			},
+			expect: func(t *testing.T, taskManager TaskManager, hotTasks []HotTask) {
+				assert := assert.New(t)
+				assert.Equal(len(hotTasks), 3)
+				for _, hotTask := range hotTasks {
+					assert.NotEqual(hotTask.ID, "qux")
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			gc := gc.NewMockGC(ctl)
+			tc.mock(gc.EXPECT())
+
+			taskManager, err := newTaskManager(mockTaskGCConfig, gc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for id, requests := range map[string]int{"foo": 1, "bar": 2, "baz": 3, "qux": 0} {
+				mockTask := NewTask(id, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, WithBackToSourceLimit(mockTaskBackToSourceLimit))
+				for i := 0; i < requests; i++ {
+					mockTask.Stats.AddRequest()
+				}
+				taskManager.Store(mockTask)
+			}
+
+			task, _ := taskManager.Load("baz")
+			task.Stats.AddBackToSource()
+			task.Stats.AddServedBytes(1024)
+
+			tc.expect(t, taskManager, taskManager.HotTasks(tc.n))
+		})
+	}
+}
+
 func TestTaskManager_RunGC(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,167 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	// DefaultTaskStatsWindow is the default sliding window of task statistics.
+	DefaultTaskStatsWindow = 5 * time.Minute
+
+	// taskStatsBucketCount is the count of buckets in the sliding window.
+	taskStatsBucketCount = 10
+)
+
+// TaskStats is the statistics of task in the sliding window.
+type TaskStats struct {
+	// window is the duration of sliding window.
+	window time.Duration
+
+	// requests is the count of peers registered.
+	requests *slidingWindow
+
+	// backToSources is the count of peers downloaded back-to-source.
+	backToSources *slidingWindow
+
+	// servedBytes is the bytes of pieces served by parents.
+	servedBytes *slidingWindow
+
+	// warmedUp is whether the seed peer is triggered for the hot task.
+	warmedUp *atomic.Bool
+}
+
+// NewTaskStats returns a new TaskStats with the sliding window,
+// the default window is used when the window is invalid.
+func NewTaskStats(window time.Duration) *TaskStats {
+	if window <= 0 {
+		window = DefaultTaskStatsWindow
+	}
+
+	return &TaskStats{
+		window:        window,
+		requests:      newSlidingWindow(window, taskStatsBucketCount),
+		backToSources: newSlidingWindow(window, taskStatsBucketCount),
+		servedBytes:   newSlidingWindow(window, taskStatsBucketCount),
+		warmedUp:      atomic.NewBool(false),
+	}
+}
+
+// Window returns the duration of sliding window.
+func (s *TaskStats) Window() time.Duration {
+	return s.window
+}
+
+// AddRequest counts a peer registered to the task.
+func (s *TaskStats) AddRequest() {
+	s.requests.add(time.Now(), 1)
+}
+
+// Requests returns the count of peers registered in the window.
+func (s *TaskStats) Requests() int64 {
+	return s.requests.sum(time.Now())
+}
+
+// RequestRate returns the count of peers registered per second in the window.
+func (s *TaskStats) RequestRate() float64 {
+	return float64(s.Requests()) / s.window.Seconds()
+}
+
+// AddBackToSource counts a peer downloaded back-to-source.
+func (s *TaskStats) AddBackToSource() {
+	s.backToSources.add(time.Now(), 1)
+}
+
+// BackToSources returns the count of peers downloaded back-to-source in the window.
+func (s *TaskStats) BackToSources() int64 {
+	return s.backToSources.sum(time.Now())
+}
+
+// AddServedBytes counts the bytes of piece served by parent.
+func (s *TaskStats) AddServedBytes(n int64) {
+	s.servedBytes.add(time.Now(), n)
+}
+
+// ServedBytes returns the bytes of pieces served by parents in the window.
+func (s *TaskStats) ServedBytes() int64 {
+	return s.servedBytes.sum(time.Now())
+}
+
+// MarkWarmedUp marks the task is warmed up by seed peer,
+// it returns false if the task has been marked.
+func (s *TaskStats) MarkWarmedUp() bool {
+	return s.warmedUp.CompareAndSwap(false, true)
+}
+
+// slidingWindow counts the values in the buckets of sliding window,
+// the buckets out of the window are reset when they are reused.
+type slidingWindow struct {
+	mu             sync.Mutex
+	bucketDuration time.Duration
+	buckets        []int64
+	indexes        []int64
+}
+
+// newSlidingWindow returns a new sliding window divided into buckets.
+func newSlidingWindow(window time.Duration, bucketCount int) *slidingWindow {
+	bucketDuration := window / time.Duration(bucketCount)
+	if bucketDuration <= 0 {
+		bucketDuration = 1
+	}
+
+	return &slidingWindow{
+		bucketDuration: bucketDuration,
+		buckets:        make([]int64, bucketCount),
+		indexes:        make([]int64, bucketCount),
+	}
+}
+
+// add adds the value to the bucket of the time.
+func (w *slidingWindow) add(now time.Time, n int64) {
+	index := now.UnixNano() / int64(w.bucketDuration)
+	i := int(index % int64(len(w.buckets)))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.indexes[i] != index {
+		w.indexes[i] = index
+		w.buckets[i] = 0
+	}
+	w.buckets[i] += n
+}
+
+// sum returns the sum of the buckets in the window of the time.
+func (w *slidingWindow) sum(now time.Time) int64 {
+	index := now.UnixNano() / int64(w.bucketDuration)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var sum int64
+	for i := range w.buckets {
+		if index-w.indexes[i] < int64(len(w.buckets)) {
+			sum += w.buckets[i]
+		}
+	}
+
+	return sum
+}
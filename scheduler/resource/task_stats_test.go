@@ -0,0 +1,134 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskStats_NewTaskStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		expect func(t *testing.T, stats *TaskStats)
+	}{
+		{
+			name:   "new task stats",
+			window: time.Minute,
+			expect: func(t *testing.T, stats *TaskStats) {
+				assert := assert.New(t)
+				assert.Equal(stats.Window(), time.Minute)
+				assert.Equal(stats.Requests(), int64(0))
+				assert.Equal(stats.BackToSources(), int64(0))
+				assert.Equal(stats.ServedBytes(), int64(0))
+			},
+		},
+		{
+			name:   "new task stats with invalid window",
+			window: 0,
+			expect: func(t *testing.T, stats *TaskStats) {
+				assert := assert.New(t)
+				assert.Equal(stats.Window(), DefaultTaskStatsWindow)
+				assert.Equal(stats.RequestRate(), float64(0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, NewTaskStats(tc.window))
+		})
+	}
+}
+
+func TestTaskStats_Counters(t *testing.T) {
+	assert := assert.New(t)
+	stats := NewTaskStats(time.Minute)
+	stats.AddRequest()
+	stats.AddRequest()
+	stats.AddBackToSource()
+	stats.AddServedBytes(1024)
+	stats.AddServedBytes(2048)
+
+	assert.Equal(stats.Requests(), int64(2))
+	assert.Equal(stats.RequestRate(), float64(2)/time.Minute.Seconds())
+	assert.Equal(stats.BackToSources(), int64(1))
+	assert.Equal(stats.ServedBytes(), int64(3072))
+}
+
+func TestTaskStats_MarkWarmedUp(t *testing.T) {
+	assert := assert.New(t)
+	stats := NewTaskStats(time.Minute)
+	assert.True(stats.MarkWarmedUp())
+	assert.False(stats.MarkWarmedUp())
+}
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tests := []struct {
+		name   string
+		add    func(w *slidingWindow)
+		now    time.Time
+		expect int64
+	}{
+		{
+			name: "sum values in the window",
+			add: func(w *slidingWindow) {
+				w.add(now, 1)
+				w.add(now.Add(3*time.Second), 2)
+				w.add(now.Add(9*time.Second), 3)
+			},
+			now:    now.Add(9 * time.Second),
+			expect: 6,
+		},
+		{
+			name: "values out of the window are expired",
+			add: func(w *slidingWindow) {
+				w.add(now, 1)
+				w.add(now.Add(5*time.Second), 2)
+			},
+			now:    now.Add(12 * time.Second),
+			expect: 2,
+		},
+		{
+			name: "reused bucket is reset",
+			add: func(w *slidingWindow) {
+				w.add(now, 1)
+				w.add(now.Add(10*time.Second), 2)
+			},
+			now:    now.Add(10 * time.Second),
+			expect: 2,
+		},
+		{
+			name:   "empty window",
+			add:    func(w *slidingWindow) {},
+			now:    now,
+			expect: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newSlidingWindow(10*time.Second, 10)
+			tc.add(w)
+			assert.Equal(t, w.sum(tc.now), tc.expect)
+		})
+	}
+}
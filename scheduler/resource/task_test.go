@@ -25,8 +25,12 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	errordetailsv2 "d7y.io/api/pkg/apis/errordetails/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 	"d7y.io/api/pkg/apis/scheduler/v1/mocks"
+	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
+	schedulerv2mocks "d7y.io/api/pkg/apis/scheduler/v2/mocks"
 
 	"d7y.io/dragonfly/v2/pkg/container/set"
 	"d7y.io/dragonfly/v2/pkg/idgen"
@@ -1610,6 +1614,40 @@ func TestTask_NotifyPeers(t *testing.T) {
 
 				task.NotifyPeers(&schedulerv1.PeerPacket{Code: commonv1.Code_SchedTaskStatusError}, PeerEventDownloadFailed)
 
+				assert := assert.New(t)
+				assert.True(mockPeer.FSM.Is(PeerStateFailed))
+			},
+		},
+		{
+			name: "peer state is PeerStateRunning and stream of v2 version sending failed",
+			run: func(t *testing.T, task *Task, mockPeer *Peer, stream schedulerv1.Scheduler_ReportPieceResultServer, ms *mocks.MockScheduler_ReportPieceResultServerMockRecorder) {
+				announcePeerStream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(gomock.NewController(t))
+				mockPeer.FSM.SetState(PeerStateRunning)
+				mockPeer.StoreAnnouncePeerStream(announcePeerStream)
+				announcePeerStream.EXPECT().Send(gomock.Any()).Return(errors.New("foo")).Times(1)
+
+				task.NotifyPeers(&schedulerv1.PeerPacket{Code: commonv1.Code_SchedTaskStatusError}, PeerEventDownloadFailed)
+
+				assert := assert.New(t)
+				assert.True(mockPeer.FSM.Is(PeerStateRunning))
+			},
+		},
+		{
+			name: "peer state is PeerStateRunning and notify peer of v2 version successfully",
+			run: func(t *testing.T, task *Task, mockPeer *Peer, stream schedulerv1.Scheduler_ReportPieceResultServer, ms *mocks.MockScheduler_ReportPieceResultServerMockRecorder) {
+				announcePeerStream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(gomock.NewController(t))
+				mockPeer.FSM.SetState(PeerStateRunning)
+				mockPeer.StoreAnnouncePeerStream(announcePeerStream)
+				announcePeerStream.EXPECT().Send(gomock.Eq(&schedulerv2.AnnouncePeerResponse{
+					Errordetails: &schedulerv2.AnnouncePeerResponse_SchedulePeerFailed{
+						SchedulePeerFailed: &errordetailsv2.SchedulePeerFailed{
+							Description: "task notify peer failed, code is SchedTaskStatusError",
+						},
+					},
+				})).Return(nil).Times(1)
+
+				task.NotifyPeers(&schedulerv1.PeerPacket{Code: commonv1.Code_SchedTaskStatusError}, PeerEventDownloadFailed)
+
 				assert := assert.New(t)
 				assert.True(mockPeer.FSM.Is(PeerStateFailed))
 			},
@@ -1630,3 +1668,69 @@ func TestTask_NotifyPeers(t *testing.T) {
 		})
 	}
 }
+
+func TestTask_ToV2(t *testing.T) {
+	tests := []struct {
+		name    string
+		urlMeta *commonv1.UrlMeta
+		run     func(t *testing.T, task *Task)
+	}{
+		{
+			name:    "convert task",
+			urlMeta: mockTaskURLMeta,
+			run: func(t *testing.T, task *Task) {
+				task.ContentLength.Store(1024)
+				task.TotalPieceCount.Store(1)
+
+				assert := assert.New(t)
+				resp := task.ToV2()
+				assert.Equal(resp.Id, task.ID)
+				assert.Equal(resp.Type, commonv1.TaskType_Normal.String())
+				assert.Equal(resp.SizeScope, commonv2.SizeScope_SMALL)
+				assert.Equal(resp.State, TaskStatePending)
+				assert.Equal(resp.ContentLength, int64(1024))
+				assert.Equal(resp.PeerCount, int32(0))
+				assert.False(resp.HasAvailablePeer)
+				assert.Equal(resp.Metadata.Url, mockTaskURL)
+				assert.Equal(resp.Metadata.Digest, mockTaskURLMeta.Digest)
+				assert.Equal(resp.Metadata.Tag, mockTaskURLMeta.Tag)
+				assert.Equal(resp.Metadata.Filters, []string{mockTaskURLMeta.Filter})
+				assert.Equal(resp.Metadata.Header, mockTaskURLMeta.Header)
+				assert.Nil(resp.Metadata.Range)
+				assert.Empty(resp.Pieces)
+			},
+		},
+		{
+			name: "convert task with range and filters",
+			urlMeta: &commonv1.UrlMeta{
+				Range:    "0-99",
+				Filter:   "foo&bar",
+				Priority: commonv1.Priority_LEVEL1,
+			},
+			run: func(t *testing.T, task *Task) {
+				assert := assert.New(t)
+				resp := task.ToV2()
+				assert.Equal(resp.Metadata.Range, &commonv2.Range{Begin: 0, End: 99})
+				assert.Equal(resp.Metadata.Filters, []string{"foo", "bar"})
+				assert.Equal(resp.Metadata.Priority, commonv2.Priority_LEVEL1)
+			},
+		},
+		{
+			name: "convert task without url meta",
+			run: func(t *testing.T, task *Task) {
+				assert := assert.New(t)
+				resp := task.ToV2()
+				assert.Equal(resp.Metadata.Url, mockTaskURL)
+				assert.Empty(resp.Metadata.Tag)
+				assert.Nil(resp.Metadata.Range)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			task := NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, tc.urlMeta, WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			tc.run(t, task)
+		})
+	}
+}
@@ -25,13 +25,13 @@ import (
 	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
 
 	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/metrics"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/scheduler"
 	"d7y.io/dragonfly/v2/scheduler/service"
 	"d7y.io/dragonfly/v2/scheduler/storage"
 )
 
-// TODO Implement v2 version of the rpc server apis.
 // schedulerServerV2 is v2 version of the scheduler grpc server.
 type schedulerServerV2 struct {
 	// Service interface.
@@ -51,36 +51,50 @@ func newSchedulerServerV2(
 
 // AnnouncePeer announces peer to scheduler.
 func (s *schedulerServerV2) AnnouncePeer(stream schedulerv2.Scheduler_AnnouncePeerServer) error {
-	return nil
+	metrics.ConcurrentScheduleGauge.Inc()
+	defer metrics.ConcurrentScheduleGauge.Dec()
+
+	return s.service.AnnouncePeer(stream)
 }
 
 // Checks information of peer.
 func (s *schedulerServerV2) StatPeer(ctx context.Context, req *schedulerv2.StatPeerRequest) (*commonv2.Peer, error) {
-	return nil, nil
+	return s.service.StatPeer(ctx, req)
 }
 
 // LeavePeer releases peer in scheduler.
 func (s *schedulerServerV2) LeavePeer(ctx context.Context, req *schedulerv2.LeavePeerRequest) (*emptypb.Empty, error) {
-	return nil, nil
+	if err := s.service.LeavePeer(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return new(emptypb.Empty), nil
 }
 
-// TODO exchange peer api definition.
 // ExchangePeer exchanges peer information.
 func (s *schedulerServerV2) ExchangePeer(ctx context.Context, req *schedulerv2.ExchangePeerRequest) (*schedulerv2.ExchangePeerResponse, error) {
-	return nil, nil
+	return s.service.ExchangePeer(ctx, req)
 }
 
 // Checks information of task.
 func (s *schedulerServerV2) StatTask(ctx context.Context, req *schedulerv2.StatTaskRequest) (*commonv2.Task, error) {
-	return nil, nil
+	return s.service.StatTask(ctx, req)
 }
 
 // AnnounceHost announces host to scheduler.
 func (s *schedulerServerV2) AnnounceHost(ctx context.Context, req *schedulerv2.AnnounceHostRequest) (*emptypb.Empty, error) {
-	return nil, nil
+	if err := s.service.AnnounceHost(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return new(emptypb.Empty), nil
 }
 
 // LeaveHost releases host in scheduler.
 func (s *schedulerServerV2) LeaveHost(ctx context.Context, req *schedulerv2.LeaveHostRequest) (*emptypb.Empty, error) {
-	return nil, nil
+	if err := s.service.LeaveHost(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return new(emptypb.Empty), nil
 }
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/johanbrandhorst/certify"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
@@ -50,13 +51,24 @@ import (
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/rpcserver"
 	"d7y.io/dragonfly/v2/scheduler/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/scheduler/evaluator"
 	"d7y.io/dragonfly/v2/scheduler/storage"
+	"d7y.io/dragonfly/v2/scheduler/training"
 )
 
 const (
 	// gracefulStopTimeout specifies a time limit for
 	// grpc server to complete a graceful shutdown.
 	gracefulStopTimeout = 10 * time.Minute
+
+	// evaluatorWeightsPath is the debug path of the evaluator weights in effect.
+	evaluatorWeightsPath = "/debug/evaluator/weights"
+
+	// storageRecordsPath is the path of streaming the filtered export of records.
+	storageRecordsPath = "/storage/records"
+
+	// hotTasksPath is the debug path of the top n hot tasks.
+	hotTasksPath = "/debug/tasks/hot"
 )
 
 type Server struct {
@@ -69,6 +81,9 @@ type Server struct {
 	// Metrics server.
 	metricsServer *http.Server
 
+	// Admin server.
+	adminServer *http.Server
+
 	// Manager client.
 	managerClient managerclient.V1
 
@@ -90,6 +105,9 @@ type Server struct {
 	// Announcer interface.
 	announcer announcer.Announcer
 
+	// Training interface.
+	training training.Training
+
 	// GC service.
 	gc gc.GC
 }
@@ -176,17 +194,23 @@ func New(ctx context.Context, cfg *config.Config, d dfpath.Dfpath) (*Server, err
 	s.gc = gc.New(gc.WithLogger(logger.GCLogger))
 
 	// Initialize resource.
-	resource, err := resource.New(cfg, s.gc, dynconfig, resource.WithTransportCredentials(clientTransportCredentials))
+	s.resource, err = resource.New(cfg, s.gc, dynconfig, resource.WithTransportCredentials(clientTransportCredentials))
 	if err != nil {
 		return nil, err
 	}
-	s.resource = resource
+
+	// Initialize weights of evaluator factors.
+	weights, err := evaluator.NewWeights(cfg.Scheduler.Evaluator.Weights)
+	if err != nil {
+		return nil, err
+	}
+	dynconfig.Register(weights)
 
 	// Initialize scheduler.
-	scheduler := scheduler.New(&cfg.Scheduler, dynconfig, d.PluginDir())
+	scheduler := scheduler.New(&cfg.Scheduler, dynconfig, d.PluginDir(), evaluator.WithManagerClient(s.managerClient), evaluator.WithWeights(weights))
 
 	// Initialize Storage.
-	storage, err := storage.New(
+	s.storage, err = storage.New(
 		d.DataDir(),
 		cfg.Storage.MaxSize,
 		cfg.Storage.MaxBackups,
@@ -195,7 +219,11 @@ func New(ctx context.Context, cfg *config.Config, d dfpath.Dfpath) (*Server, err
 	if err != nil {
 		return nil, err
 	}
-	s.storage = storage
+
+	// Initialize training.
+	if cfg.Scheduler.Training.Enable {
+		s.training = training.New(cfg, s.managerClient, dynconfig, s.storage)
+	}
 
 	// Initialize grpc service and server options of scheduler grpc server.
 	schedulerServerOptions := []grpc.ServerOption{}
@@ -210,12 +238,12 @@ func New(ctx context.Context, cfg *config.Config, d dfpath.Dfpath) (*Server, err
 		schedulerServerOptions = append(schedulerServerOptions, grpc.Creds(insecure.NewCredentials()))
 	}
 
-	svr := rpcserver.New(cfg, resource, scheduler, dynconfig, s.storage, schedulerServerOptions...)
+	svr := rpcserver.New(cfg, s.resource, scheduler, dynconfig, s.storage, schedulerServerOptions...)
 	s.grpcServer = svr
 
 	// Initialize job service.
 	if cfg.Job.Enable {
-		s.job, err = job.New(cfg, resource)
+		s.job, err = job.New(cfg, s.resource, job.WithTransportCredentials(clientTransportCredentials))
 		if err != nil {
 			return nil, err
 		}
@@ -224,6 +252,22 @@ func New(ctx context.Context, cfg *config.Config, d dfpath.Dfpath) (*Server, err
 	// Initialize metrics.
 	if cfg.Metrics.Enable {
 		s.metricsServer = metrics.New(&cfg.Metrics, s.grpcServer)
+		prometheus.MustRegister(resource.NewHotTaskCollector(s.resource.TaskManager(), cfg.Scheduler.HotTask.TopN))
+	}
+
+	// Initialize admin server.
+	if cfg.Admin.Enable {
+		mux := http.NewServeMux()
+		mux.Handle(evaluatorWeightsPath, weights)
+		mux.Handle(hotTasksPath, resource.NewHotTaskHandler(s.resource.TaskManager(), cfg.Scheduler.HotTask.TopN))
+		if cfg.Admin.EnableRecordsExport {
+			mux.Handle(storageRecordsPath, storage.NewHandler(s.storage))
+		}
+
+		s.adminServer = &http.Server{
+			Addr:    cfg.Admin.Addr,
+			Handler: mux,
+		}
 	}
 
 	return s, nil
@@ -261,6 +305,19 @@ func (s *Server) Serve() error {
 		}()
 	}
 
+	// Started admin server.
+	if s.adminServer != nil {
+		go func() {
+			logger.Infof("started admin server at %s", s.adminServer.Addr)
+			if err := s.adminServer.ListenAndServe(); err != nil {
+				if err == http.ErrServerClosed {
+					return
+				}
+				logger.Fatalf("admin server closed unexpect: %s", err.Error())
+			}
+		}()
+	}
+
 	// Serve announcer.
 	go func() {
 		if err := s.announcer.Serve(); err != nil {
@@ -269,6 +326,16 @@ func (s *Server) Serve() error {
 		logger.Info("announcer start successfully")
 	}()
 
+	// Serve training.
+	if s.training != nil {
+		go func() {
+			logger.Info("training start successfully")
+			if err := s.training.Serve(); err != nil {
+				logger.Errorf("training start failed %s", err.Error())
+			}
+		}()
+	}
+
 	// Generate GRPC limit listener.
 	ip, ok := ip.FormatIP(s.config.Server.ListenIP.String())
 	if !ok {
@@ -326,6 +393,15 @@ func (s *Server) Stop() {
 		}
 	}
 
+	// Stop admin server.
+	if s.adminServer != nil {
+		if err := s.adminServer.Shutdown(context.Background()); err != nil {
+			logger.Errorf("admin server failed to stop: %s", err.Error())
+		} else {
+			logger.Info("admin server closed under request")
+		}
+	}
+
 	// Stop announcer.
 	if err := s.announcer.Stop(); err != nil {
 		logger.Errorf("stop announcer failed %s", err.Error())
@@ -333,6 +409,15 @@ func (s *Server) Stop() {
 		logger.Info("stop announcer closed")
 	}
 
+	// Stop training.
+	if s.training != nil {
+		if err := s.training.Stop(); err != nil {
+			logger.Errorf("stop training failed %s", err.Error())
+		} else {
+			logger.Info("stop training closed")
+		}
+	}
+
 	// Stop manager client.
 	if s.managerClient != nil {
 		if err := s.managerClient.Close(); err != nil {
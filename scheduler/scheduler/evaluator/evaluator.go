@@ -17,6 +17,9 @@
 package evaluator
 
 import (
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	managerclient "d7y.io/dragonfly/v2/pkg/rpc/manager/client"
+	"d7y.io/dragonfly/v2/scheduler/config"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 )
 
@@ -39,16 +42,62 @@ type Evaluator interface {
 	IsBadNode(peer *resource.Peer) bool
 }
 
-func New(algorithm string, pluginDir string) Evaluator {
+// evaluatorOptions is the options of evaluator.
+type evaluatorOptions struct {
+	// Manager client.
+	managerClient managerclient.V1
+
+	// Scheduler dynamic configuration.
+	dynconfig config.DynconfigInterface
+
+	// weights is the weights of factors.
+	weights *Weights
+}
+
+// Option is a functional option for configuring the evaluator.
+type Option func(o *evaluatorOptions)
+
+// WithManagerClient sets the manager client for loading model.
+func WithManagerClient(client managerclient.V1) Option {
+	return func(o *evaluatorOptions) {
+		o.managerClient = client
+	}
+}
+
+// WithDynconfig sets the dynconfig for loading model.
+func WithDynconfig(dynconfig config.DynconfigInterface) Option {
+	return func(o *evaluatorOptions) {
+		o.dynconfig = dynconfig
+	}
+}
+
+// WithWeights sets the weights of factors for the rule-based evaluator.
+func WithWeights(weights *Weights) Option {
+	return func(o *evaluatorOptions) {
+		o.weights = weights
+	}
+}
+
+func New(algorithm string, pluginDir string, options ...Option) Evaluator {
 	switch algorithm {
 	case PluginAlgorithm:
 		if plugin, err := LoadPlugin(pluginDir); err == nil {
 			return plugin
 		}
-	// TODO Implement MLAlgorithm.
-	case MLAlgorithm, DefaultAlgorithm:
-		return NewEvaluatorBase()
+	case MLAlgorithm:
+		o := &evaluatorOptions{}
+		for _, opt := range options {
+			opt(o)
+		}
+
+		if o.managerClient != nil && o.dynconfig != nil {
+			return NewEvaluatorML(o.managerClient, o.dynconfig, options...)
+		}
+
+		logger.Warn("machine learning evaluator requires manager client and dynconfig, use default evaluator")
+	case DefaultAlgorithm:
+		return NewEvaluatorBase(options...)
 	}
 
-	return NewEvaluatorBase()
+	return NewEvaluatorBase(options...)
 }
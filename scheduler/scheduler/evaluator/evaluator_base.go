@@ -28,6 +28,7 @@ import (
 	"d7y.io/dragonfly/v2/scheduler/resource"
 )
 
+// Default weights of factors.
 const (
 	// Finished piece weight.
 	finishedPieceWeight float64 = 0.2
@@ -49,6 +50,14 @@ const (
 
 	// Location affinity weight.
 	locationAffinityWeight = 0.1
+
+	// Parent piece cost weight, piece costs are used to find the bad node
+	// by default and the weight is only set by the model or dynconfig.
+	parentPieceCostWeight = 0
+
+	// Network cost weight, probes are only collected from the piece downloads
+	// between hosts, so it is disabled by default and set by config or dynconfig.
+	networkCostWeight = 0
 )
 
 const (
@@ -70,12 +79,29 @@ const (
 
 	// Maximum number of elements.
 	maxElementLen = 5
+
+	// Bandwidth in bytes per second which scores 0.5 in the network cost.
+	referenceBandwidth float64 = 100 * 1024 * 1024
 )
 
-type evaluatorBase struct{}
+type evaluatorBase struct {
+	// weights is the weights of factors.
+	weights *Weights
+}
+
+func NewEvaluatorBase(options ...Option) Evaluator {
+	o := &evaluatorOptions{}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	weights := o.weights
+	if weights == nil {
+		// Default weights are always valid.
+		weights, _ = NewWeights(nil)
+	}
 
-func NewEvaluatorBase() Evaluator {
-	return &evaluatorBase{}
+	return &evaluatorBase{weights: weights}
 }
 
 // The larger the value after evaluation, the higher the priority.
@@ -83,42 +109,30 @@ func (eb *evaluatorBase) Evaluate(parent *resource.Peer, child *resource.Peer, t
 	var (
 		parentSecurityDomain string
 		childSecurityDomain  string
-		parentNetTopology    string
-		childNetTopology     string
-		parentLocation       string
-		childLocation        string
-		parentIDC            string
-		childIDC             string
 	)
 	if parent.Host.Network != nil {
 		parentSecurityDomain = parent.Host.Network.SecurityDomain
-		parentNetTopology = parent.Host.Network.NetTopology
-		parentLocation = parent.Host.Network.Location
-		parentIDC = parent.Host.Network.Idc
 	}
 
 	if child.Host.Network != nil {
 		childSecurityDomain = child.Host.Network.SecurityDomain
-		childNetTopology = child.Host.Network.NetTopology
-		childLocation = child.Host.Network.Location
-		childIDC = child.Host.Network.Idc
 	}
 
 	// If the SecurityDomain of hosts exists but is not equal,
 	// it cannot be scheduled as a parent.
 	if parentSecurityDomain != "" &&
 		childSecurityDomain != "" &&
-		parent.Host.Network.SecurityDomain != child.Host.Network.SecurityDomain {
+		parentSecurityDomain != childSecurityDomain {
 		return minScore
 	}
 
-	return finishedPieceWeight*calculatePieceScore(parent, child, totalPieceCount) +
-		parentHostUploadSuccessWeight*calculateParentHostUploadSuccessScore(parent) +
-		freeUploadWeight*calculateFreeUploadScore(parent.Host) +
-		hostTypeWeight*calculateHostTypeScore(parent) +
-		idcAffinityWeight*calculateIDCAffinityScore(parentIDC, childIDC) +
-		netTopologyAffinityWeight*calculateMultiElementAffinityScore(parentNetTopology, childNetTopology) +
-		locationAffinityWeight*calculateMultiElementAffinityScore(parentLocation, childLocation)
+	var score float64
+	weights := eb.weights.Load()
+	for _, f := range factors {
+		score += weights[f.name] * f.calculate(parent, child, totalPieceCount)
+	}
+
+	return score
 }
 
 // calculatePieceScore 0.0~unlimited larger and better.
@@ -221,6 +235,18 @@ func calculateMultiElementAffinityScore(dst, src string) float64 {
 	return float64(score) / float64(maxElementLen)
 }
 
+// calculateNetworkCostScore 0.0~1.0 larger and better.
+func calculateNetworkCostScore(parent *resource.Peer, child *resource.Peer) float64 {
+	// Network distance between hosts is unknown, parents which have not been
+	// probed score the same as the parents with the reference bandwidth.
+	probe, ok := child.Host.Probes.Load(parent.Host.ID)
+	if !ok {
+		return maxScore * 0.5
+	}
+
+	return probe.Bandwidth / (referenceBandwidth + probe.Bandwidth)
+}
+
 func (eb *evaluatorBase) IsBadNode(peer *resource.Peer) bool {
 	if peer.FSM.Is(resource.PeerStateFailed) || peer.FSM.Is(resource.PeerStateLeave) || peer.FSM.Is(resource.PeerStatePending) ||
 		peer.FSM.Is(resource.PeerStateReceivedTiny) || peer.FSM.Is(resource.PeerStateReceivedSmall) ||
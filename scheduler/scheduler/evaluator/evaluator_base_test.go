@@ -104,6 +104,7 @@ func TestEvaluatorBase_Evaluate(t *testing.T) {
 		parent          *resource.Peer
 		child           *resource.Peer
 		totalPieceCount int32
+		weights         map[string]float64
 		mock            func(parent *resource.Peer, child *resource.Peer)
 		expect          func(t *testing.T, score float64)
 	}{
@@ -182,11 +183,42 @@ func TestEvaluatorBase_Evaluate(t *testing.T) {
 				assert.Equal(score, float64(0.85))
 			},
 		},
+		{
+			name: "evaluate with weights",
+			parent: resource.NewPeer(idgen.PeerID("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit)),
+				resource.NewHost(mockRawSeedHost)),
+			child: resource.NewPeer(idgen.PeerID("127.0.0.1"),
+				resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit)),
+				resource.NewHost(mockRawHost)),
+			totalPieceCount: 1,
+			weights: map[string]float64{
+				FeatureFinishedPiece:           0,
+				FeatureParentHostUploadSuccess: 0,
+				FeatureFreeUpload:              0,
+				FeatureHostType:                0,
+				FeatureIDCAffinity:             1,
+				FeatureNetTopologyAffinity:     0,
+				FeatureLocationAffinity:        0,
+			},
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(1))
+			},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			eb := NewEvaluatorBase()
+			weights, err := NewWeights(tc.weights)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			eb := NewEvaluatorBase(WithWeights(weights))
 			tc.mock(tc.parent, tc.child)
 			tc.expect(t, eb.Evaluate(tc.parent, tc.child, tc.totalPieceCount))
 		})
@@ -643,6 +675,54 @@ func TestEvaluatorBase_calculateMultiElementAffinityScore(t *testing.T) {
 	}
 }
 
+func TestEvaluatorBase_calculateNetworkCostScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(parent *resource.Peer, child *resource.Peer)
+		expect func(t *testing.T, score float64)
+	}{
+		{
+			name: "probe is not found",
+			mock: func(parent *resource.Peer, child *resource.Peer) {},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, 0.5)
+			},
+		},
+		{
+			name: "probe has low bandwidth",
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				child.Host.Probes.Store(parent.Host.ID, resource.Probe{Bandwidth: referenceBandwidth / 3})
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, 0.25)
+			},
+		},
+		{
+			name: "probe has high bandwidth",
+			mock: func(parent *resource.Peer, child *resource.Peer) {
+				child.Host.Probes.Store(parent.Host.ID, resource.Probe{Bandwidth: 3 * referenceBandwidth})
+			},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, 0.75)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			parent := resource.NewPeer(mockPeerID, mockTask, resource.NewHost(mockRawSeedHost))
+			child := resource.NewPeer(mockPeerID, mockTask, resource.NewHost(mockRawHost))
+
+			tc.mock(parent, child)
+			tc.expect(t, calculateNetworkCostScore(parent, child))
+		})
+	}
+}
+
 func TestEvaluatorBase_IsBadNode(t *testing.T) {
 	mockHost := resource.NewHost(mockRawHost)
 	mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
@@ -0,0 +1,151 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	managerv1 "d7y.io/api/pkg/apis/manager/v1"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/manager/types"
+	managerclient "d7y.io/dragonfly/v2/pkg/rpc/manager/client"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+const (
+	// loadModelTimeout is the timeout of loading model from manager.
+	loadModelTimeout = 30 * time.Second
+)
+
+// modelVersion is the model of the active version.
+type modelVersion struct {
+	// id is the version id of model.
+	id string
+
+	// model is the model of version.
+	model *Model
+}
+
+type evaluatorML struct {
+	// Rule-based evaluator used when the model is unavailable.
+	Evaluator
+
+	// Manager client.
+	managerClient managerclient.V1
+
+	// refreshCh passes the scheduler id to the goroutine of refreshing model.
+	refreshCh chan uint64
+
+	// modelVersion is the model of the active version.
+	modelVersion atomic.Pointer[modelVersion]
+}
+
+// NewEvaluatorML returns a new machine learning evaluator, the evaluator
+// checks the active model version of scheduler in the manager every time
+// dynconfig notifies, swaps the model when a new version is activated,
+// and falls back to the rule-based evaluator until the model is available.
+func NewEvaluatorML(managerClient managerclient.V1, dynconfig config.DynconfigInterface, options ...Option) Evaluator {
+	e := &evaluatorML{
+		Evaluator:     NewEvaluatorBase(options...),
+		managerClient: managerClient,
+		refreshCh:     make(chan uint64, 1),
+	}
+	go e.serve()
+	dynconfig.Register(e)
+
+	return e
+}
+
+// The larger the value after evaluation, the higher the priority.
+func (e *evaluatorML) Evaluate(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+	mv := e.modelVersion.Load()
+	if mv == nil {
+		return e.Evaluator.Evaluate(parent, child, totalPieceCount)
+	}
+
+	// If the SecurityDomain of hosts exists but is not equal,
+	// it cannot be scheduled as a parent.
+	if parent.Host.Network != nil && child.Host.Network != nil &&
+		parent.Host.Network.SecurityDomain != "" &&
+		child.Host.Network.SecurityDomain != "" &&
+		parent.Host.Network.SecurityDomain != child.Host.Network.SecurityDomain {
+		return minScore
+	}
+
+	return mv.model.Predict(extractFeatures(parent, child, totalPieceCount))
+}
+
+// OnNotify triggers refreshing the model without blocking other observers of dynconfig,
+// the notification is dropped if the previous refreshing is pending.
+func (e *evaluatorML) OnNotify(data *config.DynconfigData) {
+	if data.Scheduler == nil {
+		return
+	}
+
+	select {
+	case e.refreshCh <- data.Scheduler.Id:
+	default:
+	}
+}
+
+// serve refreshes the model when dynconfig notifies.
+func (e *evaluatorML) serve() {
+	for schedulerID := range e.refreshCh {
+		ctx, cancel := context.WithTimeout(context.Background(), loadModelTimeout)
+		if err := e.refresh(ctx, schedulerID); err != nil {
+			logger.Errorf("refresh model failed: %s", err.Error())
+		}
+		cancel()
+	}
+}
+
+// refresh loads the active model version when the version is changed.
+func (e *evaluatorML) refresh(ctx context.Context, schedulerID uint64) error {
+	model, err := e.managerClient.GetModel(ctx, &managerv1.GetModelRequest{
+		SchedulerId: schedulerID,
+		ModelId:     types.ModelIDEvaluator,
+	})
+	if err != nil {
+		return err
+	}
+
+	if mv := e.modelVersion.Load(); mv != nil && mv.id == model.VersionId {
+		return nil
+	}
+
+	version, err := e.managerClient.GetModelVersion(ctx, &managerv1.GetModelVersionRequest{
+		SchedulerId: schedulerID,
+		ModelId:     types.ModelIDEvaluator,
+		VersionId:   model.VersionId,
+	})
+	if err != nil {
+		return err
+	}
+
+	m, err := ParseModel(version.Data)
+	if err != nil {
+		return err
+	}
+
+	e.modelVersion.Store(&modelVersion{id: model.VersionId, model: m})
+	logger.Infof("model is refreshed to version %s", model.VersionId)
+	return nil
+}
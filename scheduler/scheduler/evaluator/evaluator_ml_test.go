@@ -0,0 +1,288 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	managerv1 "d7y.io/api/pkg/apis/manager/v1"
+	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+
+	"d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	managerclientmocks "d7y.io/dragonfly/v2/pkg/rpc/manager/client/mocks"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+var (
+	mockModelData = []byte(`{"type":"linear","weights":{"finishedPiece":0.5,"idcAffinity":0.25},"bias":0.1}`)
+
+	mockDynconfigData = &config.DynconfigData{
+		Scheduler: &managerv1.Scheduler{
+			Id: 1,
+		},
+	}
+)
+
+func newMockEvaluatorML(ctl *gomock.Controller, managerClient *managerclientmocks.MockV1, options ...Option) *evaluatorML {
+	dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+	dynconfig.EXPECT().Register(gomock.Any()).Times(1)
+	return NewEvaluatorML(managerClient, dynconfig, options...).(*evaluatorML)
+}
+
+func newMockPeer(id string, rawHost *schedulerv1.AnnounceHostRequest) *resource.Peer {
+	host := resource.NewHost(&schedulerv1.AnnounceHostRequest{
+		Id:           rawHost.Id,
+		Type:         rawHost.Type,
+		Ip:           rawHost.Ip,
+		Port:         rawHost.Port,
+		DownloadPort: rawHost.DownloadPort,
+		Hostname:     rawHost.Hostname,
+		Network: &schedulerv1.Network{
+			SecurityDomain: "security_domain",
+			Location:       "location",
+			Idc:            "idc",
+			NetTopology:    "net_topology",
+		},
+	})
+	task := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+	return resource.NewPeer(id, task, host)
+}
+
+func TestEvaluatorML_Evaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(e *evaluatorML, parent *resource.Peer, child *resource.Peer)
+		expect func(t *testing.T, e *evaluatorML, parent *resource.Peer, child *resource.Peer, score float64)
+	}{
+		{
+			name: "model is unavailable",
+			mock: func(e *evaluatorML, parent *resource.Peer, child *resource.Peer) {
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, e *evaluatorML, parent *resource.Peer, child *resource.Peer, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, NewEvaluatorBase().Evaluate(parent, child, 1))
+			},
+		},
+		{
+			name: "evaluate by model",
+			mock: func(e *evaluatorML, parent *resource.Peer, child *resource.Peer) {
+				model, err := ParseModel(mockModelData)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				e.modelVersion.Store(&modelVersion{id: "foo", model: model})
+				parent.FinishedPieces.Set(0)
+			},
+			expect: func(t *testing.T, e *evaluatorML, parent *resource.Peer, child *resource.Peer, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, 0.85, 1e-9)
+			},
+		},
+		{
+			name: "security domain is not the same",
+			mock: func(e *evaluatorML, parent *resource.Peer, child *resource.Peer) {
+				model, err := ParseModel(mockModelData)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				e.modelVersion.Store(&modelVersion{id: "foo", model: model})
+				parent.Host.Network.SecurityDomain = "foo"
+				child.Host.Network.SecurityDomain = "bar"
+			},
+			expect: func(t *testing.T, e *evaluatorML, parent *resource.Peer, child *resource.Peer, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, float64(0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			e := newMockEvaluatorML(ctl, managerclientmocks.NewMockV1(ctl))
+			parent := newMockPeer(idgen.PeerID("127.0.0.1"), mockRawSeedHost)
+			child := newMockPeer(idgen.PeerID("127.0.0.1"), mockRawHost)
+
+			tc.mock(e, parent, child)
+			tc.expect(t, e, parent, child, e.Evaluate(parent, child, 1))
+		})
+	}
+}
+
+func TestEvaluatorML_OnNotify(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   *config.DynconfigData
+		mock   func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder)
+		expect func(t *testing.T, e *evaluatorML)
+	}{
+		{
+			name: "scheduler is invalid",
+			data: &config.DynconfigData{},
+			mock: func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder) {},
+			expect: func(t *testing.T, e *evaluatorML) {
+				assert := assert.New(t)
+				assert.Equal(len(e.refreshCh), 0)
+				assert.Nil(e.modelVersion.Load())
+			},
+		},
+		{
+			name: "load model when dynconfig notifies",
+			data: mockDynconfigData,
+			mock: func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					mm.GetModel(gomock.Any(), gomock.Any()).Return(&managerv1.Model{VersionId: "foo"}, nil).Times(1),
+					mm.GetModelVersion(gomock.Any(), gomock.Any()).Return(&managerv1.ModelVersion{VersionId: "foo", Data: mockModelData}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, e *evaluatorML) {
+				assert := assert.New(t)
+				assert.Eventually(func() bool {
+					mv := e.modelVersion.Load()
+					return mv != nil && mv.id == "foo"
+				}, time.Second, time.Millisecond)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			managerClient := managerclientmocks.NewMockV1(ctl)
+			e := newMockEvaluatorML(ctl, managerClient)
+
+			tc.mock(e, managerClient.EXPECT())
+			e.OnNotify(tc.data)
+			tc.expect(t, e)
+		})
+	}
+}
+
+func TestEvaluatorML_refresh(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder)
+		expect func(t *testing.T, e *evaluatorML, err error)
+	}{
+		{
+			name: "load model",
+			mock: func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					mm.GetModel(gomock.Any(), gomock.Eq(&managerv1.GetModelRequest{
+						SchedulerId: 1,
+						ModelId:     types.ModelIDEvaluator,
+					})).Return(&managerv1.Model{VersionId: "foo"}, nil).Times(1),
+					mm.GetModelVersion(gomock.Any(), gomock.Eq(&managerv1.GetModelVersionRequest{
+						SchedulerId: 1,
+						ModelId:     types.ModelIDEvaluator,
+						VersionId:   "foo",
+					})).Return(&managerv1.ModelVersion{VersionId: "foo", Data: mockModelData}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, e *evaluatorML, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				mv := e.modelVersion.Load()
+				assert.Equal(mv.id, "foo")
+				assert.Equal(mv.model.Type, ModelTypeLinear)
+			},
+		},
+		{
+			name: "model is not found",
+			mock: func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder) {
+				mm.GetModel(gomock.Any(), gomock.Any()).Return(nil, errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, e *evaluatorML, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+				assert.Nil(e.modelVersion.Load())
+			},
+		},
+		{
+			name: "model data is invalid",
+			mock: func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					mm.GetModel(gomock.Any(), gomock.Any()).Return(&managerv1.Model{VersionId: "foo"}, nil).Times(1),
+					mm.GetModelVersion(gomock.Any(), gomock.Any()).Return(&managerv1.ModelVersion{VersionId: "foo", Data: []byte("foo")}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, e *evaluatorML, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+				assert.Nil(e.modelVersion.Load())
+			},
+		},
+		{
+			name: "swap model when a new version is activated",
+			mock: func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder) {
+				e.modelVersion.Store(&modelVersion{id: "foo", model: &Model{}})
+				gomock.InOrder(
+					mm.GetModel(gomock.Any(), gomock.Any()).Return(&managerv1.Model{VersionId: "bar"}, nil).Times(1),
+					mm.GetModelVersion(gomock.Any(), gomock.Eq(&managerv1.GetModelVersionRequest{
+						SchedulerId: 1,
+						ModelId:     types.ModelIDEvaluator,
+						VersionId:   "bar",
+					})).Return(&managerv1.ModelVersion{VersionId: "bar", Data: mockModelData}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, e *evaluatorML, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(e.modelVersion.Load().id, "bar")
+			},
+		},
+		{
+			name: "version is not changed",
+			mock: func(e *evaluatorML, mm *managerclientmocks.MockV1MockRecorder) {
+				e.modelVersion.Store(&modelVersion{id: "foo", model: &Model{}})
+				mm.GetModel(gomock.Any(), gomock.Any()).Return(&managerv1.Model{VersionId: "foo"}, nil).Times(1)
+			},
+			expect: func(t *testing.T, e *evaluatorML, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(e.modelVersion.Load().id, "foo")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			managerClient := managerclientmocks.NewMockV1(ctl)
+			e := newMockEvaluatorML(ctl, managerClient)
+
+			tc.mock(e, managerClient.EXPECT())
+			tc.expect(t, e, e.refresh(context.Background(), 1))
+		})
+	}
+}
@@ -20,7 +20,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+
+	managerclientmocks "d7y.io/dragonfly/v2/pkg/rpc/manager/client/mocks"
+	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
 )
 
 func TestEvaluator_New(t *testing.T) {
@@ -28,6 +32,7 @@ func TestEvaluator_New(t *testing.T) {
 	tests := []struct {
 		name      string
 		algorithm string
+		options   func(ctl *gomock.Controller) []Option
 		expect    func(t *testing.T, e any)
 	}{
 		{
@@ -46,6 +51,19 @@ func TestEvaluator_New(t *testing.T) {
 				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorBase")
 			},
 		},
+		{
+			name:      "new evaluator with machine learning algorithm and manager client",
+			algorithm: "ml",
+			options: func(ctl *gomock.Controller) []Option {
+				dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+				dynconfig.EXPECT().Register(gomock.Any()).Times(1)
+				return []Option{WithManagerClient(managerclientmocks.NewMockV1(ctl)), WithDynconfig(dynconfig)}
+			},
+			expect: func(t *testing.T, e any) {
+				assert := assert.New(t)
+				assert.Equal(reflect.TypeOf(e).Elem().Name(), "evaluatorML")
+			},
+		},
 		{
 			name:      "new evaluator with plugin",
 			algorithm: "plugin",
@@ -66,7 +84,15 @@ func TestEvaluator_New(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			tc.expect(t, New(tc.algorithm, pluginDir))
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			var options []Option
+			if tc.options != nil {
+				options = tc.options(ctl)
+			}
+
+			tc.expect(t, New(tc.algorithm, pluginDir, options...))
 		})
 	}
 }
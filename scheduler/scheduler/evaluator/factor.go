@@ -0,0 +1,129 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"fmt"
+
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+const (
+	// FactorNetworkCost is the factor of network cost score between hosts,
+	// it is measured by the probes and not used by the model.
+	FactorNetworkCost = "networkCost"
+)
+
+// Factor calculates the score of parent for child, the larger and better.
+type Factor func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64
+
+// factor is the registered factor with the default weight.
+type factor struct {
+	name          string
+	calculate     Factor
+	defaultWeight float64
+}
+
+// factors is the registered factors in order of registration.
+var factors []factor
+
+func init() {
+	RegisterFactor(FeatureFinishedPiece, calculatePieceScore, finishedPieceWeight)
+	RegisterFactor(FeatureParentHostUploadSuccess, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		return calculateParentHostUploadSuccessScore(parent)
+	}, parentHostUploadSuccessWeight)
+	RegisterFactor(FeatureFreeUpload, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		return calculateFreeUploadScore(parent.Host)
+	}, freeUploadWeight)
+	RegisterFactor(FeatureHostType, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		return calculateHostTypeScore(parent)
+	}, hostTypeWeight)
+	RegisterFactor(FeatureIDCAffinity, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		var parentIDC, childIDC string
+		if parent.Host.Network != nil {
+			parentIDC = parent.Host.Network.Idc
+		}
+
+		if child.Host.Network != nil {
+			childIDC = child.Host.Network.Idc
+		}
+
+		return calculateIDCAffinityScore(parentIDC, childIDC)
+	}, idcAffinityWeight)
+	RegisterFactor(FeatureNetTopologyAffinity, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		var parentNetTopology, childNetTopology string
+		if parent.Host.Network != nil {
+			parentNetTopology = parent.Host.Network.NetTopology
+		}
+
+		if child.Host.Network != nil {
+			childNetTopology = child.Host.Network.NetTopology
+		}
+
+		return calculateMultiElementAffinityScore(parentNetTopology, childNetTopology)
+	}, netTopologyAffinityWeight)
+	RegisterFactor(FeatureLocationAffinity, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		var parentLocation, childLocation string
+		if parent.Host.Network != nil {
+			parentLocation = parent.Host.Network.Location
+		}
+
+		if child.Host.Network != nil {
+			childLocation = child.Host.Network.Location
+		}
+
+		return calculateMultiElementAffinityScore(parentLocation, childLocation)
+	}, locationAffinityWeight)
+	RegisterFactor(FeatureParentPieceCost, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		return calculatePieceCostScore(MeanPieceCost(parent.PieceCosts()))
+	}, parentPieceCostWeight)
+	RegisterFactor(FactorNetworkCost, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+		return calculateNetworkCostScore(parent, child)
+	}, networkCostWeight)
+}
+
+// RegisterFactor registers the factor by name with the default weight,
+// it is not concurrency safe and must be called in init.
+func RegisterFactor(name string, calculate Factor, defaultWeight float64) {
+	for _, f := range factors {
+		if f.name == name {
+			panic(fmt.Sprintf("factor %s is already registered", name))
+		}
+	}
+
+	factors = append(factors, factor{name: name, calculate: calculate, defaultWeight: defaultWeight})
+}
+
+// FactorNames returns the names of registered factors.
+func FactorNames() []string {
+	names := make([]string, 0, len(factors))
+	for _, f := range factors {
+		names = append(names, f.name)
+	}
+
+	return names
+}
+
+// DefaultWeights returns the default weights of registered factors.
+func DefaultWeights() map[string]float64 {
+	weights := make(map[string]float64, len(factors))
+	for _, f := range factors {
+		weights[f.name] = f.defaultWeight
+	}
+
+	return weights
+}
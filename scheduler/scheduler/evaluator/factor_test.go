@@ -0,0 +1,61 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/scheduler/resource"
+)
+
+func TestFactor_FactorNames(t *testing.T) {
+	assert := assert.New(t)
+	assert.EqualValues(FactorNames(), append(append([]string{}, Features...), FactorNetworkCost))
+}
+
+func TestFactor_DefaultWeights(t *testing.T) {
+	assert := assert.New(t)
+	assert.EqualValues(DefaultWeights(), map[string]float64{
+		FeatureFinishedPiece:           finishedPieceWeight,
+		FeatureParentHostUploadSuccess: parentHostUploadSuccessWeight,
+		FeatureFreeUpload:              freeUploadWeight,
+		FeatureHostType:                hostTypeWeight,
+		FeatureIDCAffinity:             idcAffinityWeight,
+		FeatureNetTopologyAffinity:     netTopologyAffinityWeight,
+		FeatureLocationAffinity:        locationAffinityWeight,
+		FeatureParentPieceCost:         parentPieceCostWeight,
+		FactorNetworkCost:              networkCostWeight,
+	})
+
+	var total float64
+	for _, weight := range DefaultWeights() {
+		total += weight
+	}
+	assert.InDelta(total, float64(1), 1e-9)
+	assert.Equal(DefaultWeights()[FactorNetworkCost], float64(0))
+}
+
+func TestFactor_RegisterFactor(t *testing.T) {
+	assert := assert.New(t)
+	assert.PanicsWithValue("factor finishedPiece is already registered", func() {
+		RegisterFactor(FeatureFinishedPiece, func(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) float64 {
+			return 0
+		}, 1)
+	})
+}
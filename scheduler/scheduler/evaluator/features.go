@@ -0,0 +1,157 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"time"
+
+	"d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/storage"
+)
+
+// referencePieceCost is the piece cost which scores 0.5 in the parent piece cost score.
+const referencePieceCost = time.Second
+
+// extractFeatures extracts features of parent and child used by the model.
+func extractFeatures(parent *resource.Peer, child *resource.Peer, totalPieceCount int32) map[string]float64 {
+	var (
+		parentNetTopology string
+		childNetTopology  string
+		parentLocation    string
+		childLocation     string
+		parentIDC         string
+		childIDC          string
+	)
+	if parent.Host.Network != nil {
+		parentNetTopology = parent.Host.Network.NetTopology
+		parentLocation = parent.Host.Network.Location
+		parentIDC = parent.Host.Network.Idc
+	}
+
+	if child.Host.Network != nil {
+		childNetTopology = child.Host.Network.NetTopology
+		childLocation = child.Host.Network.Location
+		childIDC = child.Host.Network.Idc
+	}
+
+	return map[string]float64{
+		FeatureFinishedPiece:           calculatePieceScore(parent, child, totalPieceCount),
+		FeatureParentHostUploadSuccess: calculateParentHostUploadSuccessScore(parent),
+		FeatureFreeUpload:              calculateFreeUploadScore(parent.Host),
+		FeatureHostType:                calculateHostTypeScore(parent),
+		FeatureIDCAffinity:             calculateIDCAffinityScore(parentIDC, childIDC),
+		FeatureNetTopologyAffinity:     calculateMultiElementAffinityScore(parentNetTopology, childNetTopology),
+		FeatureLocationAffinity:        calculateMultiElementAffinityScore(parentLocation, childLocation),
+		FeatureParentPieceCost:         calculatePieceCostScore(MeanPieceCost(parent.PieceCosts())),
+	}
+}
+
+// ExtractRecordFeatures extracts features of parent and child from the download record,
+// the features are the same as the features used by the model when evaluating.
+func ExtractRecordFeatures(record *storage.Record, parent *storage.Parent) map[string]float64 {
+	return map[string]float64{
+		FeatureFinishedPiece:           calculateRecordPieceScore(record, parent),
+		FeatureParentHostUploadSuccess: calculateRecordUploadSuccessScore(&parent.Host),
+		FeatureFreeUpload:              calculateRecordFreeUploadScore(&parent.Host),
+		FeatureHostType:                calculateRecordHostTypeScore(parent),
+		FeatureIDCAffinity:             calculateIDCAffinityScore(parent.Host.Network.IDC, record.Host.Network.IDC),
+		FeatureNetTopologyAffinity:     calculateMultiElementAffinityScore(parent.Host.Network.NetTopology, record.Host.Network.NetTopology),
+		FeatureLocationAffinity:        calculateMultiElementAffinityScore(parent.Host.Network.Location, record.Host.Network.Location),
+		FeatureParentPieceCost:         calculatePieceCostScore(parent.PieceCost),
+	}
+}
+
+// MeanPieceCost returns the mean of piece costs in milliseconds,
+// it returns zero when no piece has been downloaded.
+func MeanPieceCost(costs []int64) int64 {
+	if len(costs) == 0 {
+		return 0
+	}
+
+	var total int64
+	for _, cost := range costs {
+		total += cost
+	}
+
+	return total / int64(len(costs))
+}
+
+// calculatePieceCostScore 0.0~1.0 larger and better.
+func calculatePieceCostScore(pieceCost int64) float64 {
+	// Piece cost is unknown when parent has not downloaded any piece.
+	if pieceCost <= 0 {
+		return maxScore * 0.5
+	}
+
+	reference := float64(referencePieceCost.Milliseconds())
+	return reference / (reference + float64(pieceCost))
+}
+
+// calculateRecordPieceScore 0.0~1.0 larger and better.
+func calculateRecordPieceScore(record *storage.Record, parent *storage.Parent) float64 {
+	// The finished pieces of parent are not recorded, parent has finished
+	// all pieces when it is succeeded, otherwise the pieces uploaded to
+	// the child are the lower bound of its finished pieces.
+	if parent.State == resource.PeerStateSucceeded {
+		return maxScore
+	}
+
+	if record.Task.TotalPieceCount <= 0 {
+		return minScore
+	}
+
+	return float64(parent.UploadPieceCount) / float64(record.Task.TotalPieceCount)
+}
+
+// calculateRecordUploadSuccessScore 0.0~1.0 larger and better.
+func calculateRecordUploadSuccessScore(host *storage.Host) float64 {
+	if host.UploadCount < host.UploadFailedCount {
+		return minScore
+	}
+
+	// Host has not been scheduled, then it is scheduled first.
+	if host.UploadCount == 0 && host.UploadFailedCount == 0 {
+		return maxScore
+	}
+
+	return float64(host.UploadCount-host.UploadFailedCount) / float64(host.UploadCount)
+}
+
+// calculateRecordFreeUploadScore 0.0~1.0 larger and better.
+func calculateRecordFreeUploadScore(host *storage.Host) float64 {
+	freeUploadCount := host.ConcurrentUploadLimit - host.ConcurrentUploadCount
+	if host.ConcurrentUploadLimit > 0 && freeUploadCount > 0 {
+		return float64(freeUploadCount) / float64(host.ConcurrentUploadLimit)
+	}
+
+	return minScore
+}
+
+// calculateRecordHostTypeScore 0.0~1.0 larger and better.
+func calculateRecordHostTypeScore(parent *storage.Parent) float64 {
+	if parent.Host.Type != types.HostTypeNormalName {
+		if parent.State == resource.PeerStateReceivedNormal ||
+			parent.State == resource.PeerStateRunning {
+			return maxScore
+		}
+
+		return minScore
+	}
+
+	return maxScore * 0.5
+}
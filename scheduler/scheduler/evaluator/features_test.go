@@ -0,0 +1,168 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/storage"
+)
+
+func TestFeatures_extractFeatures(t *testing.T) {
+	parent := newMockPeer(idgen.PeerID("127.0.0.1"), mockRawSeedHost)
+	child := newMockPeer(idgen.PeerID("127.0.0.1"), mockRawHost)
+	parent.FSM.SetState(resource.PeerStateRunning)
+	parent.FinishedPieces.Set(0)
+	parent.AppendPieceCost(500)
+	parent.AppendPieceCost(1500)
+
+	features := extractFeatures(parent, child, 2)
+	assert := assert.New(t)
+	assert.Equal(len(features), len(Features))
+	assert.Equal(features[FeatureFinishedPiece], 0.5)
+	assert.Equal(features[FeatureParentHostUploadSuccess], float64(1))
+	assert.Equal(features[FeatureHostType], float64(1))
+	assert.Equal(features[FeatureIDCAffinity], float64(1))
+	assert.Equal(features[FeatureNetTopologyAffinity], float64(1))
+	assert.Equal(features[FeatureLocationAffinity], float64(1))
+	assert.Equal(features[FeatureParentPieceCost], 0.5)
+	assert.Equal(parent.Host.Type, types.HostTypeSuperSeed)
+}
+
+func TestFeatures_ExtractRecordFeatures(t *testing.T) {
+	tests := []struct {
+		name   string
+		record *storage.Record
+		parent *storage.Parent
+		expect func(t *testing.T, features map[string]float64)
+	}{
+		{
+			name: "parent is succeeded seed peer",
+			record: &storage.Record{
+				Task: storage.Task{TotalPieceCount: 4},
+				Host: storage.Host{
+					Network: storage.Network{IDC: "idc", Location: "a|b|c", NetTopology: "net_topology"},
+				},
+			},
+			parent: &storage.Parent{
+				State:            resource.PeerStateSucceeded,
+				UploadPieceCount: 2,
+				PieceCost:        3000,
+				Host: storage.Host{
+					Type:                  types.HostTypeSuperSeedName,
+					ConcurrentUploadLimit: 4,
+					ConcurrentUploadCount: 1,
+					UploadCount:           4,
+					UploadFailedCount:     1,
+					Network:               storage.Network{IDC: "idc", Location: "a|b|d", NetTopology: "net_topology"},
+				},
+			},
+			expect: func(t *testing.T, features map[string]float64) {
+				assert := assert.New(t)
+				assert.Equal(len(features), len(Features))
+				assert.Equal(features[FeatureFinishedPiece], float64(1))
+				assert.Equal(features[FeatureParentHostUploadSuccess], 0.75)
+				assert.Equal(features[FeatureFreeUpload], 0.75)
+				assert.Equal(features[FeatureHostType], float64(0))
+				assert.Equal(features[FeatureIDCAffinity], float64(1))
+				assert.Equal(features[FeatureNetTopologyAffinity], float64(1))
+				assert.Equal(features[FeatureLocationAffinity], 0.4)
+				assert.Equal(features[FeatureParentPieceCost], 0.25)
+			},
+		},
+		{
+			name: "parent is running normal peer",
+			record: &storage.Record{
+				Task: storage.Task{TotalPieceCount: 4},
+			},
+			parent: &storage.Parent{
+				State:            resource.PeerStateRunning,
+				UploadPieceCount: 1,
+				Host: storage.Host{
+					Type:                  types.HostTypeNormalName,
+					ConcurrentUploadLimit: 4,
+					ConcurrentUploadCount: 4,
+				},
+			},
+			expect: func(t *testing.T, features map[string]float64) {
+				assert := assert.New(t)
+				assert.Equal(features[FeatureFinishedPiece], 0.25)
+				assert.Equal(features[FeatureParentHostUploadSuccess], float64(1))
+				assert.Equal(features[FeatureFreeUpload], float64(0))
+				assert.Equal(features[FeatureHostType], 0.5)
+				assert.Equal(features[FeatureIDCAffinity], float64(0))
+				assert.Equal(features[FeatureNetTopologyAffinity], float64(0))
+				assert.Equal(features[FeatureLocationAffinity], float64(0))
+				assert.Equal(features[FeatureParentPieceCost], 0.5)
+			},
+		},
+		{
+			name:   "total piece count is unknown",
+			record: &storage.Record{},
+			parent: &storage.Parent{
+				State:            resource.PeerStateFailed,
+				UploadPieceCount: 1,
+				Host: storage.Host{
+					UploadCount:       1,
+					UploadFailedCount: 2,
+				},
+			},
+			expect: func(t *testing.T, features map[string]float64) {
+				assert := assert.New(t)
+				assert.Equal(features[FeatureFinishedPiece], float64(0))
+				assert.Equal(features[FeatureParentHostUploadSuccess], float64(0))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, ExtractRecordFeatures(tc.record, tc.parent))
+		})
+	}
+}
+
+func TestFeatures_MeanPieceCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		costs  []int64
+		expect int64
+	}{
+		{
+			name:   "piece costs are empty",
+			costs:  nil,
+			expect: 0,
+		},
+		{
+			name:   "mean of piece costs",
+			costs:  []int64{100, 200, 300},
+			expect: 200,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(MeanPieceCost(tc.costs), tc.expect)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"d7y.io/dragonfly/v2/pkg/slices"
+)
+
+const (
+	// ModelTypeLinear is the linear regression model.
+	ModelTypeLinear = "linear"
+
+	// ModelTypeLogistic is the logistic regression model.
+	ModelTypeLogistic = "logistic"
+)
+
+const (
+	// FeatureFinishedPiece is the feature of finished piece score.
+	FeatureFinishedPiece = "finishedPiece"
+
+	// FeatureParentHostUploadSuccess is the feature of parent's host upload success score.
+	FeatureParentHostUploadSuccess = "parentHostUploadSuccess"
+
+	// FeatureFreeUpload is the feature of free upload score.
+	FeatureFreeUpload = "freeUpload"
+
+	// FeatureHostType is the feature of host type score.
+	FeatureHostType = "hostType"
+
+	// FeatureIDCAffinity is the feature of IDC affinity score.
+	FeatureIDCAffinity = "idcAffinity"
+
+	// FeatureNetTopologyAffinity is the feature of NetTopology affinity score.
+	FeatureNetTopologyAffinity = "netTopologyAffinity"
+
+	// FeatureLocationAffinity is the feature of location affinity score.
+	FeatureLocationAffinity = "locationAffinity"
+
+	// FeatureParentPieceCost is the feature of parent's piece cost score.
+	FeatureParentPieceCost = "parentPieceCost"
+)
+
+// Features is the features of parent and child used by the model.
+var Features = []string{
+	FeatureFinishedPiece,
+	FeatureParentHostUploadSuccess,
+	FeatureFreeUpload,
+	FeatureHostType,
+	FeatureIDCAffinity,
+	FeatureNetTopologyAffinity,
+	FeatureLocationAffinity,
+	FeatureParentPieceCost,
+}
+
+// Model is the model for evaluating parents, it is trained by the
+// download records and stored in the data of model version by manager.
+type Model struct {
+	// Type is the type of model.
+	Type string `json:"type"`
+
+	// Weights is the weight of features.
+	Weights map[string]float64 `json:"weights"`
+
+	// Bias is the bias of model.
+	Bias float64 `json:"bias"`
+}
+
+// ParseModel parses model from the data of model version.
+func ParseModel(data []byte) (*Model, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty model data")
+	}
+
+	model := &Model{}
+	if err := json.Unmarshal(data, model); err != nil {
+		return nil, err
+	}
+
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// Marshal returns the data of model version.
+func (m *Model) Marshal() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// Validate validates the model.
+func (m *Model) Validate() error {
+	if m.Type != ModelTypeLinear && m.Type != ModelTypeLogistic {
+		return fmt.Errorf("invalid model type %s", m.Type)
+	}
+
+	if len(m.Weights) == 0 {
+		return errors.New("empty model weights")
+	}
+
+	for feature, weight := range m.Weights {
+		if !slices.Contains(Features, feature) {
+			return fmt.Errorf("invalid model feature %s", feature)
+		}
+
+		if math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("invalid weight of model feature %s", feature)
+		}
+	}
+
+	if math.IsNaN(m.Bias) || math.IsInf(m.Bias, 0) {
+		return errors.New("invalid model bias")
+	}
+
+	return nil
+}
+
+// Predict returns the score of features, features not in the model are ignored.
+func (m *Model) Predict(features map[string]float64) float64 {
+	score := m.Bias
+	for feature, weight := range m.Weights {
+		score += weight * features[feature]
+	}
+
+	if m.Type == ModelTypeLogistic {
+		return 1 / (1 + math.Exp(-score))
+	}
+
+	return score
+}
@@ -0,0 +1,196 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_ParseModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		expect func(t *testing.T, model *Model, err error)
+	}{
+		{
+			name: "parse linear model",
+			data: []byte(`{"type":"linear","weights":{"finishedPiece":0.5,"idcAffinity":0.2},"bias":0.1}`),
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.EqualValues(model, &Model{
+					Type:    ModelTypeLinear,
+					Weights: map[string]float64{FeatureFinishedPiece: 0.5, FeatureIDCAffinity: 0.2},
+					Bias:    0.1,
+				})
+			},
+		},
+		{
+			name: "data is empty",
+			data: []byte{},
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "empty model data")
+			},
+		},
+		{
+			name: "data is invalid json",
+			data: []byte("foo"),
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.Error(err)
+			},
+		},
+		{
+			name: "model type is invalid",
+			data: []byte(`{"type":"foo","weights":{"finishedPiece":0.5}}`),
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid model type foo")
+			},
+		},
+		{
+			name: "model weights are empty",
+			data: []byte(`{"type":"linear"}`),
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "empty model weights")
+			},
+		},
+		{
+			name: "model feature is invalid",
+			data: []byte(`{"type":"logistic","weights":{"foo":0.5}}`),
+			expect: func(t *testing.T, model *Model, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid model feature foo")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			model, err := ParseModel(tc.data)
+			tc.expect(t, model, err)
+		})
+	}
+}
+
+func TestModel_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  *Model
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "model is valid",
+			model: &Model{
+				Type:    ModelTypeLogistic,
+				Weights: map[string]float64{FeatureFreeUpload: 1},
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "weight is NaN",
+			model: &Model{
+				Type:    ModelTypeLinear,
+				Weights: map[string]float64{FeatureFreeUpload: math.NaN()},
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid weight of model feature freeUpload")
+			},
+		},
+		{
+			name: "bias is infinite",
+			model: &Model{
+				Type:    ModelTypeLinear,
+				Weights: map[string]float64{FeatureFreeUpload: 1},
+				Bias:    math.Inf(1),
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid model bias")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, tc.model.Validate())
+		})
+	}
+}
+
+func TestModel_Predict(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    *Model
+		features map[string]float64
+		expect   func(t *testing.T, score float64)
+	}{
+		{
+			name: "predict by linear model",
+			model: &Model{
+				Type:    ModelTypeLinear,
+				Weights: map[string]float64{FeatureFinishedPiece: 0.5, FeatureIDCAffinity: 0.25},
+				Bias:    0.1,
+			},
+			features: map[string]float64{FeatureFinishedPiece: 1, FeatureIDCAffinity: 1, FeatureHostType: 1},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.InDelta(score, 0.85, 1e-9)
+			},
+		},
+		{
+			name: "predict by logistic model",
+			model: &Model{
+				Type:    ModelTypeLogistic,
+				Weights: map[string]float64{FeatureFinishedPiece: 1},
+			},
+			features: map[string]float64{FeatureFinishedPiece: 0},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, 0.5)
+			},
+		},
+		{
+			name: "features are missing",
+			model: &Model{
+				Type:    ModelTypeLinear,
+				Weights: map[string]float64{FeatureFinishedPiece: 1},
+				Bias:    0.2,
+			},
+			features: map[string]float64{},
+			expect: func(t *testing.T, score float64) {
+				assert := assert.New(t)
+				assert.Equal(score, 0.2)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, tc.model.Predict(tc.features))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+const (
+	// WeightsSourceConfig is the source of weights set by scheduler config.
+	WeightsSourceConfig = "config"
+
+	// WeightsSourceDynconfig is the source of weights overridden by scheduler cluster config.
+	WeightsSourceDynconfig = "dynconfig"
+)
+
+// weightsInEffect is the weights of factors in effect.
+type weightsInEffect struct {
+	// Source is the source of weights.
+	Source string `json:"source"`
+
+	// Weights is the weight of factors.
+	Weights map[string]float64 `json:"weights"`
+}
+
+// Weights is the weights of factors used by the evaluator, the weights are set by
+// scheduler config and can be overridden by the scheduler cluster config of manager.
+type Weights struct {
+	// config is the weights set by scheduler config.
+	config map[string]float64
+
+	// current is the weights in effect.
+	current atomic.Pointer[weightsInEffect]
+}
+
+// NewWeights returns the weights of factors, factors not set use the default weights.
+func NewWeights(weights map[string]float64) (*Weights, error) {
+	merged, err := mergeWeights(DefaultWeights(), weights)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Weights{config: merged}
+	w.current.Store(&weightsInEffect{Source: WeightsSourceConfig, Weights: merged})
+	return w, nil
+}
+
+// Load returns the weights in effect, the weights are shared by the
+// evaluations and must be read-only, they are replaced instead of
+// being modified when the weights are changed.
+func (w *Weights) Load() map[string]float64 {
+	return w.current.Load().Weights
+}
+
+// OnNotify overrides the weights by the scheduler cluster config, the invalid
+// weights are rejected and the weights in effect are kept.
+func (w *Weights) OnNotify(data *config.DynconfigData) {
+	if data.Scheduler == nil || data.Scheduler.SchedulerCluster == nil {
+		return
+	}
+
+	var clusterConfig types.SchedulerClusterConfig
+	if len(data.Scheduler.SchedulerCluster.Config) > 0 {
+		if err := json.Unmarshal(data.Scheduler.SchedulerCluster.Config, &clusterConfig); err != nil {
+			logger.Errorf("unmarshal scheduler cluster config failed: %s", err.Error())
+			return
+		}
+	}
+
+	current := &weightsInEffect{Source: WeightsSourceConfig, Weights: w.config}
+	if len(clusterConfig.EvaluatorWeights) > 0 {
+		weights, err := mergeWeights(w.config, clusterConfig.EvaluatorWeights)
+		if err != nil {
+			logger.Errorf("reject evaluator weights of scheduler cluster: %s", err.Error())
+			return
+		}
+
+		current = &weightsInEffect{Source: WeightsSourceDynconfig, Weights: weights}
+	}
+
+	if reflect.DeepEqual(w.current.Load(), current) {
+		return
+	}
+
+	w.current.Store(current)
+	logger.Infof("evaluator weights are changed by %s: %#v", current.Source, current.Weights)
+}
+
+// ServeHTTP writes the weights in effect.
+func (w *Weights) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(w.current.Load()); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// mergeWeights returns the weights overridden by the overrides and validates them.
+func mergeWeights(weights map[string]float64, overrides map[string]float64) (map[string]float64, error) {
+	merged := make(map[string]float64, len(weights))
+	for name, weight := range weights {
+		merged[name] = weight
+	}
+
+	for name, weight := range overrides {
+		// The keys of config are case-insensitive, so match
+		// the name of factor case-insensitively.
+		factorName, ok := lookupFactorName(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid factor %s", name)
+		}
+
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return nil, fmt.Errorf("invalid weight of factor %s", name)
+		}
+
+		merged[factorName] = weight
+	}
+
+	var total float64
+	for _, weight := range merged {
+		total += weight
+	}
+
+	if total <= 0 {
+		return nil, errors.New("weights of factors are all zero")
+	}
+
+	return merged, nil
+}
+
+// lookupFactorName returns the name of registered factor case-insensitively.
+func lookupFactorName(name string) (string, bool) {
+	for _, f := range factors {
+		if strings.EqualFold(f.name, name) {
+			return f.name, true
+		}
+	}
+
+	return "", false
+}
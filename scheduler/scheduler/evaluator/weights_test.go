@@ -0,0 +1,216 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package evaluator
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	managerv1 "d7y.io/api/pkg/apis/manager/v1"
+
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+func TestWeights_NewWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]float64
+		expect  func(t *testing.T, w *Weights, err error)
+	}{
+		{
+			name:    "default weights",
+			weights: nil,
+			expect: func(t *testing.T, w *Weights, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.EqualValues(w.Load(), DefaultWeights())
+			},
+		},
+		{
+			name:    "override weights",
+			weights: map[string]float64{FeatureFinishedPiece: 0.5, "idcaffinity": 0},
+			expect: func(t *testing.T, w *Weights, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				weights := w.Load()
+				assert.Equal(weights[FeatureFinishedPiece], 0.5)
+				assert.Equal(weights[FeatureIDCAffinity], float64(0))
+				assert.Equal(weights[FeatureFreeUpload], freeUploadWeight)
+				assert.Equal(len(weights), len(FactorNames()))
+			},
+		},
+		{
+			name:    "factor is invalid",
+			weights: map[string]float64{"foo": 0.5},
+			expect: func(t *testing.T, w *Weights, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid factor foo")
+			},
+		},
+		{
+			name:    "weight is negative",
+			weights: map[string]float64{FeatureFinishedPiece: -1},
+			expect: func(t *testing.T, w *Weights, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid weight of factor finishedPiece")
+			},
+		},
+		{
+			name:    "weight is NaN",
+			weights: map[string]float64{FeatureFinishedPiece: math.NaN()},
+			expect: func(t *testing.T, w *Weights, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid weight of factor finishedPiece")
+			},
+		},
+		{
+			name: "weights are all zero",
+			weights: map[string]float64{
+				FeatureFinishedPiece:           0,
+				FeatureParentHostUploadSuccess: 0,
+				FeatureFreeUpload:              0,
+				FeatureHostType:                0,
+				FeatureIDCAffinity:             0,
+				FeatureNetTopologyAffinity:     0,
+				FeatureLocationAffinity:        0,
+				FactorNetworkCost:              0,
+			},
+			expect: func(t *testing.T, w *Weights, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "weights of factors are all zero")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWeights(tc.weights)
+			tc.expect(t, w, err)
+		})
+	}
+}
+
+func TestWeights_OnNotify(t *testing.T) {
+	newDynconfigData := func(clusterConfig string) *config.DynconfigData {
+		return &config.DynconfigData{
+			Scheduler: &managerv1.Scheduler{
+				SchedulerCluster: &managerv1.SchedulerCluster{
+					Config: []byte(clusterConfig),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		data   *config.DynconfigData
+		mock   func(w *Weights)
+		expect func(t *testing.T, w *Weights)
+	}{
+		{
+			name: "scheduler cluster is invalid",
+			data: &config.DynconfigData{Scheduler: &managerv1.Scheduler{}},
+			mock: func(w *Weights) {},
+			expect: func(t *testing.T, w *Weights) {
+				assert := assert.New(t)
+				assert.Equal(w.current.Load().Source, WeightsSourceConfig)
+				assert.Equal(w.Load()[FeatureFinishedPiece], 0.3)
+			},
+		},
+		{
+			name: "override weights by scheduler cluster config",
+			data: newDynconfigData(`{"evaluator_weights":{"freeUpload":0.4}}`),
+			mock: func(w *Weights) {},
+			expect: func(t *testing.T, w *Weights) {
+				assert := assert.New(t)
+				assert.Equal(w.current.Load().Source, WeightsSourceDynconfig)
+				assert.Equal(w.Load()[FeatureFinishedPiece], 0.3)
+				assert.Equal(w.Load()[FeatureFreeUpload], 0.4)
+			},
+		},
+		{
+			name: "reject invalid weights of scheduler cluster config",
+			data: newDynconfigData(`{"evaluator_weights":{"foo":0.4}}`),
+			mock: func(w *Weights) {
+				w.OnNotify(newDynconfigData(`{"evaluator_weights":{"freeUpload":0.4}}`))
+			},
+			expect: func(t *testing.T, w *Weights) {
+				assert := assert.New(t)
+				assert.Equal(w.current.Load().Source, WeightsSourceDynconfig)
+				assert.Equal(w.Load()[FeatureFreeUpload], 0.4)
+			},
+		},
+		{
+			name: "scheduler cluster config is invalid",
+			data: newDynconfigData("foo"),
+			mock: func(w *Weights) {},
+			expect: func(t *testing.T, w *Weights) {
+				assert := assert.New(t)
+				assert.Equal(w.current.Load().Source, WeightsSourceConfig)
+			},
+		},
+		{
+			name: "restore weights when the override is removed",
+			data: newDynconfigData(`{"filter_parent_limit":10}`),
+			mock: func(w *Weights) {
+				w.OnNotify(newDynconfigData(`{"evaluator_weights":{"freeUpload":0.4}}`))
+			},
+			expect: func(t *testing.T, w *Weights) {
+				assert := assert.New(t)
+				assert.Equal(w.current.Load().Source, WeightsSourceConfig)
+				assert.Equal(w.Load()[FeatureFreeUpload], freeUploadWeight)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWeights(map[string]float64{FeatureFinishedPiece: 0.3})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(w)
+			w.OnNotify(tc.data)
+			tc.expect(t, w)
+		})
+	}
+}
+
+func TestWeights_ServeHTTP(t *testing.T) {
+	w, err := NewWeights(map[string]float64{FeatureFinishedPiece: 0.3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/evaluator/weights", nil))
+
+	assert := assert.New(t)
+	assert.Equal(rec.Code, http.StatusOK)
+	assert.Equal(rec.Header().Get("Content-Type"), "application/json")
+
+	var body weightsInEffect
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(body.Source, WeightsSourceConfig)
+	assert.EqualValues(body.Weights, w.Load())
+}
@@ -66,6 +66,20 @@ func (mr *MockSchedulerMockRecorder) NotifyAndFindParent(arg0, arg1, arg2 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyAndFindParent", reflect.TypeOf((*MockScheduler)(nil).NotifyAndFindParent), arg0, arg1, arg2)
 }
 
+// ScheduleCandidateParents mocks base method.
+func (m *MockScheduler) ScheduleCandidateParents(arg0 context.Context, arg1 *resource.Peer, arg2 set.SafeSet[string]) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleCandidateParents", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleCandidateParents indicates an expected call of ScheduleCandidateParents.
+func (mr *MockSchedulerMockRecorder) ScheduleCandidateParents(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleCandidateParents", reflect.TypeOf((*MockScheduler)(nil).ScheduleCandidateParents), arg0, arg1, arg2)
+}
+
 // ScheduleParent mocks base method.
 func (m *MockScheduler) ScheduleParent(arg0 context.Context, arg1 *resource.Peer, arg2 set.SafeSet[string]) {
 	m.ctrl.T.Helper()
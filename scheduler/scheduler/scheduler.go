@@ -20,11 +20,19 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	errordetailsv2 "d7y.io/api/pkg/apis/errordetails/v2"
 	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
 
 	"d7y.io/dragonfly/v2/pkg/container/set"
 	"d7y.io/dragonfly/v2/pkg/types"
@@ -40,6 +48,9 @@ type Scheduler interface {
 	// Find the parent that best matches the evaluation and notify peer.
 	NotifyAndFindParent(context.Context, *resource.Peer, set.SafeSet[string]) ([]*resource.Peer, bool)
 
+	// ScheduleCandidateParents schedules candidate parents to a peer of v2 version.
+	ScheduleCandidateParents(context.Context, *resource.Peer, set.SafeSet[string]) error
+
 	// Find the parent that best matches the evaluation.
 	FindParent(context.Context, *resource.Peer, set.SafeSet[string]) (*resource.Peer, bool)
 }
@@ -55,9 +66,11 @@ type scheduler struct {
 	dynconfig config.DynconfigInterface
 }
 
-func New(cfg *config.SchedulerConfig, dynconfig config.DynconfigInterface, pluginDir string) Scheduler {
+func New(cfg *config.SchedulerConfig, dynconfig config.DynconfigInterface, pluginDir string, options ...evaluator.Option) Scheduler {
+	options = append([]evaluator.Option{evaluator.WithDynconfig(dynconfig)}, options...)
+
 	return &scheduler{
-		evaluator: evaluator.New(cfg.Algorithm, pluginDir),
+		evaluator: evaluator.New(cfg.Algorithm, pluginDir, options...),
 		config:    cfg,
 		dynconfig: dynconfig,
 	}
@@ -65,6 +78,15 @@ func New(cfg *config.SchedulerConfig, dynconfig config.DynconfigInterface, plugi
 
 // ScheduleParent schedule a parent and candidates to a peer.
 func (s *scheduler) ScheduleParent(ctx context.Context, peer *resource.Peer, blocklist set.SafeSet[string]) {
+	// Peer of v2 version is scheduled with candidate parents.
+	if _, ok := peer.LoadAnnouncePeerStream(); ok {
+		if err := s.ScheduleCandidateParents(ctx, peer, blocklist); err != nil {
+			peer.Log.Error(err)
+		}
+
+		return
+	}
+
 	var n int
 	for {
 		select {
@@ -208,6 +230,176 @@ func (s *scheduler) NotifyAndFindParent(ctx context.Context, peer *resource.Peer
 	return candidateParents, true
 }
 
+// ScheduleCandidateParents schedules candidate parents to a peer of v2 version.
+func (s *scheduler) ScheduleCandidateParents(ctx context.Context, peer *resource.Peer, blocklist set.SafeSet[string]) error {
+	var n int
+	for {
+		select {
+		case <-ctx.Done():
+			peer.Log.Infof("context was done")
+			return ctx.Err()
+		default:
+		}
+
+		// If the scheduling exceeds the RetryBackToSourceLimit or peer needs back-to-source,
+		// peer will download the task back-to-source.
+		needBackToSource := peer.NeedBackToSource.Load()
+		peer.Log.Infof("peer needs to back-to-source: %t", needBackToSource)
+		if (n >= s.config.RetryBackToSourceLimit || needBackToSource) &&
+			peer.Task.CanBackToSource() {
+			stream, ok := peer.LoadAnnouncePeerStream()
+			if !ok {
+				peer.Log.Error("load stream failed")
+				return errors.New("load stream failed")
+			}
+			peer.Log.Infof("schedule peer back-to-source in %d times", n)
+
+			reason := fmt.Sprintf("scheduling exceeds the RetryBackToSourceLimit %d times", s.config.RetryBackToSourceLimit)
+			if needBackToSource {
+				reason = "peer needs back-to-source"
+			}
+
+			// Notify peer back-to-source.
+			if err := stream.Send(&schedulerv2.AnnouncePeerResponse{
+				Response: &schedulerv2.AnnouncePeerResponse_NeedBackToSourceResponse{
+					NeedBackToSourceResponse: &schedulerv2.NeedBackToSourceResponse{
+						Reason: reason,
+					},
+				},
+			}); err != nil {
+				peer.Log.Error(err)
+				return err
+			}
+
+			if err := peer.FSM.Event(ctx, resource.PeerEventDownloadBackToSource); err != nil {
+				peer.Log.Errorf("peer fsm event failed: %s", err.Error())
+				return err
+			}
+
+			// If the task state is TaskStateFailed,
+			// peer back-to-source and reset task state to TaskStateRunning.
+			if peer.Task.FSM.Is(resource.TaskStateFailed) {
+				if err := peer.Task.FSM.Event(ctx, resource.TaskEventDownload); err != nil {
+					peer.Task.Log.Errorf("task fsm event failed: %s", err.Error())
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		// Handle peer schedule failed.
+		if n >= s.config.RetryLimit {
+			msg := fmt.Sprintf("peer scheduling exceeds the limit %d times", s.config.RetryLimit)
+			peer.Log.Error(msg)
+
+			stream, ok := peer.LoadAnnouncePeerStream()
+			if !ok {
+				peer.Log.Error("load stream failed")
+				return status.Error(codes.FailedPrecondition, msg)
+			}
+
+			// Notify peer schedule failed.
+			if err := stream.Send(&schedulerv2.AnnouncePeerResponse{
+				Errordetails: &schedulerv2.AnnouncePeerResponse_SchedulePeerFailed{
+					SchedulePeerFailed: &errordetailsv2.SchedulePeerFailed{
+						Description: msg,
+					},
+				},
+			}); err != nil {
+				peer.Log.Error(err)
+			}
+
+			return status.Error(codes.FailedPrecondition, msg)
+		}
+
+		if _, ok := s.notifyCandidateParents(ctx, peer, blocklist); !ok {
+			n++
+			peer.Log.Infof("schedule candidate parents failed in %d times ", n)
+
+			// Sleep to avoid hot looping.
+			select {
+			case <-ctx.Done():
+				peer.Log.Infof("context was done")
+				return ctx.Err()
+			case <-time.After(s.config.RetryInterval):
+			}
+			continue
+		}
+
+		peer.Log.Infof("schedule candidate parents successfully in %d times", n+1)
+		return nil
+	}
+}
+
+// notifyCandidateParents finds candidate parents that best match the evaluation
+// and notify peer of v2 version.
+func (s *scheduler) notifyCandidateParents(ctx context.Context, peer *resource.Peer, blocklist set.SafeSet[string]) ([]*resource.Peer, bool) {
+	// Only PeerStateRunning peers need to be rescheduled,
+	// and other states including the PeerStateBackToSource indicate that
+	// they have been scheduled.
+	if !peer.FSM.Is(resource.PeerStateRunning) {
+		peer.Log.Infof("peer state is %s, can not schedule candidate parents", peer.FSM.Current())
+		return []*resource.Peer{}, false
+	}
+
+	// Delete inedges of vertex.
+	if err := peer.Task.DeletePeerInEdges(peer.ID); err != nil {
+		peer.Log.Errorf("peer deletes inedges failed: %s", err.Error())
+		return []*resource.Peer{}, false
+	}
+
+	// Find the candidate parent that can be scheduled.
+	candidateParents := s.filterCandidateParents(peer, blocklist)
+	if len(candidateParents) == 0 {
+		peer.Log.Info("can not find candidate parents")
+		return []*resource.Peer{}, false
+	}
+
+	// Sort candidate parents by evaluation score.
+	taskTotalPieceCount := peer.Task.TotalPieceCount.Load()
+	sort.Slice(
+		candidateParents,
+		func(i, j int) bool {
+			return s.evaluator.Evaluate(candidateParents[i], peer, taskTotalPieceCount) > s.evaluator.Evaluate(candidateParents[j], peer, taskTotalPieceCount)
+		},
+	)
+
+	// Add edges between candidate parent and peer.
+	var (
+		parents   []*resource.Peer
+		parentIDs []string
+	)
+	for _, candidateParent := range candidateParents {
+		if err := peer.Task.AddPeerEdge(candidateParent, peer); err != nil {
+			peer.Log.Debugf("peer adds edge failed: %s", err.Error())
+			continue
+		}
+		parents = append(parents, candidateParent)
+		parentIDs = append(parentIDs, candidateParent.ID)
+	}
+
+	if len(parents) <= 0 {
+		peer.Log.Info("can not add edges for vertex")
+		return []*resource.Peer{}, false
+	}
+
+	// Send scheduling success message.
+	stream, ok := peer.LoadAnnouncePeerStream()
+	if !ok {
+		peer.Log.Error("load peer stream failed")
+		return []*resource.Peer{}, false
+	}
+
+	if err := stream.Send(constructSuccessNormalTaskResponse(s.dynconfig, parents)); err != nil {
+		peer.Log.Error(err)
+		return []*resource.Peer{}, false
+	}
+
+	peer.Log.Infof("schedule candidate parents is %#v", parentIDs)
+	return parents, true
+}
+
 // FindParent finds parent that best matches the evaluation.
 func (s *scheduler) FindParent(ctx context.Context, peer *resource.Peer, blocklist set.SafeSet[string]) (*resource.Peer, bool) {
 	// Filter the candidate parent that can be scheduled.
@@ -344,3 +536,25 @@ func constructSuccessPeerPacket(dynconfig config.DynconfigInterface, peer *resou
 		Code:           commonv1.Code_Success,
 	}
 }
+
+// Construct normal task response of v2 version.
+func constructSuccessNormalTaskResponse(dynconfig config.DynconfigInterface, candidateParents []*resource.Peer) *schedulerv2.AnnouncePeerResponse {
+	parallelCount := config.DefaultPeerParallelCount
+	if config, err := dynconfig.GetSchedulerClusterClientConfig(); err == nil && config.ParallelCount > 0 {
+		parallelCount = int(config.ParallelCount)
+	}
+
+	var parents []*commonv2.Peer
+	for _, candidateParent := range candidateParents {
+		parents = append(parents, candidateParent.ToV2())
+	}
+
+	return &schedulerv2.AnnouncePeerResponse{
+		Response: &schedulerv2.AnnouncePeerResponse_NormalTaskResponse{
+			NormalTaskResponse: &schedulerv2.NormalTaskResponse{
+				CandidateParents: parents,
+				ParallelCount:    int32(parallelCount),
+			},
+		},
+	}
+}
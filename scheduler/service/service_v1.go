@@ -41,6 +41,7 @@ import (
 	"d7y.io/dragonfly/v2/scheduler/metrics"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/scheduler/evaluator"
 	"d7y.io/dragonfly/v2/scheduler/storage"
 )
 
@@ -84,11 +85,20 @@ func (v *V1) RegisterPeerTask(ctx context.Context, req *schedulerv1.PeerTaskRequ
 	logger.WithPeer(req.PeerHost.Id, req.TaskId, req.PeerId).Infof("register peer task request: %#v %#v %#v",
 		req, req.UrlMeta, req.HostLoad)
 
+	return v.registerPeerTask(ctx, req, commonv1.TaskType_Normal)
+}
+
+// registerPeerTask registers peer with the task type and triggers seed peer download task.
+func (v *V1) registerPeerTask(ctx context.Context, req *schedulerv1.PeerTaskRequest, taskType commonv1.TaskType) (*schedulerv1.RegisterResult, error) {
 	// Store resource.
-	task := v.storeTask(ctx, req, commonv1.TaskType_Normal)
+	task := v.storeTask(ctx, req, taskType)
 	host := v.storeHost(ctx, req.PeerHost)
 	peer := v.storePeer(ctx, req.PeerId, task, host, req.UrlMeta.Tag, req.UrlMeta.Application)
 
+	// Count the request of task and warm up the task by seed peer when it is hot.
+	task.Stats.AddRequest()
+	v.warmUpHotTask(ctx, task)
+
 	// Trigger the first download of the task.
 	if err := v.triggerTask(ctx, req, task, host, peer, v.dynconfig); err != nil {
 		peer.Log.Error(err)
@@ -230,23 +240,7 @@ func (v *V1) ReportPieceResult(stream schedulerv1.Scheduler_ReportPieceResultSer
 		if piece.Success {
 			peer.Log.Infof("receive success piece: %#v %#v", piece, piece.PieceInfo)
 			v.handlePieceSuccess(ctx, peer, piece)
-
-			// Collect peer host traffic metrics.
-			if v.config.Metrics.Enable && v.config.Metrics.EnablePeerHost {
-				metrics.PeerHostTraffic.WithLabelValues(peer.Tag, peer.Application, metrics.PeerHostTrafficDownloadType, peer.Host.ID, peer.Host.IP).Add(float64(piece.PieceInfo.RangeSize))
-				if parent, loaded := v.resource.PeerManager().Load(piece.DstPid); loaded {
-					metrics.PeerHostTraffic.WithLabelValues(peer.Tag, peer.Application, metrics.PeerHostTrafficUploadType, parent.Host.ID, parent.Host.IP).Add(float64(piece.PieceInfo.RangeSize))
-				} else if !resource.IsPieceBackToSource(piece) {
-					peer.Log.Warnf("dst peer %s not found", piece.DstPid)
-				}
-			}
-
-			// Collect traffic metrics.
-			if !resource.IsPieceBackToSource(piece) {
-				metrics.Traffic.WithLabelValues(peer.Tag, peer.Application, metrics.TrafficP2PType).Add(float64(piece.PieceInfo.RangeSize))
-			} else {
-				metrics.Traffic.WithLabelValues(peer.Tag, peer.Application, metrics.TrafficBackToSourceType).Add(float64(piece.PieceInfo.RangeSize))
-			}
+			v.collectTrafficMetrics(peer, piece)
 			continue
 		}
 
@@ -569,12 +563,40 @@ func (v *V1) triggerSeedPeerTask(ctx context.Context, task *resource.Task) {
 	v.handlePeerSuccess(ctx, peer)
 }
 
+// warmUpHotTask triggers seed peer to download the task once, when the count of
+// registered peers in the window reaches the warm up threshold.
+func (v *V1) warmUpHotTask(ctx context.Context, task *resource.Task) {
+	threshold := v.config.Scheduler.HotTask.WarmUpThreshold
+	if !v.config.SeedPeer.Enable || threshold <= 0 {
+		return
+	}
+
+	requests := task.Stats.Requests()
+	if requests < threshold {
+		return
+	}
+
+	// Seed peer has already downloaded the task or is downloading it.
+	if _, loaded := task.LoadSeedPeer(); loaded {
+		return
+	}
+
+	if !task.Stats.MarkWarmedUp() {
+		return
+	}
+
+	task.Log.Infof("task is hot with %d requests in %s, warm up by seed peer", requests, task.Stats.Window())
+	go v.triggerSeedPeerTask(ctx, task)
+}
+
 // storeTask stores a new task or reuses a previous task.
 func (v *V1) storeTask(ctx context.Context, req *schedulerv1.PeerTaskRequest, taskType commonv1.TaskType) *resource.Task {
 	task, loaded := v.resource.TaskManager().Load(req.TaskId)
 	if !loaded {
 		// Create a task for the first time.
-		task = resource.NewTask(req.TaskId, req.Url, taskType, req.UrlMeta, resource.WithBackToSourceLimit(int32(v.config.Scheduler.BackToSourceCount)))
+		task = resource.NewTask(req.TaskId, req.Url, taskType, req.UrlMeta,
+			resource.WithBackToSourceLimit(int32(v.config.Scheduler.BackToSourceCount)),
+			resource.WithStatsWindow(v.config.Scheduler.HotTask.Window))
 		v.resource.TaskManager().Store(task)
 		task.Log.Info("create new task")
 		return task
@@ -804,8 +826,17 @@ func (v *V1) handlePieceSuccess(ctx context.Context, peer *resource.Peer, piece
 	// dst peer's UpdatedAt needs to be updated
 	// to prevent the dst peer from being GC during the download process.
 	if !resource.IsPieceBackToSource(piece) {
+		peer.Task.Stats.AddServedBytes(int64(piece.PieceInfo.RangeSize))
 		if destPeer, loaded := v.resource.PeerManager().Load(piece.DstPid); loaded {
 			destPeer.UpdatedAt.Store(time.Now())
+
+			// Piece downloading is a probe from the host to dst peer's host,
+			// which measures the achieved bandwidth between hosts.
+			if cost := pkgtime.SubNano(int64(piece.EndTime), int64(piece.BeginTime)); cost > 0 && piece.PieceInfo.RangeSize > 0 {
+				peer.Host.Probes.Store(destPeer.Host.ID, resource.Probe{
+					Bandwidth: float64(piece.PieceInfo.RangeSize) / cost.Seconds(),
+				})
+			}
 		}
 	}
 
@@ -816,6 +847,26 @@ func (v *V1) handlePieceSuccess(ctx context.Context, peer *resource.Peer, piece
 	}
 }
 
+// collectTrafficMetrics collects traffic metrics of successful piece.
+func (v *V1) collectTrafficMetrics(peer *resource.Peer, piece *schedulerv1.PieceResult) {
+	// Collect peer host traffic metrics.
+	if v.config.Metrics.Enable && v.config.Metrics.EnablePeerHost {
+		metrics.PeerHostTraffic.WithLabelValues(peer.Tag, peer.Application, metrics.PeerHostTrafficDownloadType, peer.Host.ID, peer.Host.IP).Add(float64(piece.PieceInfo.RangeSize))
+		if parent, loaded := v.resource.PeerManager().Load(piece.DstPid); loaded {
+			metrics.PeerHostTraffic.WithLabelValues(peer.Tag, peer.Application, metrics.PeerHostTrafficUploadType, parent.Host.ID, parent.Host.IP).Add(float64(piece.PieceInfo.RangeSize))
+		} else if !resource.IsPieceBackToSource(piece) {
+			peer.Log.Warnf("dst peer %s not found", piece.DstPid)
+		}
+	}
+
+	// Collect traffic metrics.
+	if !resource.IsPieceBackToSource(piece) {
+		metrics.Traffic.WithLabelValues(peer.Tag, peer.Application, metrics.TrafficP2PType).Add(float64(piece.PieceInfo.RangeSize))
+	} else {
+		metrics.Traffic.WithLabelValues(peer.Tag, peer.Application, metrics.TrafficBackToSourceType).Add(float64(piece.PieceInfo.RangeSize))
+	}
+}
+
 // handlePieceFailure handles failed piece.
 func (v *V1) handlePieceFailure(ctx context.Context, peer *resource.Peer, piece *schedulerv1.PieceResult) {
 	// Failed to download piece back-to-source.
@@ -1054,6 +1105,7 @@ func (v *V1) createRecord(peer *resource.Peer, parents []*resource.Peer, req *sc
 			State:            parent.FSM.Current(),
 			Cost:             parent.Cost.Load().Nanoseconds(),
 			UploadPieceCount: 0,
+			PieceCost:        evaluator.MeanPieceCost(parent.PieceCosts()),
 			CreatedAt:        parent.CreatedAt.Load().UnixNano(),
 			UpdatedAt:        parent.UpdatedAt.Load().UnixNano(),
 			Host: storage.Host{
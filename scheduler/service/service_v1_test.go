@@ -2821,6 +2821,110 @@ func TestService_triggerSeedPeerTask(t *testing.T) {
 	}
 }
 
+func TestService_warmUpHotTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		enable   bool
+		requests int
+		mock     func(task *resource.Task, peer *resource.Peer, seedPeer resource.SeedPeer, done chan struct{}, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder)
+		expect   func(t *testing.T, task *resource.Task, done chan struct{})
+	}{
+		{
+			name:     "warm up hot task",
+			enable:   true,
+			requests: 2,
+			mock: func(task *resource.Task, peer *resource.Peer, seedPeer resource.SeedPeer, done chan struct{}, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder) {
+				gomock.InOrder(
+					mr.SeedPeer().Return(seedPeer).Times(1),
+					mc.TriggerTask(gomock.Any(), gomock.Any()).DoAndReturn(
+						func(context.Context, *resource.Task) (*resource.Peer, *schedulerv1.PeerResult, error) {
+							close(done)
+							return peer, &schedulerv1.PeerResult{}, errors.New("foo")
+						}).Times(1),
+				)
+			},
+			expect: func(t *testing.T, task *resource.Task, done chan struct{}) {
+				assert := assert.New(t)
+				<-done
+				assert.False(task.Stats.MarkWarmedUp())
+			},
+		},
+		{
+			name:     "seed peer is disabled",
+			enable:   false,
+			requests: 2,
+			mock: func(task *resource.Task, peer *resource.Peer, seedPeer resource.SeedPeer, done chan struct{}, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder) {
+			},
+			expect: func(t *testing.T, task *resource.Task, done chan struct{}) {
+				assert := assert.New(t)
+				assert.True(task.Stats.MarkWarmedUp())
+			},
+		},
+		{
+			name:     "requests are below the threshold",
+			enable:   true,
+			requests: 1,
+			mock: func(task *resource.Task, peer *resource.Peer, seedPeer resource.SeedPeer, done chan struct{}, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder) {
+			},
+			expect: func(t *testing.T, task *resource.Task, done chan struct{}) {
+				assert := assert.New(t)
+				assert.True(task.Stats.MarkWarmedUp())
+			},
+		},
+		{
+			name:     "task has seed peer",
+			enable:   true,
+			requests: 2,
+			mock: func(task *resource.Task, peer *resource.Peer, seedPeer resource.SeedPeer, done chan struct{}, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder) {
+				task.StorePeer(resource.NewPeer(mockSeedPeerID, task, resource.NewHost(mockRawSeedHost)))
+			},
+			expect: func(t *testing.T, task *resource.Task, done chan struct{}) {
+				assert := assert.New(t)
+				assert.True(task.Stats.MarkWarmedUp())
+			},
+		},
+		{
+			name:     "task has been warmed up",
+			enable:   true,
+			requests: 2,
+			mock: func(task *resource.Task, peer *resource.Peer, seedPeer resource.SeedPeer, done chan struct{}, mr *resource.MockResourceMockRecorder, mc *resource.MockSeedPeerMockRecorder) {
+				task.Stats.MarkWarmedUp()
+			},
+			expect: func(t *testing.T, task *resource.Task, done chan struct{}) {
+				assert := assert.New(t)
+				assert.False(task.Stats.MarkWarmedUp())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			seedPeer := resource.NewMockSeedPeer(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			task := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, task, mockHost)
+			schedulerConfig := mockSchedulerConfig
+			schedulerConfig.HotTask.WarmUpThreshold = 2
+			svc := NewV1(&config.Config{Scheduler: schedulerConfig, SeedPeer: config.SeedPeerConfig{Enable: tc.enable}}, res, scheduler, dynconfig, storage)
+
+			for i := 0; i < tc.requests; i++ {
+				task.Stats.AddRequest()
+			}
+
+			done := make(chan struct{})
+			tc.mock(task, peer, seedPeer, done, res.EXPECT(), seedPeer.EXPECT())
+			svc.warmUpHotTask(context.Background(), task)
+			tc.expect(t, task, done)
+		})
+	}
+}
+
 func TestService_handleBeginOfPiece(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -2909,7 +3013,7 @@ func TestService_handlePieceSuccess(t *testing.T) {
 		name   string
 		piece  *schedulerv1.PieceResult
 		peer   *resource.Peer
-		mock   func(peer *resource.Peer)
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder)
 		expect func(t *testing.T, peer *resource.Peer)
 	}{
 		{
@@ -2923,7 +3027,7 @@ func TestService_handlePieceSuccess(t *testing.T) {
 				EndTime:   uint64(now.Add(1 * time.Millisecond).UnixNano()),
 			},
 			peer: resource.NewPeer(mockPeerID, mockTask, mockHost),
-			mock: func(peer *resource.Peer) {
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
 				peer.FSM.SetState(resource.PeerStateRunning)
 			},
 			expect: func(t *testing.T, peer *resource.Peer) {
@@ -2944,7 +3048,7 @@ func TestService_handlePieceSuccess(t *testing.T) {
 				EndTime:   uint64(now.Add(1 * time.Millisecond).UnixNano()),
 			},
 			peer: resource.NewPeer(mockPeerID, mockTask, mockHost),
-			mock: func(peer *resource.Peer) {
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
 				peer.FSM.SetState(resource.PeerStateBackToSource)
 			},
 			expect: func(t *testing.T, peer *resource.Peer) {
@@ -2960,6 +3064,35 @@ func TestService_handlePieceSuccess(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "piece success from parent",
+			piece: &schedulerv1.PieceResult{
+				DstPid: mockSeedPeerID,
+				PieceInfo: &commonv1.PieceInfo{
+					PieceNum:  0,
+					RangeSize: 1024,
+					PieceMd5:  "ac32345ef819f03710e2105c81106fdd",
+				},
+				BeginTime: uint64(now.UnixNano()),
+				EndTime:   uint64(now.Add(1 * time.Millisecond).UnixNano()),
+			},
+			peer: resource.NewPeer(mockPeerID, mockTask, mockHost),
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockSeedPeerID)).Return(resource.NewPeer(mockSeedPeerID, mockTask, resource.NewHost(mockRawSeedHost)), true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer) {
+				assert := assert.New(t)
+				assert.Equal(peer.Pieces.Len(), uint(1))
+				assert.Equal(peer.FinishedPieces.Count(), uint(1))
+				probe, ok := peer.Host.Probes.Load(mockRawSeedHost.Id)
+				assert.True(ok)
+				assert.InDelta(probe.Bandwidth, float64(1024*1000), 1e-6)
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -2970,9 +3103,10 @@ func TestService_handlePieceSuccess(t *testing.T) {
 			res := resource.NewMockResource(ctl)
 			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
 			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
 			svc := NewV1(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{EnablePeerHost: true}}, res, scheduler, dynconfig, storage)
 
-			tc.mock(tc.peer)
+			tc.mock(tc.peer, peerManager, res.EXPECT(), peerManager.EXPECT())
 			svc.handlePieceSuccess(context.Background(), tc.peer, tc.piece)
 			tc.expect(t, tc.peer)
 		})
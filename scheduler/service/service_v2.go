@@ -18,17 +18,35 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
 	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	errordetailsv2 "d7y.io/api/pkg/apis/errordetails/v2"
+	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
 	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
 
+	"d7y.io/dragonfly/v2/internal/dferrors"
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/pkg/digest"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	nethttp "d7y.io/dragonfly/v2/pkg/net/http"
+	"d7y.io/dragonfly/v2/pkg/rpc/common"
+	"d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/metrics"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/scheduler"
 	"d7y.io/dragonfly/v2/scheduler/storage"
 )
 
-// TODO Implement v2 version of the service functions.
 // V2 is the interface for v2 version of the service.
 type V2 struct {
 	// Resource interface.
@@ -45,6 +63,10 @@ type V2 struct {
 
 	// Storage interface.
 	storage storage.Storage
+
+	// v1 is the v1 version of the service, requests of v2 version
+	// are converted to v1 version and handled by it.
+	v1 *V1
 }
 
 // New v2 version of service instance.
@@ -61,41 +83,635 @@ func NewV2(
 		config:    cfg,
 		dynconfig: dynconfig,
 		storage:   storage,
+		v1:        NewV1(cfg, resource, scheduler, dynconfig, storage),
 	}
 }
 
 // AnnouncePeer announces peer to scheduler.
 func (v *V2) AnnouncePeer(stream schedulerv2.Scheduler_AnnouncePeerServer) error {
-	return nil
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	// The stream of peer is valid only in the current AnnouncePeer call,
+	// peers registered by the stream release it when the call returns.
+	var peers []*resource.Peer
+	defer func() {
+		for _, peer := range peers {
+			peer.DeleteAnnouncePeerStream(stream)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("context was done")
+			return ctx.Err()
+		default:
+		}
+
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			logger.Errorf("receive failed: %s", err.Error())
+			return err
+		}
+
+		log := logger.WithTaskAndPeerID(req.TaskId, req.PeerId)
+
+		// If the request carries error details, the download of peer or piece failed.
+		if req.GetErrordetails() != nil {
+			switch errordetails := req.GetErrordetails().(type) {
+			case *schedulerv2.AnnouncePeerRequest_DownloadPeerBackToSourceFailed:
+				log.Infof("receive DownloadPeerBackToSourceFailed: %#v", errordetails.DownloadPeerBackToSourceFailed)
+				if err := v.handleDownloadPeerBackToSourceFailed(ctx, req.TaskId, req.PeerId); err != nil {
+					log.Error(err)
+					return err
+				}
+			case *schedulerv2.AnnouncePeerRequest_DownloadPieceBackToSourceFailed:
+				log.Infof("receive DownloadPieceBackToSourceFailed: %#v", errordetails.DownloadPieceBackToSourceFailed)
+				if err := v.handleDownloadPieceBackToSourceFailed(ctx, req.PeerId, errordetails.DownloadPieceBackToSourceFailed); err != nil {
+					log.Error(err)
+					return err
+				}
+			case *schedulerv2.AnnouncePeerRequest_SyncPiecesFailed:
+				log.Infof("receive SyncPiecesFailed: %#v", errordetails.SyncPiecesFailed)
+				if err := v.handleDownloadPieceFailed(ctx, req.PeerId, errordetails.SyncPiecesFailed.ParentId); err != nil {
+					log.Error(err)
+					return err
+				}
+			case *schedulerv2.AnnouncePeerRequest_DownloadPieceFailed:
+				log.Infof("receive DownloadPieceFailed: %#v", errordetails.DownloadPieceFailed)
+				if err := v.handleDownloadPieceFailed(ctx, req.PeerId, errordetails.DownloadPieceFailed.ParentId); err != nil {
+					log.Error(err)
+					return err
+				}
+			default:
+				msg := fmt.Sprintf("receive unknow errordetails: %#v", errordetails)
+				log.Error(msg)
+				return status.Error(codes.FailedPrecondition, msg)
+			}
+
+			continue
+		}
+
+		switch announcePeerRequest := req.GetRequest().(type) {
+		case *schedulerv2.AnnouncePeerRequest_RegisterPeerRequest:
+			log.Infof("receive RegisterPeerRequest: %#v", announcePeerRequest.RegisterPeerRequest.Metadata)
+			peer, err := v.handleRegisterPeerRequest(ctx, stream, req.TaskId, req.PeerId, announcePeerRequest.RegisterPeerRequest)
+			if err != nil {
+				log.Error(err)
+				return err
+			}
+
+			peers = append(peers, peer)
+		case *schedulerv2.AnnouncePeerRequest_DownloadPeerStartedRequest:
+			log.Info("receive DownloadPeerStartedRequest")
+			if err := v.handleDownloadPeerStartedRequest(ctx, req.PeerId); err != nil {
+				log.Error(err)
+				return err
+			}
+		case *schedulerv2.AnnouncePeerRequest_DownloadPeerBackToSourceStartedRequest:
+			log.Infof("receive DownloadPeerBackToSourceStartedRequest: %s", announcePeerRequest.DownloadPeerBackToSourceStartedRequest.Reason)
+			if err := v.handleDownloadPeerBackToSourceStartedRequest(ctx, req.PeerId); err != nil {
+				log.Error(err)
+				return err
+			}
+		case *schedulerv2.AnnouncePeerRequest_DownloadPeerFinishedRequest:
+			log.Infof("receive DownloadPeerFinishedRequest: %#v", announcePeerRequest.DownloadPeerFinishedRequest)
+			finishedRequest := announcePeerRequest.DownloadPeerFinishedRequest
+			if err := v.handleDownloadPeerFinishedRequest(ctx, req.TaskId, req.PeerId, finishedRequest.ContentLength, finishedRequest.PieceCount); err != nil {
+				log.Error(err)
+				return err
+			}
+		case *schedulerv2.AnnouncePeerRequest_DownloadPeerBackToSourceFinishedRequest:
+			log.Infof("receive DownloadPeerBackToSourceFinishedRequest: %#v", announcePeerRequest.DownloadPeerBackToSourceFinishedRequest)
+			finishedRequest := announcePeerRequest.DownloadPeerBackToSourceFinishedRequest
+			if err := v.handleDownloadPeerFinishedRequest(ctx, req.TaskId, req.PeerId, finishedRequest.ContentLength, finishedRequest.PieceCount); err != nil {
+				log.Error(err)
+				return err
+			}
+		case *schedulerv2.AnnouncePeerRequest_DownloadPieceFinishedRequest:
+			log.Infof("receive DownloadPieceFinishedRequest: %#v", announcePeerRequest.DownloadPieceFinishedRequest.Piece)
+			if err := v.handleDownloadPieceFinishedRequest(ctx, req.TaskId, req.PeerId, announcePeerRequest.DownloadPieceFinishedRequest.Piece); err != nil {
+				log.Error(err)
+				return err
+			}
+		case *schedulerv2.AnnouncePeerRequest_DownloadPieceBackToSourceFinishedRequest:
+			log.Infof("receive DownloadPieceBackToSourceFinishedRequest: %#v", announcePeerRequest.DownloadPieceBackToSourceFinishedRequest.Piece)
+			if err := v.handleDownloadPieceBackToSourceFinishedRequest(ctx, req.TaskId, req.PeerId, announcePeerRequest.DownloadPieceBackToSourceFinishedRequest.Piece); err != nil {
+				log.Error(err)
+				return err
+			}
+		default:
+			msg := fmt.Sprintf("receive unknow request: %#v", announcePeerRequest)
+			log.Error(msg)
+			return status.Error(codes.FailedPrecondition, msg)
+		}
+	}
 }
 
-// Checks information of peer.
+// StatPeer checks information of peer.
 func (v *V2) StatPeer(ctx context.Context, req *schedulerv2.StatPeerRequest) (*commonv2.Peer, error) {
-	return nil, nil
+	logger.WithTaskAndPeerID(req.TaskId, req.PeerId).Infof("stat peer request: %#v", req)
+
+	peer, loaded := v.resource.PeerManager().Load(req.PeerId)
+	if !loaded {
+		msg := fmt.Sprintf("peer %s not found", req.PeerId)
+		logger.Error(msg)
+		return nil, status.Error(codes.NotFound, msg)
+	}
+
+	return peer.ToV2(), nil
 }
 
 // LeavePeer releases peer in scheduler.
 func (v *V2) LeavePeer(ctx context.Context, req *schedulerv2.LeavePeerRequest) error {
+	if err := v.v1.LeaveTask(ctx, &schedulerv1.PeerTarget{
+		TaskId: req.TaskId,
+		PeerId: req.PeerId,
+	}); err != nil {
+		return newStatusError(err)
+	}
+
 	return nil
 }
 
-// TODO exchange peer api definition.
 // ExchangePeer exchanges peer information.
+// ExchangePeerResponse carries no fields in the current api definition,
+// so only the peer of request is validated.
 func (v *V2) ExchangePeer(ctx context.Context, req *schedulerv2.ExchangePeerRequest) (*schedulerv2.ExchangePeerResponse, error) {
-	return nil, nil
+	logger.WithTaskAndPeerID(req.TaskId, req.PeerId).Infof("exchange peer request: %#v", req)
+
+	peer, loaded := v.resource.PeerManager().Load(req.PeerId)
+	if !loaded {
+		msg := fmt.Sprintf("peer %s not found", req.PeerId)
+		logger.Error(msg)
+		return nil, status.Error(codes.NotFound, msg)
+	}
+
+	if peer.Task.ID != req.TaskId {
+		msg := fmt.Sprintf("peer %s does not belong to task %s", req.PeerId, req.TaskId)
+		logger.Error(msg)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	return &schedulerv2.ExchangePeerResponse{}, nil
 }
 
-// Checks information of task.
+// StatTask checks information of task.
 func (v *V2) StatTask(ctx context.Context, req *schedulerv2.StatTaskRequest) (*commonv2.Task, error) {
-	return nil, nil
+	logger.WithTaskID(req.Id).Infof("stat task request: %#v", req)
+
+	task, loaded := v.resource.TaskManager().Load(req.Id)
+	if !loaded {
+		msg := fmt.Sprintf("task %s not found", req.Id)
+		logger.Info(msg)
+		return nil, status.Error(codes.NotFound, msg)
+	}
+
+	return task.ToV2(), nil
 }
 
 // AnnounceHost announces host to scheduler.
 func (v *V2) AnnounceHost(ctx context.Context, req *schedulerv2.AnnounceHostRequest) error {
+	if err := v.v1.AnnounceHost(ctx, newAnnounceHostRequestV1(req)); err != nil {
+		return newStatusError(err)
+	}
+
 	return nil
 }
 
 // LeaveHost releases host in scheduler.
 func (v *V2) LeaveHost(ctx context.Context, req *schedulerv2.LeaveHostRequest) error {
+	logger.WithHostID(req.Id).Infof("leave host request: %#v", req)
+
+	host, loaded := v.resource.HostManager().Load(req.Id)
+	if !loaded {
+		msg := fmt.Sprintf("host %s not found", req.Id)
+		logger.Error(msg)
+		return status.Error(codes.NotFound, msg)
+	}
+
+	host.LeavePeers()
+	return nil
+}
+
+// handleRegisterPeerRequest handles RegisterPeerRequest of AnnouncePeerRequest.
+func (v *V2) handleRegisterPeerRequest(ctx context.Context, stream schedulerv2.Scheduler_AnnouncePeerServer, taskID, peerID string, req *schedulerv2.RegisterPeerRequest) (*resource.Peer, error) {
+	if req.Metadata == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid metadata")
+	}
+
+	// The host of peer must be announced by AnnounceHost before registering.
+	host, err := v.loadHost(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := resource.DefaultTag
+	if req.Metadata.Tag != "" {
+		tag = req.Metadata.Tag
+	}
+
+	application := resource.DefaultApplication
+	if req.Metadata.Application != "" {
+		application = req.Metadata.Application
+	}
+	metrics.RegisterPeerTaskCount.WithLabelValues(tag, application).Inc()
+
+	result, err := v.v1.registerPeerTask(ctx, newPeerTaskRequestV1(taskID, peerID, host, req.Metadata), commonv1.TaskType(req.Metadata.Type))
+	if err != nil {
+		metrics.RegisterPeerTaskFailureCount.WithLabelValues(tag, application).Inc()
+		return nil, newStatusError(err)
+	}
+	metrics.PeerTaskCounter.WithLabelValues(tag, application, result.SizeScope.String()).Inc()
+
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return nil, status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+	peer.StoreAnnouncePeerStream(stream)
+
+	switch result.SizeScope {
+	case commonv1.SizeScope_EMPTY, commonv1.SizeScope_TINY:
+		if err := stream.Send(&schedulerv2.AnnouncePeerResponse{
+			Response: &schedulerv2.AnnouncePeerResponse_TinyTaskResponse{
+				TinyTaskResponse: &schedulerv2.TinyTaskResponse{
+					Data: result.GetPieceContent(),
+				},
+			},
+		}); err != nil {
+			return nil, err
+		}
+	case commonv1.SizeScope_SMALL:
+		singlePiece := result.GetSinglePiece()
+		if err := stream.Send(&schedulerv2.AnnouncePeerResponse{
+			Response: &schedulerv2.AnnouncePeerResponse_SmallTaskResponse{
+				SmallTaskResponse: &schedulerv2.SmallTaskResponse{
+					Piece: resource.PieceResultToV2(&schedulerv1.PieceResult{
+						DstPid:    singlePiece.GetDstPid(),
+						PieceInfo: singlePiece.GetPieceInfo(),
+					}),
+				},
+			},
+		}); err != nil {
+			return nil, err
+		}
+	default:
+		// Candidate parents of normal task are scheduled
+		// after the peer starts downloading.
+	}
+
+	return peer, nil
+}
+
+// handleDownloadPeerStartedRequest handles DownloadPeerStartedRequest of AnnouncePeerRequest.
+func (v *V2) handleDownloadPeerStartedRequest(ctx context.Context, peerID string) error {
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+
+	v.v1.handleBeginOfPiece(ctx, peer)
+	return nil
+}
+
+// handleDownloadPeerBackToSourceStartedRequest handles DownloadPeerBackToSourceStartedRequest of AnnouncePeerRequest.
+func (v *V2) handleDownloadPeerBackToSourceStartedRequest(ctx context.Context, peerID string) error {
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+
+	// The peer may have been switched to back-to-source by scheduler.
+	if peer.FSM.Is(resource.PeerStateBackToSource) {
+		return nil
+	}
+
+	if err := peer.FSM.Event(ctx, resource.PeerEventDownloadBackToSource); err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	// If the task state is TaskStateFailed,
+	// peer back-to-source and reset task state to TaskStateRunning.
+	if peer.Task.FSM.Is(resource.TaskStateFailed) {
+		if err := peer.Task.FSM.Event(ctx, resource.TaskEventDownload); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// handleDownloadPeerFinishedRequest handles DownloadPeerFinishedRequest and
+// DownloadPeerBackToSourceFinishedRequest of AnnouncePeerRequest.
+func (v *V2) handleDownloadPeerFinishedRequest(ctx context.Context, taskID, peerID string, contentLength, pieceCount int64) error {
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+
+	if err := v.v1.ReportPeerResult(ctx, &schedulerv1.PeerResult{
+		TaskId:          taskID,
+		PeerId:          peerID,
+		Success:         true,
+		Code:            commonv1.Code_Success,
+		ContentLength:   contentLength,
+		TotalPieceCount: int32(pieceCount),
+		Cost:            uint32(time.Since(peer.CreatedAt.Load()).Milliseconds()),
+	}); err != nil {
+		return newStatusError(err)
+	}
+
+	return nil
+}
+
+// handleDownloadPieceFinishedRequest handles DownloadPieceFinishedRequest of AnnouncePeerRequest.
+func (v *V2) handleDownloadPieceFinishedRequest(ctx context.Context, taskID, peerID string, piece *commonv2.Piece) error {
+	if piece == nil {
+		return status.Error(codes.InvalidArgument, "invalid piece")
+	}
+
+	return v.handlePieceSuccess(ctx, peerID, newPieceResultV1(taskID, peerID, piece))
+}
+
+// handleDownloadPieceBackToSourceFinishedRequest handles DownloadPieceBackToSourceFinishedRequest of AnnouncePeerRequest.
+func (v *V2) handleDownloadPieceBackToSourceFinishedRequest(ctx context.Context, taskID, peerID string, piece *commonv2.Piece) error {
+	if piece == nil {
+		return status.Error(codes.InvalidArgument, "invalid piece")
+	}
+
+	// Piece downloaded back-to-source has no parent.
+	pieceResult := newPieceResultV1(taskID, peerID, piece)
+	pieceResult.DstPid = ""
+	return v.handlePieceSuccess(ctx, peerID, pieceResult)
+}
+
+// handlePieceSuccess handles successful piece of v1 version.
+func (v *V2) handlePieceSuccess(ctx context.Context, peerID string, pieceResult *schedulerv1.PieceResult) error {
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+
+	v.v1.handlePieceSuccess(ctx, peer, pieceResult)
+	v.v1.collectTrafficMetrics(peer, pieceResult)
+	return nil
+}
+
+// handleDownloadPeerBackToSourceFailed handles DownloadPeerBackToSourceFailed of AnnouncePeerRequest.
+func (v *V2) handleDownloadPeerBackToSourceFailed(ctx context.Context, taskID, peerID string) error {
+	if err := v.v1.ReportPeerResult(ctx, &schedulerv1.PeerResult{
+		TaskId:  taskID,
+		PeerId:  peerID,
+		Success: false,
+		Code:    commonv1.Code_ClientBackSourceError,
+	}); err != nil {
+		return newStatusError(err)
+	}
+
+	return nil
+}
+
+// handleDownloadPieceBackToSourceFailed handles DownloadPieceBackToSourceFailed of AnnouncePeerRequest.
+// As in v1 version, failed pieces of back-to-source are retried by the peer itself and
+// the scheduler does not reschedule, the failure of peer is recorded when the peer
+// reports DownloadPeerBackToSourceFailed at last.
+func (v *V2) handleDownloadPieceBackToSourceFailed(ctx context.Context, peerID string, failed *errordetailsv2.DownloadPieceBackToSourceFailed) error {
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+
+	peer.Log.Warnf("download piece %d back-to-source failed, temporary: %t, description: %s",
+		failed.GetPieceNumber(), failed.GetTemporary(), failed.GetDescription())
+	return nil
+}
+
+// handleDownloadPieceFailed handles DownloadPieceFailed and SyncPiecesFailed of AnnouncePeerRequest.
+func (v *V2) handleDownloadPieceFailed(ctx context.Context, peerID, parentID string) error {
+	peer, loaded := v.resource.PeerManager().Load(peerID)
+	if !loaded {
+		return status.Errorf(codes.NotFound, "peer %s not found", peerID)
+	}
+
+	// The failed parent is blocked and candidate parents are rescheduled,
+	// peer that can not be rescheduled keeps the stream.
+	v.v1.handlePieceFailure(ctx, peer, &schedulerv1.PieceResult{
+		SrcPid: peerID,
+		DstPid: parentID,
+		Code:   commonv1.Code_ClientPieceDownloadFail,
+	})
 	return nil
 }
+
+// loadHost loads the announced host of the peer by the host id in grpc metadata,
+// RegisterPeerRequest of v2 version does not carry the host id yet.
+func (v *V2) loadHost(ctx context.Context) (*resource.Host, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid grpc metadata")
+	}
+
+	hostIDs := md.Get(common.HostIDMetadataKey)
+	if len(hostIDs) == 0 || hostIDs[0] == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid grpc metadata %s", common.HostIDMetadataKey)
+	}
+
+	host, loaded := v.resource.HostManager().Load(hostIDs[0])
+	if !loaded {
+		return nil, status.Errorf(codes.NotFound, "host %s not found", hostIDs[0])
+	}
+
+	return host, nil
+}
+
+// newStatusError converts error of v1 version to grpc status error.
+func newStatusError(err error) error {
+	dferr, ok := err.(*dferrors.DfError)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	switch dferr.Code {
+	case commonv1.Code_SchedPeerNotFound:
+		return status.Error(codes.NotFound, dferr.Message)
+	case commonv1.Code_SchedForbidden, commonv1.Code_SchedTaskStatusError:
+		return status.Error(codes.FailedPrecondition, dferr.Message)
+	default:
+		return status.Error(codes.Internal, dferr.Message)
+	}
+}
+
+// newPeerTaskRequestV1 converts RegisterPeerRequest of v2 version to PeerTaskRequest of v1 version.
+func newPeerTaskRequestV1(taskID, peerID string, host *resource.Host, metadata *commonv2.Metadata) *schedulerv1.PeerTaskRequest {
+	peerHost := &schedulerv1.PeerHost{
+		Id:       host.ID,
+		Ip:       host.IP,
+		RpcPort:  host.Port,
+		DownPort: host.DownloadPort,
+		HostName: host.Hostname,
+	}
+
+	if host.Network != nil {
+		peerHost.SecurityDomain = host.Network.SecurityDomain
+		peerHost.Location = host.Network.Location
+		peerHost.Idc = host.Network.Idc
+		peerHost.NetTopology = host.Network.NetTopology
+	}
+
+	return &schedulerv1.PeerTaskRequest{
+		Url:      metadata.Url,
+		UrlMeta:  newURLMetaV1(metadata),
+		PeerId:   peerID,
+		PeerHost: peerHost,
+		TaskId:   taskID,
+	}
+}
+
+// newURLMetaV1 converts metadata of v2 version to url meta of v1 version.
+func newURLMetaV1(metadata *commonv2.Metadata) *commonv1.UrlMeta {
+	urlMeta := &commonv1.UrlMeta{
+		Digest:      metadata.Digest,
+		Tag:         metadata.Tag,
+		Filter:      strings.Join(metadata.Filters, idgen.FilterSeparator),
+		Header:      metadata.Header,
+		Application: metadata.Application,
+		Priority:    commonv1.Priority(metadata.Priority),
+	}
+
+	if metadata.Range != nil {
+		urlMeta.Range = nethttp.Range{
+			StartIndex: metadata.Range.Begin,
+			EndIndex:   metadata.Range.End,
+		}.String()
+	}
+
+	return urlMeta
+}
+
+// newAnnounceHostRequestV1 converts AnnounceHostRequest of v2 version to v1 version.
+func newAnnounceHostRequestV1(req *schedulerv2.AnnounceHostRequest) *schedulerv1.AnnounceHostRequest {
+	hostType := types.HostType(req.Type)
+	rawHost := &schedulerv1.AnnounceHostRequest{
+		Id:              req.Id,
+		Type:            hostType.Name(),
+		Hostname:        req.Hostname,
+		Ip:              req.Ip,
+		Port:            req.Port,
+		DownloadPort:    req.DownloadPort,
+		Os:              req.Os,
+		Platform:        req.Platform,
+		PlatformFamily:  req.PlatformFamily,
+		PlatformVersion: req.PlatformVersion,
+		KernelVersion:   req.KernelVersion,
+	}
+
+	if req.Cpu != nil {
+		rawHost.Cpu = &schedulerv1.CPU{
+			LogicalCount:   req.Cpu.LogicalCount,
+			PhysicalCount:  req.Cpu.PhysicalCount,
+			Percent:        req.Cpu.Percent,
+			ProcessPercent: req.Cpu.ProcessPercent,
+		}
+
+		if req.Cpu.Times != nil {
+			rawHost.Cpu.Times = &schedulerv1.CPUTimes{
+				User:      req.Cpu.Times.User,
+				System:    req.Cpu.Times.System,
+				Idle:      req.Cpu.Times.Idle,
+				Nice:      req.Cpu.Times.Nice,
+				Iowait:    req.Cpu.Times.Iowait,
+				Irq:       req.Cpu.Times.Irq,
+				Softirq:   req.Cpu.Times.Softirq,
+				Steal:     req.Cpu.Times.Steal,
+				Guest:     req.Cpu.Times.Guest,
+				GuestNice: req.Cpu.Times.GuestNice,
+			}
+		}
+	}
+
+	if req.Memory != nil {
+		rawHost.Memory = &schedulerv1.Memory{
+			Total:              req.Memory.Total,
+			Available:          req.Memory.Available,
+			Used:               req.Memory.Used,
+			UsedPercent:        req.Memory.UsedPercent,
+			ProcessUsedPercent: req.Memory.ProcessUsedPercent,
+			Free:               req.Memory.Free,
+		}
+	}
+
+	if req.Network != nil {
+		rawHost.Network = &schedulerv1.Network{
+			TcpConnectionCount:       req.Network.TcpConnectionCount,
+			UploadTcpConnectionCount: req.Network.UploadTcpConnectionCount,
+			SecurityDomain:           req.Network.SecurityDomain,
+			Location:                 req.Network.Location,
+			Idc:                      req.Network.Idc,
+			NetTopology:              req.Network.NetTopology,
+		}
+	}
+
+	if req.Disk != nil {
+		rawHost.Disk = &schedulerv1.Disk{
+			Total:             req.Disk.Total,
+			Free:              req.Disk.Free,
+			Used:              req.Disk.Used,
+			UsedPercent:       req.Disk.UsedPercent,
+			InodesTotal:       req.Disk.InodesTotal,
+			InodesUsed:        req.Disk.InodesUsed,
+			InodesFree:        req.Disk.InodesFree,
+			InodesUsedPercent: req.Disk.InodesUsedPercent,
+		}
+	}
+
+	if req.Build != nil {
+		rawHost.Build = &schedulerv1.Build{
+			GitVersion: req.Build.GitVersion,
+			GitCommit:  req.Build.GitCommit,
+			GoVersion:  req.Build.GoVersion,
+			Platform:   req.Build.Platform,
+		}
+	}
+
+	return rawHost
+}
+
+// newPieceResultV1 converts piece of v2 version to piece result of v1 version.
+func newPieceResultV1(taskID, peerID string, piece *commonv2.Piece) *schedulerv1.PieceResult {
+	pieceInfo := &commonv1.PieceInfo{
+		PieceNum:    int32(piece.Number),
+		RangeStart:  piece.Offset,
+		RangeSize:   uint32(piece.Size),
+		PieceOffset: piece.Offset,
+	}
+
+	if d, err := digest.Parse(piece.Digest); err == nil && d.Algorithm == digest.AlgorithmMD5 {
+		pieceInfo.PieceMd5 = d.Encoded
+	}
+
+	var cost time.Duration
+	if piece.Cost != nil {
+		cost = piece.Cost.AsDuration()
+		pieceInfo.DownloadCost = uint64(cost.Milliseconds())
+	}
+
+	var beginTime uint64
+	if piece.CreatedAt != nil {
+		beginTime = uint64(piece.CreatedAt.AsTime().UnixNano())
+	}
+
+	return &schedulerv1.PieceResult{
+		TaskId:    taskID,
+		SrcPid:    peerID,
+		DstPid:    piece.ParentId,
+		PieceInfo: pieceInfo,
+		BeginTime: beginTime,
+		EndTime:   beginTime + uint64(cost.Nanoseconds()),
+		Success:   true,
+	}
+}
@@ -17,20 +17,65 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"io"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	commonv1 "d7y.io/api/pkg/apis/common/v1"
+	commonv2 "d7y.io/api/pkg/apis/common/v2"
+	errordetailsv2 "d7y.io/api/pkg/apis/errordetails/v2"
+	schedulerv1 "d7y.io/api/pkg/apis/scheduler/v1"
+	schedulerv2 "d7y.io/api/pkg/apis/scheduler/v2"
+	schedulerv2mocks "d7y.io/api/pkg/apis/scheduler/v2/mocks"
+
+	"d7y.io/dragonfly/v2/internal/dferrors"
+	"d7y.io/dragonfly/v2/manager/types"
+	"d7y.io/dragonfly/v2/pkg/container/set"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/common"
+	pkgtypes "d7y.io/dragonfly/v2/pkg/types"
 	"d7y.io/dragonfly/v2/scheduler/config"
 	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
 	"d7y.io/dragonfly/v2/scheduler/resource"
 	"d7y.io/dragonfly/v2/scheduler/scheduler/mocks"
+	"d7y.io/dragonfly/v2/scheduler/storage"
 	storagemocks "d7y.io/dragonfly/v2/scheduler/storage/mocks"
 )
 
-func TestService_NewV2(t *testing.T) {
+var (
+	mockMetadata = &commonv2.Metadata{
+		Url:         mockTaskURL,
+		Type:        commonv2.TaskType_DFDAEMON,
+		Digest:      "digest",
+		Tag:         "tag",
+		Application: "application",
+		Priority:    commonv2.Priority_LEVEL0,
+		Filters:     []string{"foo", "bar"},
+		Header: map[string]string{
+			"content-length": "100",
+		},
+		Range: &commonv2.Range{
+			Begin: 0,
+			End:   100,
+		},
+	}
+
+	mockHostIDContext = metadata.NewIncomingContext(context.Background(), metadata.Pairs(common.HostIDMetadataKey, mockRawHost.Id))
+)
+
+func TestServiceV2_NewV2(t *testing.T) {
 	tests := []struct {
 		name   string
 		expect func(t *testing.T, s any)
@@ -56,3 +101,1634 @@ func TestService_NewV2(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceV2_AnnouncePeer(t *testing.T) {
+	tests := []struct {
+		name string
+		mock func(
+			peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+			hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+			ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+			mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+		)
+		expect func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error)
+	}{
+		{
+			name: "context was done",
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				ma.Context().Return(ctx).Times(1)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error) {
+				assert := assert.New(t)
+				assert.ErrorIs(err, context.Canceled)
+			},
+		},
+		{
+			name: "receive io.EOF",
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				gomock.InOrder(
+					ma.Context().Return(mockHostIDContext).Times(1),
+					ma.Recv().Return(nil, io.EOF).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "receive error",
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				gomock.InOrder(
+					ma.Context().Return(mockHostIDContext).Times(1),
+					ma.Recv().Return(nil, errors.New("foo")).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+		{
+			name: "receive unknow request",
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				gomock.InOrder(
+					ma.Context().Return(mockHostIDContext).Times(1),
+					ma.Recv().Return(&schedulerv2.AnnouncePeerRequest{
+						TaskId: mockTaskID,
+						PeerId: mockPeerID,
+					}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.FailedPrecondition)
+			},
+		},
+		{
+			name: "receive DownloadPieceBackToSourceFailed and peer not found",
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				gomock.InOrder(
+					ma.Context().Return(mockHostIDContext).Times(1),
+					ma.Recv().Return(&schedulerv2.AnnouncePeerRequest{
+						TaskId: mockTaskID,
+						PeerId: mockPeerID,
+						Errordetails: &schedulerv2.AnnouncePeerRequest_DownloadPieceBackToSourceFailed{
+							DownloadPieceBackToSourceFailed: &errordetailsv2.DownloadPieceBackToSourceFailed{},
+						},
+					}, nil).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "register peer and release stream of peer when the call returns",
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStateRunning)
+				seedPeer.FSM.SetState(resource.PeerStateRunning)
+				peer.Task.StorePeer(seedPeer)
+				gomock.InOrder(
+					ma.Context().Return(mockHostIDContext).Times(1),
+					ma.Recv().Return(&schedulerv2.AnnouncePeerRequest{
+						TaskId: mockTaskID,
+						PeerId: mockPeerID,
+						Request: &schedulerv2.AnnouncePeerRequest_RegisterPeerRequest{
+							RegisterPeerRequest: &schedulerv2.RegisterPeerRequest{
+								Metadata: mockMetadata,
+							},
+						},
+					}, nil).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					ma.Recv().DoAndReturn(func() (*schedulerv2.AnnouncePeerRequest, error) {
+						if _, ok := peer.LoadAnnouncePeerStream(); !ok {
+							t.Fatal("stream of peer is not stored")
+						}
+
+						return nil, io.EOF
+					}).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateReceivedNormal))
+				_, ok := peer.LoadAnnouncePeerStream()
+				assert.False(ok)
+			},
+		},
+		{
+			name: "register peer and keep newer stream of peer when the call returns",
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStateRunning)
+				seedPeer.FSM.SetState(resource.PeerStateRunning)
+				peer.Task.StorePeer(seedPeer)
+				gomock.InOrder(
+					ma.Context().Return(mockHostIDContext).Times(1),
+					ma.Recv().Return(&schedulerv2.AnnouncePeerRequest{
+						TaskId: mockTaskID,
+						PeerId: mockPeerID,
+						Request: &schedulerv2.AnnouncePeerRequest_RegisterPeerRequest{
+							RegisterPeerRequest: &schedulerv2.RegisterPeerRequest{
+								Metadata: mockMetadata,
+							},
+						},
+					}, nil).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					ma.Recv().DoAndReturn(func() (*schedulerv2.AnnouncePeerRequest, error) {
+						// The peer registers again with a newer stream.
+						peer.StoreAnnouncePeerStream(&schedulerv2mocks.MockScheduler_AnnouncePeerServer{})
+						return nil, io.EOF
+					}).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, stream schedulerv2.Scheduler_AnnouncePeerServer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				newStream, ok := peer.LoadAnnouncePeerStream()
+				assert.True(ok)
+				assert.NotEqual(newStream, stream)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			taskManager := resource.NewMockTaskManager(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			stream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			mockSeedHost := resource.NewHost(mockRawSeedHost)
+			seedPeer := resource.NewPeer(mockSeedPeerID, mockTask, mockSeedHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, seedPeer, stream, hostManager, taskManager, peerManager, stream.EXPECT(), res.EXPECT(), hostManager.EXPECT(), taskManager.EXPECT(), peerManager.EXPECT())
+			tc.expect(t, peer, stream, svc.AnnouncePeer(stream))
+		})
+	}
+}
+
+func TestServiceV2_StatPeer(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, peer *resource.Peer, resp *commonv2.Peer, err error)
+	}{
+		{
+			name: "peer not found",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, resp *commonv2.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peer has been loaded",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				peer.Pieces.Add(&schedulerv1.PieceResult{
+					DstPid: mockSeedPeerID,
+					PieceInfo: &commonv1.PieceInfo{
+						PieceNum:  1,
+						RangeSize: 1024,
+					},
+				})
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, resp *commonv2.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(resp.Id, peer.ID)
+				assert.Equal(resp.State, resource.PeerStateRunning)
+				assert.Equal(resp.Task.Id, peer.Task.ID)
+				assert.Equal(resp.Host.Id, peer.Host.ID)
+				assert.Equal(len(resp.Pieces), 1)
+				assert.Equal(resp.Pieces[0].ParentId, mockSeedPeerID)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, peerManager, res.EXPECT(), peerManager.EXPECT())
+			resp, err := svc.StatPeer(context.Background(), &schedulerv2.StatPeerRequest{TaskId: mockTaskID, PeerId: mockPeerID})
+			tc.expect(t, peer, resp, err)
+		})
+	}
+}
+
+func TestServiceV2_LeavePeer(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name: "peer not found",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peer state is PeerStateLeave",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateLeave)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.FailedPrecondition)
+				assert.True(peer.FSM.Is(resource.PeerStateLeave))
+			},
+		},
+		{
+			name: "peer state is PeerStateRunning",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateLeave))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, peerManager, res.EXPECT(), peerManager.EXPECT())
+			tc.expect(t, peer, svc.LeavePeer(context.Background(), &schedulerv2.LeavePeerRequest{TaskId: mockTaskID, PeerId: mockPeerID}))
+		})
+	}
+}
+
+func TestServiceV2_ExchangePeer(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *schedulerv2.ExchangePeerRequest
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, resp *schedulerv2.ExchangePeerResponse, err error)
+	}{
+		{
+			name: "peer not found",
+			req:  &schedulerv2.ExchangePeerRequest{TaskId: mockTaskID, PeerId: mockPeerID},
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, resp *schedulerv2.ExchangePeerResponse, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peer does not belong to the task",
+			req:  &schedulerv2.ExchangePeerRequest{TaskId: "foo", PeerId: mockPeerID},
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, resp *schedulerv2.ExchangePeerResponse, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.InvalidArgument)
+			},
+		},
+		{
+			name: "exchange peer",
+			req:  &schedulerv2.ExchangePeerRequest{TaskId: mockTaskID, PeerId: mockPeerID},
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, resp *schedulerv2.ExchangePeerResponse, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.NotNil(resp)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, peerManager, res.EXPECT(), peerManager.EXPECT())
+			resp, err := svc.ExchangePeer(context.Background(), tc.req)
+			tc.expect(t, resp, err)
+		})
+	}
+}
+
+func TestServiceV2_StatTask(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(task *resource.Task, taskManager resource.TaskManager, mr *resource.MockResourceMockRecorder, mt *resource.MockTaskManagerMockRecorder)
+		expect func(t *testing.T, task *resource.Task, resp *commonv2.Task, err error)
+	}{
+		{
+			name: "task not found",
+			mock: func(task *resource.Task, taskManager resource.TaskManager, mr *resource.MockResourceMockRecorder, mt *resource.MockTaskManagerMockRecorder) {
+				gomock.InOrder(
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, task *resource.Task, resp *commonv2.Task, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "task has been loaded",
+			mock: func(task *resource.Task, taskManager resource.TaskManager, mr *resource.MockResourceMockRecorder, mt *resource.MockTaskManagerMockRecorder) {
+				task.FSM.SetState(resource.TaskStateSucceeded)
+				task.ContentLength.Store(1024)
+				task.TotalPieceCount.Store(1)
+				gomock.InOrder(
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(task, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, task *resource.Task, resp *commonv2.Task, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(resp.Id, task.ID)
+				assert.Equal(resp.State, resource.TaskStateSucceeded)
+				assert.Equal(resp.ContentLength, int64(1024))
+				assert.Equal(resp.SizeScope, commonv2.SizeScope_SMALL)
+				assert.Equal(resp.Metadata.Url, mockTaskURL)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			taskManager := resource.NewMockTaskManager(ctl)
+			task := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(task, taskManager, res.EXPECT(), taskManager.EXPECT())
+			resp, err := svc.StatTask(context.Background(), &schedulerv2.StatTaskRequest{Id: mockTaskID})
+			tc.expect(t, task, resp, err)
+		})
+	}
+}
+
+func TestServiceV2_AnnounceHost(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *schedulerv2.AnnounceHostRequest
+		run  func(t *testing.T, svc *V2, req *schedulerv2.AnnounceHostRequest, host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, md *configmocks.MockDynconfigInterfaceMockRecorder)
+	}{
+		{
+			name: "host not found",
+			req: &schedulerv2.AnnounceHostRequest{
+				Id:           mockRawHost.Id,
+				Type:         uint32(pkgtypes.HostTypeNormal),
+				Hostname:     "hostname",
+				Ip:           "127.0.0.1",
+				Port:         8003,
+				DownloadPort: 8001,
+				Network: &schedulerv2.Network{
+					SecurityDomain: "product",
+				},
+			},
+			run: func(t *testing.T, svc *V2, req *schedulerv2.AnnounceHostRequest, host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				gomock.InOrder(
+					md.GetSchedulerClusterClientConfig().Return(types.SchedulerClusterClientConfig{LoadLimit: 10}, nil).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(req.Id)).Return(nil, false).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Store(gomock.Any()).Do(func(host *resource.Host) {
+						assert := assert.New(t)
+						assert.Equal(host.ID, req.Id)
+						assert.Equal(host.Type, pkgtypes.HostTypeNormal)
+						assert.Equal(host.IP, req.Ip)
+						assert.Equal(host.Network.SecurityDomain, req.Network.SecurityDomain)
+						assert.Equal(host.ConcurrentUploadLimit.Load(), int32(10))
+					}).Times(1),
+				)
+
+				assert := assert.New(t)
+				assert.NoError(svc.AnnounceHost(context.Background(), req))
+			},
+		},
+		{
+			name: "host has been loaded",
+			req: &schedulerv2.AnnounceHostRequest{
+				Id:           mockRawHost.Id,
+				Type:         uint32(pkgtypes.HostTypeSuperSeed),
+				Hostname:     "foo",
+				Ip:           "127.0.0.2",
+				Port:         8003,
+				DownloadPort: 8001,
+			},
+			run: func(t *testing.T, svc *V2, req *schedulerv2.AnnounceHostRequest, host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder, md *configmocks.MockDynconfigInterfaceMockRecorder) {
+				gomock.InOrder(
+					md.GetSchedulerClusterClientConfig().Return(types.SchedulerClusterClientConfig{}, errors.New("foo")).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(req.Id)).Return(host, true).Times(1),
+				)
+
+				assert := assert.New(t)
+				assert.NoError(svc.AnnounceHost(context.Background(), req))
+				assert.Equal(host.Type, pkgtypes.HostTypeSuperSeed)
+				assert.Equal(host.Hostname, req.Hostname)
+				assert.Equal(host.IP, req.Ip)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			host := resource.NewHost(mockRawHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.run(t, svc, tc.req, host, hostManager, res.EXPECT(), hostManager.EXPECT(), dynconfig.EXPECT())
+		})
+	}
+}
+
+func TestServiceV2_LeaveHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(host *resource.Host, mockPeer *resource.Peer, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name: "host not found",
+			mock: func(host *resource.Host, mockPeer *resource.Peer, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Any()).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peers of host leave",
+			mock: func(host *resource.Host, mockPeer *resource.Peer, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+				host.StorePeer(mockPeer)
+				mockPeer.FSM.SetState(resource.PeerStateRunning)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Any()).Return(host, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateLeave))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			host := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			mockPeer := resource.NewPeer(mockPeerID, mockTask, host)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(host, mockPeer, hostManager, res.EXPECT(), hostManager.EXPECT())
+			tc.expect(t, mockPeer, svc.LeaveHost(context.Background(), &schedulerv2.LeaveHostRequest{Id: host.ID}))
+		})
+	}
+}
+
+func TestServiceV2_handleRegisterPeerRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata *commonv2.Metadata
+		mock     func(
+			peer *resource.Peer, seedPeer *resource.Peer,
+			hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+			ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+			mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+		)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name:     "host not found",
+			metadata: mockMetadata,
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Id)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+				assert.True(peer.FSM.Is(resource.PeerStatePending))
+			},
+		},
+		{
+			name: "priority is Priority_LEVEL1",
+			metadata: &commonv2.Metadata{
+				Url:      mockTaskURL,
+				Priority: commonv2.Priority_LEVEL1,
+			},
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStatePending)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Delete(gomock.Eq(peer.ID)).Return().Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.FailedPrecondition)
+				assert.True(peer.FSM.Is(resource.PeerStateLeave))
+			},
+		},
+		{
+			name: "priority is Priority_LEVEL2",
+			metadata: &commonv2.Metadata{
+				Url:      mockTaskURL,
+				Priority: commonv2.Priority_LEVEL2,
+			},
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStatePending)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Delete(gomock.Eq(peer.ID)).Return().Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.FailedPrecondition)
+				assert.True(peer.FSM.Is(resource.PeerStateLeave))
+			},
+		},
+		{
+			name: "priority is Priority_LEVEL3",
+			metadata: &commonv2.Metadata{
+				Url:      mockTaskURL,
+				Priority: commonv2.Priority_LEVEL3,
+			},
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStatePending)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.NeedBackToSource.Load())
+				assert.True(peer.Task.FSM.Is(resource.TaskStateRunning))
+				assert.True(peer.FSM.Is(resource.PeerStateReceivedNormal))
+			},
+		},
+		{
+			name: "priority is Priority_LEVEL6 and seed peer is disabled",
+			metadata: &commonv2.Metadata{
+				Url:      mockTaskURL,
+				Priority: commonv2.Priority_LEVEL6,
+			},
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStatePending)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.NeedBackToSource.Load())
+				assert.True(peer.FSM.Is(resource.PeerStateReceivedNormal))
+			},
+		},
+		{
+			name:     "size scope is SizeScope_EMPTY",
+			metadata: mockMetadata,
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStateSucceeded)
+				peer.Task.ContentLength.Store(0)
+				seedPeer.FSM.SetState(resource.PeerStateSucceeded)
+				peer.Task.StorePeer(seedPeer)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+					ma.Send(gomock.Eq(&schedulerv2.AnnouncePeerResponse{
+						Response: &schedulerv2.AnnouncePeerResponse_TinyTaskResponse{
+							TinyTaskResponse: &schedulerv2.TinyTaskResponse{
+								Data: []byte{},
+							},
+						},
+					})).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateReceivedEmpty))
+			},
+		},
+		{
+			name:     "size scope is SizeScope_TINY",
+			metadata: mockMetadata,
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStateSucceeded)
+				peer.Task.ContentLength.Store(1)
+				peer.Task.TotalPieceCount.Store(1)
+				peer.Task.DirectPiece = []byte{1}
+				seedPeer.FSM.SetState(resource.PeerStateSucceeded)
+				peer.Task.StorePeer(seedPeer)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+					ma.Send(gomock.Eq(&schedulerv2.AnnouncePeerResponse{
+						Response: &schedulerv2.AnnouncePeerResponse_TinyTaskResponse{
+							TinyTaskResponse: &schedulerv2.TinyTaskResponse{
+								Data: []byte{1},
+							},
+						},
+					})).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateReceivedTiny))
+			},
+		},
+		{
+			name:     "size scope is SizeScope_SMALL",
+			metadata: mockMetadata,
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStateSucceeded)
+				peer.Task.ContentLength.Store(resource.TinyFileSize + 1)
+				peer.Task.TotalPieceCount.Store(1)
+				peer.Task.StorePiece(&commonv1.PieceInfo{
+					PieceNum:  0,
+					RangeSize: resource.TinyFileSize + 1,
+				})
+				seedPeer.FSM.SetState(resource.PeerStateSucceeded)
+				peer.Task.StorePeer(peer)
+				peer.Task.StorePeer(seedPeer)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					ms.FindParent(gomock.Any(), gomock.Eq(peer), gomock.Any()).Return(seedPeer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+					ma.Send(gomock.Eq(&schedulerv2.AnnouncePeerResponse{
+						Response: &schedulerv2.AnnouncePeerResponse_SmallTaskResponse{
+							SmallTaskResponse: &schedulerv2.SmallTaskResponse{
+								Piece: &commonv2.Piece{
+									Number:      0,
+									ParentId:    seedPeer.ID,
+									Size:        resource.TinyFileSize + 1,
+									TrafficType: commonv2.TrafficType_REMOTE_PEER,
+									Cost:        durationpb.New(0),
+								},
+							},
+						},
+					})).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateReceivedSmall))
+				assert.Equal(len(peer.Parents()), 1)
+			},
+		},
+		{
+			name:     "size scope is SizeScope_NORMAL",
+			metadata: mockMetadata,
+			mock: func(
+				peer *resource.Peer, seedPeer *resource.Peer,
+				hostManager resource.HostManager, taskManager resource.TaskManager, peerManager resource.PeerManager,
+				ma *schedulerv2mocks.MockScheduler_AnnouncePeerServerMockRecorder, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder,
+				mh *resource.MockHostManagerMockRecorder, mt *resource.MockTaskManagerMockRecorder, mp *resource.MockPeerManagerMockRecorder,
+			) {
+				peer.Task.FSM.SetState(resource.TaskStateSucceeded)
+				peer.Task.ContentLength.Store(resource.TinyFileSize + 1)
+				peer.Task.TotalPieceCount.Store(2)
+				seedPeer.FSM.SetState(resource.PeerStateSucceeded)
+				peer.Task.StorePeer(seedPeer)
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.TaskManager().Return(taskManager).Times(1),
+					mt.Load(gomock.Eq(mockTaskID)).Return(peer.Task, true).Times(1),
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(peer.Host.ID)).Return(peer.Host, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.LoadOrStore(gomock.Any()).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(peer.ID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateReceivedNormal))
+				_, ok := peer.LoadAnnouncePeerStream()
+				assert.True(ok)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			taskManager := resource.NewMockTaskManager(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			stream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			mockSeedHost := resource.NewHost(mockRawSeedHost)
+			seedPeer := resource.NewPeer(mockSeedPeerID, mockTask, mockSeedHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, seedPeer, hostManager, taskManager, peerManager, stream.EXPECT(), scheduler.EXPECT(), res.EXPECT(), hostManager.EXPECT(), taskManager.EXPECT(), peerManager.EXPECT())
+			_, err := svc.handleRegisterPeerRequest(mockHostIDContext, stream, mockTaskID, mockPeerID, &schedulerv2.RegisterPeerRequest{Metadata: tc.metadata})
+			tc.expect(t, peer, err)
+		})
+	}
+}
+
+func TestServiceV2_handleDownloadPeerFinishedRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, ms *storagemocks.MockStorageMockRecorder, wg *sync.WaitGroup)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name: "peer not found",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, ms *storagemocks.MockStorageMockRecorder, wg *sync.WaitGroup) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peer downloads back-to-source successfully",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, ms *storagemocks.MockStorageMockRecorder, wg *sync.WaitGroup) {
+				wg.Add(1)
+				peer.FSM.SetState(resource.PeerStateBackToSource)
+				peer.Task.FSM.SetState(resource.TaskStateRunning)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					ms.Create(gomock.Any()).Do(func(record storage.Record) { wg.Done() }).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateSucceeded))
+				assert.True(peer.Task.FSM.Is(resource.TaskStateSucceeded))
+				assert.Equal(peer.Task.ContentLength.Load(), int64(resource.TinyFileSize+1))
+				assert.Equal(peer.Task.TotalPieceCount.Load(), int32(2))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			var wg sync.WaitGroup
+			tc.mock(peer, peerManager, res.EXPECT(), peerManager.EXPECT(), storage.EXPECT(), &wg)
+			err := svc.handleDownloadPeerFinishedRequest(context.Background(), mockTaskID, mockPeerID, resource.TinyFileSize+1, 2)
+			wg.Wait()
+			tc.expect(t, peer, err)
+		})
+	}
+}
+
+func TestServiceV2_handleDownloadPieceFinishedRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		piece  *commonv2.Piece
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name:  "invalid piece",
+			piece: nil,
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.InvalidArgument)
+			},
+		},
+		{
+			name: "peer not found",
+			piece: &commonv2.Piece{
+				Number:   1,
+				ParentId: mockSeedPeerID,
+			},
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "piece downloads successfully",
+			piece: &commonv2.Piece{
+				Number:   1,
+				ParentId: mockSeedPeerID,
+				Size:     1024,
+				Cost:     durationpb.New(time.Second),
+			},
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockSeedPeerID)).Return(nil, false).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockSeedPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(peer.Pieces.Len(), uint(1))
+				assert.True(peer.FinishedPieces.Test(1))
+				assert.Equal(peer.PieceCosts(), []int64{time.Second.Milliseconds()})
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig, Metrics: config.MetricsConfig{Enable: true, EnablePeerHost: true}}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, peerManager, res.EXPECT(), peerManager.EXPECT())
+			tc.expect(t, peer, svc.handleDownloadPieceFinishedRequest(context.Background(), mockTaskID, mockPeerID, tc.piece))
+		})
+	}
+}
+
+func TestServiceV2_handleDownloadPeerBackToSourceFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, ms *storagemocks.MockStorageMockRecorder, wg *sync.WaitGroup)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name: "peer not found",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, ms *storagemocks.MockStorageMockRecorder, wg *sync.WaitGroup) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peer downloads back-to-source failed",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, ms *storagemocks.MockStorageMockRecorder, wg *sync.WaitGroup) {
+				wg.Add(1)
+				peer.FSM.SetState(resource.PeerStateBackToSource)
+				peer.Task.FSM.SetState(resource.TaskStateRunning)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					ms.Create(gomock.Any()).Do(func(record storage.Record) { wg.Done() }).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateFailed))
+				assert.True(peer.Task.FSM.Is(resource.TaskStateFailed))
+			},
+		},
+		{
+			name: "peer downloads back-to-source failed and running peers of v2 version are notified",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder, ms *storagemocks.MockStorageMockRecorder, wg *sync.WaitGroup) {
+				wg.Add(1)
+				peer.FSM.SetState(resource.PeerStateBackToSource)
+				peer.Task.FSM.SetState(resource.TaskStateRunning)
+				peer.Task.PeerFailedCount.Store(resource.FailedPeerCountLimit + 1)
+
+				ctl := gomock.NewController(t)
+				stream := schedulerv2mocks.NewMockScheduler_AnnouncePeerServer(ctl)
+				runningPeer := resource.NewPeer(idgen.PeerID("127.0.0.1"), peer.Task, peer.Host)
+				runningPeer.FSM.SetState(resource.PeerStateRunning)
+				runningPeer.StoreAnnouncePeerStream(stream)
+				peer.Task.StorePeer(runningPeer)
+
+				stream.EXPECT().Send(gomock.Eq(&schedulerv2.AnnouncePeerResponse{
+					Errordetails: &schedulerv2.AnnouncePeerResponse_SchedulePeerFailed{
+						SchedulePeerFailed: &errordetailsv2.SchedulePeerFailed{
+							Description: "task notify peer failed, code is SchedTaskStatusError",
+						},
+					},
+				})).Return(nil).Times(1)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					ms.Create(gomock.Any()).Do(func(record storage.Record) { wg.Done() }).Return(nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateFailed))
+				assert.True(peer.Task.FSM.Is(resource.TaskStateFailed))
+
+				// The count is reset by the task failure and then increased by the peer failure.
+				assert.Equal(peer.Task.PeerFailedCount.Load(), int32(1))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			var wg sync.WaitGroup
+			tc.mock(peer, peerManager, res.EXPECT(), peerManager.EXPECT(), storage.EXPECT(), &wg)
+			err := svc.handleDownloadPeerBackToSourceFailed(context.Background(), mockTaskID, mockPeerID)
+			wg.Wait()
+			tc.expect(t, peer, err)
+		})
+	}
+}
+
+func TestServiceV2_handleDownloadPieceBackToSourceFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, peer *resource.Peer, err error)
+	}{
+		{
+			name: "peer not found",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peer retries by itself",
+			mock: func(peer *resource.Peer, peerManager resource.PeerManager, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateBackToSource)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.True(peer.FSM.Is(resource.PeerStateBackToSource))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, peerManager, res.EXPECT(), peerManager.EXPECT())
+			tc.expect(t, peer, svc.handleDownloadPieceBackToSourceFailed(context.Background(), mockPeerID, &errordetailsv2.DownloadPieceBackToSourceFailed{PieceNumber: 1}))
+		})
+	}
+}
+
+func TestServiceV2_handleDownloadPieceFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(peer *resource.Peer, parent *resource.Peer, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder)
+		expect func(t *testing.T, peer *resource.Peer, parent *resource.Peer, err error)
+	}{
+		{
+			name: "peer not found",
+			mock: func(peer *resource.Peer, parent *resource.Peer, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, parent *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "peer state is PeerStateBackToSource",
+			mock: func(peer *resource.Peer, parent *resource.Peer, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateBackToSource)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, parent *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(parent.Host.UploadFailedCount.Load(), int64(0))
+				assert.False(peer.BlockParents.Contains(parent.ID))
+			},
+		},
+		{
+			name: "peer can not be rescheduled",
+			mock: func(peer *resource.Peer, parent *resource.Peer, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateSucceeded)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(parent.ID)).Return(parent, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, parent *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(parent.Host.UploadFailedCount.Load(), int64(1))
+				assert.True(peer.FSM.Is(resource.PeerStateSucceeded))
+			},
+		},
+		{
+			name: "reschedule candidate parents of peer",
+			mock: func(peer *resource.Peer, parent *resource.Peer, peerManager resource.PeerManager, ms *mocks.MockSchedulerMockRecorder, mr *resource.MockResourceMockRecorder, mp *resource.MockPeerManagerMockRecorder) {
+				peer.FSM.SetState(resource.PeerStateRunning)
+				blocklist := set.NewSafeSet[string]()
+				blocklist.Add(parent.ID)
+				gomock.InOrder(
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(mockPeerID)).Return(peer, true).Times(1),
+					mr.PeerManager().Return(peerManager).Times(1),
+					mp.Load(gomock.Eq(parent.ID)).Return(parent, true).Times(1),
+					ms.ScheduleParent(gomock.Any(), gomock.Eq(peer), gomock.Eq(blocklist)).Return().Times(1),
+				)
+			},
+			expect: func(t *testing.T, peer *resource.Peer, parent *resource.Peer, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(parent.Host.UploadFailedCount.Load(), int64(1))
+				assert.True(peer.BlockParents.Contains(parent.ID))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			peerManager := resource.NewMockPeerManager(ctl)
+			mockHost := resource.NewHost(mockRawHost)
+			mockTask := resource.NewTask(mockTaskID, mockTaskURL, commonv1.TaskType_Normal, mockTaskURLMeta, resource.WithBackToSourceLimit(mockTaskBackToSourceLimit))
+			peer := resource.NewPeer(mockPeerID, mockTask, mockHost)
+			mockSeedHost := resource.NewHost(mockRawSeedHost)
+			parent := resource.NewPeer(mockSeedPeerID, mockTask, mockSeedHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(peer, parent, peerManager, scheduler.EXPECT(), res.EXPECT(), peerManager.EXPECT())
+			tc.expect(t, peer, parent, svc.handleDownloadPieceFailed(context.Background(), mockPeerID, parent.ID))
+		})
+	}
+}
+
+func TestServiceV2_loadHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		mock   func(host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder)
+		expect func(t *testing.T, host *resource.Host, err error)
+	}{
+		{
+			name: "grpc metadata is invalid",
+			ctx:  context.Background(),
+			mock: func(host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+			},
+			expect: func(t *testing.T, host *resource.Host, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.InvalidArgument)
+			},
+		},
+		{
+			name: "grpc metadata does not carry host id",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs("foo", "bar")),
+			mock: func(host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+			},
+			expect: func(t *testing.T, host *resource.Host, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.InvalidArgument)
+			},
+		},
+		{
+			name: "host not found",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs(common.HostIDMetadataKey, mockRawHost.Id)),
+			mock: func(host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Id)).Return(nil, false).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, err error) {
+				assert := assert.New(t)
+				assert.Equal(status.Code(err), codes.NotFound)
+			},
+		},
+		{
+			name: "host is loaded by host id",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs(common.HostIDMetadataKey, mockRawHost.Id)),
+			mock: func(host *resource.Host, hostManager resource.HostManager, mr *resource.MockResourceMockRecorder, mh *resource.MockHostManagerMockRecorder) {
+				gomock.InOrder(
+					mr.HostManager().Return(hostManager).Times(1),
+					mh.Load(gomock.Eq(mockRawHost.Id)).Return(host, true).Times(1),
+				)
+			},
+			expect: func(t *testing.T, host *resource.Host, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(host.ID, mockRawHost.Id)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			scheduler := mocks.NewMockScheduler(ctl)
+			res := resource.NewMockResource(ctl)
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			hostManager := resource.NewMockHostManager(ctl)
+			host := resource.NewHost(mockRawHost)
+			svc := NewV2(&config.Config{Scheduler: mockSchedulerConfig}, res, scheduler, dynconfig, storage)
+
+			tc.mock(host, hostManager, res.EXPECT(), hostManager.EXPECT())
+			host, err := svc.loadHost(tc.ctx)
+			tc.expect(t, host, err)
+		})
+	}
+}
+
+func TestServiceV2_newStatusError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{
+			name: "peer not found",
+			err:  dferrors.New(commonv1.Code_SchedPeerNotFound, "foo"),
+			code: codes.NotFound,
+		},
+		{
+			name: "schedule forbidden",
+			err:  dferrors.New(commonv1.Code_SchedForbidden, "foo"),
+			code: codes.FailedPrecondition,
+		},
+		{
+			name: "task status error",
+			err:  dferrors.New(commonv1.Code_SchedTaskStatusError, "foo"),
+			code: codes.FailedPrecondition,
+		},
+		{
+			name: "schedule error",
+			err:  dferrors.New(commonv1.Code_SchedError, "foo"),
+			code: codes.Internal,
+		},
+		{
+			name: "unknown error",
+			err:  errors.New("foo"),
+			code: codes.Internal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			err := newStatusError(tc.err)
+			assert.Equal(status.Code(err), tc.code)
+			assert.Equal(status.Convert(err).Message(), "foo")
+		})
+	}
+}
+
+func TestServiceV2_newURLMetaV1(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata *commonv2.Metadata
+		expect   func(t *testing.T, urlMeta *commonv1.UrlMeta)
+	}{
+		{
+			name:     "metadata has range and filters",
+			metadata: mockMetadata,
+			expect: func(t *testing.T, urlMeta *commonv1.UrlMeta) {
+				assert := assert.New(t)
+				assert.EqualValues(urlMeta, &commonv1.UrlMeta{
+					Digest:      "digest",
+					Tag:         "tag",
+					Range:       "0-100",
+					Filter:      "foo&bar",
+					Header:      map[string]string{"content-length": "100"},
+					Application: "application",
+					Priority:    commonv1.Priority_LEVEL0,
+				})
+			},
+		},
+		{
+			name: "metadata does not have range",
+			metadata: &commonv2.Metadata{
+				Url:      mockTaskURL,
+				Priority: commonv2.Priority_LEVEL3,
+			},
+			expect: func(t *testing.T, urlMeta *commonv1.UrlMeta) {
+				assert := assert.New(t)
+				assert.Equal(urlMeta.Range, "")
+				assert.Equal(urlMeta.Filter, "")
+				assert.Equal(urlMeta.Priority, commonv1.Priority_LEVEL3)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, newURLMetaV1(tc.metadata))
+		})
+	}
+}
+
+func TestServiceV2_newPieceResultV1(t *testing.T) {
+	createdAt := time.Now()
+	tests := []struct {
+		name   string
+		piece  *commonv2.Piece
+		expect func(t *testing.T, pieceResult *schedulerv1.PieceResult)
+	}{
+		{
+			name: "piece has created time and md5 digest",
+			piece: &commonv2.Piece{
+				Number:    1,
+				ParentId:  mockSeedPeerID,
+				Offset:    1024,
+				Size:      1024,
+				Digest:    "md5:ad83a945518a4ef007d8b2db2ef165b3",
+				Cost:      durationpb.New(time.Second),
+				CreatedAt: timestamppb.New(createdAt),
+			},
+			expect: func(t *testing.T, pieceResult *schedulerv1.PieceResult) {
+				assert := assert.New(t)
+				assert.True(pieceResult.Success)
+				assert.Equal(pieceResult.TaskId, mockTaskID)
+				assert.Equal(pieceResult.SrcPid, mockPeerID)
+				assert.Equal(pieceResult.DstPid, mockSeedPeerID)
+				assert.Equal(pieceResult.PieceInfo.PieceNum, int32(1))
+				assert.Equal(pieceResult.PieceInfo.RangeStart, uint64(1024))
+				assert.Equal(pieceResult.PieceInfo.RangeSize, uint32(1024))
+				assert.Equal(pieceResult.PieceInfo.PieceMd5, "ad83a945518a4ef007d8b2db2ef165b3")
+				assert.Equal(pieceResult.PieceInfo.DownloadCost, uint64(time.Second.Milliseconds()))
+				assert.Equal(pieceResult.BeginTime, uint64(createdAt.UnixNano()))
+				assert.Equal(pieceResult.EndTime, uint64(createdAt.Add(time.Second).UnixNano()))
+			},
+		},
+		{
+			name: "piece does not have created time and digest",
+			piece: &commonv2.Piece{
+				Number: 1,
+				Size:   1024,
+				Cost:   durationpb.New(time.Second),
+			},
+			expect: func(t *testing.T, pieceResult *schedulerv1.PieceResult) {
+				assert := assert.New(t)
+				assert.Equal(pieceResult.PieceInfo.PieceMd5, "")
+				assert.Equal(pieceResult.BeginTime, uint64(0))
+				assert.Equal(pieceResult.EndTime, uint64(time.Second.Nanoseconds()))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, newPieceResultV1(mockTaskID, mockPeerID, tc.piece))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gocarina/gocsv"
+)
+
+const (
+	// ExportFormatCSV is the csv format with headers.
+	ExportFormatCSV = "csv"
+
+	// ExportFormatJSONL is the JSON Lines format, one record per line.
+	ExportFormatJSONL = "jsonl"
+
+	// ExportFormatParquet is the apache parquet format.
+	ExportFormatParquet = "parquet"
+)
+
+const (
+	// csvBatchSize is the count of records marshaled by csv exporter at once.
+	csvBatchSize = 100
+)
+
+// Exporter is the interface used for exporting records.
+type Exporter interface {
+	// Write writes the record to exporter.
+	Write(Record) error
+
+	// Close flushes the buffered records, it does not close the writer.
+	Close() error
+}
+
+// NewExporter returns a new Exporter of the format.
+func NewExporter(w io.Writer, format string) (Exporter, error) {
+	switch format {
+	case ExportFormatCSV:
+		return &csvExporter{w: w, buffer: make([]Record, 0, csvBatchSize)}, nil
+	case ExportFormatJSONL:
+		return &jsonlExporter{encoder: json.NewEncoder(w)}, nil
+	case ExportFormatParquet:
+		return newParquetExporter(w), nil
+	default:
+		return nil, fmt.Errorf("invalid export format %s", format)
+	}
+}
+
+// Export writes the records match the filter in the format.
+func Export(s Storage, w io.Writer, format string, filter Filter) error {
+	exporter, err := NewExporter(w, format)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Iterate(filter, exporter.Write); err != nil {
+		return err
+	}
+
+	return exporter.Close()
+}
+
+// csvExporter exports records in csv format.
+type csvExporter struct {
+	w           io.Writer
+	buffer      []Record
+	wroteHeader bool
+}
+
+// Write writes the record to exporter.
+func (e *csvExporter) Write(record Record) error {
+	e.buffer = append(e.buffer, record)
+	if len(e.buffer) < csvBatchSize {
+		return nil
+	}
+
+	return e.flush()
+}
+
+// Close flushes the buffered records.
+func (e *csvExporter) Close() error {
+	if len(e.buffer) == 0 && e.wroteHeader {
+		return nil
+	}
+
+	return e.flush()
+}
+
+// flush marshals the buffered records, the headers are written with the first batch.
+func (e *csvExporter) flush() error {
+	if e.wroteHeader {
+		if err := gocsv.MarshalWithoutHeaders(e.buffer, e.w); err != nil {
+			return err
+		}
+	} else {
+		if err := gocsv.Marshal(e.buffer, e.w); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	// Keep allocated memory.
+	e.buffer = e.buffer[:0]
+	return nil
+}
+
+// jsonlExporter exports records in JSON Lines format.
+type jsonlExporter struct {
+	encoder *json.Encoder
+}
+
+// Write writes the record to exporter.
+func (e *jsonlExporter) Write(record Record) error {
+	record.Parents = compactParents(record.Parents)
+	return e.encoder.Encode(record)
+}
+
+// Close does nothing, records are written without buffer.
+func (e *jsonlExporter) Close() error {
+	return nil
+}
+
+// compactParents removes the empty parents, which are padded
+// to the fixed length of parents in csv file.
+func compactParents(parents []Parent) []Parent {
+	compacted := make([]Parent, 0, len(parents))
+	for _, parent := range parents {
+		if parent.ID == "" {
+			continue
+		}
+
+		compacted = append(compacted, parent)
+	}
+
+	return compacted
+}
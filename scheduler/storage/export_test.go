@@ -0,0 +1,142 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/gocarina/gocsv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExporter_Write(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		records []Record
+		expect  func(t *testing.T, data []byte)
+	}{
+		{
+			name:    "export records in csv format",
+			format:  ExportFormatCSV,
+			records: []Record{mockRecord, {ID: "5"}},
+			expect: func(t *testing.T, data []byte) {
+				assert := assert.New(t)
+				header, body, found := bytes.Cut(data, []byte("\n"))
+				assert.True(found)
+				assert.True(bytes.HasPrefix(header, []byte("id,tag,application,state")))
+
+				var records []Record
+				assert.NoError(gocsv.UnmarshalWithoutHeaders(bytes.NewReader(body), &records))
+				assert.Equal(len(records), 2)
+				assert.EqualValues(records[0], mockRecord)
+				assert.Equal(records[1].ID, "5")
+			},
+		},
+		{
+			name:    "export records in jsonl format",
+			format:  ExportFormatJSONL,
+			records: []Record{mockRecord, {ID: "5"}},
+			expect: func(t *testing.T, data []byte) {
+				assert := assert.New(t)
+				var records []Record
+				scanner := bufio.NewScanner(bytes.NewReader(data))
+				scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+				for scanner.Scan() {
+					var record Record
+					assert.NoError(json.Unmarshal(scanner.Bytes(), &record))
+					records = append(records, record)
+				}
+				assert.NoError(scanner.Err())
+				assert.Equal(len(records), 2)
+				assert.Equal(records[0].ID, mockRecord.ID)
+				assert.EqualValues(records[0].Parents, []Parent{mockParent})
+				assert.Equal(records[1].ID, "5")
+				assert.Equal(len(records[1].Parents), 0)
+			},
+		},
+		{
+			name:    "export records in parquet format",
+			format:  ExportFormatParquet,
+			records: []Record{mockRecord, {ID: "5"}},
+			expect: func(t *testing.T, data []byte) {
+				assert := assert.New(t)
+				assert.Equal(string(data[:4]), parquetMagic)
+				assert.Equal(string(data[len(data)-4:]), parquetMagic)
+
+				size := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+				metadata := data[len(data)-8-size : len(data)-8]
+				assert.True(bytes.Contains(metadata, []byte(parquetCreatedBy)))
+				assert.True(bytes.Contains(data, []byte(mockHost.Hostname)))
+			},
+		},
+		{
+			name:   "export empty records in parquet format",
+			format: ExportFormatParquet,
+			expect: func(t *testing.T, data []byte) {
+				assert := assert.New(t)
+				assert.Equal(string(data[:4]), parquetMagic)
+				assert.Equal(string(data[len(data)-4:]), parquetMagic)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter, err := NewExporter(&buf, tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, record := range tc.records {
+				if err := exporter.Write(record); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := exporter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.expect(t, buf.Bytes())
+		})
+	}
+}
+
+func TestExporter_NewExporter(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewExporter(&bytes.Buffer{}, "foo")
+	assert.EqualError(err, "invalid export format foo")
+}
+
+func TestParquet_newParquetSchema(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(parquetSchema[0].numChildren, int32(11))
+
+	var paths []string
+	for _, column := range parquetColumns {
+		if len(column.path) == 1 {
+			paths = append(paths, column.path[0])
+		}
+	}
+	assert.EqualValues(paths, []string{"id", "tag", "application", "state", "cost", "parents", "createdAt", "updatedAt"})
+}
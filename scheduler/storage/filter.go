@@ -0,0 +1,70 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import "time"
+
+// Filter is the filter of records, the zero value matches all records.
+type Filter struct {
+	// StartTime matches records created at or after the time.
+	StartTime time.Time
+
+	// EndTime matches records created before the time.
+	EndTime time.Time
+
+	// TaskID matches records of the task.
+	TaskID string
+
+	// HostID matches records downloaded by the host.
+	HostID string
+
+	// Failed matches failed records if it is true and succeeded records
+	// if it is false, the record is failed when the error code is not empty.
+	Failed *bool
+}
+
+// Match returns whether the record matches the filter.
+func (f *Filter) Match(record *Record) bool {
+	if !f.StartTime.IsZero() && record.CreatedAt < f.StartTime.UnixNano() {
+		return false
+	}
+
+	if !f.EndTime.IsZero() && record.CreatedAt >= f.EndTime.UnixNano() {
+		return false
+	}
+
+	if f.TaskID != "" && record.Task.ID != f.TaskID {
+		return false
+	}
+
+	if f.HostID != "" && record.Host.ID != f.HostID {
+		return false
+	}
+
+	if f.Failed != nil && *f.Failed != (record.Error.Code != "") {
+		return false
+	}
+
+	return true
+}
+
+// skip returns whether the backup file modified at modTime can be skipped,
+// records are appended to the file after they are created, so the file
+// modified before the start time has no record matches the filter.
+func (f *Filter) skip(modTime time.Time) bool {
+	return !f.StartTime.IsZero() && modTime.Before(f.StartTime)
+}
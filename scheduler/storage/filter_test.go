@@ -0,0 +1,116 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Match(t *testing.T) {
+	createdAt := time.Unix(100, 0)
+	failed := true
+	succeeded := false
+
+	tests := []struct {
+		name   string
+		filter Filter
+		record Record
+		expect func(t *testing.T, matched bool)
+	}{
+		{
+			name:   "empty filter matches all records",
+			filter: Filter{},
+			record: mockRecord,
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.True(matched)
+			},
+		},
+		{
+			name:   "record is created before the start time",
+			filter: Filter{StartTime: createdAt.Add(time.Second)},
+			record: Record{CreatedAt: createdAt.UnixNano()},
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.False(matched)
+			},
+		},
+		{
+			name:   "record is created at the end time",
+			filter: Filter{StartTime: createdAt, EndTime: createdAt},
+			record: Record{CreatedAt: createdAt.UnixNano()},
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.False(matched)
+			},
+		},
+		{
+			name:   "record is created in the time range",
+			filter: Filter{StartTime: createdAt, EndTime: createdAt.Add(time.Second)},
+			record: Record{CreatedAt: createdAt.UnixNano()},
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.True(matched)
+			},
+		},
+		{
+			name:   "task id and host id match",
+			filter: Filter{TaskID: mockTask.ID, HostID: mockHost.ID},
+			record: mockRecord,
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.True(matched)
+			},
+		},
+		{
+			name:   "host id does not match",
+			filter: Filter{TaskID: mockTask.ID, HostID: "foo"},
+			record: mockRecord,
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.False(matched)
+			},
+		},
+		{
+			name:   "failed record matches",
+			filter: Filter{Failed: &failed},
+			record: mockRecord,
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.True(matched)
+			},
+		},
+		{
+			name:   "failed record does not match succeeded filter",
+			filter: Filter{Failed: &succeeded},
+			record: mockRecord,
+			expect: func(t *testing.T, matched bool) {
+				assert := assert.New(t)
+				assert.False(matched)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.expect(t, tc.filter.Match(&tc.record))
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+)
+
+// contentTypes is the content type of export formats.
+var contentTypes = map[string]string{
+	ExportFormatCSV:     "text/csv",
+	ExportFormatJSONL:   "application/x-ndjson",
+	ExportFormatParquet: "application/vnd.apache.parquet",
+}
+
+// handler streams the records match the filter of query.
+type handler struct {
+	storage Storage
+}
+
+// NewHandler returns a http handler that streams the records match the filter,
+// the query parameters are format, start_time, end_time (RFC3339), task_id,
+// host_id and failed, e.g. /records?format=jsonl&task_id=foo&failed=true.
+func NewHandler(storage Storage) http.Handler {
+	return &handler{storage: storage}
+}
+
+// ServeHTTP streams the records in the format.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	contentType, ok := contentTypes[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("invalid format %s", format), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", RecordFilePrefix, format))
+
+	rw := &responseWriter{ResponseWriter: w}
+	bw := bufio.NewWriter(rw)
+	if err := Export(h.storage, bw, format, filter); err != nil {
+		logger.Errorf("export records failed: %s", err.Error())
+
+		// The status code can not be changed after the records are streamed.
+		if !rw.written {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := bw.Flush(); err != nil {
+		logger.Errorf("flush records failed: %s", err.Error())
+	}
+}
+
+// responseWriter records whether the response body has been written.
+type responseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// Write writes the data to response.
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(data)
+}
+
+// parseFilter parses the filter from query.
+func parseFilter(query url.Values) (Filter, error) {
+	filter := Filter{
+		TaskID: query.Get("task_id"),
+		HostID: query.Get("host_id"),
+	}
+
+	if v := query.Get("start_time"); v != "" {
+		startTime, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid start_time %s", v)
+		}
+		filter.StartTime = startTime
+	}
+
+	if v := query.Get("end_time"); v != "" {
+		endTime, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid end_time %s", v)
+		}
+		filter.EndTime = endTime
+	}
+
+	if v := query.Get("failed"); v != "" {
+		failed, err := strconv.ParseBool(v)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid failed %s", v)
+		}
+		filter.Failed = &failed
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,124 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/scheduler/config"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		mock   func(t *testing.T, s Storage)
+		expect func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "method is not allowed",
+			method: http.MethodPost,
+			target: "/records",
+			mock:   func(t *testing.T, s Storage) {},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusMethodNotAllowed)
+			},
+		},
+		{
+			name:   "format is invalid",
+			method: http.MethodGet,
+			target: "/records?format=foo",
+			mock:   func(t *testing.T, s Storage) {},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+			},
+		},
+		{
+			name:   "start time is invalid",
+			method: http.MethodGet,
+			target: "/records?start_time=foo",
+			mock:   func(t *testing.T, s Storage) {},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+				assert.Contains(w.Body.String(), "invalid start_time foo")
+			},
+		},
+		{
+			name:   "failed is invalid",
+			method: http.MethodGet,
+			target: "/records?failed=foo",
+			mock:   func(t *testing.T, s Storage) {},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusBadRequest)
+				assert.Contains(w.Body.String(), "invalid failed foo")
+			},
+		},
+		{
+			name:   "stream filtered records in jsonl format",
+			method: http.MethodGet,
+			target: "/records?format=jsonl&task_id=1&failed=true&start_time=2006-01-02T15:04:05Z",
+			mock: func(t *testing.T, s Storage) {
+				for _, record := range []Record{{ID: "1"}, mockRecord, {ID: "3"}} {
+					if err := s.Create(record); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			expect: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert := assert.New(t)
+				assert.Equal(w.Code, http.StatusOK)
+				assert.Equal(w.Header().Get("Content-Type"), "application/x-ndjson")
+
+				lines := bytes.Split(bytes.TrimSpace(w.Body.Bytes()), []byte("\n"))
+				assert.Equal(len(lines), 1)
+
+				var record Record
+				assert.NoError(json.Unmarshal(lines[0], &record))
+				assert.Equal(record.ID, mockRecord.ID)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(os.TempDir(), config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(t, s)
+			w := httptest.NewRecorder()
+			NewHandler(s).ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+			tc.expect(t, w)
+			if err := s.Clear(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
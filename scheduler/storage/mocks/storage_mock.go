@@ -77,6 +77,20 @@ func (mr *MockStorageMockRecorder) Create(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStorage)(nil).Create), arg0)
 }
 
+// Iterate mocks base method.
+func (m *MockStorage) Iterate(filter storage.Filter, fn func(storage.Record) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iterate", filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Iterate indicates an expected call of Iterate.
+func (mr *MockStorageMockRecorder) Iterate(filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterate", reflect.TypeOf((*MockStorage)(nil).Iterate), filter, fn)
+}
+
 // List mocks base method.
 func (m *MockStorage) List() ([]storage.Record, error) {
 	m.ctrl.T.Helper()
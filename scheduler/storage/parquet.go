@@ -0,0 +1,396 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// The parquet exporter writes records in the parquet file format,
+// refer to https://github.com/apache/parquet-format. Every field of record
+// is a required column without compression, the nested structs are
+// groups and the slices are JSON strings, so the file is written with
+// PLAIN encoding and data page v1 only.
+
+const (
+	// parquetMagic is the magic number of parquet file.
+	parquetMagic = "PAR1"
+
+	// parquetRowGroupSize is the count of records in a row group.
+	parquetRowGroupSize = 10000
+
+	// parquetCreatedBy is the application that writes the file.
+	parquetCreatedBy = "dragonfly scheduler"
+)
+
+// Types of parquet.
+const (
+	parquetTypeInt32     int32 = 1
+	parquetTypeInt64     int32 = 2
+	parquetTypeDouble    int32 = 5
+	parquetTypeByteArray int32 = 6
+)
+
+// Converted types of parquet.
+const (
+	parquetConvertedTypeUTF8   int32 = 0
+	parquetConvertedTypeUint32 int32 = 13
+	parquetConvertedTypeUint64 int32 = 14
+	parquetConvertedTypeJSON   int32 = 19
+)
+
+const (
+	// parquetRepetitionRequired is the required repetition type of parquet.
+	parquetRepetitionRequired int32 = 0
+
+	// parquetEncodingPlain is the plain encoding of parquet.
+	parquetEncodingPlain int32 = 0
+
+	// parquetEncodingRLE is the RLE encoding of parquet.
+	parquetEncodingRLE int32 = 3
+
+	// parquetCodecUncompressed is the uncompressed codec of parquet.
+	parquetCodecUncompressed int32 = 0
+
+	// parquetPageTypeData is the data page type of parquet.
+	parquetPageTypeData int32 = 0
+)
+
+// parquetSchemaElement is the element of parquet schema.
+type parquetSchemaElement struct {
+	name          string
+	typ           int32
+	convertedType int32
+	hasConverted  bool
+	numChildren   int32
+	group         bool
+}
+
+// parquetColumn is the leaf column of parquet schema.
+type parquetColumn struct {
+	path          []string
+	index         []int
+	typ           int32
+	convertedType int32
+	hasConverted  bool
+}
+
+// parquetSchema and parquetColumns are the schema of record in parquet,
+// they are generated from the csv tags of record.
+var parquetSchema, parquetColumns = newParquetSchema(reflect.TypeOf(Record{}))
+
+// newParquetSchema returns the schema elements in depth-first order and the leaf columns.
+func newParquetSchema(t reflect.Type) ([]parquetSchemaElement, []parquetColumn) {
+	schema := []parquetSchemaElement{{name: "schema", group: true}}
+	var columns []parquetColumn
+	schema[0].numChildren = walkParquetSchema(t, nil, nil, &schema, &columns)
+	return schema, columns
+}
+
+// walkParquetSchema appends the schema elements of struct fields, and returns the count of fields.
+func walkParquetSchema(t reflect.Type, path []string, index []int, schema *[]parquetSchemaElement, columns *[]parquetColumn) int32 {
+	var count int32
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("csv")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		count++
+
+		fieldPath := append(append([]string{}, path...), name)
+		fieldIndex := append(append([]int{}, index...), i)
+		if field.Type.Kind() == reflect.Struct {
+			n := len(*schema)
+			*schema = append(*schema, parquetSchemaElement{name: name, group: true})
+			(*schema)[n].numChildren = walkParquetSchema(field.Type, fieldPath, fieldIndex, schema, columns)
+			continue
+		}
+
+		column := parquetColumn{path: fieldPath, index: fieldIndex}
+		switch field.Type.Kind() {
+		case reflect.String:
+			column.typ, column.convertedType, column.hasConverted = parquetTypeByteArray, parquetConvertedTypeUTF8, true
+		case reflect.Slice:
+			column.typ, column.convertedType, column.hasConverted = parquetTypeByteArray, parquetConvertedTypeJSON, true
+		case reflect.Int32:
+			column.typ = parquetTypeInt32
+		case reflect.Int64:
+			column.typ = parquetTypeInt64
+		case reflect.Uint32:
+			column.typ, column.convertedType, column.hasConverted = parquetTypeInt32, parquetConvertedTypeUint32, true
+		case reflect.Uint64:
+			column.typ, column.convertedType, column.hasConverted = parquetTypeInt64, parquetConvertedTypeUint64, true
+		case reflect.Float64:
+			column.typ = parquetTypeDouble
+		default:
+			panic(fmt.Sprintf("unsupported parquet type %s of field %s", field.Type, strings.Join(fieldPath, ".")))
+		}
+
+		*schema = append(*schema, parquetSchemaElement{
+			name:          name,
+			typ:           column.typ,
+			convertedType: column.convertedType,
+			hasConverted:  column.hasConverted,
+		})
+		*columns = append(*columns, column)
+	}
+
+	return count
+}
+
+// parquetColumnChunk is the written column chunk of row group.
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+// parquetRowGroup is the written row group.
+type parquetRowGroup struct {
+	chunks  []parquetColumnChunk
+	size    int64
+	numRows int64
+	offset  int64
+}
+
+// parquetExporter exports records in parquet format, records are buffered
+// by columns and written as a row group when the buffer is full.
+type parquetExporter struct {
+	w         io.Writer
+	offset    int64
+	buffers   []bytes.Buffer
+	numRows   int64
+	rowGroups []parquetRowGroup
+}
+
+// newParquetExporter returns a new parquet exporter.
+func newParquetExporter(w io.Writer) *parquetExporter {
+	return &parquetExporter{
+		w:       w,
+		buffers: make([]bytes.Buffer, len(parquetColumns)),
+	}
+}
+
+// Write writes the record to exporter.
+func (e *parquetExporter) Write(record Record) error {
+	record.Parents = compactParents(record.Parents)
+	v := reflect.ValueOf(record)
+	for i, column := range parquetColumns {
+		if err := encodeParquetValue(&e.buffers[i], v.FieldByIndex(column.index)); err != nil {
+			return err
+		}
+	}
+
+	e.numRows++
+	if e.numRows < parquetRowGroupSize {
+		return nil
+	}
+
+	return e.flush()
+}
+
+// Close writes the buffered records and the footer of parquet file.
+func (e *parquetExporter) Close() error {
+	if e.numRows > 0 {
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+
+	if e.offset == 0 {
+		if err := e.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+	}
+
+	metadata := e.encodeFileMetaData()
+	if err := e.write(metadata); err != nil {
+		return err
+	}
+
+	footer := make([]byte, 4, 4+len(parquetMagic))
+	binary.LittleEndian.PutUint32(footer, uint32(len(metadata)))
+	return e.write(append(footer, parquetMagic...))
+}
+
+// flush writes the buffered records as a row group.
+func (e *parquetExporter) flush() error {
+	if e.offset == 0 {
+		if err := e.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+	}
+
+	rowGroup := parquetRowGroup{numRows: e.numRows, offset: e.offset}
+	for i := range parquetColumns {
+		data := e.buffers[i].Bytes()
+		header := encodeParquetPageHeader(int32(e.numRows), int32(len(data)))
+
+		chunk := parquetColumnChunk{offset: e.offset, size: int64(len(header) + len(data))}
+		if err := e.write(header); err != nil {
+			return err
+		}
+
+		if err := e.write(data); err != nil {
+			return err
+		}
+
+		rowGroup.chunks = append(rowGroup.chunks, chunk)
+		rowGroup.size += chunk.size
+		e.buffers[i].Reset()
+	}
+
+	e.rowGroups = append(e.rowGroups, rowGroup)
+	e.numRows = 0
+	return nil
+}
+
+// write writes data to writer and updates the offset.
+func (e *parquetExporter) write(data []byte) error {
+	n, err := e.w.Write(data)
+	e.offset += int64(n)
+	return err
+}
+
+// encodeFileMetaData encodes the FileMetaData of parquet file.
+func (e *parquetExporter) encodeFileMetaData() []byte {
+	var numRows int64
+	for _, rowGroup := range e.rowGroups {
+		numRows += rowGroup.numRows
+	}
+
+	t := &thriftCompactEncoder{}
+	t.structBegin()
+	t.i32(1, 1)
+	t.listBegin(2, thriftTypeStruct, len(parquetSchema))
+	for i, element := range parquetSchema {
+		t.structBegin()
+		if !element.group {
+			t.i32(1, element.typ)
+		}
+		// The root of schema has no repetition type.
+		if i > 0 {
+			t.i32(3, parquetRepetitionRequired)
+		}
+		t.binary(4, []byte(element.name))
+		if element.group {
+			t.i32(5, element.numChildren)
+		}
+		if element.hasConverted {
+			t.i32(6, element.convertedType)
+		}
+		t.structEnd()
+	}
+	t.i64(3, numRows)
+	t.listBegin(4, thriftTypeStruct, len(e.rowGroups))
+	for _, rowGroup := range e.rowGroups {
+		t.structBegin()
+		t.listBegin(1, thriftTypeStruct, len(rowGroup.chunks))
+		for i, chunk := range rowGroup.chunks {
+			column := parquetColumns[i]
+			t.structBegin()
+			t.i64(2, chunk.offset)
+			t.fieldStructBegin(3)
+			t.i32(1, column.typ)
+			t.listBegin(2, thriftTypeI32, 2)
+			t.i32Value(parquetEncodingPlain)
+			t.i32Value(parquetEncodingRLE)
+			t.listBegin(3, thriftTypeBinary, len(column.path))
+			for _, name := range column.path {
+				t.binaryValue([]byte(name))
+			}
+			t.i32(4, parquetCodecUncompressed)
+			t.i64(5, rowGroup.numRows)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, rowGroup.size)
+		t.i64(3, rowGroup.numRows)
+		t.i64(5, rowGroup.offset)
+		t.i64(6, rowGroup.size)
+		t.structEnd()
+	}
+	t.binary(6, []byte(parquetCreatedBy))
+	t.structEnd()
+	return t.bytes()
+}
+
+// encodeParquetPageHeader encodes the PageHeader of data page.
+func encodeParquetPageHeader(numValues, size int32) []byte {
+	t := &thriftCompactEncoder{}
+	t.structBegin()
+	t.i32(1, parquetPageTypeData)
+	t.i32(2, size)
+	t.i32(3, size)
+	t.fieldStructBegin(5)
+	t.i32(1, numValues)
+	t.i32(2, parquetEncodingPlain)
+	t.i32(3, parquetEncodingRLE)
+	t.i32(4, parquetEncodingRLE)
+	t.structEnd()
+	t.structEnd()
+	return t.bytes()
+}
+
+// encodeParquetValue encodes the value with PLAIN encoding.
+func encodeParquetValue(buf *bytes.Buffer, v reflect.Value) error {
+	var b [8]byte
+	switch v.Kind() {
+	case reflect.String:
+		binary.LittleEndian.PutUint32(b[:4], uint32(v.Len()))
+		buf.Write(b[:4])
+		buf.WriteString(v.String())
+	case reflect.Slice:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(data)))
+		buf.Write(b[:4])
+		buf.Write(data)
+	case reflect.Int32:
+		binary.LittleEndian.PutUint32(b[:4], uint32(v.Int()))
+		buf.Write(b[:4])
+	case reflect.Uint32:
+		binary.LittleEndian.PutUint32(b[:4], uint32(v.Uint()))
+		buf.Write(b[:4])
+	case reflect.Int64:
+		binary.LittleEndian.PutUint64(b[:], uint64(v.Int()))
+		buf.Write(b[:])
+	case reflect.Uint64:
+		binary.LittleEndian.PutUint64(b[:], v.Uint())
+		buf.Write(b[:])
+	case reflect.Float64:
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v.Float()))
+		buf.Write(b[:])
+	default:
+		return fmt.Errorf("unsupported parquet type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// thriftCompactDecoder decodes the thrift compact protocol, it is written
+// against the specification instead of the encoder, so that the files
+// written by the exporter are read back independently.
+type thriftCompactDecoder struct {
+	buf []byte
+	pos int
+}
+
+// byte reads a byte.
+func (d *thriftCompactDecoder) byte() byte {
+	b := d.buf[d.pos]
+	d.pos++
+	return b
+}
+
+// uvarint reads an unsigned varint.
+func (d *thriftCompactDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	if n <= 0 {
+		panic("invalid varint")
+	}
+
+	d.pos += n
+	return v
+}
+
+// varint reads a zigzag varint.
+func (d *thriftCompactDecoder) varint() int64 {
+	v := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// structValue reads a struct as the map of field id to value.
+func (d *thriftCompactDecoder) structValue() map[int16]any {
+	fields := map[int16]any{}
+	var lastFieldID int16
+	for {
+		header := d.byte()
+		if header == 0 {
+			return fields
+		}
+
+		typ := header & 0x0f
+		if delta := int16(header >> 4); delta != 0 {
+			lastFieldID += delta
+		} else {
+			lastFieldID = int16(d.varint())
+		}
+
+		// Bool fields are encoded in the type of field header.
+		switch typ {
+		case 1:
+			fields[lastFieldID] = true
+		case 2:
+			fields[lastFieldID] = false
+		default:
+			fields[lastFieldID] = d.value(typ)
+		}
+	}
+}
+
+// value reads a value of the type, maps are not used by parquet metadata.
+func (d *thriftCompactDecoder) value(typ byte) any {
+	switch typ {
+	case 1, 2:
+		return d.byte() == 1
+	case 3:
+		return int64(int8(d.byte()))
+	case 4, 5, 6:
+		return d.varint()
+	case 7:
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf[d.pos:]))
+		d.pos += 8
+		return v
+	case 8:
+		n := int(d.uvarint())
+		v := d.buf[d.pos : d.pos+n]
+		d.pos += n
+		return v
+	case 9, 10:
+		header := d.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(d.uvarint())
+		}
+
+		elems := make([]any, 0, size)
+		for i := 0; i < size; i++ {
+			elems = append(elems, d.value(header&0x0f))
+		}
+		return elems
+	case 12:
+		return d.structValue()
+	default:
+		panic("invalid thrift type " + strconv.Itoa(int(typ)))
+	}
+}
+
+// parquetFile is the content of parquet file read back.
+type parquetFile struct {
+	numRows   int64
+	rowGroups int
+	schema    []string
+	columns   map[string][]any
+}
+
+// readParquetFile reads the parquet file by the footer, and decodes the
+// PLAIN values of the data pages into the columns by the dotted path.
+func readParquetFile(t *testing.T, data []byte) *parquetFile {
+	assert := assert.New(t)
+	if !assert.Greater(len(data), 12) ||
+		!assert.Equal(string(data[:4]), parquetMagic) ||
+		!assert.Equal(string(data[len(data)-4:]), parquetMagic) {
+		t.FailNow()
+	}
+
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	metadata := (&thriftCompactDecoder{buf: data[len(data)-8-size : len(data)-8]}).structValue()
+	file := &parquetFile{
+		numRows: metadata[3].(int64),
+		columns: map[string][]any{},
+	}
+
+	for _, element := range metadata[2].([]any) {
+		file.schema = append(file.schema, string(element.(map[int16]any)[4].([]byte)))
+	}
+
+	for _, rawRowGroup := range metadata[4].([]any) {
+		rowGroup := rawRowGroup.(map[int16]any)
+		file.rowGroups++
+
+		for _, rawChunk := range rowGroup[1].([]any) {
+			chunk := rawChunk.(map[int16]any)[3].(map[int16]any)
+			var path []string
+			for _, name := range chunk[3].([]any) {
+				path = append(path, string(name.([]byte)))
+			}
+
+			d := &thriftCompactDecoder{buf: data, pos: int(chunk[9].(int64))}
+			header := d.structValue()
+			page := &thriftCompactDecoder{buf: data[d.pos : d.pos+int(header[3].(int64))]}
+			numValues := header[5].(map[int16]any)[1].(int64)
+			assert.Equal(numValues, rowGroup[3].(int64))
+
+			name := strings.Join(path, ".")
+			for i := int64(0); i < numValues; i++ {
+				file.columns[name] = append(file.columns[name], page.plainValue(int32(chunk[1].(int64))))
+			}
+			assert.Equal(page.pos, len(page.buf))
+		}
+	}
+
+	return file
+}
+
+// plainValue reads a PLAIN encoded value of the parquet type.
+func (d *thriftCompactDecoder) plainValue(typ int32) any {
+	switch typ {
+	case parquetTypeInt32:
+		v := int32(binary.LittleEndian.Uint32(d.buf[d.pos:]))
+		d.pos += 4
+		return v
+	case parquetTypeInt64:
+		v := int64(binary.LittleEndian.Uint64(d.buf[d.pos:]))
+		d.pos += 8
+		return v
+	case parquetTypeDouble:
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf[d.pos:]))
+		d.pos += 8
+		return v
+	case parquetTypeByteArray:
+		n := int(binary.LittleEndian.Uint32(d.buf[d.pos:]))
+		v := string(d.buf[d.pos+4 : d.pos+4+n])
+		d.pos += 4 + n
+		return v
+	default:
+		panic("invalid parquet type " + strconv.Itoa(int(typ)))
+	}
+}
+
+func TestParquet_ReadBack(t *testing.T) {
+	var records []Record
+	for i := 0; i <= parquetRowGroupSize; i++ {
+		records = append(records, Record{ID: strconv.Itoa(i), Cost: int64(i)})
+	}
+
+	tests := []struct {
+		name    string
+		records []Record
+		expect  func(t *testing.T, file *parquetFile)
+	}{
+		{
+			name:    "read back records",
+			records: []Record{mockRecord, {ID: "5"}},
+			expect: func(t *testing.T, file *parquetFile) {
+				assert := assert.New(t)
+				assert.Equal(file.numRows, int64(2))
+				assert.Equal(file.rowGroups, 1)
+				assert.Equal(len(file.schema), len(parquetSchema))
+				assert.Equal(len(file.columns), len(parquetColumns))
+				assert.EqualValues(file.columns["id"], []any{"4", "5"})
+				assert.EqualValues(file.columns["cost"], []any{mockRecord.Cost, int64(0)})
+				assert.EqualValues(file.columns["task.totalPieceCount"], []any{mockRecord.Task.TotalPieceCount, int32(0)})
+				assert.EqualValues(file.columns["host.network.idc"], []any{mockRecord.Host.Network.IDC, ""})
+
+				var parents []Parent
+				assert.NoError(json.Unmarshal([]byte(file.columns["parents"][0].(string)), &parents))
+				assert.EqualValues(parents, []Parent{mockParent})
+				assert.Equal(file.columns["parents"][1], "[]")
+			},
+		},
+		{
+			name:    "read back records of multiple row groups",
+			records: records,
+			expect: func(t *testing.T, file *parquetFile) {
+				assert := assert.New(t)
+				assert.Equal(file.numRows, int64(parquetRowGroupSize+1))
+				assert.Equal(file.rowGroups, 2)
+				assert.Equal(len(file.columns["id"]), parquetRowGroupSize+1)
+				assert.Equal(file.columns["id"][parquetRowGroupSize], strconv.Itoa(parquetRowGroupSize))
+				assert.Equal(file.columns["cost"][parquetRowGroupSize], int64(parquetRowGroupSize))
+			},
+		},
+		{
+			name:    "read back empty records",
+			records: nil,
+			expect: func(t *testing.T, file *parquetFile) {
+				assert := assert.New(t)
+				assert.Equal(file.numRows, int64(0))
+				assert.Equal(file.rowGroups, 0)
+				assert.Empty(file.columns)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter := newParquetExporter(&buf)
+			for _, record := range tc.records {
+				if err := exporter.Write(record); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := exporter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.expect(t, readParquetFile(t, buf.Bytes()))
+		})
+	}
+}
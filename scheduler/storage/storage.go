@@ -61,6 +61,10 @@ type Storage interface {
 	// List returns all of records in csv file.
 	List() ([]Record, error)
 
+	// Iterate calls fn for each record matches the filter, the backup files
+	// are loaded one by one, and iteration stops when fn returns error.
+	Iterate(filter Filter, fn func(Record) error) error
+
 	// Count returns the count of records.
 	Count() int64
 
@@ -176,6 +180,90 @@ func (s *storage) List() ([]Record, error) {
 	return records, nil
 }
 
+// Iterate calls fn for each record matches the filter, the backup files
+// are loaded one by one, and iteration stops when fn returns error.
+func (s *storage) Iterate(filter Filter, fn func(Record) error) error {
+	files, err := s.openBackups(filter)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, file := range files {
+			if err := file.Close(); err != nil {
+				logger.Error(err)
+			}
+		}
+	}()
+
+	for _, file := range files {
+		if err := iterateFile(file, filter, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// iterateFile decodes the records of file one by one and calls fn for each
+// record matches the filter, so the file is not loaded into memory at once.
+func iterateFile(r io.Reader, filter Filter, fn func(Record) error) error {
+	records := make(chan Record)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- gocsv.UnmarshalToChanWithoutHeaders(r, records)
+	}()
+
+	var fnErr error
+	for record := range records {
+		// The records are drained after fn returns error,
+		// so the decoding goroutine is not blocked.
+		if fnErr != nil || !filter.Match(&record) {
+			continue
+		}
+
+		fnErr = fn(record)
+	}
+
+	if err := <-errCh; err != nil {
+		return err
+	}
+
+	return fnErr
+}
+
+// openBackups opens the backup files that may have records match the filter,
+// the opened files are readable after rotation, so the lock is
+// not held during iteration.
+func (s *storage) openBackups(filter Filter) ([]*os.File, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fileInfos, err := s.backups()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*os.File
+	for _, fileInfo := range fileInfos {
+		if filter.skip(fileInfo.ModTime()) {
+			continue
+		}
+
+		file, err := os.Open(filepath.Join(s.baseDir, fileInfo.Name()))
+		if err != nil {
+			for _, file := range files {
+				file.Close()
+			}
+
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
 // Count returns the count of records.
 func (s *storage) Count() int64 {
 	return s.count
@@ -17,6 +17,7 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
@@ -398,6 +399,170 @@ func TestStorage_List(t *testing.T) {
 	}
 }
 
+func TestStorage_Iterate(t *testing.T) {
+	tests := []struct {
+		name       string
+		baseDir    string
+		bufferSize int
+		filter     Filter
+		mock       func(t *testing.T, s Storage, baseDir string)
+		expect     func(t *testing.T, s Storage, filter Filter)
+	}{
+		{
+			name:       "get file infos failed",
+			baseDir:    os.TempDir(),
+			bufferSize: config.DefaultStorageBufferSize,
+			mock: func(t *testing.T, s Storage, baseDir string) {
+				s.(*storage).baseDir = "baz"
+			},
+			expect: func(t *testing.T, s Storage, filter Filter) {
+				assert := assert.New(t)
+				assert.Error(s.Iterate(filter, func(Record) error { return nil }))
+			},
+		},
+		{
+			name:       "iterate records of multi files",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			mock: func(t *testing.T, s Storage, baseDir string) {
+				file, err := os.OpenFile(filepath.Join(baseDir, "record-test.csv"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer file.Close()
+
+				if err := gocsv.MarshalWithoutHeaders([]Record{{ID: "2"}}, file); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.Create(Record{ID: "1"}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.Create(Record{ID: "3"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, s Storage, filter Filter) {
+				assert := assert.New(t)
+				var ids []string
+				assert.NoError(s.Iterate(filter, func(record Record) error {
+					ids = append(ids, record.ID)
+					return nil
+				}))
+				assert.EqualValues(ids, []string{"2", "1"})
+			},
+		},
+		{
+			name:       "iterate records match the filter",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			filter:     Filter{TaskID: mockTask.ID},
+			mock: func(t *testing.T, s Storage, baseDir string) {
+				if err := s.Create(Record{ID: "1"}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.Create(mockRecord); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.Create(Record{ID: "3"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, s Storage, filter Filter) {
+				assert := assert.New(t)
+				var records []Record
+				assert.NoError(s.Iterate(filter, func(record Record) error {
+					records = append(records, record)
+					return nil
+				}))
+				assert.Equal(len(records), 1)
+				assert.EqualValues(records[0], mockRecord)
+			},
+		},
+		{
+			name:       "skip files modified before the start time",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			filter:     Filter{StartTime: time.Now().Add(time.Hour)},
+			mock: func(t *testing.T, s Storage, baseDir string) {
+				if err := s.Create(Record{ID: "1"}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.Create(Record{ID: "2"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, s Storage, filter Filter) {
+				assert := assert.New(t)
+				assert.NoError(s.Iterate(filter, func(record Record) error {
+					return fmt.Errorf("unexpected record %s", record.ID)
+				}))
+			},
+		},
+		{
+			name:       "stop iteration when fn returns error",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			mock: func(t *testing.T, s Storage, baseDir string) {
+				for _, id := range []string{"1", "2", "3"} {
+					if err := s.Create(Record{ID: id}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			expect: func(t *testing.T, s Storage, filter Filter) {
+				assert := assert.New(t)
+				var count int
+				assert.EqualError(s.Iterate(filter, func(record Record) error {
+					count++
+					return errors.New("foo")
+				}), "foo")
+				assert.Equal(count, 1)
+			},
+		},
+		{
+			name:       "decode records failed",
+			baseDir:    os.TempDir(),
+			bufferSize: 1,
+			mock: func(t *testing.T, s Storage, baseDir string) {
+				if err := s.Create(Record{ID: "1"}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(filepath.Join(baseDir, "record-test.csv"), []byte("1,a,b,c,d,e,foo\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expect: func(t *testing.T, s Storage, filter Filter) {
+				assert := assert.New(t)
+				assert.Error(s.Iterate(filter, func(record Record) error {
+					return nil
+				}))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(tc.baseDir, config.DefaultStorageMaxSize, config.DefaultStorageMaxBackups, tc.bufferSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.mock(t, s, tc.baseDir)
+			tc.expect(t, s, tc.filter)
+			s.(*storage).baseDir = tc.baseDir
+			if err := s.Clear(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func TestStorage_Open(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -0,0 +1,122 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import "encoding/binary"
+
+// Types of thrift compact protocol.
+const (
+	thriftTypeI32    byte = 5
+	thriftTypeI64    byte = 6
+	thriftTypeBinary byte = 8
+	thriftTypeList   byte = 9
+	thriftTypeStruct byte = 12
+)
+
+// thriftCompactEncoder encodes the thrift compact protocol used by parquet metadata,
+// refer to https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md.
+type thriftCompactEncoder struct {
+	buf []byte
+
+	// lastFieldID is the last field id of current struct.
+	lastFieldID int16
+
+	// lastFieldIDs is the stack of last field ids of the outer structs.
+	lastFieldIDs []int16
+}
+
+// bytes returns the encoded data.
+func (t *thriftCompactEncoder) bytes() []byte {
+	return t.buf
+}
+
+// structBegin begins a struct, the struct of field is begun by fieldStructBegin.
+func (t *thriftCompactEncoder) structBegin() {
+	t.lastFieldIDs = append(t.lastFieldIDs, t.lastFieldID)
+	t.lastFieldID = 0
+}
+
+// structEnd writes the stop field and ends the struct.
+func (t *thriftCompactEncoder) structEnd() {
+	t.buf = append(t.buf, 0)
+	t.lastFieldID = t.lastFieldIDs[len(t.lastFieldIDs)-1]
+	t.lastFieldIDs = t.lastFieldIDs[:len(t.lastFieldIDs)-1]
+}
+
+// fieldStructBegin begins a struct field.
+func (t *thriftCompactEncoder) fieldStructBegin(id int16) {
+	t.fieldHeader(id, thriftTypeStruct)
+	t.structBegin()
+}
+
+// i32 writes an i32 field.
+func (t *thriftCompactEncoder) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftTypeI32)
+	t.i32Value(v)
+}
+
+// i64 writes an i64 field.
+func (t *thriftCompactEncoder) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftTypeI64)
+	t.buf = binary.AppendUvarint(t.buf, zigzag(v))
+}
+
+// binary writes a binary field.
+func (t *thriftCompactEncoder) binary(id int16, v []byte) {
+	t.fieldHeader(id, thriftTypeBinary)
+	t.binaryValue(v)
+}
+
+// listBegin writes a list field header, the elements are written after it.
+func (t *thriftCompactEncoder) listBegin(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftTypeList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elemType)
+		return
+	}
+
+	t.buf = append(t.buf, 0xf0|elemType)
+	t.buf = binary.AppendUvarint(t.buf, uint64(size))
+}
+
+// i32Value writes an i32 value without field header.
+func (t *thriftCompactEncoder) i32Value(v int32) {
+	t.buf = binary.AppendUvarint(t.buf, zigzag(int64(v)))
+}
+
+// binaryValue writes a binary value without field header.
+func (t *thriftCompactEncoder) binaryValue(v []byte) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
+
+// fieldHeader writes the field header with delta encoding of field id.
+func (t *thriftCompactEncoder) fieldHeader(id int16, typ byte) {
+	if delta := id - t.lastFieldID; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendUvarint(t.buf, zigzag(int64(id)))
+	}
+
+	t.lastFieldID = id
+}
+
+// zigzag returns the zigzag encoding of v.
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
@@ -21,312 +21,315 @@ import "time"
 // Task contains content for task.
 type Task struct {
 	// ID is task id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// URL is task download url.
-	URL string `csv:"url"`
+	URL string `csv:"url" json:"url"`
 
 	// Type is task type.
-	Type string `csv:"type"`
+	Type string `csv:"type" json:"type"`
 
 	// ContentLength is task total content length.
-	ContentLength int64 `csv:"contentLength"`
+	ContentLength int64 `csv:"contentLength" json:"contentLength"`
 
 	// TotalPieceCount is total piece count.
-	TotalPieceCount int32 `csv:"totalPieceCount"`
+	TotalPieceCount int32 `csv:"totalPieceCount" json:"totalPieceCount"`
 
 	// BackToSourceLimit is back-to-source limit.
-	BackToSourceLimit int32 `csv:"backToSourceLimit"`
+	BackToSourceLimit int32 `csv:"backToSourceLimit" json:"backToSourceLimit"`
 
 	// BackToSourcePeerCount is back-to-source peer count.
-	BackToSourcePeerCount int32 `csv:"backToSourcePeerCount"`
+	BackToSourcePeerCount int32 `csv:"backToSourcePeerCount" json:"backToSourcePeerCount"`
 
 	// State is the download state of the task.
-	State string `csv:"state"`
+	State string `csv:"state" json:"state"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
 }
 
 // Host contains content for host.
 type Host struct {
 	// ID is host id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// Type is host type.
-	Type string `csv:"type"`
+	Type string `csv:"type" json:"type"`
 
 	// Hostname is host name.
-	Hostname string `csv:"hostname"`
+	Hostname string `csv:"hostname" json:"hostname"`
 
 	// IP is host ip.
-	IP string `csv:"ip"`
+	IP string `csv:"ip" json:"ip"`
 
 	// Port is grpc service port.
-	Port int32 `csv:"port"`
+	Port int32 `csv:"port" json:"port"`
 
 	// DownloadPort is piece downloading port.
-	DownloadPort int32 `csv:"downloadPort"`
+	DownloadPort int32 `csv:"downloadPort" json:"downloadPort"`
 
 	// Host OS.
-	OS string `csv:"os"`
+	OS string `csv:"os" json:"os"`
 
 	// Host platform.
-	Platform string `csv:"platform"`
+	Platform string `csv:"platform" json:"platform"`
 
 	// Host platform family.
-	PlatformFamily string `csv:"platformFamily"`
+	PlatformFamily string `csv:"platformFamily" json:"platformFamily"`
 
 	// Host platform version.
-	PlatformVersion string `csv:"platformVersion"`
+	PlatformVersion string `csv:"platformVersion" json:"platformVersion"`
 
 	// Host kernel version.
-	KernelVersion string `csv:"kernelVersion"`
+	KernelVersion string `csv:"kernelVersion" json:"kernelVersion"`
 
 	// ConcurrentUploadLimit is concurrent upload limit count.
-	ConcurrentUploadLimit int32 `csv:"concurrentUploadLimit"`
+	ConcurrentUploadLimit int32 `csv:"concurrentUploadLimit" json:"concurrentUploadLimit"`
 
 	// ConcurrentUploadCount is concurrent upload count.
-	ConcurrentUploadCount int32 `csv:"concurrentUploadCount"`
+	ConcurrentUploadCount int32 `csv:"concurrentUploadCount" json:"concurrentUploadCount"`
 
 	// UploadCount is total upload count.
-	UploadCount int64 `csv:"uploadCount"`
+	UploadCount int64 `csv:"uploadCount" json:"uploadCount"`
 
 	// UploadFailedCount is upload failed count.
-	UploadFailedCount int64 `csv:"uploadFailedCount"`
+	UploadFailedCount int64 `csv:"uploadFailedCount" json:"uploadFailedCount"`
 
 	// CPU Stat.
-	CPU CPU `csv:"cpu"`
+	CPU CPU `csv:"cpu" json:"cpu"`
 
 	// Memory Stat.
-	Memory Memory `csv:"memory"`
+	Memory Memory `csv:"memory" json:"memory"`
 
 	// Network Stat.
-	Network Network `csv:"network"`
+	Network Network `csv:"network" json:"network"`
 
 	// Disk Stat.
-	Disk Disk `csv:"disk"`
+	Disk Disk `csv:"disk" json:"disk"`
 
 	// Build information.
-	Build Build `csv:"build"`
+	Build Build `csv:"build" json:"build"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
 }
 
 // CPU contains content for cpu.
 type CPU struct {
 	// Number of logical cores in the system.
-	LogicalCount uint32 `csv:"logicalCount"`
+	LogicalCount uint32 `csv:"logicalCount" json:"logicalCount"`
 
 	// Number of physical cores in the system.
-	PhysicalCount uint32 `csv:"physicalCount"`
+	PhysicalCount uint32 `csv:"physicalCount" json:"physicalCount"`
 
 	// Percent calculates the percentage of cpu used.
-	Percent float64 `csv:"percent"`
+	Percent float64 `csv:"percent" json:"percent"`
 
 	// Calculates the percentage of cpu used by process.
-	ProcessPercent float64 `csv:"processPercent"`
+	ProcessPercent float64 `csv:"processPercent" json:"processPercent"`
 
 	// Times contains the amounts of time the CPU has spent performing different kinds of work.
-	Times CPUTimes `csv:"times"`
+	Times CPUTimes `csv:"times" json:"times"`
 }
 
 // CPUTimes contains content for cpu times.
 type CPUTimes struct {
 	// CPU time of user.
-	User float64 `csv:"user"`
+	User float64 `csv:"user" json:"user"`
 
 	// CPU time of system.
-	System float64 `csv:"system"`
+	System float64 `csv:"system" json:"system"`
 
 	// CPU time of idle.
-	Idle float64 `csv:"idle"`
+	Idle float64 `csv:"idle" json:"idle"`
 
 	// CPU time of nice.
-	Nice float64 `csv:"nice"`
+	Nice float64 `csv:"nice" json:"nice"`
 
 	// CPU time of iowait.
-	Iowait float64 `csv:"iowait"`
+	Iowait float64 `csv:"iowait" json:"iowait"`
 
 	// CPU time of irq.
-	Irq float64 `csv:"irq"`
+	Irq float64 `csv:"irq" json:"irq"`
 
 	// CPU time of softirq.
-	Softirq float64 `csv:"softirq"`
+	Softirq float64 `csv:"softirq" json:"softirq"`
 
 	// CPU time of steal.
-	Steal float64 `csv:"steal"`
+	Steal float64 `csv:"steal" json:"steal"`
 
 	// CPU time of guest.
-	Guest float64 `csv:"guest"`
+	Guest float64 `csv:"guest" json:"guest"`
 
 	// CPU time of guest nice.
-	GuestNice float64 `csv:"guestNice"`
+	GuestNice float64 `csv:"guestNice" json:"guestNice"`
 }
 
 // Memory contains content for memory.
 type Memory struct {
 	// Total amount of RAM on this system.
-	Total uint64 `csv:"total"`
+	Total uint64 `csv:"total" json:"total"`
 
 	// RAM available for programs to allocate.
-	Available uint64 `csv:"available"`
+	Available uint64 `csv:"available" json:"available"`
 
 	// RAM used by programs.
-	Used uint64 `csv:"used"`
+	Used uint64 `csv:"used" json:"used"`
 
 	// Percentage of RAM used by programs.
-	UsedPercent float64 `csv:"usedPercent"`
+	UsedPercent float64 `csv:"usedPercent" json:"usedPercent"`
 
 	// Calculates the percentage of memory used by process.
-	ProcessUsedPercent float64 `csv:"processUsedPercent"`
+	ProcessUsedPercent float64 `csv:"processUsedPercent" json:"processUsedPercent"`
 
 	// This is the kernel's notion of free memory.
-	Free uint64 `csv:"free"`
+	Free uint64 `csv:"free" json:"free"`
 }
 
 // Network contains content for network.
 type Network struct {
 	// Return count of tcp connections opened and status is ESTABLISHED.
-	TCPConnectionCount uint32 `csv:"tcpConnectionCount"`
+	TCPConnectionCount uint32 `csv:"tcpConnectionCount" json:"tcpConnectionCount"`
 
 	// Return count of upload tcp connections opened and status is ESTABLISHED.
-	UploadTCPConnectionCount uint32 `csv:"uploadTCPConnectionCount"`
+	UploadTCPConnectionCount uint32 `csv:"uploadTCPConnectionCount" json:"uploadTCPConnectionCount"`
 
 	// Security domain for network.
-	SecurityDomain string `csv:"securityDomain"`
+	SecurityDomain string `csv:"securityDomain" json:"securityDomain"`
 
 	// Location path(area|country|province|city|...).
-	Location string `csv:"location"`
+	Location string `csv:"location" json:"location"`
 
 	// IDC where the peer host is located
-	IDC string `csv:"idc"`
+	IDC string `csv:"idc" json:"idc"`
 
 	// Network topology(switch|router|...).
-	NetTopology string `csv:"netTopology"`
+	NetTopology string `csv:"netTopology" json:"netTopology"`
 }
 
 // Build contains content for build.
 type Build struct {
 	// Git version.
-	GitVersion string `csv:"gitVersion"`
+	GitVersion string `csv:"gitVersion" json:"gitVersion"`
 
 	// Git commit.
-	GitCommit string `csv:"gitCommit"`
+	GitCommit string `csv:"gitCommit" json:"gitCommit"`
 
 	// Golang version.
-	GoVersion string `csv:"goVersion"`
+	GoVersion string `csv:"goVersion" json:"goVersion"`
 
 	// Build platform.
-	Platform string `csv:"platform"`
+	Platform string `csv:"platform" json:"platform"`
 }
 
 // Disk contains content for disk.
 type Disk struct {
 	// Total amount of disk on the data path of dragonfly.
-	Total uint64 `csv:"total"`
+	Total uint64 `csv:"total" json:"total"`
 
 	// Free amount of disk on the data path of dragonfly.
-	Free uint64 `csv:"free"`
+	Free uint64 `csv:"free" json:"free"`
 
 	// Used amount of disk on the data path of dragonfly.
-	Used uint64 `csv:"used"`
+	Used uint64 `csv:"used" json:"used"`
 
 	// Used percent of disk on the data path of dragonfly directory.
-	UsedPercent float64 `csv:"usedPercent"`
+	UsedPercent float64 `csv:"usedPercent" json:"usedPercent"`
 
 	// Total amount of indoes on the data path of dragonfly directory.
-	InodesTotal uint64 `csv:"inodesTotal"`
+	InodesTotal uint64 `csv:"inodesTotal" json:"inodesTotal"`
 
 	// Used amount of indoes on the data path of dragonfly directory.
-	InodesUsed uint64 `csv:"inodesUsed"`
+	InodesUsed uint64 `csv:"inodesUsed" json:"inodesUsed"`
 
 	// Free amount of indoes on the data path of dragonfly directory.
-	InodesFree uint64 `csv:"inodesFree"`
+	InodesFree uint64 `csv:"inodesFree" json:"inodesFree"`
 
 	// Used percent of indoes on the data path of dragonfly directory.
-	InodesUsedPercent float64 `csv:"inodesUsedPercent"`
+	InodesUsedPercent float64 `csv:"inodesUsedPercent" json:"inodesUsedPercent"`
 }
 
 // Parent contains content for parent.
 type Parent struct {
 	// ID is peer id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// Tag is peer tag.
-	Tag string `csv:"tag"`
+	Tag string `csv:"tag" json:"tag"`
 
 	// Application is peer application.
-	Application string `csv:"application"`
+	Application string `csv:"application" json:"application"`
 
 	// State is the download state of the peer.
-	State string `csv:"state"`
+	State string `csv:"state" json:"state"`
 
 	// Cost is the task download duration of nanosecond.
-	Cost int64 `csv:"cost"`
+	Cost int64 `csv:"cost" json:"cost"`
 
 	// UploadPieceCount is upload piece count.
-	UploadPieceCount int32 `csv:"uploadPieceCount"`
+	UploadPieceCount int32 `csv:"uploadPieceCount" json:"uploadPieceCount"`
+
+	// PieceCost is the mean piece download duration of millisecond.
+	PieceCost int64 `csv:"pieceCost" json:"pieceCost"`
 
 	// Host is peer host.
-	Host Host `csv:"host"`
+	Host Host `csv:"host" json:"host"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
 }
 
 // Error contains content for error.
 type Error struct {
 	time.Duration
 	// Code is the code of error.
-	Code string `csv:"code"`
+	Code string `csv:"code" json:"code"`
 
 	// Message is the message of error.
-	Message string `csv:"message"`
+	Message string `csv:"message" json:"message"`
 }
 
 // Record contains content for record.
 type Record struct {
 	// ID is peer id.
-	ID string `csv:"id"`
+	ID string `csv:"id" json:"id"`
 
 	// Tag is peer tag.
-	Tag string `csv:"tag"`
+	Tag string `csv:"tag" json:"tag"`
 
 	// Application is peer application.
-	Application string `csv:"application"`
+	Application string `csv:"application" json:"application"`
 
 	// State is the download state of the peer.
-	State string `csv:"state"`
+	State string `csv:"state" json:"state"`
 
 	// Error is the details of error.
-	Error Error `csv:"error"`
+	Error Error `csv:"error" json:"error"`
 
 	// Cost is the task download duration of nanosecond.
-	Cost int64 `csv:"cost"`
+	Cost int64 `csv:"cost" json:"cost"`
 
 	// Task is peer task.
-	Task Task `csv:"task"`
+	Task Task `csv:"task" json:"task"`
 
 	// Host is peer host.
-	Host Host `csv:"host"`
+	Host Host `csv:"host" json:"host"`
 
 	// Parents is peer parents.
-	Parents []Parent `csv:"parents" csv[]:"20"`
+	Parents []Parent `csv:"parents" csv[]:"20" json:"parents"`
 
 	// CreatedAt is peer create nanosecond time.
-	CreatedAt int64 `csv:"createdAt"`
+	CreatedAt int64 `csv:"createdAt" json:"createdAt"`
 
 	// UpdatedAt is peer update nanosecond time.
-	UpdatedAt int64 `csv:"updatedAt"`
+	UpdatedAt int64 `csv:"updatedAt" json:"updatedAt"`
 }
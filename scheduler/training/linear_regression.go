@@ -0,0 +1,200 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package training
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"d7y.io/dragonfly/v2/scheduler/scheduler/evaluator"
+)
+
+const (
+	// ridgeLambda is the L2 regularization of weights, it keeps the normal
+	// equations solvable when features are constant or collinear.
+	ridgeLambda = 1e-3
+
+	// minPivot is the minimum absolute pivot of gaussian elimination.
+	minPivot = 1e-12
+)
+
+// metrics is the metrics of model evaluated by the test set.
+type metrics struct {
+	mae  float64
+	mse  float64
+	rmse float64
+	r2   float64
+}
+
+// fitLinearRegression fits the linear regression model by solving the ridge
+// normal equations, returns the weights of features and the bias. The normal
+// equations are accumulated by cpu workers concurrently.
+func fitLinearRegression(features [][]float64, labels []float64, cpu int) ([]float64, float64, error) {
+	if len(features) == 0 || len(features) != len(labels) {
+		return nil, 0, errors.New("invalid samples")
+	}
+
+	if cpu <= 0 {
+		cpu = 1
+	}
+
+	// The last column of design matrix is the bias.
+	n := len(features[0]) + 1
+	var (
+		xtx = newMatrix(n, n)
+		xty = make([]float64, n)
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+	)
+
+	chunkSize := (len(features) + cpu - 1) / cpu
+	for start := 0; start < len(features); start += chunkSize {
+		end := start + chunkSize
+		if end > len(features) {
+			end = len(features)
+		}
+
+		wg.Add(1)
+		go func(features [][]float64, labels []float64) {
+			defer wg.Done()
+
+			partialXTX := newMatrix(n, n)
+			partialXTY := make([]float64, n)
+			row := make([]float64, n)
+			for i, feature := range features {
+				copy(row, feature)
+				row[n-1] = 1
+
+				for j := 0; j < n; j++ {
+					partialXTY[j] += row[j] * labels[i]
+					for k := 0; k < n; k++ {
+						partialXTX[j][k] += row[j] * row[k]
+					}
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for j := 0; j < n; j++ {
+				xty[j] += partialXTY[j]
+				for k := 0; k < n; k++ {
+					xtx[j][k] += partialXTX[j][k]
+				}
+			}
+		}(features[start:end], labels[start:end])
+	}
+	wg.Wait()
+
+	// Bias is not regularized.
+	for i := 0; i < n-1; i++ {
+		xtx[i][i] += ridgeLambda * float64(len(features))
+	}
+
+	coefficients, err := solve(xtx, xty)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return coefficients[:n-1], coefficients[n-1], nil
+}
+
+// solve solves the linear equations ax = b by gaussian elimination with partial pivoting.
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	for i := 0; i < n; i++ {
+		pivot := i
+		for j := i + 1; j < n; j++ {
+			if math.Abs(a[j][i]) > math.Abs(a[pivot][i]) {
+				pivot = j
+			}
+		}
+
+		if math.Abs(a[pivot][i]) < minPivot {
+			return nil, errors.New("singular matrix")
+		}
+
+		a[i], a[pivot] = a[pivot], a[i]
+		b[i], b[pivot] = b[pivot], b[i]
+
+		for j := i + 1; j < n; j++ {
+			factor := a[j][i] / a[i][i]
+			for k := i; k < n; k++ {
+				a[j][k] -= factor * a[i][k]
+			}
+			b[j] -= factor * b[i]
+		}
+	}
+
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := b[i]
+		for j := i + 1; j < n; j++ {
+			sum -= a[i][j] * x[j]
+		}
+		x[i] = sum / a[i][i]
+	}
+
+	return x, nil
+}
+
+// evaluate returns the metrics of model evaluated by the test set.
+func evaluate(model *evaluator.Model, features [][]float64, labels []float64) metrics {
+	if len(labels) == 0 {
+		return metrics{}
+	}
+
+	var mean float64
+	for _, label := range labels {
+		mean += label
+	}
+	mean /= float64(len(labels))
+
+	var absoluteError, squaredError, totalSquares float64
+	for i, feature := range features {
+		values := make(map[string]float64, len(evaluator.Features))
+		for j, name := range evaluator.Features {
+			values[name] = feature[j]
+		}
+
+		residual := labels[i] - model.Predict(values)
+		absoluteError += math.Abs(residual)
+		squaredError += residual * residual
+		totalSquares += (labels[i] - mean) * (labels[i] - mean)
+	}
+
+	m := metrics{
+		mae: absoluteError / float64(len(labels)),
+		mse: squaredError / float64(len(labels)),
+	}
+	m.rmse = math.Sqrt(m.mse)
+	if totalSquares > 0 {
+		m.r2 = 1 - squaredError/totalSquares
+	}
+
+	return m
+}
+
+// newMatrix returns a zero matrix with rows and cols.
+func newMatrix(rows, cols int) [][]float64 {
+	matrix := make([][]float64, rows)
+	for i := range matrix {
+		matrix[i] = make([]float64, cols)
+	}
+
+	return matrix
+}
@@ -0,0 +1,134 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package training
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/scheduler/scheduler/evaluator"
+)
+
+func TestLinearRegression_fitLinearRegression(t *testing.T) {
+	tests := []struct {
+		name     string
+		features [][]float64
+		labels   []float64
+		cpu      int
+		expect   func(t *testing.T, weights []float64, bias float64, err error)
+	}{
+		{
+			name: "fit linear function",
+			features: func() [][]float64 {
+				var features [][]float64
+				for i := 0; i < 100; i++ {
+					features = append(features, []float64{float64(i%10) / 10, float64(i/10) / 10})
+				}
+				return features
+			}(),
+			labels: func() []float64 {
+				var labels []float64
+				for i := 0; i < 100; i++ {
+					labels = append(labels, 0.5*float64(i%10)/10+0.25*float64(i/10)/10+0.1)
+				}
+				return labels
+			}(),
+			cpu: 3,
+			expect: func(t *testing.T, weights []float64, bias float64, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.Equal(len(weights), 2)
+				assert.InDelta(weights[0], 0.5, 1e-2)
+				assert.InDelta(weights[1], 0.25, 1e-2)
+				assert.InDelta(bias, 0.1, 1e-2)
+			},
+		},
+		{
+			name:     "feature is constant",
+			features: [][]float64{{1}, {1}, {1}, {1}},
+			labels:   []float64{0.2, 0.4, 0.2, 0.4},
+			cpu:      1,
+			expect: func(t *testing.T, weights []float64, bias float64, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+				assert.InDelta(weights[0]+bias, 0.3, 1e-2)
+			},
+		},
+		{
+			name:     "samples are empty",
+			features: [][]float64{},
+			labels:   []float64{},
+			cpu:      1,
+			expect: func(t *testing.T, weights []float64, bias float64, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid samples")
+			},
+		},
+		{
+			name:     "samples are mismatched",
+			features: [][]float64{{1}},
+			labels:   []float64{},
+			cpu:      1,
+			expect: func(t *testing.T, weights []float64, bias float64, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "invalid samples")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			weights, bias, err := fitLinearRegression(tc.features, tc.labels, tc.cpu)
+			tc.expect(t, weights, bias, err)
+		})
+	}
+}
+
+func TestLinearRegression_solve(t *testing.T) {
+	x, err := solve([][]float64{{0, 1}, {2, 0}}, []float64{3, 4})
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.InDeltaSlice(x, []float64{2, 3}, 1e-9)
+
+	_, err = solve([][]float64{{1, 1}, {1, 1}}, []float64{1, 1})
+	assert.EqualError(err, "singular matrix")
+}
+
+func TestLinearRegression_evaluate(t *testing.T) {
+	model := &evaluator.Model{
+		Type:    evaluator.ModelTypeLinear,
+		Weights: map[string]float64{evaluator.FeatureFinishedPiece: 1},
+	}
+
+	features := make([][]float64, 2)
+	for i := range features {
+		features[i] = make([]float64, len(evaluator.Features))
+		features[i][0] = float64(i)
+	}
+
+	assert := assert.New(t)
+	assert.Equal(evaluator.Features[0], evaluator.FeatureFinishedPiece)
+	assert.Equal(evaluate(model, features, []float64{0, 1}), metrics{r2: 1})
+
+	m := evaluate(model, features, []float64{1, 0})
+	assert.Equal(m.mae, float64(1))
+	assert.Equal(m.mse, float64(1))
+	assert.Equal(m.rmse, float64(1))
+	assert.Equal(m.r2, float64(-3))
+	assert.Equal(evaluate(model, nil, nil), metrics{})
+}
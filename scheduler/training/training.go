@@ -0,0 +1,293 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+//go:generate mockgen -destination mocks/training_mock.go -source training.go -package mocks
+
+package training
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	managerv1 "d7y.io/api/pkg/apis/manager/v1"
+
+	logger "d7y.io/dragonfly/v2/internal/dflog"
+	"d7y.io/dragonfly/v2/manager/types"
+	managerclient "d7y.io/dragonfly/v2/pkg/rpc/manager/client"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/scheduler/evaluator"
+	"d7y.io/dragonfly/v2/scheduler/storage"
+)
+
+const (
+	// trainTimeout is the timeout of training model.
+	trainTimeout = 30 * time.Minute
+
+	// minSampleCount is the minimum count of samples for training model.
+	minSampleCount = 100
+
+	// testSampleInterval is the interval of sampling test set from samples,
+	// every fifth sample is used to evaluate the model.
+	testSampleInterval = 5
+
+	// referencePieceCost is the piece cost which scores 0.5 in the label,
+	// the faster the pieces are downloaded, the closer the score is to 1.
+	referencePieceCost = float64(time.Second)
+)
+
+// Training is the interface used for training model.
+type Training interface {
+	// Started training server, it trains model periodically.
+	Serve() error
+
+	// Stop training server.
+	Stop() error
+
+	// Train trains model by the download records and creates the model version in manager.
+	Train(ctx context.Context) error
+}
+
+// training provides training function.
+type training struct {
+	config        *config.Config
+	managerClient managerclient.V1
+	dynconfig     config.DynconfigInterface
+	storage       storage.Storage
+	done          chan struct{}
+}
+
+// New returns a new Training interface.
+func New(cfg *config.Config, managerClient managerclient.V1, dynconfig config.DynconfigInterface, storage storage.Storage) Training {
+	return &training{
+		config:        cfg,
+		managerClient: managerClient,
+		dynconfig:     dynconfig,
+		storage:       storage,
+		done:          make(chan struct{}),
+	}
+}
+
+// Started training server.
+func (t *training) Serve() error {
+	tick := time.NewTicker(t.config.Scheduler.Training.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			ctx, cancel := context.WithTimeout(context.Background(), trainTimeout)
+			if err := t.Train(ctx); err != nil {
+				logger.Errorf("train model failed: %s", err.Error())
+			}
+			cancel()
+		case <-t.done:
+			return nil
+		}
+	}
+}
+
+// Stop training server.
+func (t *training) Stop() error {
+	close(t.done)
+	return nil
+}
+
+// Train trains model by the download records and creates the model version in manager,
+// the model version is activated when it is better than the active version.
+func (t *training) Train(ctx context.Context) error {
+	scheduler, err := t.dynconfig.GetScheduler()
+	if err != nil {
+		return err
+	}
+
+	features, labels, err := t.loadSamples()
+	if err != nil {
+		return err
+	}
+
+	if len(labels) < minSampleCount {
+		return fmt.Errorf("insufficient samples %d, at least %d samples are required", len(labels), minSampleCount)
+	}
+
+	var (
+		trainFeatures [][]float64
+		trainLabels   []float64
+		testFeatures  [][]float64
+		testLabels    []float64
+	)
+	for i := range labels {
+		if i%testSampleInterval == testSampleInterval-1 {
+			testFeatures = append(testFeatures, features[i])
+			testLabels = append(testLabels, labels[i])
+			continue
+		}
+
+		trainFeatures = append(trainFeatures, features[i])
+		trainLabels = append(trainLabels, labels[i])
+	}
+
+	weights, bias, err := fitLinearRegression(trainFeatures, trainLabels, t.config.Scheduler.Training.CPU)
+	if err != nil {
+		return err
+	}
+
+	model := &evaluator.Model{
+		Type:    evaluator.ModelTypeLinear,
+		Weights: make(map[string]float64, len(evaluator.Features)),
+		Bias:    bias,
+	}
+	for i, feature := range evaluator.Features {
+		model.Weights[feature] = weights[i]
+	}
+
+	if err := model.Validate(); err != nil {
+		return err
+	}
+
+	data, err := model.Marshal()
+	if err != nil {
+		return err
+	}
+
+	metric := evaluate(model, testFeatures, testLabels)
+	version, err := t.managerClient.CreateModelVersion(ctx, &managerv1.CreateModelVersionRequest{
+		SchedulerId: scheduler.Id,
+		ModelId:     types.ModelIDEvaluator,
+		Data:        data,
+		Mae:         metric.mae,
+		Mse:         metric.mse,
+		Rmse:        metric.rmse,
+		R2:          metric.r2,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("create model version %s with %d samples, mae: %f, mse: %f, rmse: %f, r2: %f",
+		version.VersionId, len(labels), metric.mae, metric.mse, metric.rmse, metric.r2)
+
+	return t.activate(ctx, scheduler, version)
+}
+
+// activate activates the model version when it is better than the active version.
+func (t *training) activate(ctx context.Context, scheduler *managerv1.Scheduler, version *managerv1.ModelVersion) error {
+	model, err := t.managerClient.GetModel(ctx, &managerv1.GetModelRequest{
+		SchedulerId: scheduler.Id,
+		ModelId:     types.ModelIDEvaluator,
+	})
+	if err != nil {
+		// Model of scheduler has not been created, then create model with the version.
+		logger.Infof("get model failed: %s, create model with version %s", err.Error(), version.VersionId)
+		if _, err := t.managerClient.CreateModel(ctx, &managerv1.CreateModelRequest{
+			ModelId:     types.ModelIDEvaluator,
+			Name:        types.ModelIDEvaluator,
+			VersionId:   version.VersionId,
+			SchedulerId: scheduler.Id,
+			HostName:    scheduler.HostName,
+			Ip:          scheduler.Ip,
+		}); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	activeVersion, err := t.managerClient.GetModelVersion(ctx, &managerv1.GetModelVersionRequest{
+		SchedulerId: scheduler.Id,
+		ModelId:     types.ModelIDEvaluator,
+		VersionId:   model.VersionId,
+	})
+	if err == nil && activeVersion.R2 > version.R2 {
+		logger.Infof("model version %s is not better than the active version %s", version.VersionId, model.VersionId)
+		return nil
+	}
+
+	if _, err := t.managerClient.UpdateModel(ctx, &managerv1.UpdateModelRequest{
+		ModelId:     types.ModelIDEvaluator,
+		VersionId:   version.VersionId,
+		SchedulerId: scheduler.Id,
+	}); err != nil {
+		return err
+	}
+
+	logger.Infof("model version %s is activated", version.VersionId)
+	return nil
+}
+
+// loadSamples loads the samples from the download records in storage,
+// the records are iterated one by one instead of being loaded at once.
+func (t *training) loadSamples() ([][]float64, []float64, error) {
+	var (
+		features [][]float64
+		labels   []float64
+	)
+	if err := t.storage.Iterate(storage.Filter{}, func(record storage.Record) error {
+		recordFeatures, recordLabels := newSamples(&record)
+		features = append(features, recordFeatures...)
+		labels = append(labels, recordLabels...)
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return features, labels, nil
+}
+
+// newSamples returns the features and labels of the download record, every parent
+// of record is a sample. The label of parent is the ratio of pieces uploaded by the
+// parent, scaled by the piece cost of record, and it is zero when the download failed.
+func newSamples(record *storage.Record) ([][]float64, []float64) {
+	if record.Task.TotalPieceCount <= 0 {
+		return nil, nil
+	}
+
+	if record.State != resource.PeerStateSucceeded && record.State != resource.PeerStateFailed {
+		return nil, nil
+	}
+
+	var (
+		features [][]float64
+		labels   []float64
+	)
+	pieceCost := float64(record.Cost) / float64(record.Task.TotalPieceCount)
+	for i := range record.Parents {
+		parent := &record.Parents[i]
+
+		// Parents of record are padded to fixed length in storage,
+		// the padded parents are empty and skipped.
+		if parent.ID == "" {
+			continue
+		}
+
+		recordFeatures := evaluator.ExtractRecordFeatures(record, parent)
+		feature := make([]float64, len(evaluator.Features))
+		for j, name := range evaluator.Features {
+			feature[j] = recordFeatures[name]
+		}
+		features = append(features, feature)
+
+		if record.State == resource.PeerStateFailed {
+			labels = append(labels, 0)
+			continue
+		}
+
+		uploadPieceRatio := float64(parent.UploadPieceCount) / float64(record.Task.TotalPieceCount)
+		labels = append(labels, uploadPieceRatio*referencePieceCost/(referencePieceCost+pieceCost))
+	}
+
+	return features, labels
+}
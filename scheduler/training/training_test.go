@@ -0,0 +1,358 @@
+/*
+ *     Copyright 2023 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package training
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	managerv1 "d7y.io/api/pkg/apis/manager/v1"
+
+	"d7y.io/dragonfly/v2/manager/types"
+	managerclientmocks "d7y.io/dragonfly/v2/pkg/rpc/manager/client/mocks"
+	pkgtypes "d7y.io/dragonfly/v2/pkg/types"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	configmocks "d7y.io/dragonfly/v2/scheduler/config/mocks"
+	"d7y.io/dragonfly/v2/scheduler/resource"
+	"d7y.io/dragonfly/v2/scheduler/scheduler/evaluator"
+	"d7y.io/dragonfly/v2/scheduler/storage"
+	storagemocks "d7y.io/dragonfly/v2/scheduler/storage/mocks"
+)
+
+var (
+	mockConfig = &config.Config{
+		Scheduler: config.SchedulerConfig{
+			Training: config.TrainingConfig{
+				Enable:   true,
+				CPU:      2,
+				Interval: time.Hour,
+			},
+		},
+	}
+
+	mockScheduler = &managerv1.Scheduler{
+		Id:       1,
+		HostName: "foo",
+		Ip:       "127.0.0.1",
+	}
+)
+
+// newMockRecords returns records with count, and every record has one parent.
+func newMockRecords(count int) []storage.Record {
+	var records []storage.Record
+	for i := 0; i < count; i++ {
+		state := resource.PeerStateSucceeded
+		if i%7 == 0 {
+			state = resource.PeerStateFailed
+		}
+
+		idc := "idc"
+		if i%2 == 0 {
+			idc = "foo"
+		}
+
+		records = append(records, storage.Record{
+			ID:    "record",
+			State: state,
+			Cost:  int64(i) * int64(time.Millisecond),
+			Task: storage.Task{
+				TotalPieceCount: 4,
+			},
+			Host: storage.Host{
+				Type:    pkgtypes.HostTypeNormalName,
+				Network: storage.Network{IDC: "idc"},
+			},
+			Parents: []storage.Parent{
+				{
+					ID:               "parent",
+					State:            resource.PeerStateRunning,
+					UploadPieceCount: int32(i % 3),
+					PieceCost:        int64(i % 5 * 100),
+					Host: storage.Host{
+						Type:                  pkgtypes.HostTypeNormalName,
+						ConcurrentUploadLimit: 10,
+						ConcurrentUploadCount: int32(i % 10),
+						UploadCount:           int64(i),
+						UploadFailedCount:     int64(i % 3),
+						Network:               storage.Network{IDC: idc},
+					},
+				},
+			},
+		})
+	}
+
+	return records
+}
+
+// newMockIterate returns the iterate function of records, records are
+// written to and read from csv, the same as the records in storage.
+func newMockIterate(t *testing.T, records []storage.Record) func(storage.Filter, func(storage.Record) error) error {
+	var buf bytes.Buffer
+	if err := gocsv.MarshalWithoutHeaders(records, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var csvRecords []storage.Record
+	if err := gocsv.UnmarshalWithoutHeaders(&buf, &csvRecords); err != nil {
+		t.Fatal(err)
+	}
+
+	return func(filter storage.Filter, fn func(storage.Record) error) error {
+		for _, record := range csvRecords {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func TestTraining_New(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	tr := New(mockConfig, managerclientmocks.NewMockV1(ctl), configmocks.NewMockDynconfigInterface(ctl), storagemocks.NewMockStorage(ctl))
+	assert := assert.New(t)
+	assert.Equal(tr.(*training).config, mockConfig)
+	assert.NoError(tr.Stop())
+	assert.NoError(tr.Serve())
+}
+
+func TestTraining_Train(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder)
+		expect func(t *testing.T, err error)
+	}{
+		{
+			name: "get scheduler failed",
+			mock: func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder) {
+				md.GetScheduler().Return(nil, errors.New("foo")).Times(1)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+		{
+			name: "iterate storage failed",
+			mock: func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					md.GetScheduler().Return(mockScheduler, nil).Times(1),
+					ms.Iterate(gomock.Any(), gomock.Any()).Return(errors.New("foo")).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+		{
+			name: "samples are insufficient",
+			mock: func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					md.GetScheduler().Return(mockScheduler, nil).Times(1),
+					ms.Iterate(gomock.Any(), gomock.Any()).DoAndReturn(newMockIterate(t, newMockRecords(10))).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "insufficient samples 10, at least 100 samples are required")
+			},
+		},
+		{
+			name: "create model version failed",
+			mock: func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					md.GetScheduler().Return(mockScheduler, nil).Times(1),
+					ms.Iterate(gomock.Any(), gomock.Any()).DoAndReturn(newMockIterate(t, newMockRecords(200))).Times(1),
+					mm.CreateModelVersion(gomock.Any(), gomock.Any()).Return(nil, errors.New("foo")).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.EqualError(err, "foo")
+			},
+		},
+		{
+			name: "create model with the version",
+			mock: func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					md.GetScheduler().Return(mockScheduler, nil).Times(1),
+					ms.Iterate(gomock.Any(), gomock.Any()).DoAndReturn(newMockIterate(t, newMockRecords(200))).Times(1),
+					mm.CreateModelVersion(gomock.Any(), gomock.Any()).DoAndReturn(
+						func(ctx context.Context, req *managerv1.CreateModelVersionRequest, opts ...any) (*managerv1.ModelVersion, error) {
+							assert := assert.New(t)
+							assert.Equal(req.SchedulerId, uint64(1))
+							assert.Equal(req.ModelId, types.ModelIDEvaluator)
+
+							model, err := evaluator.ParseModel(req.Data)
+							assert.NoError(err)
+							assert.Equal(model.Type, evaluator.ModelTypeLinear)
+							assert.Equal(len(model.Weights), len(evaluator.Features))
+							assert.Greater(req.R2, float64(0))
+							return &managerv1.ModelVersion{VersionId: "bar", R2: req.R2}, nil
+						}).Times(1),
+					mm.GetModel(gomock.Any(), gomock.Any()).Return(nil, errors.New("foo")).Times(1),
+					mm.CreateModel(gomock.Any(), gomock.Eq(&managerv1.CreateModelRequest{
+						ModelId:     types.ModelIDEvaluator,
+						Name:        types.ModelIDEvaluator,
+						VersionId:   "bar",
+						SchedulerId: 1,
+						HostName:    "foo",
+						Ip:          "127.0.0.1",
+					})).Return(&managerv1.Model{}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "activate the version",
+			mock: func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					md.GetScheduler().Return(mockScheduler, nil).Times(1),
+					ms.Iterate(gomock.Any(), gomock.Any()).DoAndReturn(newMockIterate(t, newMockRecords(200))).Times(1),
+					mm.CreateModelVersion(gomock.Any(), gomock.Any()).Return(&managerv1.ModelVersion{VersionId: "bar", R2: 0.5}, nil).Times(1),
+					mm.GetModel(gomock.Any(), gomock.Any()).Return(&managerv1.Model{VersionId: "foo"}, nil).Times(1),
+					mm.GetModelVersion(gomock.Any(), gomock.Eq(&managerv1.GetModelVersionRequest{
+						SchedulerId: 1,
+						ModelId:     types.ModelIDEvaluator,
+						VersionId:   "foo",
+					})).Return(&managerv1.ModelVersion{VersionId: "foo", R2: 0.1}, nil).Times(1),
+					mm.UpdateModel(gomock.Any(), gomock.Eq(&managerv1.UpdateModelRequest{
+						ModelId:     types.ModelIDEvaluator,
+						VersionId:   "bar",
+						SchedulerId: 1,
+					})).Return(&managerv1.Model{}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+		{
+			name: "active version is better",
+			mock: func(t *testing.T, md *configmocks.MockDynconfigInterfaceMockRecorder, ms *storagemocks.MockStorageMockRecorder, mm *managerclientmocks.MockV1MockRecorder) {
+				gomock.InOrder(
+					md.GetScheduler().Return(mockScheduler, nil).Times(1),
+					ms.Iterate(gomock.Any(), gomock.Any()).DoAndReturn(newMockIterate(t, newMockRecords(200))).Times(1),
+					mm.CreateModelVersion(gomock.Any(), gomock.Any()).Return(&managerv1.ModelVersion{VersionId: "bar", R2: 0.5}, nil).Times(1),
+					mm.GetModel(gomock.Any(), gomock.Any()).Return(&managerv1.Model{VersionId: "foo"}, nil).Times(1),
+					mm.GetModelVersion(gomock.Any(), gomock.Any()).Return(&managerv1.ModelVersion{VersionId: "foo", R2: 0.9}, nil).Times(1),
+				)
+			},
+			expect: func(t *testing.T, err error) {
+				assert := assert.New(t)
+				assert.NoError(err)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+			dynconfig := configmocks.NewMockDynconfigInterface(ctl)
+			storage := storagemocks.NewMockStorage(ctl)
+			managerClient := managerclientmocks.NewMockV1(ctl)
+
+			tc.mock(t, dynconfig.EXPECT(), storage.EXPECT(), managerClient.EXPECT())
+			tc.expect(t, New(mockConfig, managerClient, dynconfig, storage).Train(context.Background()))
+		})
+	}
+}
+
+func TestTraining_newSamples(t *testing.T) {
+	tests := []struct {
+		name   string
+		record storage.Record
+		expect func(t *testing.T, features [][]float64, labels []float64)
+	}{
+		{
+			name:   "record is failed",
+			record: newMockRecords(1)[0],
+			expect: func(t *testing.T, features [][]float64, labels []float64) {
+				assert := assert.New(t)
+				assert.Equal(len(features), 1)
+				assert.Equal(len(features[0]), len(evaluator.Features))
+				assert.Equal(labels, []float64{0})
+			},
+		},
+		{
+			name:   "record is succeeded",
+			record: newMockRecords(2)[1],
+			expect: func(t *testing.T, features [][]float64, labels []float64) {
+				assert := assert.New(t)
+				assert.Equal(len(features), 1)
+				assert.Equal(len(labels), 1)
+
+				// Record uploads one of four pieces and the piece cost is 0.25ms.
+				assert.InDelta(labels[0], 0.25*referencePieceCost/(referencePieceCost+float64(250*time.Microsecond)), 1e-9)
+			},
+		},
+		{
+			name: "padded parents are skipped",
+			record: storage.Record{
+				State:   resource.PeerStateSucceeded,
+				Task:    storage.Task{TotalPieceCount: 1},
+				Parents: append(newMockRecords(1)[0].Parents, storage.Parent{}, storage.Parent{}),
+			},
+			expect: func(t *testing.T, features [][]float64, labels []float64) {
+				assert := assert.New(t)
+				assert.Equal(len(features), 1)
+				assert.Equal(len(labels), 1)
+			},
+		},
+		{
+			name:   "record is running",
+			record: storage.Record{State: resource.PeerStateRunning, Task: storage.Task{TotalPieceCount: 1}, Parents: []storage.Parent{{ID: "foo"}}},
+			expect: func(t *testing.T, features [][]float64, labels []float64) {
+				assert := assert.New(t)
+				assert.Empty(features)
+				assert.Empty(labels)
+			},
+		},
+		{
+			name:   "total piece count is invalid",
+			record: storage.Record{State: resource.PeerStateSucceeded, Parents: []storage.Parent{{ID: "foo"}}},
+			expect: func(t *testing.T, features [][]float64, labels []float64) {
+				assert := assert.New(t)
+				assert.Empty(features)
+				assert.Empty(labels)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			features, labels := newSamples(&tc.record)
+			tc.expect(t, features, labels)
+		})
+	}
+}